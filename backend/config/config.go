@@ -104,8 +104,10 @@ type S3Config struct {
 // ModelConfig 模型配置
 type ModelConfig struct {
 	BasePath    string `json:"basePath"`
-	DefaultModel string `json:"defaultModel"`
+	DefaultModel string `json:"defaultModel"` // 启动时预热的模型，格式为"模型ID"或"模型ID@版本号"
 	MaxConcurrent int    `json:"maxConcurrent"`
+	ManifestKey  string `json:"manifestKey"` // 模型文件清单签名密钥，为空时使用JWT密钥
+	MaxMemoryMB  int    `json:"maxMemoryMB"` // 已加载模型的内存上限(MB)，为0时使用默认值
 }
 
 var (
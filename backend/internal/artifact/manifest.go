@@ -0,0 +1,64 @@
+package artifact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/image-recognition-engine/config"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// KeyFromConfig 获取清单签名密钥，未单独配置时使用JWT密钥
+func KeyFromConfig(cfg *config.Config) []byte {
+	if cfg.Model.ManifestKey != "" {
+		return []byte(cfg.Model.ManifestKey)
+	}
+	return []byte(cfg.JWT.Secret)
+}
+
+// Sign 计算清单签名并写入Signature
+func Sign(manifest *model.ArtifactManifest, key []byte) error {
+	sig, err := signature(manifest, key)
+	if err != nil {
+		return err
+	}
+	manifest.Signature = sig
+	return nil
+}
+
+// VerifySignature 校验清单签名
+func VerifySignature(manifest *model.ArtifactManifest, key []byte) bool {
+	expected, err := signature(manifest, key)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(expected), []byte(manifest.Signature))
+}
+
+// signature 对除签名外的清单内容计算HMAC-SHA256
+// encoding/json按字段声明顺序输出、map按key排序，序列化结果稳定
+func signature(manifest *model.ArtifactManifest, key []byte) (string, error) {
+	unsigned := *manifest
+	unsigned.Signature = ""
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Checksum 计算数据流的SHA-256及长度
+func Checksum(r io.Reader) (string, int64, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return "", n, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
@@ -0,0 +1,456 @@
+package artifact
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+const (
+	// DefaultChunkSize 默认分块大小
+	DefaultChunkSize = 8 * 1024 * 1024
+	// MaxChunkSize 单个分块大小上限
+	MaxChunkSize = 64 * 1024 * 1024
+	// MaxArtifactSize 模型文件大小上限
+	MaxArtifactSize = 10 * 1024 * 1024 * 1024
+
+	// manifestFile 清单文件名，与模型文件位于同一目录
+	manifestFile = "manifest.json"
+	// uploadDir 分块上传临时目录，位于存储根目录下以保证可原子重命名
+	uploadDir = ".uploads"
+)
+
+var (
+	// ErrMissing 模型文件或清单不存在
+	ErrMissing = stderrors.New("model artifact missing")
+	// ErrTampered 模型文件或清单与签名、校验和不一致
+	ErrTampered = stderrors.New("model artifact tampered")
+)
+
+// VersionStore 模型版本查询与更新，由repository.ModelRepository实现
+type VersionStore interface {
+	FindModelVersion(ctx context.Context, modelID, version string) (*model.ModelVersion, error)
+	SetModelVersionArtifact(ctx context.Context, id primitive.ObjectID, filePath, checksum string, size int64) error
+}
+
+// verifiedFile 已校验通过的模型文件，文件大小和修改时间不变时无需重复计算校验和
+type verifiedFile struct {
+	size    int64
+	modTime time.Time
+	sha256  string
+}
+
+// Store 模型文件存储，文件位于 basePath/模型ID/版本号/ 下，并附带签名清单
+type Store struct {
+	basePath string
+	key      []byte
+	uploads  model.ArtifactUploadRepository
+	versions VersionStore
+
+	mu       sync.Mutex
+	verified map[string]verifiedFile
+}
+
+// NewStore 创建模型文件存储
+func NewStore(basePath string, key []byte, uploads model.ArtifactUploadRepository, versions VersionStore) *Store {
+	return &Store{
+		basePath: basePath,
+		key:      key,
+		uploads:  uploads,
+		versions: versions,
+		verified: make(map[string]verifiedFile),
+	}
+}
+
+// CreateUpload 为模型版本创建分块上传会话
+// 已上线或已归档的版本文件不可替换
+func (s *Store) CreateUpload(ctx context.Context, upload *model.ArtifactUpload) error {
+	if err := validSegment(upload.ModelID); err != nil {
+		return errors.NewValidationError("无效的模型ID")
+	}
+	if err := validSegment(upload.Version); err != nil {
+		return errors.NewValidationError("无效的版本号")
+	}
+	upload.FileName = filepath.Base(upload.FileName)
+	if err := validSegment(upload.FileName); err != nil || upload.FileName == manifestFile {
+		return errors.NewValidationError("无效的文件名")
+	}
+	if upload.Format == "" {
+		return errors.NewValidationError("请指定模型文件格式")
+	}
+	if upload.TotalSize <= 0 || upload.TotalSize > MaxArtifactSize {
+		return errors.NewValidationError("模型文件大小超出限制")
+	}
+	if upload.ChunkSize == 0 {
+		upload.ChunkSize = DefaultChunkSize
+	}
+	if upload.ChunkSize < 0 || upload.ChunkSize > MaxChunkSize {
+		return errors.NewValidationError("分块大小超出限制")
+	}
+
+	v, err := s.versions.FindModelVersion(ctx, upload.ModelID, upload.Version)
+	if err != nil {
+		return errors.NewServerError("查询模型版本失败")
+	}
+	if v == nil {
+		return errors.NewNotFoundError("模型版本不存在")
+	}
+	if v.Status == model.ModelVersionProduction || v.Status == model.ModelVersionArchived {
+		return errors.NewConflictError(fmt.Sprintf("%s状态的版本不能替换模型文件", v.Status))
+	}
+
+	upload.TotalChunks = int((upload.TotalSize + upload.ChunkSize - 1) / upload.ChunkSize)
+	upload.Received = []int{}
+	upload.Status = model.ArtifactUploadUploading
+	if err := s.uploads.Create(upload); err != nil {
+		return errors.NewServerError("创建上传会话失败")
+	}
+	return nil
+}
+
+// WriteChunk 写入一个分块，分块可乱序或重复上传
+func (s *Store) WriteChunk(ctx context.Context, uploadID string, index int, body io.Reader) (*model.ArtifactUpload, error) {
+	upload, err := s.openUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= upload.TotalChunks {
+		return nil, errors.NewValidationError("分块序号超出范围")
+	}
+
+	if err := os.MkdirAll(filepath.Join(s.basePath, uploadDir), 0755); err != nil {
+		return nil, errors.NewServerError("创建上传目录失败")
+	}
+	f, err := os.OpenFile(s.partPath(upload.ID), os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.NewServerError("打开上传文件失败")
+	}
+	defer f.Close()
+
+	// 只写入本分块的长度，避免超长数据覆盖相邻分块
+	expected := chunkLength(upload, index)
+	w := io.NewOffsetWriter(f, int64(index)*upload.ChunkSize)
+	n, err := io.Copy(w, io.LimitReader(body, expected))
+	if err != nil {
+		return nil, errors.NewServerError("写入分块失败")
+	}
+	var extra [1]byte
+	if m, _ := body.Read(extra[:]); n != expected || m > 0 {
+		return nil, errors.NewValidationError(fmt.Sprintf("分块大小不正确，应为%d字节", expected))
+	}
+
+	if err := s.uploads.AddChunk(upload.ID, index); err != nil {
+		return nil, errors.NewServerError("记录上传分块失败")
+	}
+	upload.Received = addChunk(upload.Received, index)
+	return upload, nil
+}
+
+// Complete 合并分块，校验SHA-256后写入模型目录并生成签名清单
+// checksum为客户端计算的SHA-256，为空时只记录服务端计算结果
+func (s *Store) Complete(ctx context.Context, uploadID, checksum string, userID int64) (*model.ArtifactManifest, error) {
+	upload, err := s.openUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if missing := MissingChunks(upload); len(missing) > 0 {
+		return nil, errors.NewAppError(errors.ValidationError, "分块未上传完成", "缺少分块: "+joinInts(missing))
+	}
+
+	// 先占用会话，防止并发完成同一上传
+	ok, err := s.uploads.TransitionStatus(upload.ID, model.ArtifactUploadUploading, model.ArtifactUploadCompleted)
+	if err != nil {
+		return nil, errors.NewServerError("更新上传会话失败")
+	}
+	if !ok {
+		return nil, errors.NewConflictError("上传会话已结束")
+	}
+
+	manifest, err := s.commit(ctx, upload, checksum, userID)
+	if err != nil {
+		// 失败时恢复会话，允许重新上传分块后再次完成
+		s.uploads.TransitionStatus(upload.ID, model.ArtifactUploadCompleted, model.ArtifactUploadUploading)
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Abort 放弃上传并删除临时文件
+func (s *Store) Abort(uploadID string) error {
+	ok, err := s.uploads.TransitionStatus(uploadID, model.ArtifactUploadUploading, model.ArtifactUploadAborted)
+	if err != nil {
+		return errors.NewServerError("更新上传会话失败")
+	}
+	if !ok {
+		return errors.NewConflictError("上传会话不存在或已结束")
+	}
+	os.Remove(s.partPath(uploadID))
+	return nil
+}
+
+// Verify 校验模型版本的文件：清单签名有效、文件存在、大小和SHA-256与清单及版本记录一致
+// 返回的错误包装ErrMissing或ErrTampered
+func (s *Store) Verify(ctx context.Context, modelID, version string) (*model.ArtifactManifest, error) {
+	if validSegment(modelID) != nil || validSegment(version) != nil {
+		return nil, fmt.Errorf("%w: 无效的模型ID或版本号", ErrMissing)
+	}
+	dir := filepath.Join(s.basePath, modelID, version)
+
+	manifest, err := readManifest(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, err
+	}
+	if !VerifySignature(manifest, s.key) {
+		return nil, fmt.Errorf("%w: 清单签名无效", ErrTampered)
+	}
+	if manifest.ModelID != modelID || manifest.Version != version || validSegment(manifest.FileName) != nil {
+		return nil, fmt.Errorf("%w: 清单与模型版本不符", ErrTampered)
+	}
+
+	v, err := s.versions.FindModelVersion(ctx, modelID, version)
+	if err != nil {
+		return nil, err
+	}
+	if v != nil && v.Checksum != "" && v.Checksum != manifest.SHA256 {
+		return nil, fmt.Errorf("%w: 清单校验和与版本记录不符", ErrTampered)
+	}
+
+	path := filepath.Join(dir, manifest.FileName)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrMissing, manifest.FileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() != manifest.Size {
+		return nil, fmt.Errorf("%w: 文件大小与清单不符", ErrTampered)
+	}
+
+	s.mu.Lock()
+	cached, ok := s.verified[path]
+	s.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) && cached.sha256 == manifest.SHA256 {
+		return manifest, nil
+	}
+
+	sum, err := fileChecksum(path)
+	if err != nil {
+		return nil, err
+	}
+	if sum != manifest.SHA256 {
+		s.forget(path)
+		return nil, fmt.Errorf("%w: 文件校验和与清单不符", ErrTampered)
+	}
+	s.remember(path, info, sum)
+	return manifest, nil
+}
+
+// ArtifactPath 清单对应的模型文件路径
+func (s *Store) ArtifactPath(manifest *model.ArtifactManifest) string {
+	return filepath.Join(s.basePath, manifest.ModelID, manifest.Version, manifest.FileName)
+}
+
+// commit 校验合并后的文件，移动到模型目录并写入清单和版本记录
+func (s *Store) commit(ctx context.Context, upload *model.ArtifactUpload, checksum string, userID int64) (*model.ArtifactManifest, error) {
+	part := s.partPath(upload.ID)
+	sum, err := fileChecksum(part)
+	if err != nil {
+		return nil, errors.NewServerError("读取上传文件失败")
+	}
+	info, err := os.Stat(part)
+	if err != nil {
+		return nil, errors.NewServerError("读取上传文件失败")
+	}
+	if info.Size() != upload.TotalSize {
+		return nil, errors.NewValidationError("上传文件大小与声明不符")
+	}
+	if checksum != "" && !strings.EqualFold(checksum, sum) {
+		return nil, errors.NewAppError(errors.ValidationError, "校验和不匹配", "服务端计算结果: "+sum)
+	}
+
+	v, err := s.versions.FindModelVersion(ctx, upload.ModelID, upload.Version)
+	if err != nil {
+		return nil, errors.NewServerError("查询模型版本失败")
+	}
+	if v == nil {
+		return nil, errors.NewNotFoundError("模型版本不存在")
+	}
+	if v.Status == model.ModelVersionProduction || v.Status == model.ModelVersionArchived {
+		return nil, errors.NewConflictError(fmt.Sprintf("%s状态的版本不能替换模型文件", v.Status))
+	}
+
+	dir := filepath.Join(s.basePath, upload.ModelID, upload.Version)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.NewServerError("创建模型目录失败")
+	}
+	// 替换文件前先删除旧清单，中途失败时版本按文件缺失处理而不是使用旧清单
+	os.Remove(filepath.Join(dir, manifestFile))
+	dst := filepath.Join(dir, upload.FileName)
+	if err := os.Rename(part, dst); err != nil {
+		return nil, errors.NewServerError("保存模型文件失败")
+	}
+
+	manifest := &model.ArtifactManifest{
+		ModelID:    upload.ModelID,
+		Version:    upload.Version,
+		FileName:   upload.FileName,
+		Format:     upload.Format,
+		Size:       upload.TotalSize,
+		SHA256:     sum,
+		InputShape: upload.InputShape,
+		LabelMap:   upload.LabelMap,
+		UploadedBy: userID,
+		CreateTime: time.Now().UTC().Truncate(time.Second),
+	}
+	if err := Sign(manifest, s.key); err != nil {
+		return nil, errors.NewServerError("生成模型清单失败")
+	}
+	if err := writeManifest(filepath.Join(dir, manifestFile), manifest); err != nil {
+		return nil, errors.NewServerError("保存模型清单失败")
+	}
+	if err := s.versions.SetModelVersionArtifact(ctx, v.ID, dst, sum, upload.TotalSize); err != nil {
+		return nil, errors.NewServerError("更新模型版本失败")
+	}
+
+	if info, err := os.Stat(dst); err == nil {
+		s.remember(dst, info, sum)
+	}
+	return manifest, nil
+}
+
+// openUpload 获取进行中的上传会话
+func (s *Store) openUpload(uploadID string) (*model.ArtifactUpload, error) {
+	upload, err := s.uploads.FindByID(uploadID)
+	if err != nil {
+		return nil, errors.NewServerError("查询上传会话失败")
+	}
+	if upload == nil {
+		return nil, errors.NewNotFoundError("上传会话不存在")
+	}
+	if upload.Status != model.ArtifactUploadUploading {
+		return nil, errors.NewConflictError("上传会话已结束")
+	}
+	return upload, nil
+}
+
+// partPath 上传会话的临时文件路径
+func (s *Store) partPath(uploadID string) string {
+	return filepath.Join(s.basePath, uploadDir, filepath.Base(uploadID)+".part")
+}
+
+func (s *Store) remember(path string, info os.FileInfo, sum string) {
+	s.mu.Lock()
+	s.verified[path] = verifiedFile{size: info.Size(), modTime: info.ModTime(), sha256: sum}
+	s.mu.Unlock()
+}
+
+func (s *Store) forget(path string) {
+	s.mu.Lock()
+	delete(s.verified, path)
+	s.mu.Unlock()
+}
+
+// MissingChunks 返回尚未上传的分块序号
+func MissingChunks(upload *model.ArtifactUpload) []int {
+	received := make(map[int]bool, len(upload.Received))
+	for _, i := range upload.Received {
+		received[i] = true
+	}
+	var missing []int
+	for i := 0; i < upload.TotalChunks; i++ {
+		if !received[i] {
+			missing = append(missing, i)
+		}
+	}
+	return missing
+}
+
+// chunkLength 分块的字节数，最后一个分块可能不足ChunkSize
+func chunkLength(upload *model.ArtifactUpload, index int) int64 {
+	offset := int64(index) * upload.ChunkSize
+	if remaining := upload.TotalSize - offset; remaining < upload.ChunkSize {
+		return remaining
+	}
+	return upload.ChunkSize
+}
+
+// addChunk 将分块序号加入有序列表
+func addChunk(received []int, index int) []int {
+	for _, i := range received {
+		if i == index {
+			return received
+		}
+	}
+	received = append(received, index)
+	sort.Ints(received)
+	return received
+}
+
+// validSegment 校验路径片段，防止目录穿越
+func validSegment(s string) error {
+	if s == "" || s == "." || s == ".." || strings.ContainsAny(s, `/\`) || strings.HasPrefix(s, ".") {
+		return fmt.Errorf("invalid path segment: %q", s)
+	}
+	return nil
+}
+
+func readManifest(path string) (*model.ArtifactManifest, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: 清单不存在", ErrMissing)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest model.ArtifactManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: 清单格式错误", ErrTampered)
+	}
+	return &manifest, nil
+}
+
+// writeManifest 先写临时文件再重命名，避免读到不完整的清单
+func writeManifest(path string, manifest *model.ArtifactManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	sum, _, err := Checksum(f)
+	return sum, err
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,182 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+type memoryUploads struct {
+	uploads map[string]*model.ArtifactUpload
+}
+
+func (m *memoryUploads) Create(upload *model.ArtifactUpload) error {
+	upload.ID = strconv.Itoa(len(m.uploads) + 1)
+	copied := *upload
+	m.uploads[upload.ID] = &copied
+	return nil
+}
+
+func (m *memoryUploads) FindByID(id string) (*model.ArtifactUpload, error) {
+	u, ok := m.uploads[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *u
+	copied.Received = append([]int(nil), u.Received...)
+	return &copied, nil
+}
+
+func (m *memoryUploads) AddChunk(id string, index int) error {
+	m.uploads[id].Received = addChunk(m.uploads[id].Received, index)
+	return nil
+}
+
+func (m *memoryUploads) TransitionStatus(id, from, to string) (bool, error) {
+	u, ok := m.uploads[id]
+	if !ok || u.Status != from {
+		return false, nil
+	}
+	u.Status = to
+	return true, nil
+}
+
+type memoryVersions struct {
+	version *model.ModelVersion
+}
+
+func (m *memoryVersions) FindModelVersion(ctx context.Context, modelID, version string) (*model.ModelVersion, error) {
+	if m.version.ModelID != modelID || m.version.Version != version {
+		return nil, nil
+	}
+	copied := *m.version
+	return &copied, nil
+}
+
+func (m *memoryVersions) SetModelVersionArtifact(ctx context.Context, id primitive.ObjectID, filePath, checksum string, size int64) error {
+	m.version.FilePath = filePath
+	m.version.Checksum = checksum
+	m.version.ArtifactSize = size
+	return nil
+}
+
+func newTestStore(t *testing.T) (*Store, *memoryVersions) {
+	versions := &memoryVersions{version: &model.ModelVersion{ID: primitive.NewObjectID(), ModelID: "m1", Version: "v2", Status: model.ModelVersionTesting}}
+	return NewStore(t.TempDir(), []byte("secret"), &memoryUploads{uploads: map[string]*model.ArtifactUpload{}}, versions), versions
+}
+
+func upload(t *testing.T, s *Store, data []byte, chunkSize int64) *model.ArtifactUpload {
+	u := &model.ArtifactUpload{ModelID: "m1", Version: "v2", FileName: "../model.onnx", Format: "onnx", TotalSize: int64(len(data)), ChunkSize: chunkSize}
+	require.NoError(t, s.CreateUpload(context.Background(), u))
+	assert.Equal(t, "model.onnx", u.FileName)
+
+	// 乱序上传
+	for i := u.TotalChunks - 1; i >= 0; i-- {
+		start := int64(i) * chunkSize
+		end := start + chunkLength(u, i)
+		_, err := s.WriteChunk(context.Background(), u.ID, i, bytes.NewReader(data[start:end]))
+		require.NoError(t, err)
+	}
+	return u
+}
+
+func TestUploadAndVerify(t *testing.T) {
+	s, versions := newTestStore(t)
+	data := bytes.Repeat([]byte("weights"), 10)
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	u := upload(t, s, data, 16)
+	assert.Equal(t, 5, u.TotalChunks)
+
+	manifest, err := s.Complete(context.Background(), u.ID, checksum, 7)
+	require.NoError(t, err)
+	assert.Equal(t, checksum, manifest.SHA256)
+	assert.Equal(t, int64(70), manifest.Size)
+	assert.NotEmpty(t, manifest.Signature)
+	assert.Equal(t, checksum, versions.version.Checksum)
+
+	verified, err := s.Verify(context.Background(), "m1", "v2")
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Signature, verified.Signature)
+
+	// 会话已完成，不能再次完成
+	_, err = s.Complete(context.Background(), u.ID, checksum, 7)
+	assert.Error(t, err)
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	s, _ := newTestStore(t)
+	data := []byte("model weights")
+	u := upload(t, s, data, 8)
+	_, err := s.Complete(context.Background(), u.ID, "", 7)
+	require.NoError(t, err)
+
+	dir := filepath.Join(s.basePath, "m1", "v2")
+	path := filepath.Join(dir, "model.onnx")
+
+	// 篡改文件内容，大小不变
+	require.NoError(t, os.WriteFile(path, []byte("model WEIGHTS"), 0644))
+	_, err = s.Verify(context.Background(), "m1", "v2")
+	assert.ErrorIs(t, err, ErrTampered)
+
+	// 篡改清单中的校验和以匹配新文件，签名失效
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	manifestPath := filepath.Join(dir, manifestFile)
+	raw, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(manifestPath, bytes.Replace(raw, []byte(`"format": "onnx"`), []byte(`"format": "pt"`), 1), 0644))
+	_, err = s.Verify(context.Background(), "m1", "v2")
+	assert.ErrorIs(t, err, ErrTampered)
+
+	require.NoError(t, os.Remove(manifestPath))
+	_, err = s.Verify(context.Background(), "m1", "v2")
+	assert.ErrorIs(t, err, ErrMissing)
+
+	_, err = s.Verify(context.Background(), "m1", "v9")
+	assert.ErrorIs(t, err, ErrMissing)
+}
+
+func TestUploadRejectsBadInput(t *testing.T) {
+	s, versions := newTestStore(t)
+	data := []byte("0123456789")
+	u := &model.ArtifactUpload{ModelID: "m1", Version: "v2", FileName: "model.onnx", Format: "onnx", TotalSize: int64(len(data)), ChunkSize: 4}
+	require.NoError(t, s.CreateUpload(context.Background(), u))
+
+	// 分块长度不符
+	_, err := s.WriteChunk(context.Background(), u.ID, 0, bytes.NewReader(data[:5]))
+	assert.Error(t, err)
+	_, err = s.WriteChunk(context.Background(), u.ID, 3, bytes.NewReader(nil))
+	assert.Error(t, err)
+
+	_, err = s.WriteChunk(context.Background(), u.ID, 0, bytes.NewReader(data[:4]))
+	require.NoError(t, err)
+	_, err = s.Complete(context.Background(), u.ID, "", 7)
+	assert.Error(t, err, "缺少分块时不能完成")
+
+	_, err = s.WriteChunk(context.Background(), u.ID, 1, bytes.NewReader(data[4:8]))
+	require.NoError(t, err)
+	_, err = s.WriteChunk(context.Background(), u.ID, 2, bytes.NewReader(data[8:]))
+	require.NoError(t, err)
+	_, err = s.Complete(context.Background(), u.ID, "deadbeef", 7)
+	assert.Error(t, err, "校验和不匹配")
+
+	// 校验失败后会话恢复，可再次完成
+	_, err = s.Complete(context.Background(), u.ID, "", 7)
+	require.NoError(t, err)
+
+	versions.version.Status = model.ModelVersionProduction
+	err = s.CreateUpload(context.Background(), &model.ArtifactUpload{ModelID: "m1", Version: "v2", FileName: "model.onnx", Format: "onnx", TotalSize: 10})
+	assert.Error(t, err, "生产版本不能替换文件")
+}
@@ -0,0 +1,187 @@
+package billing
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// DefaultCurrency 套餐未配置计价规则时的币种
+const DefaultCurrency = "CNY"
+
+// Segment 账单月份内客户订阅同一套餐的时间段
+type Segment struct {
+	PlanID  int64
+	Plan    *model.ServicePlan // 未订阅套餐或套餐已删除时为nil，该时间段不计费
+	Pricing *model.PlanPricing // 套餐未配置计价规则时为nil，只收取月费
+	Start   time.Time
+	End     time.Time
+	Usage   []*model.UsageSummary
+}
+
+// MonthRange 账单月份的起止时间，month为该月任意时间
+func MonthRange(month time.Time) (time.Time, time.Time) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// Calculate 按订阅时间段计算客户的月度账单
+// 月费和套餐包含的请求数按订阅时长占整月的比例折算，超出部分按阶梯计价，
+// 模型类型附加费和百万像素费用按实际用量计算
+func Calculate(customerID int64, month time.Time, segments []*Segment) *model.Invoice {
+	monthStart, monthEnd := MonthRange(month)
+	invoice := &model.Invoice{
+		CustomerID: customerID,
+		Month:      monthStart,
+		Currency:   DefaultCurrency,
+		Lines:      make([]*model.InvoiceLine, 0),
+	}
+
+	monthLength := monthEnd.Sub(monthStart).Seconds()
+	for _, seg := range segments {
+		if seg.Plan == nil || !seg.End.After(seg.Start) {
+			continue
+		}
+		if seg.Pricing != nil && seg.Pricing.Currency != "" {
+			invoice.Currency = seg.Pricing.Currency
+		}
+		fraction := seg.End.Sub(seg.Start).Seconds() / monthLength
+		invoice.Lines = append(invoice.Lines, segmentLines(seg, fraction)...)
+	}
+
+	total := 0.0
+	for _, line := range invoice.Lines {
+		total += line.Amount
+	}
+	invoice.Total = roundMoney(total)
+	return invoice
+}
+
+// segmentLines 计算单个订阅时间段的账单明细
+func segmentLines(seg *Segment, fraction float64) []*model.InvoiceLine {
+	// 金额按未取整的数量计算，数量只在展示时取整
+	newLine := func(kind, description string, quantity, unitPrice float64) *model.InvoiceLine {
+		return &model.InvoiceLine{
+			Kind:        kind,
+			Description: description,
+			PlanID:      seg.PlanID,
+			PeriodStart: seg.Start,
+			PeriodEnd:   seg.End,
+			Quantity:    roundQuantity(quantity),
+			UnitPrice:   unitPrice,
+			Amount:      roundMoney(quantity * unitPrice),
+		}
+	}
+
+	lines := []*model.InvoiceLine{
+		newLine(model.InvoiceLineBase, fmt.Sprintf("%s 套餐月费", seg.Plan.Name), fraction, seg.Plan.Price),
+	}
+
+	var requests int64
+	var megapixels float64
+	for _, u := range seg.Usage {
+		requests += u.Requests
+		megapixels += u.Megapixels
+	}
+	pricing := seg.Pricing
+	if pricing == nil {
+		return lines
+	}
+
+	// 套餐不限请求数时没有超额费用
+	if seg.Plan.RequestLimit > 0 {
+		included := int64(math.Round(float64(seg.Plan.RequestLimit) * fraction))
+		for _, charge := range TieredCharges(requests-included, pricing.OverageTiers) {
+			description := fmt.Sprintf("超额请求 第%d-%d次", charge.From, charge.To)
+			lines = append(lines, newLine(model.InvoiceLineOverage, description, float64(charge.Quantity), charge.UnitPrice))
+		}
+	}
+
+	usage := append([]*model.UsageSummary(nil), seg.Usage...)
+	sort.Slice(usage, func(i, j int) bool { return usage[i].ModelType < usage[j].ModelType })
+	for _, u := range usage {
+		price := pricing.ModelTypePrices[u.ModelType]
+		if price <= 0 || u.Requests == 0 {
+			continue
+		}
+		description := fmt.Sprintf("%s 模型识别附加费", u.ModelType)
+		lines = append(lines, newLine(model.InvoiceLineModelType, description, float64(u.Requests), price))
+	}
+
+	if pricing.MegapixelPrice > 0 && megapixels > 0 {
+		lines = append(lines, newLine(model.InvoiceLineMegapixel, "图片像素费用(百万像素)", megapixels, pricing.MegapixelPrice))
+	}
+	return lines
+}
+
+// TierCharge 一个价格档位内的超额请求
+type TierCharge struct {
+	From      int64 // 本档第一次超额请求的序号，从1开始
+	To        int64
+	Quantity  int64
+	UnitPrice float64
+}
+
+// TieredCharges 按阶梯价格拆分超额请求数，超出最后一档上限的请求按最后一档价格计算
+func TieredCharges(overage int64, tiers []model.PriceTier) []TierCharge {
+	if overage <= 0 || len(tiers) == 0 {
+		return nil
+	}
+
+	charges := make([]TierCharge, 0, len(tiers))
+	var billed int64
+	for i, tier := range tiers {
+		upper := tier.UpTo
+		if upper <= 0 || i == len(tiers)-1 || upper > overage {
+			upper = overage
+		}
+		if upper <= billed {
+			continue
+		}
+		charges = append(charges, TierCharge{From: billed + 1, To: upper, Quantity: upper - billed, UnitPrice: tier.UnitPrice})
+		billed = upper
+		if billed >= overage {
+			break
+		}
+	}
+	return charges
+}
+
+// ValidatePricing 校验计价规则，返回错误提示
+func ValidatePricing(pricing *model.PlanPricing) string {
+	if pricing.MegapixelPrice < 0 {
+		return "百万像素价格不能为负数"
+	}
+	for modelType, price := range pricing.ModelTypePrices {
+		if modelType == "" || price < 0 {
+			return "模型类型附加费配置无效"
+		}
+	}
+	var previous int64
+	for i, tier := range pricing.OverageTiers {
+		if tier.UnitPrice < 0 {
+			return "超额请求价格不能为负数"
+		}
+		if tier.UpTo <= 0 && i != len(pricing.OverageTiers)-1 {
+			return "只有最后一档可以不设上限"
+		}
+		if tier.UpTo > 0 && tier.UpTo <= previous {
+			return "超额请求阶梯上限必须递增"
+		}
+		previous = tier.UpTo
+	}
+	return ""
+}
+
+// roundMoney 金额保留两位小数
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// roundQuantity 数量保留四位小数
+func roundQuantity(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
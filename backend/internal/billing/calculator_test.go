@@ -0,0 +1,184 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+func TestTieredCharges(t *testing.T) {
+	tiers := []model.PriceTier{
+		{UpTo: 1000, UnitPrice: 0.01},
+		{UpTo: 5000, UnitPrice: 0.008},
+		{UnitPrice: 0.005},
+	}
+
+	assert.Empty(t, TieredCharges(0, tiers))
+	assert.Empty(t, TieredCharges(-5, tiers))
+	assert.Empty(t, TieredCharges(100, nil))
+
+	assert.Equal(t, []TierCharge{{From: 1, To: 800, Quantity: 800, UnitPrice: 0.01}}, TieredCharges(800, tiers))
+	assert.Equal(t, []TierCharge{
+		{From: 1, To: 1000, Quantity: 1000, UnitPrice: 0.01},
+		{From: 1001, To: 5000, Quantity: 4000, UnitPrice: 0.008},
+		{From: 5001, To: 7000, Quantity: 2000, UnitPrice: 0.005},
+	}, TieredCharges(7000, tiers))
+
+	// 最后一档设置了上限时，超出部分仍按最后一档计价
+	capped := []model.PriceTier{{UpTo: 10, UnitPrice: 1}, {UpTo: 20, UnitPrice: 0.5}}
+	assert.Equal(t, []TierCharge{
+		{From: 1, To: 10, Quantity: 10, UnitPrice: 1},
+		{From: 11, To: 30, Quantity: 20, UnitPrice: 0.5},
+	}, TieredCharges(30, capped))
+}
+
+func TestCalculateFullMonth(t *testing.T) {
+	month := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	start, end := MonthRange(month)
+	plan := &model.ServicePlan{ID: 1, Name: "标准版", Price: 300, RequestLimit: 1000}
+	pricing := &model.PlanPricing{
+		PlanID:          1,
+		Currency:        "USD",
+		OverageTiers:    []model.PriceTier{{UpTo: 100, UnitPrice: 0.1}, {UnitPrice: 0.05}},
+		ModelTypePrices: map[string]float64{"detection": 0.02},
+		MegapixelPrice:  0.001,
+	}
+	segments := []*Segment{{
+		PlanID: 1, Plan: plan, Pricing: pricing, Start: start, End: end,
+		Usage: []*model.UsageSummary{
+			{ModelType: "classification", Requests: 900, Megapixels: 1000},
+			{ModelType: "detection", Requests: 300, Megapixels: 500},
+		},
+	}}
+
+	invoice := Calculate(7, month, segments)
+	assert.Equal(t, int64(7), invoice.CustomerID)
+	assert.Equal(t, start, invoice.Month)
+	assert.Equal(t, "USD", invoice.Currency)
+
+	require.Len(t, invoice.Lines, 5)
+	assert.Equal(t, model.InvoiceLineBase, invoice.Lines[0].Kind)
+	assert.Equal(t, 300.0, invoice.Lines[0].Amount)
+	// 超额200次：前100次0.1，后100次0.05
+	assert.Equal(t, model.InvoiceLineOverage, invoice.Lines[1].Kind)
+	assert.Equal(t, 10.0, invoice.Lines[1].Amount)
+	assert.Equal(t, 5.0, invoice.Lines[2].Amount)
+	assert.Equal(t, model.InvoiceLineModelType, invoice.Lines[3].Kind)
+	assert.Equal(t, 6.0, invoice.Lines[3].Amount)
+	assert.Equal(t, model.InvoiceLineMegapixel, invoice.Lines[4].Kind)
+	assert.Equal(t, 1.5, invoice.Lines[4].Amount)
+	assert.Equal(t, 322.5, invoice.Total)
+}
+
+func TestCalculateProratesMidMonthChange(t *testing.T) {
+	month := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC) // 30天
+	start, end := MonthRange(month)
+	change := start.AddDate(0, 0, 10)
+	basic := &model.ServicePlan{ID: 1, Name: "基础版", Price: 90, RequestLimit: 300}
+	pro := &model.ServicePlan{ID: 2, Name: "专业版", Price: 300, RequestLimit: 3000}
+	tiers := &model.PlanPricing{OverageTiers: []model.PriceTier{{UnitPrice: 0.1}}}
+
+	invoice := Calculate(1, month, []*Segment{
+		{PlanID: 1, Plan: basic, Pricing: tiers, Start: start, End: change,
+			Usage: []*model.UsageSummary{{ModelType: "classification", Requests: 150}}},
+		{PlanID: 2, Plan: pro, Pricing: tiers, Start: change, End: end,
+			Usage: []*model.UsageSummary{{ModelType: "classification", Requests: 1000}}},
+	})
+
+	require.Len(t, invoice.Lines, 3)
+	// 前10天：月费30，包含100次，超额50次
+	assert.Equal(t, 30.0, invoice.Lines[0].Amount)
+	assert.Equal(t, 50.0, invoice.Lines[1].Quantity)
+	assert.Equal(t, 5.0, invoice.Lines[1].Amount)
+	// 后20天：月费200，包含2000次，未超额
+	assert.Equal(t, 200.0, invoice.Lines[2].Amount)
+	assert.Equal(t, int64(2), invoice.Lines[2].PlanID)
+	assert.Equal(t, 235.0, invoice.Total)
+	assert.Equal(t, DefaultCurrency, invoice.Currency)
+}
+
+func TestCalculateSkipsPeriodsWithoutPlan(t *testing.T) {
+	month := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	start, end := MonthRange(month)
+	invoice := Calculate(1, month, []*Segment{{Start: start, End: end,
+		Usage: []*model.UsageSummary{{Requests: 100}}}})
+	assert.Empty(t, invoice.Lines)
+	assert.Equal(t, 0.0, invoice.Total)
+}
+
+func TestSubscriptionPeriods(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	day := func(d int) time.Time { return start.AddDate(0, 0, d) }
+
+	// 没有变更记录时整月按当前套餐
+	assert.Equal(t, []period{{planID: 3, start: start, end: end}}, subscriptionPeriods(nil, nil, 3, start, end))
+
+	changes := []*model.PlanChange{
+		{PlanID: 1, EffectiveAt: day(-40)},
+		{PlanID: 2, EffectiveAt: day(-3)},
+		{PlanID: 4, EffectiveAt: day(10)},
+		{PlanID: 0, EffectiveAt: day(20)},
+	}
+	assert.Equal(t, []period{
+		{planID: 2, start: start, end: day(10)},
+		{planID: 4, start: day(10), end: day(20)},
+		{planID: 0, start: day(20), end: end},
+	}, subscriptionPeriods(changes, nil, 0, start, end))
+
+	// 月中开通的客户，开通前不计费
+	assert.Equal(t, []period{
+		{planID: 0, start: start, end: day(5)},
+		{planID: 1, start: day(5), end: end},
+	}, subscriptionPeriods([]*model.PlanChange{{PlanID: 1, EffectiveAt: day(5)}}, nil, 1, start, end))
+
+	// 开始记录变更之前已订阅的客户，15日变更前按变更前的套餐计费
+	assert.Equal(t, []period{
+		{planID: 2, start: start, end: day(14)},
+		{planID: 3, start: day(14), end: end},
+	}, subscriptionPeriods([]*model.PlanChange{{PreviousPlanID: 2, PlanID: 3, EffectiveAt: day(14)}}, nil, 3, start, end))
+}
+
+func TestSubscriptionPeriodsChangeAfterMonth(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	// 账单月之后才生效的变更，整月按变更前的套餐计费而不是客户的当前套餐
+	next := &model.PlanChange{PreviousPlanID: 2, PlanID: 5, EffectiveAt: end.AddDate(0, 0, 3)}
+	assert.Equal(t, []period{{planID: 2, start: start, end: end}}, subscriptionPeriods(nil, next, 5, start, end))
+
+	// 账单月之后才开通的客户当月不计费
+	opened := &model.PlanChange{PlanID: 5, EffectiveAt: end}
+	assert.Equal(t, []period{{planID: 0, start: start, end: end}}, subscriptionPeriods(nil, opened, 5, start, end))
+}
+
+func TestValidatePricing(t *testing.T) {
+	assert.Empty(t, ValidatePricing(&model.PlanPricing{OverageTiers: []model.PriceTier{{UpTo: 10}, {UpTo: 20}, {}}}))
+	assert.NotEmpty(t, ValidatePricing(&model.PlanPricing{OverageTiers: []model.PriceTier{{UpTo: 20}, {UpTo: 10}}}))
+	assert.NotEmpty(t, ValidatePricing(&model.PlanPricing{OverageTiers: []model.PriceTier{{}, {UpTo: 10}}}))
+	assert.NotEmpty(t, ValidatePricing(&model.PlanPricing{MegapixelPrice: -1}))
+	assert.NotEmpty(t, ValidatePricing(&model.PlanPricing{ModelTypePrices: map[string]float64{"detection": -1}}))
+}
+
+func TestExportRows(t *testing.T) {
+	invoice := &model.Invoice{
+		ID: "inv1", CustomerID: 3, Month: time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local),
+		Currency: "CNY", Status: model.InvoiceIssued, Total: 12,
+		Lines: []*model.InvoiceLine{{Kind: model.InvoiceLineBase, Amount: 10}, {Kind: model.InvoiceLineOverage, Amount: 2}},
+	}
+	rows := ExportRows([]*model.Invoice{invoice})
+	require.Len(t, rows, 2)
+	assert.Equal(t, "2024-05", rows[0].Month)
+	assert.Equal(t, 12.0, rows[1].InvoiceTotal)
+	assert.Equal(t, model.InvoiceLineOverage, rows[1].Kind)
+
+	month, err := ParseMonth("2024-05")
+	require.NoError(t, err)
+	assert.Equal(t, 5, int(month.Month()))
+	_, err = ParseMonth("2024/05")
+	assert.Error(t, err)
+}
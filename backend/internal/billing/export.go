@@ -0,0 +1,63 @@
+package billing
+
+import (
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// ExportRow 财务系统导入用的账单明细行，每行对应一条账单明细
+type ExportRow struct {
+	InvoiceID    string    `json:"invoiceId"`
+	CustomerID   int64     `json:"customerId"`
+	Month        string    `json:"month"` // 如2024-05
+	Currency     string    `json:"currency"`
+	Status       string    `json:"status"`
+	InvoiceTotal float64   `json:"invoiceTotal"`
+	Kind         string    `json:"kind"`
+	Description  string    `json:"description"`
+	PlanID       int64     `json:"planId"`
+	PeriodStart  time.Time `json:"periodStart"`
+	PeriodEnd    time.Time `json:"periodEnd"`
+	Quantity     float64   `json:"quantity"`
+	UnitPrice    float64   `json:"unitPrice"`
+	Amount       float64   `json:"amount"`
+}
+
+// MonthFormat 账单月份的格式
+const MonthFormat = "2006-01"
+
+// ExportRows 将账单展开为明细行
+func ExportRows(invoices []*model.Invoice) []ExportRow {
+	rows := make([]ExportRow, 0)
+	for _, inv := range invoices {
+		for _, line := range inv.Lines {
+			rows = append(rows, ExportRow{
+				InvoiceID:    inv.ID,
+				CustomerID:   inv.CustomerID,
+				Month:        inv.Month.Format(MonthFormat),
+				Currency:     inv.Currency,
+				Status:       inv.Status,
+				InvoiceTotal: inv.Total,
+				Kind:         line.Kind,
+				Description:  line.Description,
+				PlanID:       line.PlanID,
+				PeriodStart:  line.PeriodStart,
+				PeriodEnd:    line.PeriodEnd,
+				Quantity:     line.Quantity,
+				UnitPrice:    line.UnitPrice,
+				Amount:       line.Amount,
+			})
+		}
+	}
+	return rows
+}
+
+// ParseMonth 解析账单月份，为空时取当前月份
+func ParseMonth(value string) (time.Time, error) {
+	if value == "" {
+		start, _ := MonthRange(time.Now())
+		return start, nil
+	}
+	return time.ParseInLocation(MonthFormat, value, time.Local)
+}
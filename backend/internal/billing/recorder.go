@@ -0,0 +1,82 @@
+package billing
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// modelTypeCacheTTL 模型类型的缓存时长
+const modelTypeCacheTTL = 5 * time.Minute
+
+// ModelStore 模型查询，由model.ModelRepository实现
+type ModelStore interface {
+	FindByID(id string) (*model.Model, error)
+}
+
+// Recorder 记录可计费的识别请求
+type Recorder struct {
+	events model.UsageEventRepository
+	models ModelStore
+
+	mu    sync.Mutex
+	types map[string]cachedModelType
+}
+
+type cachedModelType struct {
+	modelType string
+	expireAt  time.Time
+}
+
+// NewRecorder 创建用量记录器
+func NewRecorder(events model.UsageEventRepository, models ModelStore) *Recorder {
+	return &Recorder{
+		events: events,
+		models: models,
+		types:  make(map[string]cachedModelType),
+	}
+}
+
+// RecordRecognition 为成功的识别请求记录用量事件，失败的识别不计费
+func (r *Recorder) RecordRecognition(record *model.RecognitionRecord) error {
+	if record.Status != model.RecognitionSucceeded {
+		return nil
+	}
+
+	event := &model.UsageEvent{
+		CustomerID:    record.CustomerID,
+		RecognitionID: record.ID.Hex(),
+		ModelID:       record.ModelID,
+		ModelType:     r.modelType(record.ModelID),
+		Megapixels:    float64(record.ImageWidth) * float64(record.ImageHeight) / 1e6,
+		CreateTime:    record.CreateTime,
+	}
+	return r.events.Create(event)
+}
+
+// modelType 查询模型类型，查询失败时记为空类型
+func (r *Recorder) modelType(modelID string) string {
+	r.mu.Lock()
+	cached, ok := r.types[modelID]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expireAt) {
+		return cached.modelType
+	}
+
+	m, err := r.models.FindByID(modelID)
+	if err != nil {
+		log.Printf("查询模型%s的类型失败: %v", modelID, err)
+		return ""
+	}
+	modelType := ""
+	if m != nil {
+		modelType = m.Type
+	}
+
+	r.mu.Lock()
+	r.types[modelID] = cachedModelType{modelType: modelType, expireAt: time.Now().Add(modelTypeCacheTTL)}
+	r.mu.Unlock()
+	return modelType
+}
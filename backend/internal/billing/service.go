@@ -0,0 +1,185 @@
+package billing
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// Service 计费服务，汇总用量事件和套餐变更生成月度账单
+type Service struct {
+	customers model.CustomerRepository
+	plans     model.ServicePlanRepository
+	pricing   model.PlanPricingRepository
+	changes   model.PlanChangeRepository
+	usage     model.UsageEventRepository
+	invoices  model.InvoiceRepository
+}
+
+// NewService 创建计费服务
+func NewService(customers model.CustomerRepository, plans model.ServicePlanRepository, pricing model.PlanPricingRepository,
+	changes model.PlanChangeRepository, usage model.UsageEventRepository, invoices model.InvoiceRepository) *Service {
+	return &Service{
+		customers: customers,
+		plans:     plans,
+		pricing:   pricing,
+		changes:   changes,
+		usage:     usage,
+		invoices:  invoices,
+	}
+}
+
+// Preview 计算客户的月度账单，不保存
+func (s *Service) Preview(customerID int64, month time.Time) (*model.Invoice, error) {
+	customer, err := s.customers.FindByID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer == nil {
+		return nil, errors.NewNotFoundError("客户不存在")
+	}
+
+	segments, err := s.Segments(customer, month)
+	if err != nil {
+		return nil, err
+	}
+	return Calculate(customerID, month, segments), nil
+}
+
+// Generate 生成客户的月度草稿账单，重复生成时覆盖之前的草稿，已开具的账单不能重新生成
+func (s *Service) Generate(customerID int64, month time.Time) (*model.Invoice, error) {
+	invoice, err := s.Preview(customerID, month)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.invoices.SaveDraft(invoice)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, errors.NewConflictError("该月账单已开具，不能重新生成")
+	}
+	invoice.Status = model.InvoiceDraft
+	return invoice, nil
+}
+
+// GenerateAll 生成所有客户的月度草稿账单，跳过已开具账单的客户，返回生成的账单数
+func (s *Service) GenerateAll(month time.Time) (int, error) {
+	const pageSize = 100
+	generated := 0
+	for page := 1; ; page++ {
+		customers, total, err := s.customers.List(page, pageSize)
+		if err != nil {
+			return generated, err
+		}
+		for _, customer := range customers {
+			if _, err := s.Generate(customer.ID, month); err != nil {
+				if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ConflictError {
+					continue
+				}
+				return generated, fmt.Errorf("生成客户%d的账单失败: %w", customer.ID, err)
+			}
+			generated++
+		}
+		if len(customers) == 0 || int64(page*pageSize) >= total {
+			return generated, nil
+		}
+	}
+}
+
+// Issue 开具草稿账单，开具后不再变更
+func (s *Service) Issue(id string) (*model.Invoice, error) {
+	invoice, err := s.invoices.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if invoice == nil {
+		return nil, errors.NewNotFoundError("账单不存在")
+	}
+
+	issued, err := s.invoices.Issue(id)
+	if err != nil {
+		return nil, err
+	}
+	if !issued {
+		return nil, errors.NewConflictError("账单已开具")
+	}
+	return s.invoices.FindByID(id)
+}
+
+// Segments 按套餐变更记录拆分客户在账单月份内的订阅时间段，并汇总每段的用量
+// 没有任何变更记录的客户视为整月订阅当前套餐
+func (s *Service) Segments(customer *model.Customer, month time.Time) ([]*Segment, error) {
+	monthStart, monthEnd := MonthRange(month)
+	changes, err := s.changes.ListByCustomer(customer.ID, monthEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	// 月末之前没有变更时，账单月之后的第一次变更记录了当月订阅的套餐
+	var next *model.PlanChange
+	if len(changes) == 0 {
+		if next, err = s.changes.FindFirstAfter(customer.ID, monthEnd); err != nil {
+			return nil, err
+		}
+	}
+
+	periods := subscriptionPeriods(changes, next, customer.PlanID, monthStart, monthEnd)
+	segments := make([]*Segment, 0, len(periods))
+	for _, p := range periods {
+		seg := &Segment{PlanID: p.planID, Start: p.start, End: p.end}
+		if p.planID != 0 {
+			if seg.Plan, err = s.plans.FindByID(p.planID); err != nil {
+				return nil, err
+			}
+			if seg.Plan == nil {
+				log.Printf("客户%d订阅的套餐%d不存在，该时间段不计费", customer.ID, p.planID)
+			}
+			if seg.Pricing, err = s.pricing.FindByPlan(p.planID); err != nil {
+				return nil, err
+			}
+		}
+		if seg.Usage, err = s.usage.Summarize(customer.ID, p.start, p.end); err != nil {
+			return nil, err
+		}
+		segments = append(segments, seg)
+	}
+	return segments, nil
+}
+
+type period struct {
+	planID     int64
+	start, end time.Time
+}
+
+// subscriptionPeriods 根据按生效时间升序的套餐变更计算[monthStart, monthEnd)内的订阅时间段
+// next为monthEnd及之后的第一次变更，changes为空时整月按其变更前的套餐计费
+func subscriptionPeriods(changes []*model.PlanChange, next *model.PlanChange, currentPlanID int64, monthStart, monthEnd time.Time) []period {
+	if len(changes) == 0 {
+		if next != nil {
+			currentPlanID = next.PreviousPlanID
+		}
+		return []period{{planID: currentPlanID, start: monthStart, end: monthEnd}}
+	}
+
+	// 月初生效的套餐为月初之前最后一次变更的套餐，之前没有变更时为本月第一次变更前的套餐
+	planID := changes[0].PreviousPlanID
+	cursor := monthStart
+	periods := make([]period, 0)
+	for _, change := range changes {
+		if !change.EffectiveAt.After(monthStart) {
+			planID = change.PlanID
+			continue
+		}
+		if change.EffectiveAt.After(cursor) {
+			periods = append(periods, period{planID: planID, start: cursor, end: change.EffectiveAt})
+			cursor = change.EffectiveAt
+		}
+		planID = change.PlanID
+	}
+	return append(periods, period{planID: planID, start: cursor, end: monthEnd})
+}
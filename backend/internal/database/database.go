@@ -10,6 +10,7 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/image-recognition-engine/config"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -84,24 +85,194 @@ func OptimizeMySQL() error {
 	return nil
 }
 
+// mongoIndex 启动时创建的MongoDB索引
+type mongoIndex struct {
+	collection  string
+	description string
+	model       mongo.IndexModel
+}
+
+// mongoIndexes 返回需要创建的索引，单个索引创建失败不影响其他索引
+func mongoIndexes() []mongoIndex {
+	return []mongoIndex{
+		{
+			collection:  "recognition_results",
+			description: "识别结果",
+			model: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "created_at", Value: 1},
+					{Key: "status", Value: 1},
+				},
+				Options: options.Index().SetBackground(true),
+			},
+		},
+		{
+			// 每个模型同时只能有一个生产版本，创建前需补齐旧版本文档的modelId
+			collection:  "model_versions",
+			description: "模型版本",
+			model: mongo.IndexModel{
+				Keys: bson.D{{Key: "modelId", Value: 1}},
+				Options: options.Index().
+					SetName("uniq_production_per_model").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"status": "production"}),
+			},
+		},
+		{
+			// 自动采集的性能数据每个模型版本每个采集桶一条
+			collection:  "model_performance",
+			description: "模型性能数据",
+			model: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "modelId", Value: 1},
+					{Key: "modelVersion", Value: 1},
+					{Key: "bucketStart", Value: 1},
+				},
+				Options: options.Index().
+					SetName("uniq_performance_bucket").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"bucketStart": bson.M{"$exists": true}}),
+			},
+		},
+		{
+			// 客户反馈准确率每个模型每个类别每天一条
+			collection:  "accuracy_stats",
+			description: "准确率统计",
+			model: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "model_id", Value: 1},
+					{Key: "source", Value: 1},
+					{Key: "date", Value: 1},
+					{Key: "category", Value: 1},
+				},
+				Options: options.Index().
+					SetName("uniq_feedback_accuracy").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"source": "feedback"}),
+			},
+		},
+		{
+			// 标签映射版本号在同一模型内唯一
+			collection:  "label_maps",
+			description: "标签映射",
+			model: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "model_id", Value: 1},
+					{Key: "version", Value: 1},
+				},
+				Options: options.Index().SetName("uniq_label_map_version").SetUnique(true),
+			},
+		},
+		{
+			// 每个客户每月只有一张账单，已开具的账单不会被草稿覆盖
+			collection:  "invoices",
+			description: "账单",
+			model: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "customer_id", Value: 1},
+					{Key: "month", Value: 1},
+				},
+				Options: options.Index().SetName("uniq_invoice_month").SetUnique(true),
+			},
+		},
+		{
+			// 按客户和时间汇总计费用量
+			collection:  "usage_events",
+			description: "用量事件",
+			model: mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "customer_id", Value: 1},
+					{Key: "create_time", Value: 1},
+				},
+				Options: options.Index().SetName("idx_usage_customer_time"),
+			},
+		},
+		{
+			// 客户端请求通过AppID查找应用
+			collection:  "applications",
+			description: "应用",
+			model: mongo.IndexModel{
+				Keys:    bson.D{{Key: "app_id", Value: 1}},
+				Options: options.Index().SetName("uniq_application_app_id").SetUnique(true),
+			},
+		},
+		{
+			// API密钥按摘要查找
+			collection:  "api_keys",
+			description: "API密钥",
+			model: mongo.IndexModel{
+				Keys:    bson.D{{Key: "key_hash", Value: 1}},
+				Options: options.Index().SetName("uniq_api_key_hash").SetUnique(true),
+			},
+		},
+	}
+}
+
 // OptimizeMongoDB 优化MongoDB配置和性能
+// 逐个创建索引，失败时记录日志并继续创建其余索引，最后返回失败的索引数
 func OptimizeMongoDB() error {
-	// 创建复合索引
-	indexModel := mongo.IndexModel{
-		Keys: bson.D{
-			{"created_at", 1},
-			{"status", 1},
-		},
-		Options: options.Index().SetBackground(true),
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if updated, err := backfillModelVersionModelID(ctx); err != nil {
+		log.Printf("补齐模型版本modelId失败: %v", err)
+	} else if updated > 0 {
+		log.Printf("已补齐%d个模型版本的modelId", updated)
 	}
 
-	_, err := MongoDB.Collection("recognition_results").Indexes().CreateOne(context.Background(), indexModel)
+	failed := 0
+	for _, index := range mongoIndexes() {
+		if _, err := MongoDB.Collection(index.collection).Indexes().CreateOne(ctx, index.model); err != nil {
+			log.Printf("创建%s索引失败: %v", index.description, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d个MongoDB索引创建失败", failed)
+	}
+	return nil
+}
+
+// backfillModelVersionModelID 为没有modelId的旧模型版本文档补齐所属模型
+// 版本号只对应一个模型时使用该模型ID，否则写入以文档ID区分的占位值，
+// 避免多个旧生产版本的modelId都为空导致唯一索引无法创建
+func backfillModelVersionModelID(ctx context.Context) (int64, error) {
+	versions := MongoDB.Collection("model_versions")
+	cursor, err := versions.Find(ctx, bson.M{"modelId": bson.M{"$in": bson.A{nil, ""}}},
+		options.Find().SetProjection(bson.M{"_id": 1, "version": 1}))
 	if err != nil {
-		log.Printf("创建MongoDB索引失败: %v", err)
-		return err
+		return 0, fmt.Errorf("查询旧模型版本失败: %w", err)
+	}
+	var legacy []struct {
+		ID      primitive.ObjectID `bson:"_id"`
+		Version string             `bson:"version"`
+	}
+	if err := cursor.All(ctx, &legacy); err != nil {
+		return 0, fmt.Errorf("解析旧模型版本失败: %w", err)
 	}
 
-	return nil
+	var updated int64
+	for _, v := range legacy {
+		modelID := "legacy-" + v.ID.Hex()
+		ids, err := MongoDB.Collection("models").Distinct(ctx, "_id", bson.M{"version": v.Version})
+		if err != nil {
+			return updated, fmt.Errorf("查询模型版本%s所属模型失败: %w", v.Version, err)
+		}
+		if len(ids) == 1 {
+			if id, ok := ids[0].(primitive.ObjectID); ok {
+				modelID = id.Hex()
+			}
+		}
+
+		result, err := versions.UpdateOne(ctx,
+			bson.M{"_id": v.ID, "modelId": bson.M{"$in": bson.A{nil, ""}}},
+			bson.M{"$set": bson.M{"modelId": modelID}})
+		if err != nil {
+			return updated, fmt.Errorf("补齐模型版本%s失败: %w", v.ID.Hex(), err)
+		}
+		updated += result.ModifiedCount
+	}
+	return updated, nil
 }
 
 // GetDatabaseStats 获取数据库统计信息
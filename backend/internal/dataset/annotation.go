@@ -0,0 +1,299 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// maxDisagreements 一致性报告中返回的分歧样本上限
+const maxDisagreements = 100
+
+// IsApproved 判断标注是否已通过审核，没有审核状态的导入标注视为已通过
+func IsApproved(a model.Annotation) bool {
+	return a.Status == "" || a.Status == model.ReviewApproved
+}
+
+// ValidReviewStatus 判断审核状态是否有效
+func ValidReviewStatus(status string) bool {
+	switch status {
+	case model.ReviewPending, model.ReviewApproved, model.ReviewRejected:
+		return true
+	}
+	return false
+}
+
+// SyncLabels 根据标注变更重新计算样本标签
+// 样本标签由两部分组成：不来自标注的图像级标签(手工设置或上传时指定)保持不变，
+// 其余标签取自已通过审核的标注
+func SyncLabels(labels []string, before, after []model.Annotation) []string {
+	derived := make(map[string]bool)
+	for _, a := range before {
+		if IsApproved(a) {
+			derived[a.Label] = true
+		}
+	}
+
+	result := make([]string, 0, len(labels))
+	seen := make(map[string]bool)
+	for _, l := range labels {
+		if !derived[l] && !seen[l] {
+			seen[l] = true
+			result = append(result, l)
+		}
+	}
+	for _, a := range after {
+		if IsApproved(a) && !seen[a.Label] {
+			seen[a.Label] = true
+			result = append(result, a.Label)
+		}
+	}
+	return result
+}
+
+// annotatorKey 标注人标识，优先使用用户名
+func annotatorKey(a model.Annotation) string {
+	if a.Annotator != "" {
+		return a.Annotator
+	}
+	return fmt.Sprintf("user-%d", a.AnnotatorID)
+}
+
+// raterView 某标注人对某样本的标注
+type raterView struct {
+	labels []string
+	boxes  map[string][]*model.BoundingBox
+}
+
+// labelKey 标签集合的规范表示
+func (v *raterView) labelKey() string {
+	return strings.Join(v.labels, "|")
+}
+
+// collectRaters 按标注人汇总样本的人工标注，忽略已驳回和导入的标注
+func collectRaters(sample *model.DatasetSample) map[string]*raterView {
+	raters := make(map[string]*raterView)
+	for _, a := range sample.Annotations {
+		if a.AnnotatorID == 0 || a.Status == model.ReviewRejected {
+			continue
+		}
+		key := annotatorKey(a)
+		v, ok := raters[key]
+		if !ok {
+			v = &raterView{boxes: make(map[string][]*model.BoundingBox)}
+			raters[key] = v
+		}
+		v.labels = append(v.labels, a.Label)
+		if a.BBox != nil {
+			v.boxes[a.Label] = append(v.boxes[a.Label], a.BBox)
+		}
+	}
+	for _, v := range raters {
+		v.labels = uniqueSorted(v.labels)
+	}
+	return raters
+}
+
+// uniqueSorted 去重并排序
+func uniqueSorted(values []string) []string {
+	sort.Strings(values)
+	result := make([]string, 0, len(values))
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// pairStats 标注人两两统计
+type pairStats struct {
+	shared, agreed int
+	countA, countB map[string]int
+	iouSum         float64
+	matched        int
+}
+
+// Agreement 计算数据集的标注人一致性报告
+// 标签一致率和Cohen's kappa以每名标注人对样本给出的标签集合为类别计算，
+// 目标框按相同类别贪心匹配后统计平均IoU
+func Agreement(datasetID string, samples []*model.DatasetSample) *model.AnnotationAgreementReport {
+	report := &model.AnnotationAgreementReport{
+		DatasetID:     datasetID,
+		StatusCount:   make(map[string]int),
+		Pairs:         make([]model.AnnotatorPairAgreement, 0),
+		Disagreements: make([]model.SampleDisagreement, 0),
+	}
+
+	annotators := make(map[string]bool)
+	pairs := make(map[[2]string]*pairStats)
+	for _, s := range samples {
+		for _, a := range s.Annotations {
+			if a.AnnotatorID == 0 {
+				continue
+			}
+			status := a.Status
+			if status == "" {
+				status = model.ReviewApproved
+			}
+			report.StatusCount[status]++
+		}
+
+		raters := collectRaters(s)
+		if len(raters) == 0 {
+			continue
+		}
+		report.AnnotatedSamples++
+		names := make([]string, 0, len(raters))
+		for name := range raters {
+			names = append(names, name)
+			annotators[name] = true
+		}
+		if len(raters) < 2 {
+			continue
+		}
+		report.MultiRaterSamples++
+		sort.Strings(names)
+
+		disagree := false
+		for i := 0; i < len(names); i++ {
+			for j := i + 1; j < len(names); j++ {
+				a, b := raters[names[i]], raters[names[j]]
+				key := [2]string{names[i], names[j]}
+				p, ok := pairs[key]
+				if !ok {
+					p = &pairStats{countA: make(map[string]int), countB: make(map[string]int)}
+					pairs[key] = p
+				}
+				p.shared++
+				p.countA[a.labelKey()]++
+				p.countB[b.labelKey()]++
+				if a.labelKey() == b.labelKey() {
+					p.agreed++
+				} else {
+					disagree = true
+				}
+				for label, boxesA := range a.boxes {
+					sum, n := matchBoxes(boxesA, b.boxes[label])
+					p.iouSum += sum
+					p.matched += n
+				}
+			}
+		}
+
+		if disagree && len(report.Disagreements) < maxDisagreements {
+			labels := make(map[string][]string, len(raters))
+			for name, v := range raters {
+				labels[name] = v.labels
+			}
+			report.Disagreements = append(report.Disagreements, model.SampleDisagreement{
+				SampleID: s.ID,
+				FileName: s.FileName,
+				Labels:   labels,
+			})
+		}
+	}
+	report.Annotators = len(annotators)
+
+	keys := make([][2]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+
+	var weight, agreementSum, kappaSum float64
+	for _, k := range keys {
+		p := pairs[k]
+		pair := model.AnnotatorPairAgreement{
+			AnnotatorA:    k[0],
+			AnnotatorB:    k[1],
+			SharedSamples: p.shared,
+			Agreement:     float64(p.agreed) / float64(p.shared),
+			Kappa:         cohenKappa(p),
+			MatchedBoxes:  p.matched,
+		}
+		if p.matched > 0 {
+			pair.MeanIoU = p.iouSum / float64(p.matched)
+		}
+		report.Pairs = append(report.Pairs, pair)
+
+		w := float64(p.shared)
+		weight += w
+		agreementSum += pair.Agreement * w
+		kappaSum += pair.Kappa * w
+	}
+	if weight > 0 {
+		report.OverallAgreement = agreementSum / weight
+		report.OverallKappa = kappaSum / weight
+	}
+
+	return report
+}
+
+// cohenKappa 计算Cohen's kappa，期望一致率为1时退化为观测一致率
+func cohenKappa(p *pairStats) float64 {
+	n := float64(p.shared)
+	po := float64(p.agreed) / n
+	var pe float64
+	for k, ca := range p.countA {
+		pe += (float64(ca) / n) * (float64(p.countB[k]) / n)
+	}
+	if pe >= 1 {
+		return po
+	}
+	return (po - pe) / (1 - pe)
+}
+
+// matchBoxes 按IoU从高到低贪心匹配两组目标框，返回匹配对的IoU之和及匹配数
+func matchBoxes(a, b []*model.BoundingBox) (float64, int) {
+	type candidate struct {
+		i, j int
+		iou  float64
+	}
+	candidates := make([]candidate, 0, len(a)*len(b))
+	for i, x := range a {
+		for j, y := range b {
+			if v := IoU(x, y); v > 0 {
+				candidates = append(candidates, candidate{i, j, v})
+			}
+		}
+	}
+	sort.Slice(candidates, func(x, y int) bool { return candidates[x].iou > candidates[y].iou })
+
+	usedA := make(map[int]bool)
+	usedB := make(map[int]bool)
+	var sum float64
+	var n int
+	for _, c := range candidates {
+		if usedA[c.i] || usedB[c.j] {
+			continue
+		}
+		usedA[c.i], usedB[c.j] = true, true
+		sum += c.iou
+		n++
+	}
+	return sum, n
+}
+
+// IoU 计算两个目标框的交并比
+func IoU(a, b *model.BoundingBox) float64 {
+	w := math.Min(a.X+a.Width, b.X+b.Width) - math.Max(a.X, b.X)
+	h := math.Min(a.Y+a.Height, b.Y+b.Height) - math.Max(a.Y, b.Y)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	inter := w * h
+	union := a.Width*a.Height + b.Width*b.Height - inter
+	if union <= 0 {
+		return 0
+	}
+	return inter / union
+}
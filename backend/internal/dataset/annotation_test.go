@@ -0,0 +1,76 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+func TestSyncLabels(t *testing.T) {
+	before := []model.Annotation{
+		{ID: "1", Label: "cat", Status: model.ReviewApproved},
+		{ID: "2", Label: "dog", Status: model.ReviewPending},
+	}
+	after := []model.Annotation{
+		{ID: "1", Label: "cat", Status: model.ReviewRejected},
+		{ID: "2", Label: "dog", Status: model.ReviewApproved},
+	}
+
+	// outdoor为手工设置的图像级标签，保持不变
+	labels := SyncLabels([]string{"outdoor", "cat"}, before, after)
+	assert.Equal(t, []string{"outdoor", "dog"}, labels)
+
+	// 导入的标注没有审核状态，视为已通过
+	labels = SyncLabels(nil, nil, []model.Annotation{{ID: "1", Label: "bird"}})
+	assert.Equal(t, []string{"bird"}, labels)
+}
+
+func TestAgreement(t *testing.T) {
+	box := func(x float64) *model.BoundingBox { return &model.BoundingBox{X: x, Y: 0, Width: 10, Height: 10} }
+	samples := []*model.DatasetSample{
+		{ID: "s1", Annotations: []model.Annotation{
+			{Label: "cat", AnnotatorID: 1, Annotator: "alice", BBox: box(0)},
+			{Label: "cat", AnnotatorID: 2, Annotator: "bob", BBox: box(5)},
+		}},
+		{ID: "s2", Annotations: []model.Annotation{
+			{Label: "dog", AnnotatorID: 1, Annotator: "alice", Status: model.ReviewApproved},
+			{Label: "dog", AnnotatorID: 2, Annotator: "bob", Status: model.ReviewPending},
+		}},
+		{ID: "s3", Annotations: []model.Annotation{
+			{Label: "cat", AnnotatorID: 1, Annotator: "alice"},
+			{Label: "dog", AnnotatorID: 2, Annotator: "bob"},
+			{Label: "cat", AnnotatorID: 2, Annotator: "bob", Status: model.ReviewRejected},
+		}},
+		{ID: "s4", Annotations: []model.Annotation{
+			{Label: "cat", AnnotatorID: 1, Annotator: "alice"},
+		}},
+		{ID: "s5", Annotations: []model.Annotation{{Label: "cat"}}},
+	}
+
+	report := Agreement("ds1", samples)
+	assert.Equal(t, 4, report.AnnotatedSamples)
+	assert.Equal(t, 3, report.MultiRaterSamples)
+	assert.Equal(t, 2, report.Annotators)
+	assert.Equal(t, 1, report.StatusCount[model.ReviewRejected])
+	assert.Equal(t, 1, report.StatusCount[model.ReviewPending])
+
+	pair := report.Pairs[0]
+	assert.Equal(t, "alice", pair.AnnotatorA)
+	assert.Equal(t, 3, pair.SharedSamples)
+	assert.InDelta(t, 2.0/3, pair.Agreement, 1e-9)
+	// po=2/3, pe=(2/3*1/3)+(1/3*2/3)=4/9 -> kappa=0.4
+	assert.InDelta(t, 0.4, pair.Kappa, 1e-9)
+	assert.Equal(t, 1, pair.MatchedBoxes)
+	assert.InDelta(t, 50.0/150, pair.MeanIoU, 1e-9)
+
+	assert.Len(t, report.Disagreements, 1)
+	assert.Equal(t, "s3", report.Disagreements[0].SampleID)
+}
+
+func TestIoU(t *testing.T) {
+	a := &model.BoundingBox{X: 0, Y: 0, Width: 10, Height: 10}
+	assert.InDelta(t, 1.0, IoU(a, a), 1e-9)
+	assert.Equal(t, 0.0, IoU(a, &model.BoundingBox{X: 20, Y: 20, Width: 5, Height: 5}))
+}
@@ -0,0 +1,261 @@
+package dataset
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// cocoFile COCO标注文件
+type cocoFile struct {
+	Info        *cocoInfo        `json:"info,omitempty"`
+	Images      []cocoImage      `json:"images"`
+	Annotations []cocoAnnotation `json:"annotations"`
+	Categories  []cocoCategory   `json:"categories"`
+}
+
+type cocoInfo struct {
+	Description string `json:"description"`
+	DateCreated string `json:"date_created"`
+}
+
+type cocoImage struct {
+	ID       int64  `json:"id"`
+	FileName string `json:"file_name"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+type cocoAnnotation struct {
+	ID         int64     `json:"id"`
+	ImageID    int64     `json:"image_id"`
+	CategoryID int64     `json:"category_id"`
+	BBox       []float64 `json:"bbox,omitempty"` // [x, y, width, height]
+	Area       float64   `json:"area,omitempty"`
+	IsCrowd    int       `json:"iscrowd"`
+}
+
+type cocoCategory struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	Supercategory string `json:"supercategory,omitempty"`
+}
+
+// findCOCOFile 查找COCO标注文件：优先根目录annotations.json，其次annotations目录下的json
+func findCOCOFile(archive *zip.Reader) *zip.File {
+	var candidate *zip.File
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || IsHiddenEntry(f.Name) || strings.ToLower(path.Ext(f.Name)) != ".json" {
+			continue
+		}
+		if path.Base(f.Name) == "annotations.json" {
+			return f
+		}
+		if candidate == nil && path.Base(path.Dir(f.Name)) == "annotations" {
+			candidate = f
+		}
+	}
+	return candidate
+}
+
+// importCOCO 解析COCO格式归档
+func importCOCO(archive *zip.Reader) ([]*ImportedSample, *ValidationReport, error) {
+	report := newReport(FormatCOCO)
+
+	entry := findCOCOFile(archive)
+	if entry == nil {
+		return nil, nil, fmt.Errorf("未找到COCO标注文件annotations.json")
+	}
+	rc, err := openAnnotation(entry, MaxCOCOAnnotationSize)
+	if errors.Is(err, ErrAnnotationTooLarge) {
+		report.addError(entry.Name, "标注文件超过%dMB限制", MaxCOCOAnnotationSize>>20)
+		return []*ImportedSample{}, report, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取COCO标注文件失败: %w", err)
+	}
+	defer rc.Close()
+
+	var doc cocoFile
+	if err := json.NewDecoder(rc).Decode(&doc); err != nil {
+		if errors.Is(err, ErrAnnotationTooLarge) {
+			report.addError(entry.Name, "标注文件超过%dMB限制", MaxCOCOAnnotationSize>>20)
+			return []*ImportedSample{}, report, nil
+		}
+		return nil, nil, fmt.Errorf("解析COCO标注文件失败: %w", err)
+	}
+
+	categories := make(map[int64]string, len(doc.Categories))
+	for _, c := range doc.Categories {
+		if strings.TrimSpace(c.Name) == "" {
+			report.addError(entry.Name, "类别%d名称为空，已忽略", c.ID)
+			continue
+		}
+		if _, dup := categories[c.ID]; dup {
+			report.addError(entry.Name, "类别ID %d重复，已忽略后出现的定义", c.ID)
+			continue
+		}
+		categories[c.ID] = c.Name
+	}
+
+	idx := indexImages(archive)
+	root := path.Dir(path.Dir(entry.Name))
+	samples := make([]*ImportedSample, 0, len(doc.Images))
+	byImageID := make(map[int64]*ImportedSample, len(doc.Images))
+	for _, img := range doc.Images {
+		if _, dup := byImageID[img.ID]; dup {
+			report.addError(img.FileName, "图像ID %d重复，已忽略", img.ID)
+			continue
+		}
+		f := idx.lookup(img.FileName, "images", path.Join(root, "images"), path.Dir(entry.Name))
+		if f == nil {
+			report.addError(img.FileName, "图像文件不存在")
+			continue
+		}
+		if f.UncompressedSize64 > uint64(MaxSampleSize) {
+			report.addError(img.FileName, "图像大小超过%dMB限制", MaxSampleSize>>20)
+			continue
+		}
+		idx.used[f] = true
+		s := &ImportedSample{
+			Entry:       f,
+			FileName:    path.Base(f.Name),
+			Width:       img.Width,
+			Height:      img.Height,
+			Annotations: make([]model.Annotation, 0),
+		}
+		byImageID[img.ID] = s
+		samples = append(samples, s)
+	}
+
+	for _, a := range doc.Annotations {
+		s, ok := byImageID[a.ImageID]
+		if !ok {
+			report.addError(entry.Name, "标注%d引用了不存在的图像%d", a.ID, a.ImageID)
+			continue
+		}
+		label, ok := categories[a.CategoryID]
+		if !ok {
+			report.addError(s.FileName, "标注%d引用了不存在的类别%d", a.ID, a.CategoryID)
+			continue
+		}
+		ann := model.Annotation{ID: fmt.Sprint(a.ID), Label: label}
+		if len(a.BBox) > 0 {
+			if len(a.BBox) != 4 {
+				report.addError(s.FileName, "标注%d目标框格式错误，应为[x,y,width,height]", a.ID)
+				continue
+			}
+			box := &model.BoundingBox{X: a.BBox[0], Y: a.BBox[1], Width: a.BBox[2], Height: a.BBox[3]}
+			if !validBBox(box, s.Width, s.Height) {
+				report.addError(s.FileName, "标注%d目标框超出图像范围或尺寸无效", a.ID)
+				continue
+			}
+			ann.BBox = box
+		}
+		s.Annotations = append(s.Annotations, ann)
+		report.Annotations++
+	}
+
+	for _, s := range samples {
+		s.Labels = annotationLabels(s.Annotations)
+		if len(s.Labels) == 0 {
+			report.addWarning(s.FileName, "图像没有标注，将作为未标注样本导入")
+		}
+	}
+	idx.reportUnused(report)
+	report.Samples = len(samples)
+	return samples, report, nil
+}
+
+// exportCOCO 导出COCO格式归档
+func exportCOCO(archive *zip.Writer, ds *model.Dataset, samples []*model.DatasetSample, open SampleOpener) (*ValidationReport, error) {
+	report := newReport(FormatCOCO)
+	names := exportNames(samples)
+
+	doc := cocoFile{
+		Info:        &cocoInfo{Description: ds.Name, DateCreated: time.Now().Format(time.RFC3339)},
+		Images:      make([]cocoImage, 0, len(samples)),
+		Annotations: make([]cocoAnnotation, 0),
+		Categories:  make([]cocoCategory, 0),
+	}
+
+	// 类别ID按名称排序分配，保证多次导出结果一致
+	labelSet := make(map[string]bool)
+	for _, s := range samples {
+		for _, l := range s.Labels {
+			labelSet[l] = true
+		}
+		for _, a := range s.Annotations {
+			labelSet[a.Label] = true
+		}
+	}
+	labels := make([]string, 0, len(labelSet))
+	for l := range labelSet {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	categoryIDs := make(map[string]int64, len(labels))
+	for i, l := range labels {
+		categoryIDs[l] = int64(i + 1)
+		doc.Categories = append(doc.Categories, cocoCategory{ID: int64(i + 1), Name: l})
+	}
+
+	var imageID, annotationID int64
+	for _, s := range samples {
+		name := names[s.ID]
+		if err := copyImage(archive, path.Join("images", name), s, open); err != nil {
+			report.addError(s.FileName, "读取样本图像失败: %v", err)
+			continue
+		}
+		imageID++
+		doc.Images = append(doc.Images, cocoImage{ID: imageID, FileName: name, Width: s.Width, Height: s.Height})
+
+		// 没有对应标注的图像级标签以无目标框标注导出
+		annotated := make(map[string]bool)
+		for _, a := range s.Annotations {
+			annotationID++
+			ca := cocoAnnotation{ID: annotationID, ImageID: imageID, CategoryID: categoryIDs[a.Label]}
+			if a.BBox != nil {
+				ca.BBox = []float64{a.BBox.X, a.BBox.Y, a.BBox.Width, a.BBox.Height}
+				ca.Area = a.BBox.Width * a.BBox.Height
+			}
+			doc.Annotations = append(doc.Annotations, ca)
+			annotated[a.Label] = true
+			report.Annotations++
+		}
+		for _, l := range s.Labels {
+			if annotated[l] {
+				continue
+			}
+			annotationID++
+			doc.Annotations = append(doc.Annotations, cocoAnnotation{ID: annotationID, ImageID: imageID, CategoryID: categoryIDs[l]})
+			report.Annotations++
+			report.addWarning(s.FileName, "标签%s没有目标框，已导出为无bbox标注", l)
+		}
+		report.Samples++
+	}
+
+	w, err := archive.Create("annotations.json")
+	if err != nil {
+		return nil, fmt.Errorf("写入COCO标注文件失败: %w", err)
+	}
+	if err := writeJSON(w, doc); err != nil {
+		return nil, fmt.Errorf("写入COCO标注文件失败: %w", err)
+	}
+	return report, nil
+}
+
+// writeJSON 以缩进格式写入JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,85 @@
+package dataset
+
+import (
+	"path"
+	"strings"
+)
+
+// 支持的样本图像格式
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".bmp":  true,
+}
+
+// MaxSampleSize 单个样本文件大小上限
+const MaxSampleSize = 10 * 1024 * 1024 // 10MB
+
+// IsImageFile 判断文件名是否为支持的图像格式
+func IsImageFile(name string) bool {
+	return imageExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// IsHiddenEntry 判断归档条目是否为系统生成的隐藏文件，如__MACOSX目录和.DS_Store
+func IsHiddenEntry(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// ArchiveRoot 归档中所有文件共同的顶层目录，如压缩工具将内容包在一个文件夹中时的photos/
+// 去掉该目录后没有文件位于子目录中时(即归档只有一个类别目录)返回空，该目录仍作为标签
+func ArchiveRoot(names []string) string {
+	root := ""
+	nested := false
+	for _, name := range names {
+		if strings.HasSuffix(name, "/") || IsHiddenEntry(name) {
+			continue
+		}
+		i := strings.Index(name, "/")
+		if i < 0 {
+			return ""
+		}
+		if root == "" {
+			root = name[:i+1]
+		} else if name[:i+1] != root {
+			return ""
+		}
+		if strings.Contains(name[i+1:], "/") {
+			nested = true
+		}
+	}
+	if !nested {
+		return ""
+	}
+	return root
+}
+
+// ArchiveLabel 归档条目去掉共同顶层目录root后的第一级目录名，文件直接位于根目录时返回空
+func ArchiveLabel(name, root string) string {
+	dir := path.Dir(strings.TrimPrefix(name, root))
+	if dir == "." {
+		return ""
+	}
+	return strings.Split(dir, "/")[0]
+}
+
+// ParseLabels 解析逗号分隔的标签列表，去除空白和重复项
+func ParseLabels(raw string) []string {
+	labels := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, label := range strings.Split(raw, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		labels = append(labels, label)
+	}
+	return labels
+}
@@ -0,0 +1,41 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveLabelWithWrappingFolder(t *testing.T) {
+	names := []string{
+		"photos/",
+		"photos/cat/1.jpg",
+		"photos/dog/2.jpg",
+		"__MACOSX/photos/cat/._1.jpg",
+		"photos/.DS_Store",
+	}
+	root := ArchiveRoot(names)
+	assert.Equal(t, "photos/", root)
+	assert.Equal(t, "cat", ArchiveLabel("photos/cat/1.jpg", root))
+	assert.Equal(t, "dog", ArchiveLabel("photos/dog/2.jpg", root))
+}
+
+func TestArchiveLabelWithoutWrappingFolder(t *testing.T) {
+	names := []string{"cat/1.jpg", "dog/2.jpg", "3.jpg"}
+	root := ArchiveRoot(names)
+	assert.Equal(t, "", root)
+	assert.Equal(t, "cat", ArchiveLabel("cat/1.jpg", root))
+	assert.Equal(t, "", ArchiveLabel("3.jpg", root))
+
+	// 只有一个类别目录时该目录仍作为标签
+	single := []string{"cat/1.jpg", "cat/2.jpg"}
+	root = ArchiveRoot(single)
+	assert.Equal(t, "", root)
+	assert.Equal(t, "cat", ArchiveLabel("cat/1.jpg", root))
+
+	// 包装文件夹中的文件直接位于该文件夹下时不作为类别
+	mixed := []string{"photos/cat/1.jpg", "photos/2.jpg"}
+	root = ArchiveRoot(mixed)
+	assert.Equal(t, "photos/", root)
+	assert.Equal(t, "", ArchiveLabel("photos/2.jpg", root))
+}
@@ -0,0 +1,291 @@
+package dataset
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// Format 数据集交换格式
+type Format string
+
+const (
+	FormatCOCO     Format = "coco"     // COCO JSON
+	FormatVOC      Format = "voc"      // Pascal VOC XML
+	FormatImageNet Format = "imagenet" // 按类别分目录
+)
+
+// 校验问题级别
+const (
+	SeverityError   = "error"   // 文件或标注被跳过
+	SeverityWarning = "warning" // 已处理但可能与预期不符
+)
+
+// 标注文件解压后的大小上限，防止高压缩比的标注文件耗尽内存
+const (
+	MaxCOCOAnnotationSize = 256 * 1024 * 1024 // 256MB，整个数据集的标注在同一文件中
+	MaxVOCAnnotationSize  = 1024 * 1024       // 1MB，每张图像一个标注文件
+)
+
+// ErrAnnotationTooLarge 标注文件超过大小限制
+var ErrAnnotationTooLarge = errors.New("标注文件超过大小限制")
+
+// ParseFormat 解析格式名称
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatCOCO:
+		return FormatCOCO, nil
+	case FormatVOC:
+		return FormatVOC, nil
+	case FormatImageNet:
+		return FormatImageNet, nil
+	default:
+		return "", fmt.Errorf("不支持的数据集格式: %s", name)
+	}
+}
+
+// ValidationIssue 校验问题
+type ValidationIssue struct {
+	File     string `json:"file"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// ValidationReport 导入导出校验报告
+type ValidationReport struct {
+	Format      Format            `json:"format"`
+	Samples     int               `json:"samples"`     // 成功处理的样本数
+	Annotations int               `json:"annotations"` // 成功处理的标注数
+	Errors      int               `json:"errors"`
+	Warnings    int               `json:"warnings"`
+	Issues      []ValidationIssue `json:"issues"`
+}
+
+// newReport 创建校验报告
+func newReport(format Format) *ValidationReport {
+	return &ValidationReport{Format: format, Issues: make([]ValidationIssue, 0)}
+}
+
+// addError 记录错误
+func (r *ValidationReport) addError(file, format string, args ...interface{}) {
+	r.Errors++
+	r.Issues = append(r.Issues, ValidationIssue{File: file, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+// addWarning 记录警告
+func (r *ValidationReport) addWarning(file, format string, args ...interface{}) {
+	r.Warnings++
+	r.Issues = append(r.Issues, ValidationIssue{File: file, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+// RejectSample 将已计入报告的样本标记为失败，用于保存阶段出错的样本
+func (r *ValidationReport) RejectSample(file, message string) {
+	r.Samples--
+	r.addError(file, "%s", message)
+}
+
+// openAnnotation 打开归档中的标注文件，读取超过limit字节时返回ErrAnnotationTooLarge
+// 归档中记录的解压后大小可能与实际内容不符，因此除预先检查外还限制实际读取的字节数
+func openAnnotation(f *zip.File, limit int64) (io.ReadCloser, error) {
+	if f.UncompressedSize64 > uint64(limit) {
+		return nil, ErrAnnotationTooLarge
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &annotationReader{ReadCloser: rc, remaining: limit}, nil
+}
+
+// annotationReader 限制读取字节数的标注文件
+type annotationReader struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (r *annotationReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		// 已读满上限，仍能读到内容说明文件超过限制
+		var probe [1]byte
+		n, err := r.ReadCloser.Read(probe[:])
+		if n > 0 {
+			return 0, ErrAnnotationTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
+
+// ImportedSample 从归档中解析出的样本，图像内容通过Entry读取
+type ImportedSample struct {
+	Entry       *zip.File
+	FileName    string
+	Width       int
+	Height      int
+	Labels      []string
+	Annotations []model.Annotation
+}
+
+// Import 按指定格式解析zip归档
+// 返回可导入的样本及校验报告；归档整体无法识别时返回error
+func Import(format Format, archive *zip.Reader) ([]*ImportedSample, *ValidationReport, error) {
+	switch format {
+	case FormatCOCO:
+		return importCOCO(archive)
+	case FormatVOC:
+		return importVOC(archive)
+	case FormatImageNet:
+		return importImageNet(archive)
+	default:
+		return nil, nil, fmt.Errorf("不支持的数据集格式: %s", format)
+	}
+}
+
+// SampleOpener 打开样本图像文件
+type SampleOpener func(sample *model.DatasetSample) (io.ReadCloser, error)
+
+// Export 按指定格式将样本写入zip归档
+// 无法导出的样本记录在校验报告中，不中断导出
+func Export(format Format, archive *zip.Writer, ds *model.Dataset, samples []*model.DatasetSample, open SampleOpener) (*ValidationReport, error) {
+	switch format {
+	case FormatCOCO:
+		return exportCOCO(archive, ds, samples, open)
+	case FormatVOC:
+		return exportVOC(archive, samples, open)
+	case FormatImageNet:
+		return exportImageNet(archive, samples, open)
+	default:
+		return nil, fmt.Errorf("不支持的数据集格式: %s", format)
+	}
+}
+
+// archiveIndex 归档内图像文件索引
+type archiveIndex struct {
+	byPath map[string]*zip.File
+	byBase map[string][]*zip.File
+	used   map[*zip.File]bool
+}
+
+// indexImages 建立归档内图像文件索引，忽略目录和隐藏文件
+func indexImages(archive *zip.Reader) *archiveIndex {
+	idx := &archiveIndex{
+		byPath: make(map[string]*zip.File),
+		byBase: make(map[string][]*zip.File),
+		used:   make(map[*zip.File]bool),
+	}
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || IsHiddenEntry(f.Name) || !IsImageFile(f.Name) {
+			continue
+		}
+		idx.byPath[f.Name] = f
+		base := path.Base(f.Name)
+		idx.byBase[base] = append(idx.byBase[base], f)
+	}
+	return idx
+}
+
+// lookup 按候选路径查找图像，最后按文件名唯一匹配
+func (idx *archiveIndex) lookup(name string, dirs ...string) *zip.File {
+	name = strings.TrimPrefix(path.Clean(strings.ReplaceAll(name, "\\", "/")), "/")
+	if f, ok := idx.byPath[name]; ok {
+		return f
+	}
+	for _, dir := range dirs {
+		if f, ok := idx.byPath[path.Join(dir, name)]; ok {
+			return f
+		}
+	}
+	if matches := idx.byBase[path.Base(name)]; len(matches) == 1 {
+		return matches[0]
+	}
+	return nil
+}
+
+// reportUnused 将未被标注文件引用的图像记为警告
+func (idx *archiveIndex) reportUnused(report *ValidationReport) {
+	names := make([]string, 0)
+	for name, f := range idx.byPath {
+		if !idx.used[f] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.addWarning(name, "图像未被任何标注引用，已忽略")
+	}
+}
+
+// annotationLabels 汇总标注中的类别，保持首次出现顺序
+func annotationLabels(annotations []model.Annotation) []string {
+	labels := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, a := range annotations {
+		if !seen[a.Label] {
+			seen[a.Label] = true
+			labels = append(labels, a.Label)
+		}
+	}
+	return labels
+}
+
+// exportNames 为样本分配归档内唯一文件名
+// 按不含扩展名的部分去重，保证VOC标注文件名也不冲突
+func exportNames(samples []*model.DatasetSample) map[string]string {
+	names := make(map[string]string, len(samples))
+	used := make(map[string]bool)
+	for _, s := range samples {
+		name := path.Base(strings.ReplaceAll(s.FileName, "\\", "/"))
+		if name == "." || name == "/" || name == "" {
+			name = s.ID + path.Ext(s.FilePath)
+		}
+		stem := strings.ToLower(strings.TrimSuffix(name, path.Ext(name)))
+		if used[stem] {
+			name = s.ID + "_" + name
+			stem = strings.ToLower(strings.TrimSuffix(name, path.Ext(name)))
+		}
+		used[stem] = true
+		names[s.ID] = name
+	}
+	return names
+}
+
+// copyImage 将样本图像写入归档
+func copyImage(archive *zip.Writer, name string, sample *model.DatasetSample, open SampleOpener) error {
+	src, err := open(sample)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// validBBox 校验目标框是否有效，图像尺寸未知时只校验宽高
+func validBBox(box *model.BoundingBox, width, height int) bool {
+	if box.Width <= 0 || box.Height <= 0 || box.X < 0 || box.Y < 0 {
+		return false
+	}
+	if width > 0 && box.X+box.Width > float64(width)+1 {
+		return false
+	}
+	if height > 0 && box.Y+box.Height > float64(height)+1 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package dataset
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// buildZip 构建内存zip归档
+func buildZip(t *testing.T, files map[string]string) *zip.Reader {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return r
+}
+
+// roundTrip 导出样本后重新导入
+func roundTrip(t *testing.T, format Format, samples []*model.DatasetSample) ([]*ImportedSample, *ValidationReport, *ValidationReport) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	exported, err := Export(format, w, &model.Dataset{Name: "test"}, samples, func(s *model.DatasetSample) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader([]byte("image-" + s.ID))), nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	imported, report, err := Import(format, r)
+	require.NoError(t, err)
+	return imported, exported, report
+}
+
+func testSamples() []*model.DatasetSample {
+	return []*model.DatasetSample{
+		{
+			ID: "1", FileName: "a.jpg", Width: 100, Height: 80, Labels: []string{"cat"},
+			Annotations: []model.Annotation{{ID: "1", Label: "cat", BBox: &model.BoundingBox{X: 10, Y: 10, Width: 30, Height: 20}}},
+		},
+		{
+			ID: "2", FileName: "a.png", Width: 50, Height: 50, Labels: []string{"dog"},
+			Annotations: []model.Annotation{{ID: "1", Label: "dog", BBox: &model.BoundingBox{X: 0, Y: 0, Width: 50, Height: 50}}},
+		},
+		{ID: "3", FileName: "c.jpg", Labels: []string{}},
+	}
+}
+
+func TestCOCORoundTrip(t *testing.T) {
+	imported, exported, report := roundTrip(t, FormatCOCO, testSamples())
+
+	assert.Equal(t, 3, exported.Samples)
+	assert.Equal(t, 0, exported.Errors)
+	require.Len(t, imported, 3)
+	assert.Equal(t, 0, report.Errors)
+	assert.Equal(t, []string{"cat"}, imported[0].Labels)
+	assert.Equal(t, model.BoundingBox{X: 10, Y: 10, Width: 30, Height: 20}, *imported[0].Annotations[0].BBox)
+	assert.Equal(t, 100, imported[0].Width)
+	assert.Empty(t, imported[2].Labels)
+}
+
+func TestVOCRoundTrip(t *testing.T) {
+	imported, exported, report := roundTrip(t, FormatVOC, testSamples())
+
+	assert.Equal(t, 3, exported.Samples)
+	require.Len(t, imported, 3)
+	assert.Equal(t, 0, report.Errors)
+
+	labels := make(map[string][]string)
+	for _, s := range imported {
+		labels[s.FileName] = s.Labels
+	}
+	// 同名不同扩展名的文件导出时需去重
+	assert.Len(t, labels, 3)
+	assert.Equal(t, []string{"cat"}, labels["a.jpg"])
+}
+
+func TestImageNetRoundTrip(t *testing.T) {
+	samples := testSamples()
+	samples[0].Labels = []string{"cat", "pet"}
+	imported, exported, report := roundTrip(t, FormatImageNet, samples)
+
+	// 未标注样本跳过，多标签样本和目标框均产生告警
+	assert.Equal(t, 2, exported.Samples)
+	assert.Equal(t, 4, exported.Warnings)
+	require.Len(t, imported, 2)
+	assert.Equal(t, 0, report.Errors)
+	assert.Equal(t, []string{"cat"}, imported[0].Labels)
+}
+
+func TestCOCOImportValidation(t *testing.T) {
+	r := buildZip(t, map[string]string{
+		"annotations.json": `{
+			"images": [
+				{"id": 1, "file_name": "a.jpg", "width": 10, "height": 10},
+				{"id": 2, "file_name": "missing.jpg", "width": 10, "height": 10}
+			],
+			"categories": [{"id": 1, "name": "cat"}],
+			"annotations": [
+				{"id": 1, "image_id": 1, "category_id": 1, "bbox": [0, 0, 5, 5]},
+				{"id": 2, "image_id": 1, "category_id": 9, "bbox": [0, 0, 5, 5]},
+				{"id": 3, "image_id": 1, "category_id": 1, "bbox": [8, 8, 5, 5]},
+				{"id": 4, "image_id": 7, "category_id": 1}
+			]
+		}`,
+		"images/a.jpg":     "x",
+		"images/extra.jpg": "x",
+	})
+
+	samples, report, err := Import(FormatCOCO, r)
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Len(t, samples[0].Annotations, 1)
+	assert.Equal(t, 4, report.Errors)   // 缺失图像、未知类别、越界目标框、未知图像
+	assert.Equal(t, 1, report.Warnings) // 未引用图像
+}
+
+func TestImageNetImportRootImage(t *testing.T) {
+	r := buildZip(t, map[string]string{
+		"train/cat/1.jpg": "x",
+		"dog/2.jpg":       "x",
+		"3.jpg":           "x",
+	})
+
+	samples, report, err := Import(FormatImageNet, r)
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, []string{"dog"}, samples[0].Labels)
+	assert.Equal(t, []string{"cat"}, samples[1].Labels)
+	assert.Equal(t, 1, report.Errors)
+}
+
+func TestImportRejectsOversizeAnnotations(t *testing.T) {
+	padding := strings.Repeat(" ", MaxVOCAnnotationSize)
+	archive := buildZip(t, map[string]string{
+		"Annotations/a.xml": "<annotation><filename>a.jpg</filename>" + padding + "</annotation>",
+		"JPEGImages/a.jpg":  "image-a",
+	})
+
+	samples, report, err := Import(FormatVOC, archive)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Errors)
+	assert.Equal(t, "Annotations/a.xml", report.Issues[0].File)
+	assert.Contains(t, report.Issues[0].Message, "超过")
+	// 图像仍作为未标注样本导入
+	require.Len(t, samples, 1)
+	assert.Empty(t, samples[0].Annotations)
+}
+
+func TestAnnotationReaderLimit(t *testing.T) {
+	// 归档记录的大小可能被伪造，实际读取超过上限时同样报错
+	r := &annotationReader{ReadCloser: io.NopCloser(strings.NewReader("0123456789")), remaining: 4}
+	_, err := io.ReadAll(r)
+	assert.ErrorIs(t, err, ErrAnnotationTooLarge)
+
+	r = &annotationReader{ReadCloser: io.NopCloser(strings.NewReader("0123")), remaining: 4}
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "0123", string(data))
+}
@@ -0,0 +1,85 @@
+package dataset
+
+import (
+	"archive/zip"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// importImageNet 解析ImageNet目录格式归档，图像的直接父目录名即类别
+func importImageNet(archive *zip.Reader) ([]*ImportedSample, *ValidationReport, error) {
+	report := newReport(FormatImageNet)
+	idx := indexImages(archive)
+	if len(idx.byPath) == 0 {
+		return nil, nil, fmt.Errorf("归档中没有图像文件")
+	}
+
+	names := make([]string, 0, len(idx.byPath))
+	for name := range idx.byPath {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	samples := make([]*ImportedSample, 0, len(names))
+	for _, name := range names {
+		f := idx.byPath[name]
+		dir := path.Dir(name)
+		if dir == "." || dir == "/" {
+			report.addError(name, "图像不在类别目录下，已跳过")
+			continue
+		}
+		if f.UncompressedSize64 > uint64(MaxSampleSize) {
+			report.addError(name, "图像大小超过%dMB限制", MaxSampleSize>>20)
+			continue
+		}
+		label := path.Base(dir)
+		samples = append(samples, &ImportedSample{
+			Entry:       f,
+			FileName:    path.Base(name),
+			Labels:      []string{label},
+			Annotations: []model.Annotation{{ID: "1", Label: label}},
+		})
+		report.Annotations++
+	}
+
+	report.Samples = len(samples)
+	return samples, report, nil
+}
+
+// exportImageNet 导出ImageNet目录格式归档，样本按主标签(第一个标签)分目录
+func exportImageNet(archive *zip.Writer, samples []*model.DatasetSample, open SampleOpener) (*ValidationReport, error) {
+	report := newReport(FormatImageNet)
+	names := exportNames(samples)
+
+	for _, s := range samples {
+		if len(s.Labels) == 0 {
+			report.addWarning(s.FileName, "样本未标注，已跳过")
+			continue
+		}
+		label := s.Labels[0]
+		if len(s.Labels) > 1 {
+			report.addWarning(s.FileName, "样本有%d个标签，仅按主标签%s导出", len(s.Labels), label)
+		}
+		if strings.ContainsAny(label, "/\\") || label == "." || label == ".." {
+			report.addError(s.FileName, "标签%s不能作为目录名，已跳过", label)
+			continue
+		}
+		for _, a := range s.Annotations {
+			if a.BBox != nil {
+				report.addWarning(s.FileName, "ImageNet格式不支持目标框，已忽略")
+				break
+			}
+		}
+		if err := copyImage(archive, path.Join(label, names[s.ID]), s, open); err != nil {
+			report.addError(s.FileName, "读取样本图像失败: %v", err)
+			continue
+		}
+		report.Annotations++
+		report.Samples++
+	}
+	return report, nil
+}
@@ -0,0 +1,128 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// splitNames 拆分顺序，与数据集类型一一对应
+var splitNames = []string{model.DatasetTypeTrain, model.DatasetTypeValidation, model.DatasetTypeTest}
+
+// ValidateRatios 校验拆分比例
+func ValidateRatios(r model.SplitRatios) error {
+	if r.Train < 0 || r.Validation < 0 || r.Test < 0 {
+		return fmt.Errorf("拆分比例不能为负数")
+	}
+	if r.Train == 0 {
+		return fmt.Errorf("训练集比例必须大于0")
+	}
+	if math.Abs(r.Train+r.Validation+r.Test-1) > 1e-6 {
+		return fmt.Errorf("拆分比例之和必须为1")
+	}
+	return nil
+}
+
+// splitUnit 拆分的最小单位，同一单位内的样本总是进入同一拆分
+type splitUnit struct {
+	key     string
+	samples []*model.DatasetSample
+}
+
+// Split 将样本按比例确定性地拆分为训练/验证/测试集
+// groupKey返回相同值的样本(如内容相同的重复图像)视为一个整体，保证不会跨拆分泄漏；
+// groupKey为nil时按样本ID分组。stratify为true时按主标签(第一个标签)分层，
+// 未标注样本单独成层。相同的样本集合、比例和种子总是得到相同结果，与输入顺序无关。
+// 返回值以数据集类型为键
+func Split(samples []*model.DatasetSample, ratios model.SplitRatios, seed int64, stratify bool, groupKey func(*model.DatasetSample) string) (map[string][]*model.DatasetSample, error) {
+	if err := ValidateRatios(ratios); err != nil {
+		return nil, err
+	}
+	if groupKey == nil {
+		groupKey = func(s *model.DatasetSample) string { return s.ID }
+	}
+
+	// 按分组键合并样本，单位内样本按ID排序
+	byKey := make(map[string]*splitUnit)
+	for _, s := range samples {
+		key := groupKey(s)
+		u, ok := byKey[key]
+		if !ok {
+			u = &splitUnit{key: key}
+			byKey[key] = u
+		}
+		u.samples = append(u.samples, s)
+	}
+
+	// 以单位内ID最小样本的主标签作为分层依据
+	strata := make(map[string][]*splitUnit)
+	for _, u := range byKey {
+		sort.Slice(u.samples, func(i, j int) bool { return u.samples[i].ID < u.samples[j].ID })
+		stratum := ""
+		if stratify && len(u.samples[0].Labels) > 0 {
+			stratum = u.samples[0].Labels[0]
+		}
+		strata[stratum] = append(strata[stratum], u)
+	}
+	stratumNames := make([]string, 0, len(strata))
+	for name := range strata {
+		stratumNames = append(stratumNames, name)
+	}
+	sort.Strings(stratumNames)
+
+	result := make(map[string][]*model.DatasetSample, len(splitNames))
+	for _, name := range splitNames {
+		result[name] = make([]*model.DatasetSample, 0)
+	}
+
+	weights := []float64{ratios.Train, ratios.Validation, ratios.Test}
+	rng := rand.New(rand.NewSource(seed))
+	for _, name := range stratumNames {
+		units := strata[name]
+		sort.Slice(units, func(i, j int) bool { return units[i].key < units[j].key })
+		rng.Shuffle(len(units), func(i, j int) { units[i], units[j] = units[j], units[i] })
+
+		offset := 0
+		for i, n := range allocate(len(units), weights) {
+			for _, u := range units[offset : offset+n] {
+				result[splitNames[i]] = append(result[splitNames[i]], u.samples...)
+			}
+			offset += n
+		}
+	}
+
+	return result, nil
+}
+
+// allocate 按权重将n个单位分配到各拆分，使用最大余数法保证总数为n
+func allocate(n int, weights []float64) []int {
+	counts := make([]int, len(weights))
+	remainders := make([]float64, len(weights))
+	assigned := 0
+	for i, w := range weights {
+		exact := float64(n) * w
+		counts[i] = int(math.Floor(exact))
+		remainders[i] = exact - float64(counts[i])
+		assigned += counts[i]
+	}
+
+	// 余数相同时优先分给靠前的拆分(训练集)
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for _, i := range order {
+		if assigned >= n {
+			break
+		}
+		if weights[i] > 0 {
+			counts[i]++
+			assigned++
+		}
+	}
+	return counts
+}
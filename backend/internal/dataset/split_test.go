@@ -0,0 +1,114 @@
+package dataset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+func splitSamples() []*model.DatasetSample {
+	samples := make([]*model.DatasetSample, 0, 130)
+	for i := 0; i < 100; i++ {
+		samples = append(samples, &model.DatasetSample{ID: fmt.Sprintf("cat-%03d", i), Labels: []string{"cat"}})
+	}
+	for i := 0; i < 30; i++ {
+		samples = append(samples, &model.DatasetSample{ID: fmt.Sprintf("dog-%03d", i), Labels: []string{"dog"}})
+	}
+	return samples
+}
+
+func countLabel(samples []*model.DatasetSample, label string) int {
+	n := 0
+	for _, s := range samples {
+		if s.Labels[0] == label {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSplitStratified(t *testing.T) {
+	ratios := model.SplitRatios{Train: 0.8, Validation: 0.1, Test: 0.1}
+	result, err := Split(splitSamples(), ratios, 42, true, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 80, countLabel(result[model.DatasetTypeTrain], "cat"))
+	assert.Equal(t, 24, countLabel(result[model.DatasetTypeTrain], "dog"))
+	assert.Equal(t, 10, countLabel(result[model.DatasetTypeValidation], "cat"))
+	assert.Equal(t, 3, countLabel(result[model.DatasetTypeTest], "dog"))
+
+	// 每个样本恰好出现在一个拆分中
+	seen := make(map[string]string)
+	for name, samples := range result {
+		for _, s := range samples {
+			_, dup := seen[s.ID]
+			assert.False(t, dup, s.ID)
+			seen[s.ID] = name
+		}
+	}
+	assert.Len(t, seen, 130)
+}
+
+func TestSplitDeterministic(t *testing.T) {
+	ratios := model.SplitRatios{Train: 0.7, Validation: 0.15, Test: 0.15}
+	samples := splitSamples()
+	first, err := Split(samples, ratios, 7, true, nil)
+	require.NoError(t, err)
+
+	// 输入顺序不影响结果
+	reversed := make([]*model.DatasetSample, len(samples))
+	for i, s := range samples {
+		reversed[len(samples)-1-i] = s
+	}
+	second, err := Split(reversed, ratios, 7, true, nil)
+	require.NoError(t, err)
+
+	for name := range first {
+		ids := func(list []*model.DatasetSample) map[string]bool {
+			m := make(map[string]bool)
+			for _, s := range list {
+				m[s.ID] = true
+			}
+			return m
+		}
+		assert.Equal(t, ids(first[name]), ids(second[name]), name)
+	}
+
+	other, err := Split(samples, ratios, 8, true, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, first[model.DatasetTypeTest], other[model.DatasetTypeTest])
+}
+
+func TestSplitGroupsStayTogether(t *testing.T) {
+	samples := splitSamples()
+	// 每5个样本内容相同
+	group := func(s *model.DatasetSample) string {
+		var n int
+		fmt.Sscanf(s.ID[4:], "%d", &n)
+		return fmt.Sprintf("%s-%d", s.Labels[0], n/5)
+	}
+	result, err := Split(samples, model.SplitRatios{Train: 0.6, Validation: 0.2, Test: 0.2}, 1, true, group)
+	require.NoError(t, err)
+
+	owner := make(map[string]string)
+	for name, list := range result {
+		for _, s := range list {
+			g := group(s)
+			if prev, ok := owner[g]; ok {
+				assert.Equal(t, prev, name, g)
+			}
+			owner[g] = name
+		}
+	}
+}
+
+func TestValidateRatios(t *testing.T) {
+	assert.NoError(t, ValidateRatios(model.SplitRatios{Train: 0.8, Test: 0.2}))
+	assert.Error(t, ValidateRatios(model.SplitRatios{Train: 0.8, Test: 0.3}))
+	assert.Error(t, ValidateRatios(model.SplitRatios{Validation: 0.5, Test: 0.5}))
+	assert.Error(t, ValidateRatios(model.SplitRatios{Train: 1.2, Test: -0.2}))
+}
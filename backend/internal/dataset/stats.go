@@ -0,0 +1,61 @@
+package dataset
+
+import (
+	"math"
+	"sort"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// BuildLabelStats 根据标签计数生成标签分布及类别不平衡统计
+// counts中空字符串键表示未标注样本数，total为数据集样本总数
+func BuildLabelStats(datasetID string, counts map[string]int64, total int64) *model.DatasetLabelStats {
+	stats := &model.DatasetLabelStats{
+		DatasetID:        datasetID,
+		TotalSamples:     total,
+		UnlabeledSamples: counts[""],
+		Labels:           make([]model.LabelCount, 0, len(counts)),
+	}
+
+	// 多标签样本会被计入多个类别，比例以标注总数为分母
+	var labeled int64
+	for label, count := range counts {
+		if label == "" || count <= 0 {
+			continue
+		}
+		labeled += count
+		stats.Labels = append(stats.Labels, model.LabelCount{Label: label, Count: count})
+	}
+	stats.LabelCount = len(stats.Labels)
+	if labeled == 0 {
+		return stats
+	}
+
+	// 按样本数降序，样本数相同时按标签名排序保证输出稳定
+	sort.Slice(stats.Labels, func(i, j int) bool {
+		if stats.Labels[i].Count != stats.Labels[j].Count {
+			return stats.Labels[i].Count > stats.Labels[j].Count
+		}
+		return stats.Labels[i].Label < stats.Labels[j].Label
+	})
+
+	var entropy float64
+	for i := range stats.Labels {
+		p := float64(stats.Labels[i].Count) / float64(labeled)
+		stats.Labels[i].Ratio = p
+		entropy -= p * math.Log(p)
+	}
+
+	majority := stats.Labels[0]
+	minority := stats.Labels[len(stats.Labels)-1]
+	stats.MajorityLabel = majority.Label
+	stats.MinorityLabel = minority.Label
+	stats.ImbalanceRatio = float64(majority.Count) / float64(minority.Count)
+
+	// 只有一个类别时无法衡量均衡程度，熵按0处理
+	if len(stats.Labels) > 1 {
+		stats.NormalizedEntropy = entropy / math.Log(float64(len(stats.Labels)))
+	}
+
+	return stats
+}
@@ -0,0 +1,45 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildLabelStats(t *testing.T) {
+	counts := map[string]int64{
+		"cat":  60,
+		"dog":  30,
+		"bird": 10,
+		"":     5,
+	}
+
+	stats := BuildLabelStats("ds1", counts, 105)
+
+	assert.Equal(t, int64(105), stats.TotalSamples)
+	assert.Equal(t, int64(5), stats.UnlabeledSamples)
+	assert.Equal(t, 3, stats.LabelCount)
+	assert.Equal(t, "cat", stats.Labels[0].Label)
+	assert.Equal(t, "bird", stats.Labels[2].Label)
+	assert.InDelta(t, 0.6, stats.Labels[0].Ratio, 1e-9)
+	assert.Equal(t, "cat", stats.MajorityLabel)
+	assert.Equal(t, "bird", stats.MinorityLabel)
+	assert.InDelta(t, 6.0, stats.ImbalanceRatio, 1e-9)
+	assert.True(t, stats.NormalizedEntropy > 0 && stats.NormalizedEntropy < 1)
+}
+
+func TestBuildLabelStatsBalanced(t *testing.T) {
+	stats := BuildLabelStats("ds1", map[string]int64{"a": 10, "b": 10}, 20)
+
+	assert.InDelta(t, 1.0, stats.ImbalanceRatio, 1e-9)
+	assert.InDelta(t, 1.0, stats.NormalizedEntropy, 1e-9)
+}
+
+func TestBuildLabelStatsEmpty(t *testing.T) {
+	stats := BuildLabelStats("ds1", map[string]int64{"": 3}, 3)
+
+	assert.Equal(t, 0, stats.LabelCount)
+	assert.Equal(t, int64(3), stats.UnlabeledSamples)
+	assert.Empty(t, stats.Labels)
+	assert.Zero(t, stats.ImbalanceRatio)
+}
@@ -0,0 +1,235 @@
+package dataset
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// vocAnnotation Pascal VOC标注文件
+type vocAnnotation struct {
+	XMLName  xml.Name    `xml:"annotation"`
+	Folder   string      `xml:"folder"`
+	Filename string      `xml:"filename"`
+	Size     vocSize     `xml:"size"`
+	Objects  []vocObject `xml:"object"`
+}
+
+type vocSize struct {
+	Width  int `xml:"width"`
+	Height int `xml:"height"`
+	Depth  int `xml:"depth"`
+}
+
+type vocObject struct {
+	Name      string     `xml:"name"`
+	Difficult int        `xml:"difficult"`
+	BndBox    *vocBndBox `xml:"bndbox"`
+}
+
+type vocBndBox struct {
+	XMin float64 `xml:"xmin"`
+	YMin float64 `xml:"ymin"`
+	XMax float64 `xml:"xmax"`
+	YMax float64 `xml:"ymax"`
+}
+
+// importVOC 解析Pascal VOC格式归档，标注位于Annotations目录，图像位于JPEGImages目录
+func importVOC(archive *zip.Reader) ([]*ImportedSample, *ValidationReport, error) {
+	report := newReport(FormatVOC)
+	idx := indexImages(archive)
+
+	xmlFiles := make([]*zip.File, 0)
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || IsHiddenEntry(f.Name) || strings.ToLower(path.Ext(f.Name)) != ".xml" {
+			continue
+		}
+		if path.Base(path.Dir(f.Name)) == "Annotations" {
+			xmlFiles = append(xmlFiles, f)
+		}
+	}
+	if len(xmlFiles) == 0 && len(idx.byPath) == 0 {
+		return nil, nil, fmt.Errorf("未找到VOC标注文件(Annotations/*.xml)")
+	}
+	sort.Slice(xmlFiles, func(i, j int) bool { return xmlFiles[i].Name < xmlFiles[j].Name })
+
+	samples := make([]*ImportedSample, 0, len(xmlFiles))
+	for _, f := range xmlFiles {
+		doc, err := readVOCAnnotation(f)
+		if errors.Is(err, ErrAnnotationTooLarge) {
+			report.addError(f.Name, "标注文件超过%dKB限制，已忽略", MaxVOCAnnotationSize>>10)
+			continue
+		}
+		if err != nil {
+			report.addError(f.Name, "解析标注文件失败: %v", err)
+			continue
+		}
+
+		// filename缺失时按标注文件名推断
+		name := doc.Filename
+		if name == "" {
+			name = strings.TrimSuffix(path.Base(f.Name), path.Ext(f.Name)) + ".jpg"
+		}
+		root := path.Dir(path.Dir(f.Name))
+		img := idx.lookup(name, path.Join(root, "JPEGImages"), "JPEGImages")
+		if img == nil {
+			report.addError(f.Name, "图像文件%s不存在", name)
+			continue
+		}
+		if idx.used[img] {
+			report.addError(f.Name, "图像文件%s已被其他标注文件引用", name)
+			continue
+		}
+		if img.UncompressedSize64 > uint64(MaxSampleSize) {
+			report.addError(img.Name, "图像大小超过%dMB限制", MaxSampleSize>>20)
+			continue
+		}
+		idx.used[img] = true
+
+		s := &ImportedSample{
+			Entry:       img,
+			FileName:    path.Base(img.Name),
+			Width:       doc.Size.Width,
+			Height:      doc.Size.Height,
+			Annotations: make([]model.Annotation, 0, len(doc.Objects)),
+		}
+		for i, obj := range doc.Objects {
+			label := strings.TrimSpace(obj.Name)
+			if label == "" {
+				report.addError(f.Name, "第%d个目标缺少类别名称", i+1)
+				continue
+			}
+			ann := model.Annotation{ID: fmt.Sprint(i + 1), Label: label}
+			if obj.BndBox != nil {
+				box := &model.BoundingBox{
+					X:      obj.BndBox.XMin,
+					Y:      obj.BndBox.YMin,
+					Width:  obj.BndBox.XMax - obj.BndBox.XMin,
+					Height: obj.BndBox.YMax - obj.BndBox.YMin,
+				}
+				if !validBBox(box, s.Width, s.Height) {
+					report.addError(f.Name, "第%d个目标框超出图像范围或尺寸无效", i+1)
+					continue
+				}
+				ann.BBox = box
+			}
+			s.Annotations = append(s.Annotations, ann)
+			report.Annotations++
+		}
+		s.Labels = annotationLabels(s.Annotations)
+		if len(s.Labels) == 0 {
+			report.addWarning(f.Name, "标注文件没有有效目标，将作为未标注样本导入")
+		}
+		samples = append(samples, s)
+	}
+
+	// 没有标注文件的图像作为未标注样本导入
+	unannotated := make([]string, 0)
+	for name, f := range idx.byPath {
+		if !idx.used[f] {
+			unannotated = append(unannotated, name)
+		}
+	}
+	sort.Strings(unannotated)
+	for _, name := range unannotated {
+		f := idx.byPath[name]
+		if f.UncompressedSize64 > uint64(MaxSampleSize) {
+			report.addError(name, "图像大小超过%dMB限制", MaxSampleSize>>20)
+			continue
+		}
+		idx.used[f] = true
+		report.addWarning(name, "图像没有对应的标注文件，将作为未标注样本导入")
+		samples = append(samples, &ImportedSample{
+			Entry:       f,
+			FileName:    path.Base(name),
+			Labels:      []string{},
+			Annotations: []model.Annotation{},
+		})
+	}
+
+	report.Samples = len(samples)
+	return samples, report, nil
+}
+
+// readVOCAnnotation 读取单个VOC标注文件
+func readVOCAnnotation(f *zip.File) (*vocAnnotation, error) {
+	rc, err := openAnnotation(f, MaxVOCAnnotationSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	var doc vocAnnotation
+	if err := xml.NewDecoder(rc).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// exportVOC 导出Pascal VOC格式归档
+func exportVOC(archive *zip.Writer, samples []*model.DatasetSample, open SampleOpener) (*ValidationReport, error) {
+	report := newReport(FormatVOC)
+	names := exportNames(samples)
+
+	for _, s := range samples {
+		name := names[s.ID]
+		if err := copyImage(archive, path.Join("JPEGImages", name), s, open); err != nil {
+			report.addError(s.FileName, "读取样本图像失败: %v", err)
+			continue
+		}
+		if s.Width == 0 || s.Height == 0 {
+			report.addWarning(s.FileName, "图像尺寸未知，size字段写入0")
+		}
+
+		doc := vocAnnotation{
+			Folder:   "JPEGImages",
+			Filename: name,
+			Size:     vocSize{Width: s.Width, Height: s.Height, Depth: 3},
+			Objects:  make([]vocObject, 0, len(s.Annotations)),
+		}
+		annotated := make(map[string]bool)
+		for _, a := range s.Annotations {
+			obj := vocObject{Name: a.Label}
+			if a.BBox != nil {
+				obj.BndBox = &vocBndBox{
+					XMin: a.BBox.X,
+					YMin: a.BBox.Y,
+					XMax: a.BBox.X + a.BBox.Width,
+					YMax: a.BBox.Y + a.BBox.Height,
+				}
+			} else {
+				report.addWarning(s.FileName, "标签%s没有目标框，已导出为无bndbox目标", a.Label)
+			}
+			doc.Objects = append(doc.Objects, obj)
+			annotated[a.Label] = true
+			report.Annotations++
+		}
+		for _, l := range s.Labels {
+			if annotated[l] {
+				continue
+			}
+			doc.Objects = append(doc.Objects, vocObject{Name: l})
+			report.Annotations++
+			report.addWarning(s.FileName, "标签%s没有目标框，已导出为无bndbox目标", l)
+		}
+
+		stem := strings.TrimSuffix(name, path.Ext(name))
+		w, err := archive.Create(path.Join("Annotations", stem+".xml"))
+		if err != nil {
+			return nil, fmt.Errorf("写入VOC标注文件失败: %w", err)
+		}
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		if err := enc.Encode(doc); err != nil {
+			return nil, fmt.Errorf("写入VOC标注文件失败: %w", err)
+		}
+		report.Samples++
+	}
+	return report, nil
+}
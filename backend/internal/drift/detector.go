@@ -0,0 +1,228 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/queue"
+)
+
+// DefaultInterval 定时检测的默认间隔
+const DefaultInterval = time.Hour
+
+// RecordSource 识别记录来源，由repository.RecognitionRepository实现
+type RecordSource interface {
+	// 按版本和时间窗口汇总模型成功识别记录的特征分箱计数，数值特征按edges分箱
+	AggregateDriftBins(ctx context.Context, modelID string, baselineStart, currentStart, endTime time.Time, edges map[string][]float64) ([]*model.DriftBin, error)
+	AggregateByVersion(ctx context.Context, startTime, endTime time.Time) ([]*model.PerformanceTotals, error)
+}
+
+// Notifier 告警通知，由queue.NotificationService实现
+type Notifier interface {
+	SendNotification(ctx context.Context, taskID string, nType queue.NotificationType, message string) error
+}
+
+// Detector 按模型版本比较基线窗口和当前窗口的分布，超过阈值时产生告警
+type Detector struct {
+	records  RecordSource
+	alerts   model.DriftAlertRepository
+	params   model.SystemParamRepository
+	notifier Notifier
+}
+
+// NewDetector 创建漂移检测器，params和notifier可以为nil
+func NewDetector(records RecordSource, alerts model.DriftAlertRepository, params model.SystemParamRepository, notifier Notifier) *Detector {
+	return &Detector{
+		records:  records,
+		alerts:   alerts,
+		params:   params,
+		notifier: notifier,
+	}
+}
+
+// Thresholds 当前生效的检测阈值
+func (d *Detector) Thresholds() Thresholds {
+	return LoadThresholds(d.params)
+}
+
+// Report 生成模型截至end的漂移报告
+func (d *Detector) Report(ctx context.Context, modelID string, end time.Time) (*model.DriftReport, error) {
+	t := d.Thresholds()
+	baselineStart, currentStart := t.Windows(end)
+
+	bins, err := d.records.AggregateDriftBins(ctx, modelID, baselineStart, currentStart, end, numericEdges)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.DriftReport{
+		ModelID:       modelID,
+		BaselineStart: baselineStart,
+		BaselineEnd:   currentStart,
+		CurrentStart:  currentStart,
+		CurrentEnd:    end,
+	}
+	report.Versions = Analyze(bins, t)
+	return report, nil
+}
+
+// Analyze 按模型版本比较基线窗口和当前窗口的分箱计数
+// 只比较同一版本自身的分布，避免把版本切换本身误判为漂移
+func Analyze(bins []*model.DriftBin, t Thresholds) []*model.VersionDrift {
+	type window struct{ baseline, current *Profile }
+	byVersion := make(map[string]*window)
+	for _, b := range bins {
+		w, ok := byVersion[b.ModelVersion]
+		if !ok {
+			w = &window{baseline: newProfile(), current: newProfile()}
+			byVersion[b.ModelVersion] = w
+		}
+		if b.Current {
+			w.current.addBin(b)
+		} else {
+			w.baseline.addBin(b)
+		}
+	}
+
+	versions := make([]string, 0, len(byVersion))
+	for v := range byVersion {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+
+	result := make([]*model.VersionDrift, 0, len(versions))
+	for _, v := range versions {
+		w := byVersion[v]
+		baseline, current := w.baseline, w.current
+		vd := &model.VersionDrift{
+			ModelVersion:    v,
+			BaselineSamples: baseline.Samples,
+			CurrentSamples:  current.Samples,
+			Status:          model.DriftStatusOK,
+			Features:        make([]*model.FeatureDrift, 0),
+		}
+		if baseline.Samples < t.MinSamples || current.Samples < t.MinSamples {
+			vd.Status = model.DriftStatusInsufficientData
+			result = append(result, vd)
+			continue
+		}
+
+		vd.Features = Compare(baseline, current)
+		for _, f := range vd.Features {
+			f.Severity = t.Severity(f.PSI, f.KL)
+			if f.Severity == model.DriftSeverityAlert {
+				vd.Status = model.DriftStatusDrift
+			}
+		}
+		result = append(result, vd)
+	}
+	return result
+}
+
+// Check 检测模型的漂移并同步告警：超过告警阈值时创建或更新告警，恢复正常后自动关闭
+func (d *Detector) Check(ctx context.Context, modelID string, end time.Time) (*model.DriftReport, error) {
+	report, err := d.Report(ctx, modelID, end)
+	if err != nil {
+		return nil, err
+	}
+	t := d.Thresholds()
+
+	for _, v := range report.Versions {
+		// 样本不足时无法判断，保持已有告警不变
+		if v.Status == model.DriftStatusInsufficientData {
+			continue
+		}
+		for _, f := range v.Features {
+			if err := d.syncAlert(ctx, modelID, v.ModelVersion, f, t); err != nil {
+				log.Printf("同步漂移告警失败: model=%s version=%s feature=%s err=%v", modelID, v.ModelVersion, f.Feature, err)
+			}
+		}
+	}
+	return report, nil
+}
+
+func (d *Detector) syncAlert(ctx context.Context, modelID, version string, f *model.FeatureDrift, t Thresholds) error {
+	active, err := d.alerts.FindActive(modelID, version, f.Feature)
+	if err != nil {
+		return err
+	}
+
+	if f.Severity != model.DriftSeverityAlert {
+		if active == nil {
+			return nil
+		}
+		_, err := d.alerts.TransitionStatus(active.ID, active.Status, model.DriftAlertResolved, 0)
+		return err
+	}
+
+	message := fmt.Sprintf("模型%s版本%s的特征%s发生漂移: PSI=%.4f, KL=%.4f", modelID, version, f.Feature, f.PSI, f.KL)
+	if active != nil {
+		return d.alerts.UpdateMetrics(active.ID, f.PSI, f.KL, message)
+	}
+
+	alert := &model.DriftAlert{
+		ModelID:      modelID,
+		ModelVersion: version,
+		Feature:      f.Feature,
+		PSI:          f.PSI,
+		KL:           f.KL,
+		Threshold:    t.PSIAlert,
+		Message:      message,
+		Status:       model.DriftAlertOpen,
+	}
+	if err := d.alerts.Create(alert); err != nil {
+		return err
+	}
+	if d.notifier != nil {
+		if err := d.notifier.SendNotification(ctx, alert.ID, queue.NotificationTypeDriftAlert, message); err != nil {
+			log.Printf("发送漂移告警通知失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// Run 每隔interval检测一次当前窗口内有识别请求的模型，直到ctx取消
+func (d *Detector) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.CheckAll(ctx, time.Now()); err != nil {
+			log.Printf("漂移检测失败: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll 检测当前窗口内有识别请求的所有模型
+func (d *Detector) CheckAll(ctx context.Context, end time.Time) error {
+	_, currentStart := d.Thresholds().Windows(end)
+	totals, err := d.records.AggregateByVersion(ctx, currentStart, end)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, total := range totals {
+		if total.ModelID == "" || seen[total.ModelID] {
+			continue
+		}
+		seen[total.ModelID] = true
+		if _, err := d.Check(ctx, total.ModelID, end); err != nil {
+			log.Printf("检测模型%s的漂移失败: %v", total.ModelID, err)
+		}
+	}
+	return nil
+}
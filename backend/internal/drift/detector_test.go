@@ -0,0 +1,333 @@
+package drift
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/queue"
+)
+
+type memoryRecords struct {
+	records []*model.RecognitionRecord
+}
+
+// AggregateDriftBins 在内存中按NewProfile的规则分箱，对应数据库中的汇总
+func (m *memoryRecords) AggregateDriftBins(ctx context.Context, modelID string, baselineStart, currentStart, endTime time.Time, edges map[string][]float64) ([]*model.DriftBin, error) {
+	var result []*model.RecognitionRecord
+	for _, r := range m.records {
+		if r.ModelID == modelID && r.Status == model.RecognitionSucceeded && !r.CreateTime.Before(baselineStart) && !r.CreateTime.After(endTime) {
+			result = append(result, r)
+		}
+	}
+	return recordBins(result, currentStart), nil
+}
+
+// recordBins 按版本和时间窗口统计记录的分箱计数
+func recordBins(records []*model.RecognitionRecord, currentStart time.Time) []*model.DriftBin {
+	type key struct {
+		version string
+		current bool
+	}
+	groups := make(map[key][]*model.RecognitionRecord)
+	for _, r := range records {
+		k := key{r.ModelVersion, !r.CreateTime.Before(currentStart)}
+		groups[k] = append(groups[k], r)
+	}
+
+	var bins []*model.DriftBin
+	for k, group := range groups {
+		p := NewProfile(group)
+		add := func(feature, category string, bin int, count int64) {
+			if count > 0 {
+				bins = append(bins, &model.DriftBin{ModelVersion: k.version, Current: k.current, Feature: feature, Category: category, Bin: bin, Count: count})
+			}
+		}
+		add("", "", 0, p.Samples)
+		for c, n := range p.Categories {
+			add(model.DriftFeatureCategory, c, 0, n)
+		}
+		for feature, counts := range p.Numeric {
+			for i, n := range counts {
+				add(feature, "", i, n)
+			}
+			add(feature, "", -1, p.Missing[feature])
+		}
+	}
+	return bins
+}
+
+func (m *memoryRecords) AggregateByVersion(ctx context.Context, startTime, endTime time.Time) ([]*model.PerformanceTotals, error) {
+	return []*model.PerformanceTotals{{ModelID: "m1", ModelVersion: "v1"}}, nil
+}
+
+type memoryAlerts struct {
+	alerts []*model.DriftAlert
+}
+
+func (m *memoryAlerts) Create(alert *model.DriftAlert) error {
+	alert.ID = fmt.Sprintf("a%d", len(m.alerts)+1)
+	m.alerts = append(m.alerts, alert)
+	return nil
+}
+
+func (m *memoryAlerts) FindActive(modelID, modelVersion, feature string) (*model.DriftAlert, error) {
+	for _, a := range m.alerts {
+		if a.ModelID == modelID && a.ModelVersion == modelVersion && a.Feature == feature && a.Status != model.DriftAlertResolved {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryAlerts) List(modelID, status string, page, size int) ([]*model.DriftAlert, int64, error) {
+	return m.alerts, int64(len(m.alerts)), nil
+}
+
+func (m *memoryAlerts) UpdateMetrics(id string, psi, kl float64, message string) error {
+	for _, a := range m.alerts {
+		if a.ID == id {
+			a.PSI, a.KL, a.Message = psi, kl, message
+		}
+	}
+	return nil
+}
+
+func (m *memoryAlerts) TransitionStatus(id, from, to string, userID int64) (bool, error) {
+	for _, a := range m.alerts {
+		if a.ID == id && a.Status == from {
+			a.Status = to
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type recordingNotifier struct {
+	messages []string
+}
+
+func (n *recordingNotifier) SendNotification(ctx context.Context, taskID string, nType queue.NotificationType, message string) error {
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+// records 生成n条成功识别记录，类别在categories中轮换
+func records(n int, at time.Time, confidence float64, categories ...string) []*model.RecognitionRecord {
+	result := make([]*model.RecognitionRecord, 0, n)
+	for i := 0; i < n; i++ {
+		result = append(result, &model.RecognitionRecord{
+			ModelID:      "m1",
+			ModelVersion: "v1",
+			Category:     categories[i%len(categories)],
+			Confidence:   confidence,
+			Status:       model.RecognitionSucceeded,
+			ImageBytes:   100 << 10,
+			ImageWidth:   640,
+			ImageHeight:  480,
+			Brightness:   120,
+			CreateTime:   at.Add(time.Duration(i) * time.Second),
+		})
+	}
+	return result
+}
+
+func TestDivergence(t *testing.T) {
+	psi, kl := Divergence([]int64{50, 50}, []int64{50, 50})
+	assert.InDelta(t, 0, psi, 1e-12)
+	assert.InDelta(t, 0, kl, 1e-12)
+
+	// 空箱经过平滑后结果仍是有限值
+	psi, kl = Divergence([]int64{100, 0}, []int64{0, 100})
+	assert.Greater(t, psi, 1.0)
+	assert.Greater(t, kl, 1.0)
+	assert.False(t, math.IsInf(psi, 0) || math.IsInf(kl, 0))
+}
+
+func TestNewProfileBinsEdges(t *testing.T) {
+	p := NewProfile([]*model.RecognitionRecord{
+		{Status: model.RecognitionSucceeded, Confidence: 0.1},
+		{Status: model.RecognitionSucceeded, Confidence: 1},
+		{Status: model.RecognitionFailed, Confidence: 0.5},
+	})
+
+	assert.Equal(t, int64(2), p.Samples)
+	conf := p.Numeric[model.DriftFeatureConfidence]
+	assert.Equal(t, int64(1), conf[1])
+	assert.Equal(t, int64(1), conf[len(conf)-1])
+	// 未解码的图片计入缺失
+	assert.Equal(t, int64(2), p.Missing[model.DriftFeatureBrightness])
+	assert.Equal(t, int64(2), p.Missing[model.DriftFeatureAspectRatio])
+}
+
+func TestAlignCategoriesMergesTail(t *testing.T) {
+	baseline := make(map[string]int64)
+	for i := 0; i < 30; i++ {
+		baseline[fmt.Sprintf("c%02d", i)] = int64(100 - i)
+	}
+	bins, base, cur := alignCategories(baseline, map[string]int64{"new": 5})
+
+	assert.Len(t, bins, maxCategories)
+	assert.Equal(t, otherCategory, bins[len(bins)-1])
+	var total int64
+	for _, n := range base {
+		total += n
+	}
+	assert.Equal(t, int64(30*100-29*30/2), total)
+	assert.Equal(t, int64(5), cur[len(cur)-1])
+}
+
+func TestAnalyzeInsufficientData(t *testing.T) {
+	now := time.Now()
+	recs := append(records(150, now.Add(-48*time.Hour), 0.9, "cat"), records(10, now.Add(-time.Hour), 0.9, "cat")...)
+
+	versions := Analyze(recordBins(recs, now.Add(-24*time.Hour)), DefaultThresholds)
+
+	require.Len(t, versions, 1)
+	assert.Equal(t, model.DriftStatusInsufficientData, versions[0].Status)
+	assert.Empty(t, versions[0].Features)
+}
+
+func TestAnalyzeBins(t *testing.T) {
+	now := time.Now()
+	currentStart := now.Add(-24 * time.Hour)
+	recs := append(records(150, now.Add(-48*time.Hour), 0.95, "cat"), records(150, now.Add(-time.Hour), 0.95, "cat")...)
+	// 无法解码的图片计入缺失，不影响其他特征
+	recs[len(recs)-1].ImageWidth = 0
+
+	versions := Analyze(recordBins(recs, currentStart), DefaultThresholds)
+	require.Len(t, versions, 1)
+	assert.Equal(t, int64(150), versions[0].BaselineSamples)
+	assert.Equal(t, int64(150), versions[0].CurrentSamples)
+	assert.Equal(t, model.DriftStatusOK, versions[0].Status)
+	for _, f := range versions[0].Features {
+		if f.Feature == model.DriftFeatureAspectRatio {
+			assert.Equal(t, int64(1), f.Missing)
+		}
+	}
+
+	// 超出分箱范围的计数被忽略
+	p := newProfile()
+	p.addBin(&model.DriftBin{Feature: model.DriftFeatureConfidence, Bin: 99, Count: 5})
+	p.addBin(&model.DriftBin{Feature: model.DriftFeatureConfidence, Bin: 9, Count: 3})
+	assert.Equal(t, int64(3), p.Numeric[model.DriftFeatureConfidence][9])
+}
+
+func TestDetectorRaisesAndResolvesAlerts(t *testing.T) {
+	now := time.Now()
+	source := &memoryRecords{records: records(200, now.Add(-48*time.Hour), 0.95, "cat", "dog")}
+	// 当前窗口的类别和置信度都明显偏移
+	source.records = append(source.records, records(200, now.Add(-2*time.Hour), 0.45, "bird")...)
+	alerts := &memoryAlerts{}
+	notifier := &recordingNotifier{}
+	detector := NewDetector(source, alerts, nil, notifier)
+
+	require.NoError(t, detector.CheckAll(context.Background(), now))
+
+	features := make(map[string]*model.DriftAlert)
+	for _, a := range alerts.alerts {
+		features[a.Feature] = a
+	}
+	require.Contains(t, features, model.DriftFeatureCategory)
+	require.Contains(t, features, model.DriftFeatureConfidence)
+	assert.NotContains(t, features, model.DriftFeatureBrightness)
+	assert.Len(t, notifier.messages, len(alerts.alerts))
+
+	// 再次检测只更新已有告警，不重复通知
+	require.NoError(t, detector.CheckAll(context.Background(), now))
+	assert.Len(t, notifier.messages, len(alerts.alerts))
+
+	// 当前窗口恢复正常后自动关闭
+	source.records = records(200, now.Add(-48*time.Hour), 0.95, "cat", "dog")
+	source.records = append(source.records, records(200, now.Add(-2*time.Hour), 0.95, "cat", "dog")...)
+	_, err := detector.Check(context.Background(), "m1", now)
+	require.NoError(t, err)
+	for _, a := range alerts.alerts {
+		assert.Equal(t, model.DriftAlertResolved, a.Status)
+	}
+}
+
+func TestImageStats(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			img.SetGray(x, y, color.Gray{Y: 200})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	w, h, brightness, err := ImageStats(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, 200, w)
+	assert.Equal(t, 100, h)
+	assert.InDelta(t, 200, brightness, 1)
+
+	_, _, _, err = ImageStats([]byte("not an image"))
+	assert.Error(t, err)
+}
+
+type memoryStats struct {
+	width, height int
+	brightness    float64
+	calls         int
+}
+
+func (m *memoryStats) SetImageStats(ctx context.Context, id primitive.ObjectID, width, height int, brightness float64) error {
+	m.width, m.height, m.brightness = width, height, brightness
+	m.calls++
+	return nil
+}
+
+func TestImageStatsPixelLimit(t *testing.T) {
+	// 只有图片头声明了超大尺寸，解码前即被拒绝
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))))
+	data := buf.Bytes()
+	// IHDR的宽高位于第16-23字节
+	data[16], data[17], data[18], data[19] = 0, 0, 0x27, 0x10 // 10000
+	data[20], data[21], data[22], data[23] = 0, 0, 0x27, 0x10 // 10000
+	binary.BigEndian.PutUint32(data[29:33], crc32.ChecksumIEEE(data[12:29]))
+
+	_, _, _, err := ImageStats(data)
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+}
+
+func TestStatsRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 40, 20))))
+	path := filepath.Join(t.TempDir(), "a.png")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+
+	store := &memoryStats{}
+	recorder := NewStatsRecorder(store)
+	id := primitive.NewObjectID()
+	data, err := json.Marshal(StatsTaskData{RecordID: id.Hex(), ImagePath: path})
+	require.NoError(t, err)
+
+	require.NoError(t, recorder.HandleTask(context.Background(), &queue.Task{Data: data}))
+	assert.Equal(t, 1, store.calls)
+	assert.Equal(t, 40, store.width)
+	assert.Equal(t, 20, store.height)
+
+	// 无法解码的图片不写入统计信息
+	require.NoError(t, os.WriteFile(path, []byte("not an image"), 0644))
+	require.NoError(t, recorder.HandleTask(context.Background(), &queue.Task{Data: data}))
+	assert.Equal(t, 1, store.calls)
+}
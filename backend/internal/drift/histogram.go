@@ -0,0 +1,217 @@
+package drift
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// maxCategories 类别特征保留的类别数，其余合并为otherCategory
+const maxCategories = 20
+
+// otherCategory 低频类别合并后的名称
+const otherCategory = "(other)"
+
+// smoothing 计算分布占比时的加法平滑，避免空箱导致PSI和KL无穷大
+const smoothing = 0.5
+
+// numericEdges 数值特征的分箱边界，基线和当前窗口使用相同的分箱
+var numericEdges = map[string][]float64{
+	model.DriftFeatureConfidence:  {0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9},
+	model.DriftFeatureBrightness:  {25.5, 51, 76.5, 102, 127.5, 153, 178.5, 204, 229.5},
+	model.DriftFeatureImageSize:   {16 << 10, 32 << 10, 64 << 10, 128 << 10, 256 << 10, 512 << 10, 1 << 20, 2 << 20, 4 << 20, 8 << 20},
+	model.DriftFeatureAspectRatio: {0.5, 0.67, 0.8, 0.95, 1.05, 1.25, 1.5, 2},
+}
+
+// Features 参与漂移检测的特征，按报告中的顺序
+var Features = []string{
+	model.DriftFeatureCategory,
+	model.DriftFeatureConfidence,
+	model.DriftFeatureImageSize,
+	model.DriftFeatureBrightness,
+	model.DriftFeatureAspectRatio,
+}
+
+// Profile 一组识别记录的特征分布
+type Profile struct {
+	Samples    int64
+	Categories map[string]int64
+	Numeric    map[string][]int64 // 各数值特征每个分箱的样本数
+	Missing    map[string]int64   // 缺少该特征的样本数
+}
+
+func newProfile() *Profile {
+	p := &Profile{
+		Categories: make(map[string]int64),
+		Numeric:    make(map[string][]int64),
+		Missing:    make(map[string]int64),
+	}
+	for feature, edges := range numericEdges {
+		p.Numeric[feature] = make([]int64, len(edges)+1)
+	}
+	return p
+}
+
+// NewProfile 统计成功识别记录的特征分布，与RecordSource在数据库中汇总的分箱规则一致
+func NewProfile(records []*model.RecognitionRecord) *Profile {
+	p := newProfile()
+	for _, r := range records {
+		if r.Status != model.RecognitionSucceeded {
+			continue
+		}
+		p.Samples++
+		p.Categories[r.Category]++
+		p.add(model.DriftFeatureConfidence, r.Confidence, true)
+		p.add(model.DriftFeatureImageSize, float64(r.ImageBytes), r.ImageBytes > 0)
+
+		decoded := r.ImageWidth > 0 && r.ImageHeight > 0
+		p.add(model.DriftFeatureBrightness, r.Brightness, decoded)
+		if decoded {
+			p.add(model.DriftFeatureAspectRatio, float64(r.ImageWidth)/float64(r.ImageHeight), true)
+		} else {
+			p.Missing[model.DriftFeatureAspectRatio]++
+		}
+	}
+	return p
+}
+
+func (p *Profile) add(feature string, value float64, ok bool) {
+	if !ok {
+		p.Missing[feature]++
+		return
+	}
+	p.Numeric[feature][sort.SearchFloat64s(numericEdges[feature], value+1e-12)]++
+}
+
+// addBin 累加数据库汇总的分箱计数，超出分箱范围的计数忽略
+func (p *Profile) addBin(b *model.DriftBin) {
+	switch {
+	case b.Feature == "":
+		p.Samples += b.Count
+	case b.Feature == model.DriftFeatureCategory:
+		p.Categories[b.Category] += b.Count
+	case b.Bin < 0:
+		p.Missing[b.Feature] += b.Count
+	default:
+		if counts, ok := p.Numeric[b.Feature]; ok && b.Bin < len(counts) {
+			counts[b.Bin] += b.Count
+		}
+	}
+}
+
+// Compare 比较基线与当前分布，返回各特征的PSI和KL
+func Compare(baseline, current *Profile) []*model.FeatureDrift {
+	drifts := make([]*model.FeatureDrift, 0, len(Features))
+	for _, feature := range Features {
+		d := &model.FeatureDrift{Feature: feature, Missing: current.Missing[feature]}
+		var base, cur []int64
+		if feature == model.DriftFeatureCategory {
+			d.Bins, base, cur = alignCategories(baseline.Categories, current.Categories)
+		} else {
+			d.Bins = binLabels(numericEdges[feature])
+			base, cur = baseline.Numeric[feature], current.Numeric[feature]
+		}
+		d.BaselineShare = shares(base)
+		d.CurrentShare = shares(cur)
+		d.PSI, d.KL = Divergence(base, cur)
+		drifts = append(drifts, d)
+	}
+	return drifts
+}
+
+// Divergence 计算PSI和KL(当前||基线)，两组计数的分箱须一致
+func Divergence(baseline, current []int64) (psi, kl float64) {
+	e := smoothed(baseline)
+	a := smoothed(current)
+	for i := range e {
+		psi += (a[i] - e[i]) * math.Log(a[i]/e[i])
+		kl += a[i] * math.Log(a[i]/e[i])
+	}
+	return psi, kl
+}
+
+// smoothed 加法平滑后的占比
+func smoothed(counts []int64) []float64 {
+	var total int64
+	for _, c := range counts {
+		total += c
+	}
+	denom := float64(total) + smoothing*float64(len(counts))
+	result := make([]float64, len(counts))
+	for i, c := range counts {
+		result[i] = (float64(c) + smoothing) / denom
+	}
+	return result
+}
+
+// shares 未平滑的占比，用于报告展示
+func shares(counts []int64) []float64 {
+	var total int64
+	for _, c := range counts {
+		total += c
+	}
+	result := make([]float64, len(counts))
+	if total == 0 {
+		return result
+	}
+	for i, c := range counts {
+		result[i] = float64(c) / float64(total)
+	}
+	return result
+}
+
+// alignCategories 对齐两个窗口的类别，保留合计最多的maxCategories个类别，其余合并
+func alignCategories(baseline, current map[string]int64) ([]string, []int64, []int64) {
+	totals := make(map[string]int64)
+	for c, n := range baseline {
+		totals[c] += n
+	}
+	for c, n := range current {
+		totals[c] += n
+	}
+
+	names := make([]string, 0, len(totals))
+	for c := range totals {
+		names = append(names, c)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if totals[names[i]] != totals[names[j]] {
+			return totals[names[i]] > totals[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	bins := names
+	if len(names) > maxCategories {
+		bins = append(append([]string(nil), names[:maxCategories-1]...), otherCategory)
+	}
+	index := make(map[string]int, len(bins))
+	for i, c := range bins {
+		index[c] = i
+	}
+	count := func(m map[string]int64) []int64 {
+		counts := make([]int64, len(bins))
+		for c, n := range m {
+			i, ok := index[c]
+			if !ok || c == otherCategory {
+				i = len(bins) - 1
+			}
+			counts[i] += n
+		}
+		return counts
+	}
+	return bins, count(baseline), count(current)
+}
+
+// binLabels 分箱的区间标签
+func binLabels(edges []float64) []string {
+	labels := make([]string, len(edges)+1)
+	labels[0] = fmt.Sprintf("<%g", edges[0])
+	for i := 1; i < len(edges); i++ {
+		labels[i] = fmt.Sprintf("[%g,%g)", edges[i-1], edges[i])
+	}
+	labels[len(edges)] = fmt.Sprintf(">=%g", edges[len(edges)-1])
+	return labels
+}
@@ -0,0 +1,72 @@
+package drift
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"  // 注册gif解码器
+	_ "image/jpeg" // 注册jpeg解码器
+	_ "image/png"  // 注册png解码器
+)
+
+// sampleGrid 计算亮度时每个方向的采样点数，大图只采样不超过sampleGrid*sampleGrid个像素
+const sampleGrid = 64
+
+// MaxImagePixels 计算统计信息时允许解码的最大像素数，防止很小的压缩文件解码后占用大量内存
+const MaxImagePixels = 40 * 1000 * 1000
+
+// ErrImageTooLarge 图片像素数超过MaxImagePixels
+var ErrImageTooLarge = errors.New("image exceeds pixel limit")
+
+// ImageSize 只读取图片头获取宽和高，不解码像素
+func ImageSize(data []byte) (int, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// ImageStats 解码图片，返回宽、高及平均亮度(0-255)
+// 先读取图片头检查尺寸，像素数超过MaxImagePixels时不解码并返回ErrImageTooLarge
+func ImageStats(data []byte) (int, int, float64, error) {
+	width, height, err := ImageSize(data)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if int64(width)*int64(height) > MaxImagePixels {
+		return 0, 0, 0, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	bounds := img.Bounds()
+	width, height = bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return width, height, 0, nil
+	}
+
+	stepX := max(width/sampleGrid, 1)
+	stepY := max(height/sampleGrid, 1)
+	var sum float64
+	var n int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			r, g, b, _ := img.At(x, y).RGBA()
+			// RGBA返回16位分量，按ITU-R BT.601计算亮度
+			sum += (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 257
+			n++
+		}
+	}
+	return width, height, sum / float64(n), nil
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
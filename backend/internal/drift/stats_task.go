@@ -0,0 +1,61 @@
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/image-recognition-engine/internal/queue"
+)
+
+// StatsStore 保存识别图片的统计信息，由repository.RecognitionRepository实现
+type StatsStore interface {
+	SetImageStats(ctx context.Context, id primitive.ObjectID, width, height int, brightness float64) error
+}
+
+// StatsTaskData 图片统计任务数据
+type StatsTaskData struct {
+	RecordID  string `json:"record_id"`
+	ImagePath string `json:"image_path"`
+}
+
+// StatsRecorder 在识别请求之外计算图片尺寸和亮度，写回识别记录供漂移检测使用
+type StatsRecorder struct {
+	records StatsStore
+}
+
+// NewStatsRecorder 创建图片统计任务处理器
+func NewStatsRecorder(records StatsStore) *StatsRecorder {
+	return &StatsRecorder{records: records}
+}
+
+// HandleTask 图片统计队列任务处理函数，注册到queue.Worker
+// 图片无法解码或超过像素上限时不写入统计信息，漂移检测将其计为缺失值
+func (s *StatsRecorder) HandleTask(ctx context.Context, task *queue.Task) error {
+	var data StatsTaskData
+	if err := json.Unmarshal(task.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal task data error: %v", err)
+	}
+	id, err := primitive.ObjectIDFromHex(data.RecordID)
+	if err != nil {
+		return fmt.Errorf("无效的识别记录ID: %w", err)
+	}
+
+	image, err := os.ReadFile(data.ImagePath)
+	if err != nil {
+		return fmt.Errorf("读取识别图片失败: %w", err)
+	}
+	width, height, brightness, err := ImageStats(image)
+	if err != nil {
+		if errors.Is(err, ErrImageTooLarge) {
+			log.Printf("识别记录%s的图片超过像素上限，跳过统计", data.RecordID)
+		}
+		return nil
+	}
+	return s.records.SetImageStats(ctx, id, width, height, brightness)
+}
@@ -0,0 +1,91 @@
+package drift
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// 漂移检测阈值对应的系统参数键
+const (
+	ParamPSIWarning   = "model.drift.psi_warning"
+	ParamPSIAlert     = "model.drift.psi_alert"
+	ParamKLAlert      = "model.drift.kl_alert"
+	ParamMinSamples   = "model.drift.min_samples"
+	ParamCurrentHours = "model.drift.current_hours"
+	ParamBaselineDays = "model.drift.baseline_days"
+)
+
+// Thresholds 漂移检测的窗口和阈值
+type Thresholds struct {
+	PSIWarning   float64 `json:"psiWarning"`
+	PSIAlert     float64 `json:"psiAlert"`
+	KLAlert      float64 `json:"klAlert"`
+	MinSamples   int64   `json:"minSamples"`   // 基线和当前窗口的样本数下限
+	CurrentHours int64   `json:"currentHours"` // 当前窗口长度
+	BaselineDays int64   `json:"baselineDays"` // 基线窗口长度，紧接在当前窗口之前
+}
+
+// DefaultThresholds 未配置系统参数时使用的默认值，PSI按业界惯例0.1预警、0.2告警
+var DefaultThresholds = Thresholds{
+	PSIWarning:   0.1,
+	PSIAlert:     0.2,
+	KLAlert:      0.1,
+	MinSamples:   100,
+	CurrentHours: 24,
+	BaselineDays: 7,
+}
+
+// LoadThresholds 从系统参数读取漂移检测阈值，未配置或格式错误的项使用默认值
+func LoadThresholds(params model.SystemParamRepository) Thresholds {
+	t := DefaultThresholds
+	if params == nil {
+		return t
+	}
+
+	number := func(key string, dst *float64) {
+		p, err := params.FindByKey(key)
+		if err != nil || p == nil {
+			return
+		}
+		if v, err := strconv.ParseFloat(p.Value, 64); err == nil && v > 0 {
+			*dst = v
+		}
+	}
+	integer := func(key string, dst *int64) {
+		p, err := params.FindByKey(key)
+		if err != nil || p == nil {
+			return
+		}
+		if v, err := strconv.ParseInt(p.Value, 10, 64); err == nil && v > 0 {
+			*dst = v
+		}
+	}
+	number(ParamPSIWarning, &t.PSIWarning)
+	number(ParamPSIAlert, &t.PSIAlert)
+	number(ParamKLAlert, &t.KLAlert)
+	integer(ParamMinSamples, &t.MinSamples)
+	integer(ParamCurrentHours, &t.CurrentHours)
+	integer(ParamBaselineDays, &t.BaselineDays)
+	return t
+}
+
+// Severity 根据PSI和KL判断漂移程度
+func (t Thresholds) Severity(psi, kl float64) string {
+	switch {
+	case psi >= t.PSIAlert || kl >= t.KLAlert:
+		return model.DriftSeverityAlert
+	case psi >= t.PSIWarning:
+		return model.DriftSeverityWarning
+	default:
+		return model.DriftSeverityNone
+	}
+}
+
+// Windows 返回以end结束的基线窗口和当前窗口
+func (t Thresholds) Windows(end time.Time) (baselineStart, currentStart time.Time) {
+	currentStart = end.Add(-time.Duration(t.CurrentHours) * time.Hour)
+	baselineStart = currentStart.AddDate(0, 0, -int(t.BaselineDays))
+	return baselineStart, currentStart
+}
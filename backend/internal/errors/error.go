@@ -21,6 +21,7 @@ const (
 	NotFoundError
 	BadRequestError
 	TimeoutError
+	ConflictError
 )
 
 // 业务级错误码定义 (2000-2999)
@@ -30,6 +31,8 @@ const (
 	ModelTrainingError
 	ImageProcessingError
 	RecognitionError
+	ModelArtifactError
+	QuotaExceededError
 )
 
 // AppError 定义应用错误结构
@@ -63,7 +66,7 @@ func getHTTPStatusCode(code ErrorCode) int {
 	switch code {
 	case InternalServerError:
 		return http.StatusInternalServerError
-	case DatabaseError, CacheError:
+	case DatabaseError, CacheError, ModelArtifactError:
 		return http.StatusServiceUnavailable
 	case ValidationError, BadRequestError:
 		return http.StatusBadRequest
@@ -71,12 +74,14 @@ func getHTTPStatusCode(code ErrorCode) int {
 		return http.StatusUnauthorized
 	case AuthorizationError:
 		return http.StatusForbidden
-	case RateLimitError:
+	case RateLimitError, QuotaExceededError:
 		return http.StatusTooManyRequests
 	case NotFoundError, ModelNotFoundError:
 		return http.StatusNotFound
 	case TimeoutError:
 		return http.StatusGatewayTimeout
+	case ConflictError:
+		return http.StatusConflict
 	default:
 		return http.StatusInternalServerError
 	}
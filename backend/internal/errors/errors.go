@@ -28,4 +28,14 @@ func NewServerError(message string) *AppError {
 // NewValidationError 
 func NewValidationError(message string) *AppError {
 	return NewAppError(ValidationError, message, "")
+}
+
+// NewConflictError 资源状态冲突
+func NewConflictError(message string) *AppError {
+	return NewAppError(ConflictError, message, "")
+}
+
+// NewQuotaExceededError 套餐额度已用完
+func NewQuotaExceededError(message string) *AppError {
+	return NewAppError(QuotaExceededError, message, "")
 }
\ No newline at end of file
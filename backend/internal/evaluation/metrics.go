@@ -0,0 +1,200 @@
+package evaluation
+
+import (
+	"math"
+	"sort"
+
+	"github.com/image-recognition-engine/internal/inference"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// NoPrediction 模型没有给出任何预测时在混淆矩阵中使用的列
+const NoPrediction = "(none)"
+
+// CalibrationBins ECE计算使用的等宽分箱数
+const CalibrationBins = 10
+
+// result 单个样本的评估结果
+type result struct {
+	truth      string
+	predicted  string
+	confidence float64
+	inTopK     bool
+}
+
+// Accumulator 逐个样本累积预测结果并计算评估指标
+type Accumulator struct {
+	topK    int
+	results []result
+}
+
+// NewAccumulator 创建评估指标累积器，topK小于1时按1处理
+func NewAccumulator(topK int) *Accumulator {
+	if topK < 1 {
+		topK = 1
+	}
+	return &Accumulator{topK: topK}
+}
+
+// Add 记录一个样本的真实标签及模型预测
+func (a *Accumulator) Add(truth string, predictions []inference.Prediction) {
+	sorted := append([]inference.Prediction(nil), predictions...)
+	inference.SortPredictions(sorted)
+
+	r := result{truth: truth, predicted: NoPrediction}
+	if len(sorted) > 0 {
+		r.predicted = sorted[0].Label
+		r.confidence = sorted[0].Confidence
+	}
+	for i := 0; i < len(sorted) && i < a.topK; i++ {
+		if sorted[i].Label == truth {
+			r.inTopK = true
+			break
+		}
+	}
+	a.results = append(a.results, r)
+}
+
+// Count 已记录的样本数
+func (a *Accumulator) Count() int {
+	return len(a.results)
+}
+
+// Fill 将评估指标写入评估记录
+func (a *Accumulator) Fill(e *model.ModelEvaluation) {
+	e.TopK = a.topK
+	e.Evaluated = int64(len(a.results))
+	e.Labels, e.ConfusionMatrix = a.confusionMatrix()
+	e.Classes = a.classMetrics(e.Labels, e.ConfusionMatrix)
+	e.Calibration, e.ECE = a.calibration()
+
+	if len(a.results) == 0 {
+		return
+	}
+
+	var correct, topK int64
+	for _, r := range a.results {
+		if r.predicted == r.truth {
+			correct++
+		}
+		if r.inTopK {
+			topK++
+		}
+	}
+	total := float64(len(a.results))
+	e.Accuracy = float64(correct) / total
+	e.TopKAccuracy = float64(topK) / total
+
+	// 宏平均只统计测试集中出现过的真实类别
+	var macro, weighted float64
+	var classes int
+	for _, c := range e.Classes {
+		if c.Support == 0 {
+			continue
+		}
+		classes++
+		macro += c.F1
+		weighted += c.F1 * float64(c.Support)
+	}
+	if classes > 0 {
+		e.MacroF1 = macro / float64(classes)
+	}
+	e.WeightedF1 = weighted / total
+}
+
+// confusionMatrix 构建混淆矩阵，行为真实标签、列为预测标签
+func (a *Accumulator) confusionMatrix() ([]string, [][]int64) {
+	seen := make(map[string]bool)
+	noPrediction := false
+	for _, r := range a.results {
+		seen[r.truth] = true
+		if r.predicted == NoPrediction {
+			noPrediction = true
+		} else {
+			seen[r.predicted] = true
+		}
+	}
+
+	labels := make([]string, 0, len(seen)+1)
+	for l := range seen {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	if noPrediction {
+		labels = append(labels, NoPrediction)
+	}
+
+	index := make(map[string]int, len(labels))
+	for i, l := range labels {
+		index[l] = i
+	}
+	matrix := make([][]int64, len(labels))
+	for i := range matrix {
+		matrix[i] = make([]int64, len(labels))
+	}
+	for _, r := range a.results {
+		matrix[index[r.truth]][index[r.predicted]]++
+	}
+	return labels, matrix
+}
+
+// classMetrics 由混淆矩阵计算各类别的精确率、召回率和F1
+func (a *Accumulator) classMetrics(labels []string, matrix [][]int64) []model.ClassMetrics {
+	classes := make([]model.ClassMetrics, 0, len(labels))
+	for i, label := range labels {
+		if label == NoPrediction {
+			continue
+		}
+		c := model.ClassMetrics{Label: label, Correct: matrix[i][i]}
+		for j := range labels {
+			c.Support += matrix[i][j]
+			c.Predicted += matrix[j][i]
+		}
+		if c.Predicted > 0 {
+			c.Precision = float64(c.Correct) / float64(c.Predicted)
+		}
+		if c.Support > 0 {
+			c.Recall = float64(c.Correct) / float64(c.Support)
+		}
+		if c.Precision+c.Recall > 0 {
+			c.F1 = 2 * c.Precision * c.Recall / (c.Precision + c.Recall)
+		}
+		classes = append(classes, c)
+	}
+	return classes
+}
+
+// calibration 按top-1置信度等宽分箱，计算各箱准确率与期望校准误差
+func (a *Accumulator) calibration() ([]model.CalibrationBin, float64) {
+	bins := make([]model.CalibrationBin, CalibrationBins)
+	correct := make([]int64, CalibrationBins)
+	for i := range bins {
+		bins[i].Lower = float64(i) / CalibrationBins
+		bins[i].Upper = float64(i+1) / CalibrationBins
+	}
+
+	for _, r := range a.results {
+		conf := math.Max(0, math.Min(1, r.confidence))
+		i := int(conf * CalibrationBins)
+		if i == CalibrationBins {
+			i--
+		}
+		bins[i].Count++
+		bins[i].Confidence += conf
+		if r.predicted == r.truth {
+			correct[i]++
+		}
+	}
+
+	var ece float64
+	for i := range bins {
+		if bins[i].Count == 0 {
+			continue
+		}
+		n := float64(bins[i].Count)
+		bins[i].Confidence /= n
+		bins[i].Accuracy = float64(correct[i]) / n
+		ece += n / float64(len(a.results)) * math.Abs(bins[i].Accuracy-bins[i].Confidence)
+	}
+	return bins, ece
+}
@@ -0,0 +1,66 @@
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/inference"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+func preds(pairs ...interface{}) []inference.Prediction {
+	var out []inference.Prediction
+	for i := 0; i < len(pairs); i += 2 {
+		out = append(out, inference.Prediction{Label: pairs[i].(string), Confidence: pairs[i+1].(float64)})
+	}
+	return out
+}
+
+func TestAccumulator(t *testing.T) {
+	acc := NewAccumulator(2)
+	acc.Add("cat", preds("cat", 0.9, "dog", 0.1))
+	acc.Add("cat", preds("dog", 0.05, "cat", 0.75)) // 未排序，top-1为cat
+	acc.Add("cat", preds("dog", 0.6, "cat", 0.4))
+	acc.Add("dog", preds("dog", 0.85))
+	acc.Add("dog", nil)
+
+	var e model.ModelEvaluation
+	acc.Fill(&e)
+
+	assert.Equal(t, int64(5), e.Evaluated)
+	assert.Equal(t, []string{"cat", "dog", NoPrediction}, e.Labels)
+	assert.Equal(t, [][]int64{{2, 1, 0}, {0, 1, 1}, {0, 0, 0}}, e.ConfusionMatrix)
+	assert.InDelta(t, 0.6, e.Accuracy, 1e-9)
+	assert.InDelta(t, 0.8, e.TopKAccuracy, 1e-9)
+
+	require.Len(t, e.Classes, 2)
+	cat, dog := e.Classes[0], e.Classes[1]
+	assert.Equal(t, int64(3), cat.Support)
+	assert.InDelta(t, 1.0, cat.Precision, 1e-9)
+	assert.InDelta(t, 2.0/3, cat.Recall, 1e-9)
+	assert.InDelta(t, 0.8, cat.F1, 1e-9)
+	assert.InDelta(t, 0.5, dog.Precision, 1e-9)
+	assert.InDelta(t, 0.5, dog.Recall, 1e-9)
+	assert.InDelta(t, 0.65, e.MacroF1, 1e-9)
+	assert.InDelta(t, (0.8*3+0.5*2)/5, e.WeightedF1, 1e-9)
+}
+
+func TestCalibration(t *testing.T) {
+	acc := NewAccumulator(1)
+	acc.Add("a", preds("a", 0.95))
+	acc.Add("a", preds("b", 0.95))
+	acc.Add("a", preds("a", 0.25))
+	acc.Add("a", preds("a", 1.0))
+
+	var e model.ModelEvaluation
+	acc.Fill(&e)
+
+	require.Len(t, e.Calibration, CalibrationBins)
+	top := e.Calibration[9]
+	assert.Equal(t, int64(3), top.Count)
+	assert.InDelta(t, 2.0/3, top.Accuracy, 1e-9)
+	// ECE = 3/4*|2/3-0.9667| + 1/4*|1-0.25|
+	assert.InDelta(t, 0.75*(2.9/3-2.0/3)+0.25*0.75, e.ECE, 1e-9)
+}
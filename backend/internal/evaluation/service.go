@@ -0,0 +1,242 @@
+package evaluation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/inference"
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/queue"
+)
+
+// DefaultTopK 未指定时计算top-5准确率
+const DefaultTopK = 5
+
+// TaskQueue 评估任务队列
+type TaskQueue interface {
+	Enqueue(ctx context.Context, taskType queue.TaskType, data interface{}) (string, error)
+}
+
+// StatsWriter 准确率统计写入接口
+type StatsWriter interface {
+	SaveAccuracyStats(ctx context.Context, stats *model.AccuracyStats) error
+}
+
+// VersionStore 模型版本查询接口，由repository.ModelRepository实现
+type VersionStore interface {
+	GetProductionVersion(ctx context.Context, modelID string) (*model.ModelVersion, error)
+}
+
+// TaskData 评估任务数据
+type TaskData struct {
+	EvaluationID string `json:"evaluation_id"`
+}
+
+// Service 模型评估服务
+// 创建评估记录后提交到队列，由worker逐个样本调用模型推理并计算评估指标，
+// 完成后按类别写入准确率统计，评估的是生产版本时回写模型准确率
+type Service struct {
+	evalRepo    model.ModelEvaluationRepository
+	modelRepo   model.ModelRepository
+	versions    VersionStore
+	datasetRepo model.DatasetRepository
+	sampleRepo  model.DatasetSampleRepository
+	stats       StatsWriter
+	predictor   inference.Predictor
+	queue       TaskQueue
+}
+
+// NewService 创建模型评估服务
+func NewService(evalRepo model.ModelEvaluationRepository, modelRepo model.ModelRepository, versions VersionStore, datasetRepo model.DatasetRepository,
+	sampleRepo model.DatasetSampleRepository, stats StatsWriter, predictor inference.Predictor, queue TaskQueue) *Service {
+	return &Service{
+		evalRepo:    evalRepo,
+		modelRepo:   modelRepo,
+		versions:    versions,
+		datasetRepo: datasetRepo,
+		sampleRepo:  sampleRepo,
+		stats:       stats,
+		predictor:   predictor,
+		queue:       queue,
+	}
+}
+
+// CreateEvaluation 创建评估记录并提交评估任务
+func (s *Service) CreateEvaluation(ctx context.Context, e *model.ModelEvaluation) (*model.ModelEvaluation, error) {
+	m, err := s.modelRepo.FindByID(e.ModelID)
+	if err != nil || m == nil {
+		return nil, errors.NewValidationError("模型不存在")
+	}
+	ds, err := s.datasetRepo.FindByID(e.DatasetID)
+	if err != nil || ds == nil {
+		return nil, errors.NewValidationError("数据集不存在")
+	}
+	if e.ModelVersion == "" {
+		e.ModelVersion = m.Version
+	}
+	if e.TopK <= 0 {
+		e.TopK = DefaultTopK
+	}
+	e.Status = model.EvaluationStatusPending
+
+	id, err := s.evalRepo.Create(e)
+	if err != nil {
+		return nil, errors.NewServerError("创建评估记录失败")
+	}
+	e.ID = id
+
+	if _, err := s.queue.Enqueue(ctx, queue.TaskTypeModelEvaluation, TaskData{EvaluationID: id}); err != nil {
+		s.fail(e, "提交评估任务失败")
+		return nil, errors.NewServerError("提交评估任务失败")
+	}
+	return e, nil
+}
+
+// HandleTask 评估队列任务处理函数，注册到queue.Worker
+func (s *Service) HandleTask(ctx context.Context, task *queue.Task) error {
+	var data TaskData
+	if err := json.Unmarshal(task.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal task data error: %v", err)
+	}
+	return s.Run(ctx, data.EvaluationID)
+}
+
+// Run 执行评估，同一评估记录只会被执行一次
+func (s *Service) Run(ctx context.Context, id string) error {
+	ok, err := s.evalRepo.TransitionStatus(id, model.EvaluationStatusPending, model.EvaluationStatusRunning)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil // 已被其他worker执行或已结束
+	}
+
+	e, err := s.evalRepo.FindByID(id)
+	if err != nil || e == nil {
+		return fmt.Errorf("查询评估记录失败: %v", err)
+	}
+	start := time.Now()
+	e.Status = model.EvaluationStatusRunning
+	e.StartTime = &start
+
+	samples, err := s.sampleRepo.ListAll(e.DatasetID)
+	if err != nil {
+		s.fail(e, "读取数据集样本失败")
+		return err
+	}
+	e.TotalSamples = int64(len(samples))
+
+	acc := NewAccumulator(e.TopK)
+	for _, sample := range samples {
+		if err := ctx.Err(); err != nil {
+			s.fail(e, "评估任务被中断")
+			return err
+		}
+		if len(sample.Labels) == 0 {
+			e.Skipped++
+			continue
+		}
+		image, err := os.ReadFile(sample.FilePath)
+		if err != nil {
+			log.Printf("读取样本文件失败 %s: %v", sample.ID, err)
+			e.Skipped++
+			continue
+		}
+		predictions, err := s.predictor.Predict(ctx, e.ModelID, e.ModelVersion, image)
+		if err != nil {
+			s.fail(e, fmt.Sprintf("模型推理失败: %v", err))
+			return err
+		}
+		// 以第一个标签作为样本的真实类别
+		acc.Add(sample.Labels[0], predictions)
+	}
+	if acc.Count() == 0 {
+		s.fail(e, "数据集中没有可评估的已标注样本")
+		return fmt.Errorf("评估%s没有可评估的样本", id)
+	}
+
+	acc.Fill(e)
+	end := time.Now()
+	e.Status = model.EvaluationStatusCompleted
+	e.EndTime = &end
+	if err := s.evalRepo.Update(e); err != nil {
+		return err
+	}
+
+	s.writeStats(ctx, e)
+	s.updateModelAccuracy(ctx, e)
+	return nil
+}
+
+// updateModelAccuracy 仅当评估的是模型当前生产版本时回写模型准确率，
+// 候选版本或历史版本的评估结果不应覆盖线上模型的准确率
+func (s *Service) updateModelAccuracy(ctx context.Context, e *model.ModelEvaluation) {
+	production, err := s.productionVersion(ctx, e.ModelID)
+	if err != nil {
+		log.Printf("查询模型生产版本失败: %v", err)
+		return
+	}
+	if production == "" || production != e.ModelVersion {
+		return
+	}
+	if err := s.modelRepo.UpdateAccuracy(e.ModelID, e.Accuracy); err != nil {
+		log.Printf("更新模型准确率失败: %v", err)
+	}
+}
+
+// productionVersion 获取模型当前的生产版本号，没有版本记录的模型以模型的Version为准
+func (s *Service) productionVersion(ctx context.Context, modelID string) (string, error) {
+	if s.versions != nil {
+		v, err := s.versions.GetProductionVersion(ctx, modelID)
+		if err != nil {
+			return "", err
+		}
+		if v != nil {
+			return v.Version, nil
+		}
+	}
+	m, err := s.modelRepo.FindByID(modelID)
+	if err != nil || m == nil {
+		return "", err
+	}
+	return m.Version, nil
+}
+
+// writeStats 按类别写入准确率统计，准确率即该类别的召回率
+func (s *Service) writeStats(ctx context.Context, e *model.ModelEvaluation) {
+	date := *e.EndTime
+	for _, c := range e.Classes {
+		if c.Support == 0 {
+			continue
+		}
+		stats := &model.AccuracyStats{
+			ModelID:        e.ModelID,
+			ModelVersion:   e.ModelVersion,
+			Category:       c.Label,
+			Accuracy:       c.Recall,
+			TotalSamples:   c.Support,
+			CorrectSamples: c.Correct,
+			Source:         model.AccuracySourceEvaluation,
+			Date:           date,
+		}
+		if err := s.stats.SaveAccuracyStats(ctx, stats); err != nil {
+			log.Printf("保存准确率统计失败: %v", err)
+		}
+	}
+}
+
+// fail 将评估标记为失败
+func (s *Service) fail(e *model.ModelEvaluation, message string) {
+	end := time.Now()
+	e.Status = model.EvaluationStatusFailed
+	e.ErrorMsg = message
+	e.EndTime = &end
+	if err := s.evalRepo.Update(e); err != nil {
+		log.Printf("更新评估记录失败: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package evaluation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+type fakeModelRepo struct {
+	model.ModelRepository
+	model    *model.Model
+	accuracy map[string]float64
+}
+
+func (r *fakeModelRepo) FindByID(id string) (*model.Model, error) {
+	return r.model, nil
+}
+
+func (r *fakeModelRepo) UpdateAccuracy(id string, accuracy float64) error {
+	r.accuracy[id] = accuracy
+	return nil
+}
+
+type fakeVersionStore struct {
+	production *model.ModelVersion
+}
+
+func (s *fakeVersionStore) GetProductionVersion(ctx context.Context, modelID string) (*model.ModelVersion, error) {
+	return s.production, nil
+}
+
+func TestUpdateModelAccuracyOnlyForProduction(t *testing.T) {
+	models := &fakeModelRepo{model: &model.Model{ID: "m1", Version: "1.0"}, accuracy: map[string]float64{}}
+	versions := &fakeVersionStore{production: &model.ModelVersion{ModelID: "m1", Version: "2.0"}}
+	s := &Service{modelRepo: models, versions: versions}
+
+	// 候选版本的评估不回写模型准确率
+	s.updateModelAccuracy(context.Background(), &model.ModelEvaluation{ModelID: "m1", ModelVersion: "3.0", Accuracy: 0.5})
+	assert.Empty(t, models.accuracy)
+
+	s.updateModelAccuracy(context.Background(), &model.ModelEvaluation{ModelID: "m1", ModelVersion: "2.0", Accuracy: 0.9})
+	assert.Equal(t, 0.9, models.accuracy["m1"])
+}
+
+func TestUpdateModelAccuracyWithoutVersionRecords(t *testing.T) {
+	models := &fakeModelRepo{model: &model.Model{ID: "m1", Version: "1.0"}, accuracy: map[string]float64{}}
+	s := &Service{modelRepo: models, versions: &fakeVersionStore{}}
+
+	s.updateModelAccuracy(context.Background(), &model.ModelEvaluation{ModelID: "m1", ModelVersion: "2.0", Accuracy: 0.5})
+	assert.Empty(t, models.accuracy)
+
+	s.updateModelAccuracy(context.Background(), &model.ModelEvaluation{ModelID: "m1", ModelVersion: "1.0", Accuracy: 0.8})
+	assert.Equal(t, 0.8, models.accuracy["m1"])
+}
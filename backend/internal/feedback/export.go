@@ -0,0 +1,41 @@
+package feedback
+
+import (
+	"path/filepath"
+
+	"github.com/google/uuid"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// ToSample 将有反馈的识别记录转换为数据集样本，标签取反馈确认后的真实类别
+// 样本的FilePath仍指向识别原图，由调用方复制到数据集目录后更新，并填写数据集和上传者
+func ToSample(record *model.RecognitionRecord) *model.DatasetSample {
+	label := record.Category
+	if record.Feedback != nil {
+		label = record.Feedback.TrueCategory(record.Category)
+	}
+
+	sample := &model.DatasetSample{
+		FileName:    filepath.Base(record.ImageURL),
+		FilePath:    record.ImageURL,
+		FileSize:    record.ImageBytes,
+		Width:       record.ImageWidth,
+		Height:      record.ImageHeight,
+		Labels:      []string{},
+		Annotations: []model.Annotation{},
+	}
+	if label == "" {
+		return sample
+	}
+	sample.Labels = append(sample.Labels, label)
+
+	// 客户提供的目标框作为已通过的标注，没有目标框时作为图像级标签
+	annotation := model.Annotation{ID: uuid.New().String(), Label: label}
+	if record.Feedback != nil && record.Feedback.BBox != nil {
+		bbox := *record.Feedback.BBox
+		annotation.BBox = &bbox
+	}
+	sample.Annotations = append(sample.Annotations, annotation)
+	return sample
+}
@@ -0,0 +1,175 @@
+package feedback
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// MaxCommentLength 反馈备注的最大字符数
+const MaxCommentLength = 500
+
+// RecordStore 识别记录存储，由repository.RecognitionRepository实现
+type RecordStore interface {
+	GetByID(ctx context.Context, id primitive.ObjectID) (*model.RecognitionRecord, error)
+	SaveFeedback(ctx context.Context, id primitive.ObjectID, customerID int64, feedback *model.RecognitionFeedback) (bool, error)
+	AggregateFeedback(ctx context.Context, modelID string, startTime, endTime time.Time) ([]*model.FeedbackTotals, error)
+}
+
+// StatsStore 准确率统计存储，由repository.StatsRepository实现
+type StatsStore interface {
+	ReplaceAccuracyStats(ctx context.Context, modelID, source string, date time.Time, stats []*model.AccuracyStats) error
+}
+
+// Request 客户提交的反馈
+type Request struct {
+	Correct bool               `json:"correct"`
+	Label   string             `json:"label"`
+	BBox    *model.BoundingBox `json:"bbox"`
+	Comment string             `json:"comment"`
+}
+
+// Service 识别结果反馈服务
+// 客户确认或修正识别结果后，按识别日期重新汇总该模型当天的反馈准确率
+type Service struct {
+	records RecordStore
+	stats   StatsStore
+}
+
+// NewService 创建识别结果反馈服务
+func NewService(records RecordStore, stats StatsStore) *Service {
+	return &Service{
+		records: records,
+		stats:   stats,
+	}
+}
+
+// Submit 保存客户对识别记录的反馈，重复提交时覆盖之前的反馈
+func (s *Service) Submit(ctx context.Context, customerID int64, recordID string, req *Request) (*model.RecognitionRecord, error) {
+	id, err := primitive.ObjectIDFromHex(recordID)
+	if err != nil {
+		return nil, errors.NewValidationError("无效的识别记录ID")
+	}
+	record, err := s.records.GetByID(ctx, id)
+	if err != nil {
+		return nil, errors.NewServerError("获取识别记录失败")
+	}
+	// 其他客户的记录按不存在处理
+	if record == nil || record.CustomerID != customerID {
+		return nil, errors.NewNotFoundError("识别记录不存在")
+	}
+
+	feedback, err := Normalize(record, req)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	feedback.CreateTime = now
+	feedback.UpdateTime = now
+	if record.Feedback != nil {
+		feedback.CreateTime = record.Feedback.CreateTime
+	}
+
+	ok, err := s.records.SaveFeedback(ctx, id, customerID, feedback)
+	if err != nil {
+		return nil, errors.NewServerError("保存反馈失败")
+	}
+	if !ok {
+		return nil, errors.NewNotFoundError("识别记录不存在")
+	}
+	record.Feedback = feedback
+
+	// 汇总失败不影响反馈本身，下次提交或手动汇总时会重新计算
+	if err := s.Rollup(ctx, record.ModelID, record.CreateTime); err != nil {
+		log.Printf("汇总模型%s的反馈准确率失败: %v", record.ModelID, err)
+	}
+	return record, nil
+}
+
+// Normalize 校验反馈并转换为保存的格式，与预测类别相同的标签视为未修正类别
+func Normalize(record *model.RecognitionRecord, req *Request) (*model.RecognitionFeedback, error) {
+	if record.Status != model.RecognitionSucceeded {
+		return nil, errors.NewValidationError("只能对识别成功的记录提交反馈")
+	}
+	if utf8.RuneCountInString(req.Comment) > MaxCommentLength {
+		return nil, errors.NewValidationError("备注过长")
+	}
+
+	feedback := &model.RecognitionFeedback{
+		Correct: req.Correct,
+		Comment: strings.TrimSpace(req.Comment),
+	}
+	label := strings.TrimSpace(req.Label)
+	if req.Correct {
+		if (label != "" && label != record.Category) || req.BBox != nil {
+			return nil, errors.NewValidationError("结果正确时无需提供标签或目标框")
+		}
+		return feedback, nil
+	}
+
+	if label != record.Category {
+		feedback.Label = label
+	}
+	if req.BBox != nil {
+		if err := validateBBox(req.BBox, record.ImageWidth, record.ImageHeight); err != nil {
+			return nil, err
+		}
+		feedback.BBox = req.BBox
+	}
+	if feedback.Label == "" && feedback.BBox == nil {
+		return nil, errors.NewValidationError("请提供正确的标签或目标框")
+	}
+	return feedback, nil
+}
+
+// validateBBox 校验目标框，图片尺寸已知时目标框不能超出图片
+func validateBBox(b *model.BoundingBox, width, height int) error {
+	if b.X < 0 || b.Y < 0 || b.Width <= 0 || b.Height <= 0 {
+		return errors.NewValidationError("无效的目标框")
+	}
+	if width > 0 && height > 0 && (b.X+b.Width > float64(width) || b.Y+b.Height > float64(height)) {
+		return errors.NewValidationError("目标框超出图片范围")
+	}
+	return nil
+}
+
+// Day 识别时间所在的统计日期(UTC零点)
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Rollup 重新汇总模型在day当天识别记录的反馈准确率
+func (s *Service) Rollup(ctx context.Context, modelID string, day time.Time) error {
+	start := Day(day)
+	totals, err := s.records.AggregateFeedback(ctx, modelID, start, start.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	return s.stats.ReplaceAccuracyStats(ctx, modelID, model.AccuracySourceFeedback, start, BuildStats(totals))
+}
+
+// BuildStats 将反馈汇总转换为按类别的准确率统计
+func BuildStats(totals []*model.FeedbackTotals) []*model.AccuracyStats {
+	stats := make([]*model.AccuracyStats, 0, len(totals))
+	for _, t := range totals {
+		if t.Total == 0 {
+			continue
+		}
+		stats = append(stats, &model.AccuracyStats{
+			ModelID:        t.ModelID,
+			Category:       t.Category,
+			Accuracy:       float64(t.Correct) / float64(t.Total),
+			TotalSamples:   t.Total,
+			CorrectSamples: t.Correct,
+		})
+	}
+	return stats
+}
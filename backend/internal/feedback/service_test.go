@@ -0,0 +1,196 @@
+package feedback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+type memoryRecords struct {
+	records map[primitive.ObjectID]*model.RecognitionRecord
+}
+
+func (m *memoryRecords) GetByID(ctx context.Context, id primitive.ObjectID) (*model.RecognitionRecord, error) {
+	r, ok := m.records[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *r
+	return &copied, nil
+}
+
+func (m *memoryRecords) SaveFeedback(ctx context.Context, id primitive.ObjectID, customerID int64, feedback *model.RecognitionFeedback) (bool, error) {
+	r, ok := m.records[id]
+	if !ok || r.CustomerID != customerID || r.Status != model.RecognitionSucceeded {
+		return false, nil
+	}
+	r.Feedback = feedback
+	return true, nil
+}
+
+// AggregateFeedback 与仓储的聚合管道逻辑一致
+func (m *memoryRecords) AggregateFeedback(ctx context.Context, modelID string, startTime, endTime time.Time) ([]*model.FeedbackTotals, error) {
+	byCategory := make(map[string]*model.FeedbackTotals)
+	var result []*model.FeedbackTotals
+	for _, r := range m.records {
+		if r.ModelID != modelID || r.Feedback == nil || r.CreateTime.Before(startTime) || !r.CreateTime.Before(endTime) {
+			continue
+		}
+		category := r.Feedback.TrueCategory(r.Category)
+		t, ok := byCategory[category]
+		if !ok {
+			t = &model.FeedbackTotals{ModelID: modelID, Category: category}
+			byCategory[category] = t
+			result = append(result, t)
+		}
+		t.Total++
+		if category == r.Category {
+			t.Correct++
+		}
+	}
+	return result, nil
+}
+
+type memoryStats struct {
+	stats map[string]*model.AccuracyStats
+}
+
+func (m *memoryStats) ReplaceAccuracyStats(ctx context.Context, modelID, source string, date time.Time, stats []*model.AccuracyStats) error {
+	m.stats = make(map[string]*model.AccuracyStats)
+	for _, s := range stats {
+		s.Source = source
+		s.Date = date
+		m.stats[s.Category] = s
+	}
+	return nil
+}
+
+func newRecord(category string, customerID int64, at time.Time) *model.RecognitionRecord {
+	return &model.RecognitionRecord{
+		ID:          primitive.NewObjectID(),
+		CustomerID:  customerID,
+		ModelID:     "m1",
+		Category:    category,
+		Status:      model.RecognitionSucceeded,
+		ImageWidth:  100,
+		ImageHeight: 80,
+		CreateTime:  at,
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	record := newRecord("cat", 1, time.Now())
+
+	f, err := Normalize(record, &Request{Correct: true, Label: "cat"})
+	require.NoError(t, err)
+	assert.True(t, f.Correct)
+	assert.Empty(t, f.Label)
+
+	_, err = Normalize(record, &Request{Correct: true, Label: "dog"})
+	assert.Error(t, err)
+
+	// 修正为与预测相同的类别且没有目标框，等于没有修正
+	_, err = Normalize(record, &Request{Label: "cat"})
+	assert.Error(t, err)
+
+	f, err = Normalize(record, &Request{Label: " dog ", BBox: &model.BoundingBox{X: 10, Y: 10, Width: 50, Height: 40}})
+	require.NoError(t, err)
+	assert.Equal(t, "dog", f.Label)
+	assert.NotNil(t, f.BBox)
+
+	_, err = Normalize(record, &Request{Label: "dog", BBox: &model.BoundingBox{X: 60, Y: 0, Width: 50, Height: 40}})
+	assert.Error(t, err)
+
+	failed := newRecord("", 1, time.Now())
+	failed.Status = model.RecognitionFailed
+	_, err = Normalize(failed, &Request{Correct: true})
+	assert.Error(t, err)
+}
+
+func TestSubmitRollsUpAccuracy(t *testing.T) {
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	records := &memoryRecords{records: make(map[primitive.ObjectID]*model.RecognitionRecord)}
+	var ids []string
+	for _, category := range []string{"cat", "cat", "dog"} {
+		r := newRecord(category, 1, day)
+		records.records[r.ID] = r
+		ids = append(ids, r.ID.Hex())
+	}
+	stats := &memoryStats{}
+	service := NewService(records, stats)
+	ctx := context.Background()
+
+	_, err := service.Submit(ctx, 1, ids[0], &Request{Correct: true})
+	require.NoError(t, err)
+	_, err = service.Submit(ctx, 1, ids[1], &Request{Label: "dog"})
+	require.NoError(t, err)
+	record, err := service.Submit(ctx, 1, ids[2], &Request{Correct: true})
+	require.NoError(t, err)
+	assert.NotNil(t, record.Feedback)
+
+	require.Contains(t, stats.stats, "cat")
+	require.Contains(t, stats.stats, "dog")
+	assert.Equal(t, int64(1), stats.stats["cat"].TotalSamples)
+	assert.Equal(t, int64(1), stats.stats["cat"].CorrectSamples)
+	// 修正为dog的样本计入dog类别且算作预测错误
+	assert.Equal(t, int64(2), stats.stats["dog"].TotalSamples)
+	assert.Equal(t, int64(1), stats.stats["dog"].CorrectSamples)
+	assert.InDelta(t, 0.5, stats.stats["dog"].Accuracy, 1e-9)
+	assert.Equal(t, Day(day), stats.stats["dog"].Date)
+	assert.Equal(t, model.AccuracySourceFeedback, stats.stats["dog"].Source)
+
+	// 重新提交覆盖之前的反馈，保留首次反馈时间
+	first := records.records[objectID(t, ids[1])].Feedback.CreateTime
+	_, err = service.Submit(ctx, 1, ids[1], &Request{Correct: true})
+	require.NoError(t, err)
+	assert.Equal(t, first, records.records[objectID(t, ids[1])].Feedback.CreateTime)
+	assert.Equal(t, int64(2), stats.stats["cat"].CorrectSamples)
+	assert.Equal(t, int64(1), stats.stats["dog"].TotalSamples)
+}
+
+func TestSubmitRejectsOtherCustomer(t *testing.T) {
+	r := newRecord("cat", 1, time.Now())
+	records := &memoryRecords{records: map[primitive.ObjectID]*model.RecognitionRecord{r.ID: r}}
+	service := NewService(records, &memoryStats{})
+
+	_, err := service.Submit(context.Background(), 2, r.ID.Hex(), &Request{Correct: true})
+	var appErr *errors.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 404, appErr.HTTPCode)
+	assert.Nil(t, r.Feedback)
+
+	_, err = service.Submit(context.Background(), 1, "bad-id", &Request{Correct: true})
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 400, appErr.HTTPCode)
+}
+
+func TestToSample(t *testing.T) {
+	r := newRecord("cat", 1, time.Now())
+	r.ImageURL = "/uploads/a.jpg"
+	r.Feedback = &model.RecognitionFeedback{Label: "dog", BBox: &model.BoundingBox{X: 1, Y: 2, Width: 3, Height: 4}}
+
+	sample := ToSample(r)
+	assert.Equal(t, "a.jpg", sample.FileName)
+	assert.Equal(t, []string{"dog"}, sample.Labels)
+	require.Len(t, sample.Annotations, 1)
+	assert.Equal(t, "dog", sample.Annotations[0].Label)
+	assert.Equal(t, 3.0, sample.Annotations[0].BBox.Width)
+
+	r.Feedback = &model.RecognitionFeedback{Correct: true}
+	sample = ToSample(r)
+	assert.Equal(t, []string{"cat"}, sample.Labels)
+	assert.Nil(t, sample.Annotations[0].BBox)
+}
+
+func objectID(t *testing.T, hex string) primitive.ObjectID {
+	id, err := primitive.ObjectIDFromHex(hex)
+	require.NoError(t, err)
+	return id
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/artifact"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// ArtifactHandler 模型文件上传与校验处理器
+type ArtifactHandler struct {
+	store *artifact.Store
+}
+
+// NewArtifactHandler 创建模型文件上传与校验处理器
+func NewArtifactHandler(store *artifact.Store) *ArtifactHandler {
+	return &ArtifactHandler{store: store}
+}
+
+// CreateUpload 为模型版本创建分块上传会话
+func (h *ArtifactHandler) CreateUpload(c *gin.Context) {
+	var req struct {
+		FileName   string            `json:"fileName" binding:"required"`
+		Format     string            `json:"format" binding:"required"`
+		TotalSize  int64             `json:"totalSize" binding:"required"`
+		ChunkSize  int64             `json:"chunkSize"` // 为空时使用默认分块大小
+		InputShape []int             `json:"inputShape"`
+		LabelMap   map[string]string `json:"labelMap"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		return
+	}
+
+	upload := &model.ArtifactUpload{
+		ModelID:    c.Param("id"),
+		Version:    c.Param("version"),
+		FileName:   req.FileName,
+		Format:     req.Format,
+		TotalSize:  req.TotalSize,
+		ChunkSize:  req.ChunkSize,
+		InputShape: req.InputShape,
+		LabelMap:   req.LabelMap,
+		UserID:     currentUserID(c),
+	}
+	if err := h.store.CreateUpload(c.Request.Context(), upload); err != nil {
+		writeAppError(c, err, "创建上传会话失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "创建成功", "data": upload})
+}
+
+// UploadChunk 上传一个分块，请求体为分块的原始字节
+func (h *ArtifactHandler) UploadChunk(c *gin.Context) {
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的分块序号"})
+		return
+	}
+
+	upload, err := h.store.WriteChunk(c.Request.Context(), c.Param("uploadId"), index, c.Request.Body)
+	if err != nil {
+		writeAppError(c, err, "上传分块失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "上传成功", "data": gin.H{
+		"received":    len(upload.Received),
+		"totalChunks": upload.TotalChunks,
+		"missing":     artifact.MissingChunks(upload),
+	}})
+}
+
+// CompleteUpload 完成上传，校验SHA-256并生成签名清单
+func (h *ArtifactHandler) CompleteUpload(c *gin.Context) {
+	var req struct {
+		SHA256 string `json:"sha256"` // 客户端计算的校验和，建议提供
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+			return
+		}
+	}
+
+	manifest, err := h.store.Complete(c.Request.Context(), c.Param("uploadId"), req.SHA256, currentUserID(c))
+	if err != nil {
+		writeAppError(c, err, "完成上传失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "上传完成", "data": manifest})
+}
+
+// AbortUpload 放弃上传
+func (h *ArtifactHandler) AbortUpload(c *gin.Context) {
+	if err := h.store.Abort(c.Param("uploadId")); err != nil {
+		writeAppError(c, err, "取消上传失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已取消上传"})
+}
+
+// VerifyArtifact 校验模型版本的文件并返回清单
+func (h *ArtifactHandler) VerifyArtifact(c *gin.Context) {
+	manifest, err := h.store.Verify(c.Request.Context(), c.Param("id"), c.Param("version"))
+	switch {
+	case stderrors.Is(err, artifact.ErrMissing):
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "模型文件不存在", "data": gin.H{"valid": false, "reason": err.Error()}})
+		return
+	case stderrors.Is(err, artifact.ErrTampered):
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "message": "模型文件校验失败", "data": gin.H{"valid": false, "reason": err.Error()}})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "校验模型文件失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "校验通过", "data": gin.H{"valid": true, "manifest": manifest}})
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClientAudience 客户端访问令牌的受众，只能用于客户端接口
+const ClientAudience = "client-api"
+
+// ClientClaims OAuth2客户端凭证模式签发的访问令牌声明
+// Subject为签发令牌所用的API密钥ID，ID(jti)用于吊销
+type ClientClaims struct {
+	AppID      string `json:"client_id"`
+	CustomerID int64  `json:"customerId"`
+	Scope      string `json:"scope"` // 以空格分隔的访问范围
+	jwt.RegisteredClaims
+}
+
+// Scopes 访问范围列表
+func (c *ClientClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// KeyID 签发令牌所用的API密钥ID
+func (c *ClientClaims) KeyID() string {
+	return c.Subject
+}
+
+// IssueClientToken 签发客户端访问令牌，返回令牌和过期时间
+func IssueClientToken(secret, tokenID, appID, keyID string, customerID int64, scopes []string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := ClientClaims{
+		AppID:      appID,
+		CustomerID: customerID,
+		Scope:      strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Subject:   keyID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "image-recognition-engine",
+			Audience:  jwt.ClaimStrings{ClientAudience},
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return tokenString, expiresAt, nil
+}
+
+// ParseClientToken 解析并校验客户端访问令牌，管理员和客户门户令牌因受众不符被拒绝
+func ParseClientToken(secret, tokenString string) (*ClientClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ClientClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(ClientAudience))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*ClientClaims)
+	if !ok || !token.Valid || claims.AppID == "" || claims.Subject == "" || claims.ID == "" {
+		return nil, errors.New("无效的访问令牌")
+	}
+	return claims, nil
+}
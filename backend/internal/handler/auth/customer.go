@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// CustomerAudience 客户门户令牌的受众，管理端认证拒绝携带该受众的令牌
+const CustomerAudience = "customer-portal"
+
+// CustomerClaims 客户门户JWT声明结构，与管理员的JWTClaims分开签发和校验
+type CustomerClaims struct {
+	CustomerID int64  `json:"customerId"`
+	Username   string `json:"username"`
+	jwt.RegisteredClaims
+}
+
+// IssueCustomerToken 签发客户门户令牌，返回令牌和过期时间
+func IssueCustomerToken(secret string, customerID int64, username string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := CustomerClaims{
+		CustomerID: customerID,
+		Username:   username,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "image-recognition-engine",
+			Audience:  jwt.ClaimStrings{CustomerAudience},
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return tokenString, expiresAt, nil
+}
+
+// ParseCustomerToken 解析并校验客户门户令牌，管理员令牌因受众不符被拒绝
+func ParseCustomerToken(secret, tokenString string) (*CustomerClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CustomerClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(CustomerAudience))
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*CustomerClaims)
+	if !ok || !token.Valid || claims.CustomerID == 0 {
+		return nil, errors.New("无效的客户令牌")
+	}
+	return claims, nil
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+func TestCustomerTokenRoundTrip(t *testing.T) {
+	token, expiresAt, err := IssueCustomerToken(testSecret, 42, "acme", time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Second)
+
+	claims, err := ParseCustomerToken(testSecret, token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), claims.CustomerID)
+	assert.Equal(t, "acme", claims.Username)
+
+	_, err = ParseCustomerToken("other-secret", token)
+	assert.Error(t, err)
+}
+
+func TestCustomerTokenExpired(t *testing.T) {
+	token, _, err := IssueCustomerToken(testSecret, 42, "acme", -time.Minute)
+	require.NoError(t, err)
+
+	_, err = ParseCustomerToken(testSecret, token)
+	assert.Error(t, err)
+}
+
+func TestAdminTokenRejectedByPortal(t *testing.T) {
+	admin := JWTClaims{
+		UserID:   1,
+		Username: "admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			Issuer:    "image-recognition-engine",
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, admin).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+
+	// 管理员令牌没有客户门户受众
+	_, err = ParseCustomerToken(testSecret, token)
+	assert.Error(t, err)
+}
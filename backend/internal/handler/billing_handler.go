@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/billing"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// exportPageSize 导出账单时每次查询的数量
+const exportPageSize = 100
+
+// BillingHandler 账单及套餐计价处理器
+type BillingHandler struct {
+	service  *billing.Service
+	invoices model.InvoiceRepository
+	pricing  model.PlanPricingRepository
+	plans    model.ServicePlanRepository
+}
+
+// NewBillingHandler 创建账单及套餐计价处理器
+func NewBillingHandler(service *billing.Service, invoices model.InvoiceRepository, pricing model.PlanPricingRepository, plans model.ServicePlanRepository) *BillingHandler {
+	return &BillingHandler{
+		service:  service,
+		invoices: invoices,
+		pricing:  pricing,
+		plans:    plans,
+	}
+}
+
+// generateInvoiceRequest 生成账单请求
+type generateInvoiceRequest struct {
+	CustomerID int64  `json:"customerId"` // 为0时生成所有客户的账单
+	Month      string `json:"month"`      // 如2024-05，为空时取当前月份
+}
+
+// ListInvoices 分页获取账单
+// 查询参数：customerId、month(如2024-05)、status
+func (h *BillingHandler) ListInvoices(c *gin.Context) {
+	customerID, month, ok := invoiceFilter(c)
+	if !ok {
+		return
+	}
+	page, size := pageParams(c)
+
+	invoices, total, err := h.invoices.List(customerID, month, c.Query("status"), page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取账单列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"total": total,
+			"items": invoices,
+		},
+	})
+}
+
+// GetInvoice 获取账单详情
+func (h *BillingHandler) GetInvoice(c *gin.Context) {
+	invoice, err := h.invoices.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取账单失败"})
+		return
+	}
+	if invoice == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "账单不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": invoice})
+}
+
+// PreviewInvoice 按当前用量预览客户的月度账单，不保存
+func (h *BillingHandler) PreviewInvoice(c *gin.Context) {
+	customerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的客户ID"})
+		return
+	}
+	month, err := billing.ParseMonth(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "账单月份格式错误，应为YYYY-MM"})
+		return
+	}
+
+	invoice, err := h.service.Preview(customerID, month)
+	if err != nil {
+		writeAppError(c, err, "计算账单失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": invoice})
+}
+
+// GenerateInvoices 生成草稿账单，指定客户时返回该客户的账单，否则返回生成的账单数
+func (h *BillingHandler) GenerateInvoices(c *gin.Context) {
+	var req generateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+	month, err := billing.ParseMonth(req.Month)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "账单月份格式错误，应为YYYY-MM"})
+		return
+	}
+
+	if req.CustomerID != 0 {
+		invoice, err := h.service.Generate(req.CustomerID, month)
+		if err != nil {
+			writeAppError(c, err, "生成账单失败")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"code": 200, "message": "生成成功", "data": invoice})
+		return
+	}
+
+	generated, err := h.service.GenerateAll(month)
+	if err != nil {
+		writeAppError(c, err, "生成账单失败")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "生成成功", "data": gin.H{"generated": generated}})
+}
+
+// IssueInvoice 开具草稿账单
+func (h *BillingHandler) IssueInvoice(c *gin.Context) {
+	invoice, err := h.service.Issue(c.Param("id"))
+	if err != nil {
+		writeAppError(c, err, "开具账单失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "开具成功", "data": invoice})
+}
+
+// ExportInvoices 按月份导出账单明细，供财务系统导入
+// 查询参数：month(必填，如2024-05)、status(默认issued)、customerId
+func (h *BillingHandler) ExportInvoices(c *gin.Context) {
+	if c.Query("month") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请指定账单月份"})
+		return
+	}
+	customerID, month, ok := invoiceFilter(c)
+	if !ok {
+		return
+	}
+	status := c.DefaultQuery("status", model.InvoiceIssued)
+
+	invoices := make([]*model.Invoice, 0)
+	for page := 1; ; page++ {
+		items, total, err := h.invoices.List(customerID, month, status, page, exportPageSize)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "导出账单失败"})
+			return
+		}
+		invoices = append(invoices, items...)
+		if len(items) == 0 || int64(len(invoices)) >= total {
+			break
+		}
+	}
+
+	data, err := json.MarshalIndent(billing.ExportRows(invoices), "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "导出账单失败"})
+		return
+	}
+	filename := fmt.Sprintf("invoices_%s.json", month.Format("200601"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// GetPricing 获取套餐的计价规则
+func (h *BillingHandler) GetPricing(c *gin.Context) {
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的套餐ID"})
+		return
+	}
+
+	pricing, err := h.pricing.FindByPlan(planID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取套餐计价规则失败"})
+		return
+	}
+	if pricing == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "套餐未配置计价规则"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": pricing})
+}
+
+// SavePricing 保存套餐的计价规则
+func (h *BillingHandler) SavePricing(c *gin.Context) {
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的套餐ID"})
+		return
+	}
+	plan, err := h.plans.FindByID(planID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "系统错误"})
+		return
+	}
+	if plan == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "服务套餐不存在"})
+		return
+	}
+
+	var pricing model.PlanPricing
+	if err := c.ShouldBindJSON(&pricing); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+	if msg := billing.ValidatePricing(&pricing); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": msg})
+		return
+	}
+	pricing.PlanID = planID
+	if pricing.Currency == "" {
+		pricing.Currency = billing.DefaultCurrency
+	}
+
+	if err := h.pricing.Save(&pricing); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存套餐计价规则失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": pricing})
+}
+
+// invoiceFilter 解析账单查询的客户和月份，月份为空时不过滤
+func invoiceFilter(c *gin.Context) (int64, time.Time, bool) {
+	var customerID int64
+	if v := c.Query("customerId"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的客户ID"})
+			return 0, time.Time{}, false
+		}
+		customerID = id
+	}
+
+	var month time.Time
+	if v := c.Query("month"); v != "" {
+		m, err := billing.ParseMonth(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "账单月份格式错误，应为YYYY-MM"})
+			return 0, time.Time{}, false
+		}
+		month = m
+	}
+	return customerID, month, true
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/feedback"
+	"github.com/image-recognition-engine/internal/security"
+)
+
+// FeedbackHandler 客户端识别结果反馈处理器
+type FeedbackHandler struct {
+	service *feedback.Service
+}
+
+// NewFeedbackHandler 创建客户端识别结果反馈处理器
+func NewFeedbackHandler(service *feedback.Service) *FeedbackHandler {
+	return &FeedbackHandler{service: service}
+}
+
+// SubmitFeedback 确认识别结果正确，或提供正确的标签和目标框
+func (h *FeedbackHandler) SubmitFeedback(c *gin.Context) {
+	customerID, ok := security.CustomerIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "未授权的访问", "data": nil})
+		return
+	}
+
+	var req feedback.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数", "data": nil})
+		return
+	}
+
+	record, err := h.service.Submit(c.Request.Context(), customerID, c.Param("id"), &req)
+	if err != nil {
+		writeError(c, err, "保存反馈失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "反馈成功", "data": NewRecognitionResponse(record)})
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+
+	"github.com/image-recognition-engine/internal/model"
 )
 
 // RecognitionRequest 
@@ -26,6 +28,9 @@ type RecognitionResponse struct {
 	ProcessingTime int64    `json:"processingTime"`
 	ModelVersion  string    `json:"modelVersion"`
 	CreatedAt     time.Time `json:"createdAt"`
+	Categories    []string  `json:"categories,omitempty"` // 预测类别及其上级分类，如cat、animal、pet
+	DisplayName   string    `json:"displayName,omitempty"` // 预测类别的本地化显示名称
+	Feedback      *model.RecognitionFeedback `json:"feedback,omitempty"`
 }
 
 // RecognizeImage 处理图像识别请求
@@ -0,0 +1,331 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/image-recognition-engine/internal/billing"
+	"github.com/image-recognition-engine/internal/drift"
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/inference"
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/queue"
+	"github.com/image-recognition-engine/internal/repository"
+	"github.com/image-recognition-engine/internal/routing"
+	"github.com/image-recognition-engine/internal/security"
+	"github.com/image-recognition-engine/internal/taxonomy"
+)
+
+// maxImageSize 单张图片大小上限
+const maxImageSize = 10 * 1024 * 1024
+
+// maxResultLabels 识别结果中返回的标签数
+const maxResultLabels = 5
+
+// TaskQueue 异步任务队列
+type TaskQueue interface {
+	Enqueue(ctx context.Context, taskType queue.TaskType, data interface{}) (string, error)
+}
+
+// RecognitionHandler 客户端识别处理器，按模型的流量分配策略选择服务版本并保存识别记录
+type RecognitionHandler struct {
+	router    *routing.Service
+	predictor inference.Predictor
+	records   repository.RecognitionRepository
+	shadow    *routing.ShadowRunner // 为nil时不做影子复跑
+	labels    *taxonomy.Service     // 为nil时不展开上级分类和显示名称
+	usage     *billing.Recorder     // 为nil时不记录计费用量
+	tasks     TaskQueue             // 为nil时不计算图片统计信息
+	uploadDir string
+}
+
+// NewRecognitionHandler 创建客户端识别处理器
+func NewRecognitionHandler(router *routing.Service, predictor inference.Predictor, records repository.RecognitionRepository, shadow *routing.ShadowRunner, labels *taxonomy.Service, usage *billing.Recorder, tasks TaskQueue, uploadDir string) *RecognitionHandler {
+	return &RecognitionHandler{
+		router:    router,
+		predictor: predictor,
+		records:   records,
+		shadow:    shadow,
+		labels:    labels,
+		usage:     usage,
+		tasks:     tasks,
+		uploadDir: uploadDir,
+	}
+}
+
+// Recognize 识别上传的图片
+func (h *RecognitionHandler) Recognize(c *gin.Context) {
+	customerID, ok := security.CustomerIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "未授权的访问", "data": nil})
+		return
+	}
+
+	modelID := c.PostForm("modelId")
+	if modelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请指定模型", "data": nil})
+		return
+	}
+	image, ext, ok := readImage(c)
+	if !ok {
+		return
+	}
+
+	digest := sha256.Sum256(image)
+	decision, err := h.router.Resolve(c.Request.Context(), modelID, customerID, hex.EncodeToString(digest[:]))
+	if err != nil {
+		writeError(c, err, "选择模型版本失败")
+		return
+	}
+
+	// 保存原图，便于复核和反馈
+	if err := os.MkdirAll(h.uploadDir, 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "文件保存失败", "data": nil})
+		return
+	}
+	dst := filepath.Join(h.uploadDir, uuid.New().String()+ext)
+	if err := os.WriteFile(dst, image, 0644); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "文件保存失败", "data": nil})
+		return
+	}
+
+	// 尺寸只读取图片头，计费用量按像素数计算，需在记录用量前确定；无法解析时为0
+	width, height, err := drift.ImageSize(image)
+	if err != nil {
+		log.Printf("读取图片尺寸失败: %v", err)
+	}
+
+	startTime := time.Now()
+	predictions, err := h.predictor.Predict(c.Request.Context(), decision.ModelID, decision.ModelVersion, image)
+	record := &model.RecognitionRecord{
+		CustomerID:   customerID,
+		ModelID:      decision.ModelID,
+		ModelVersion: decision.ModelVersion,
+		Variant:      decision.Variant,
+		ImageURL:     dst,
+		ProcessTime:  time.Since(startTime).Milliseconds(),
+		Status:       model.RecognitionSucceeded,
+		ImageBytes:   int64(len(image)),
+		ImageWidth:   width,
+		ImageHeight:  height,
+	}
+	if err != nil {
+		record.Status = model.RecognitionFailed
+		record.ErrorMessage = err.Error()
+	} else {
+		fillResult(record, predictions)
+	}
+
+	if err := h.records.Create(c.Request.Context(), record); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存识别记录失败", "data": nil})
+		return
+	}
+	if record.Status == model.RecognitionFailed {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "识别失败", "data": gin.H{"id": record.ID.Hex()}})
+		return
+	}
+
+	// 图片统计信息用于漂移检测，由队列异步解码计算，不占用识别请求的时间和内存
+	if h.tasks != nil {
+		data := drift.StatsTaskData{RecordID: record.ID.Hex(), ImagePath: dst}
+		if _, err := h.tasks.Enqueue(c.Request.Context(), queue.TaskTypeImageStats, data); err != nil {
+			log.Printf("提交识别%s的图片统计任务失败: %v", record.ID.Hex(), err)
+		}
+	}
+
+	// 计费用量记录失败不影响本次识别结果
+	if h.usage != nil {
+		if err := h.usage.RecordRecognition(record); err != nil {
+			log.Printf("记录识别%s的计费用量失败: %v", record.ID.Hex(), err)
+		}
+	}
+
+	// 异步复跑到影子版本，结果不影响本次响应
+	if decision.ShadowVersion != "" && h.shadow != nil {
+		h.shadow.Submit(&routing.ShadowJob{Record: record, ShadowVersion: decision.ShadowVersion, Image: image})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "识别成功", "data": h.response(c, record)})
+}
+
+// GetRecognition 获取当前客户的识别记录
+func (h *RecognitionHandler) GetRecognition(c *gin.Context) {
+	record, ok := h.findRecord(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": h.response(c, record)})
+}
+
+// ListRecognitions 分页获取当前客户的识别历史
+func (h *RecognitionHandler) ListRecognitions(c *gin.Context) {
+	customerID, ok := security.CustomerIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "未授权的访问", "data": nil})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+
+	records, total, err := h.records.GetByCustomerID(c.Request.Context(), customerID, page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取识别历史失败", "data": nil})
+		return
+	}
+
+	items := make([]RecognitionResponse, 0, len(records))
+	for _, r := range records {
+		items = append(items, h.response(c, r))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"total": total,
+			"items": items,
+		},
+	})
+}
+
+// findRecord 查找当前客户的识别记录，其他客户的记录按不存在处理
+func (h *RecognitionHandler) findRecord(c *gin.Context) (*model.RecognitionRecord, bool) {
+	customerID, ok := security.CustomerIDFrom(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "未授权的访问", "data": nil})
+		return nil, false
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的识别记录ID", "data": nil})
+		return nil, false
+	}
+	record, err := h.records.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取识别记录失败", "data": nil})
+		return nil, false
+	}
+	if record == nil || record.CustomerID != customerID {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "识别记录不存在", "data": nil})
+		return nil, false
+	}
+	return record, true
+}
+
+// readImage 读取并校验上传的图片
+func readImage(c *gin.Context) ([]byte, string, bool) {
+	file, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请上传图片", "data": nil})
+		return nil, "", false
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".gif" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "不支持的文件类型，仅支持jpg、jpeg、png和gif格式", "data": nil})
+		return nil, "", false
+	}
+	if file.Size > maxImageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "文件大小超过限制，最大支持10MB", "data": nil})
+		return nil, "", false
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "读取上传文件失败", "data": nil})
+		return nil, "", false
+	}
+	defer src.Close()
+
+	image, err := io.ReadAll(io.LimitReader(src, maxImageSize+1))
+	if err != nil || len(image) > maxImageSize {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "读取上传文件失败", "data": nil})
+		return nil, "", false
+	}
+	return image, ext, true
+}
+
+// fillResult 将预测结果写入识别记录
+func fillResult(record *model.RecognitionRecord, predictions []inference.Prediction) {
+	sorted := append([]inference.Prediction(nil), predictions...)
+	inference.SortPredictions(sorted)
+	if len(sorted) > maxResultLabels {
+		sorted = sorted[:maxResultLabels]
+	}
+	for _, p := range sorted {
+		record.Labels = append(record.Labels, p.Label)
+	}
+	if len(sorted) > 0 {
+		record.Category = sorted[0].Label
+		record.Confidence = sorted[0].Confidence
+	}
+}
+
+// NewRecognitionResponse 识别记录转换为客户端响应
+func NewRecognitionResponse(r *model.RecognitionRecord) RecognitionResponse {
+	labels := r.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	return RecognitionResponse{
+		ID:             r.ID.Hex(),
+		Labels:         labels,
+		Confidence:     r.Confidence,
+		ProcessingTime: r.ProcessTime,
+		ModelVersion:   r.ModelVersion,
+		CreatedAt:      r.CreateTime,
+		Feedback:       r.Feedback,
+	}
+}
+
+// response 识别记录转换为客户端响应，并按模型的标签映射补充上级分类和显示名称
+func (h *RecognitionHandler) response(c *gin.Context, r *model.RecognitionRecord) RecognitionResponse {
+	resp := NewRecognitionResponse(r)
+	if h.labels == nil || r.Category == "" {
+		return resp
+	}
+	t, err := h.labels.Taxonomy(r.ModelID)
+	if err != nil || t == nil {
+		return resp
+	}
+
+	locale := c.Query("locale")
+	if locale == "" {
+		locale = taxonomy.ParseLocale(c.GetHeader("Accept-Language"))
+	}
+	resp.Categories = t.Expand([]string{r.Category})
+	resp.DisplayName = t.DisplayName(r.Category, locale)
+	return resp
+}
+
+// writeError 将服务层返回的AppError转换为响应，其他错误按服务器错误处理
+func writeError(c *gin.Context, err error, fallback string) {
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		c.JSON(appErr.HTTPCode, gin.H{"code": appErr.HTTPCode, "message": appErr.Message, "data": nil})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": fallback, "data": nil})
+}
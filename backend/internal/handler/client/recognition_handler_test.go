@@ -0,0 +1,119 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/image-recognition-engine/internal/billing"
+	"github.com/image-recognition-engine/internal/inference"
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/repository"
+	"github.com/image-recognition-engine/internal/routing"
+)
+
+type fakePolicies struct {
+	model.RoutingPolicyRepository
+}
+
+func (fakePolicies) FindByModel(modelID string) (*model.RoutingPolicy, error) { return nil, nil }
+
+type fakeVersions struct{}
+
+func (fakeVersions) GetProductionVersion(ctx context.Context, modelID string) (*model.ModelVersion, error) {
+	return &model.ModelVersion{ModelID: modelID, Version: "v1"}, nil
+}
+
+func (fakeVersions) FindModelVersion(ctx context.Context, modelID, version string) (*model.ModelVersion, error) {
+	return nil, nil
+}
+
+type fakePredictor struct{}
+
+func (fakePredictor) Predict(ctx context.Context, modelID, modelVersion string, image []byte) ([]inference.Prediction, error) {
+	return []inference.Prediction{{Label: "cat", Confidence: 0.9}}, nil
+}
+
+type fakeRecords struct {
+	repository.RecognitionRepository
+	created []*model.RecognitionRecord
+}
+
+func (r *fakeRecords) Create(ctx context.Context, record *model.RecognitionRecord) error {
+	record.ID = primitive.NewObjectID()
+	record.CreateTime = time.Now()
+	r.created = append(r.created, record)
+	return nil
+}
+
+type fakeUsageEvents struct {
+	model.UsageEventRepository
+	events []*model.UsageEvent
+}
+
+func (r *fakeUsageEvents) Create(event *model.UsageEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+type fakeModels struct{}
+
+func (fakeModels) FindByID(id string) (*model.Model, error) {
+	return &model.Model{Type: "classification"}, nil
+}
+
+// pngUpload 构建上传指定尺寸PNG图片的识别请求
+func pngUpload(t *testing.T, width, height int) *http.Request {
+	t.Helper()
+	var img bytes.Buffer
+	require.NoError(t, png.Encode(&img, image.NewGray(image.Rect(0, 0, width, height))))
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	require.NoError(t, w.WriteField("modelId", "m1"))
+	part, err := w.CreateFormFile("image", "a.png")
+	require.NoError(t, err)
+	_, err = part.Write(img.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/client/recognitions", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestRecognizeRecordsImageMegapixels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	records := &fakeRecords{}
+	events := &fakeUsageEvents{}
+	h := NewRecognitionHandler(routing.NewService(fakePolicies{}, fakeVersions{}, nil, nil), fakePredictor{}, records,
+		nil, nil, billing.NewRecorder(events, fakeModels{}), nil, t.TempDir())
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = pngUpload(t, 1000, 500)
+	c.Set("customerId", int64(7))
+	h.Recognize(c)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Len(t, records.created, 1)
+	assert.Equal(t, 1000, records.created[0].ImageWidth)
+	assert.Equal(t, 500, records.created[0].ImageHeight)
+
+	// 图片统计任务异步执行，用量事件在请求内写入时已包含像素数
+	require.Len(t, events.events, 1)
+	assert.Equal(t, int64(7), events.events[0].CustomerID)
+	assert.Equal(t, "classification", events.events[0].ModelType)
+	assert.InDelta(t, 0.5, events.events[0].Megapixels, 1e-9)
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -12,13 +14,15 @@ import (
 type CustomerHandler struct {
 	customerRepo model.CustomerRepository
 	planRepo     model.ServicePlanRepository
+	changeRepo   model.PlanChangeRepository // 为nil时不记录套餐变更，账单按当前套餐整月计费
 }
 
 // NewCustomerHandler 创建客户处理器实例
-func NewCustomerHandler(customerRepo model.CustomerRepository, planRepo model.ServicePlanRepository) *CustomerHandler {
+func NewCustomerHandler(customerRepo model.CustomerRepository, planRepo model.ServicePlanRepository, changeRepo model.PlanChangeRepository) *CustomerHandler {
 	return &CustomerHandler{
 		customerRepo: customerRepo,
 		planRepo:     planRepo,
+		changeRepo:   changeRepo,
 	}
 }
 
@@ -61,6 +65,7 @@ func (h *CustomerHandler) Create(c *gin.Context) {
 	}
 
 	customer.ID = id
+	h.recordPlanChange(customer.ID, 0, customer.PlanID)
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
 		"message": "创建成功",
@@ -86,10 +91,12 @@ func (h *CustomerHandler) Update(c *gin.Context) {
 		return
 	}
 
+	previousPlanID := customer.PlanID
 	if err := c.ShouldBindJSON(customer); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
 		return
 	}
+	customer.ID = id
 
 	// 检查服务套餐是否存在
 	if customer.PlanID > 0 {
@@ -108,6 +115,7 @@ func (h *CustomerHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新客户信息失败"})
 		return
 	}
+	h.recordPlanChange(customer.ID, previousPlanID, customer.PlanID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    200,
@@ -222,4 +230,15 @@ func (h *CustomerHandler) UpdateUsage(c *gin.Context) {
 		"message": "更新成功",
 		"data":    usage,
 	})
+}
+
+// recordPlanChange 记录套餐变更用于账单折算，记录失败不影响客户信息的保存
+func (h *CustomerHandler) recordPlanChange(customerID, previousPlanID, planID int64) {
+	if h.changeRepo == nil || previousPlanID == planID {
+		return
+	}
+	change := &model.PlanChange{CustomerID: customerID, PreviousPlanID: previousPlanID, PlanID: planID, EffectiveAt: time.Now()}
+	if err := h.changeRepo.Create(change); err != nil {
+		log.Printf("记录客户%d的套餐变更失败: %v", customerID, err)
+	}
 }
\ No newline at end of file
@@ -0,0 +1,1322 @@
+package handler
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/image-recognition-engine/internal/dataset"
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/security"
+)
+
+// DatasetHandler 数据集处理器
+type DatasetHandler struct {
+	datasetRepo model.DatasetRepository
+	sampleRepo  model.DatasetSampleRepository
+	auditLog    *security.AuditLogService // 标注操作审计，为空时不记录
+	storagePath string                    // 数据集文件存储根目录
+}
+
+// NewDatasetHandler 创建数据集处理器实例
+func NewDatasetHandler(datasetRepo model.DatasetRepository, sampleRepo model.DatasetSampleRepository, auditLog *security.AuditLogService, storagePath string) *DatasetHandler {
+	return &DatasetHandler{
+		datasetRepo: datasetRepo,
+		sampleRepo:  sampleRepo,
+		auditLog:    auditLog,
+		storagePath: storagePath,
+	}
+}
+
+// skippedFile 上传时被跳过的文件
+type skippedFile struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+}
+
+// Create 创建数据集
+func (h *DatasetHandler) Create(c *gin.Context) {
+	var ds model.Dataset
+	if err := c.ShouldBindJSON(&ds); err != nil || ds.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+
+	ds.TotalCount = 0
+	ds.UserID = currentUserID(c)
+
+	id, err := h.datasetRepo.Create(&ds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建数据集失败"})
+		return
+	}
+
+	// 存储路径由服务端统一分配
+	ds.ID = id
+	ds.StoragePath = filepath.Join(h.storagePath, "datasets", id)
+	if err := h.datasetRepo.Update(&ds); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建数据集失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "创建成功",
+		"data":    ds,
+	})
+}
+
+// Update 更新数据集
+func (h *DatasetHandler) Update(c *gin.Context) {
+	ds, ok := h.findDataset(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Type        string `json:"type"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+
+	if req.Name != "" {
+		ds.Name = req.Name
+	}
+	if req.Type != "" {
+		ds.Type = req.Type
+	}
+	ds.Description = req.Description
+
+	if err := h.datasetRepo.Update(ds); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新数据集失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+		"data":    ds,
+	})
+}
+
+// Delete 删除数据集及其全部样本
+func (h *DatasetHandler) Delete(c *gin.Context) {
+	ds, ok := h.findDataset(c)
+	if !ok {
+		return
+	}
+
+	if err := h.sampleRepo.DeleteByDataset(ds.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "删除数据集样本失败"})
+		return
+	}
+
+	if err := h.datasetRepo.Delete(ds.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "删除数据集失败"})
+		return
+	}
+
+	// 清理样本文件，失败不影响删除结果
+	if ds.StoragePath != "" {
+		os.RemoveAll(ds.StoragePath)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "删除成功",
+	})
+}
+
+// List 获取数据集列表
+func (h *DatasetHandler) List(c *gin.Context) {
+	page, size := pageParams(c)
+	userID, _ := strconv.ParseInt(c.Query("userId"), 10, 64)
+
+	datasets, total, err := h.datasetRepo.List(userID, page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取数据集列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"total":    total,
+			"page":     page,
+			"pageSize": size,
+			"list":     datasets,
+		},
+	})
+}
+
+// GetByID 根据ID获取数据集信息
+func (h *DatasetHandler) GetByID(c *gin.Context) {
+	ds, ok := h.findDataset(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data":    ds,
+	})
+}
+
+// UploadSamples 上传样本
+// 支持两种方式：archive字段上传zip归档，子目录名作为标签；
+// files字段上传多个图像文件，labels字段为逗号分隔的标签，应用于本次上传的全部文件
+func (h *DatasetHandler) UploadSamples(c *gin.Context) {
+	ds, ok := h.findDataset(c)
+	if !ok {
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的上传请求"})
+		return
+	}
+
+	labels := dataset.ParseLabels(c.PostForm("labels"))
+	userID := currentUserID(c)
+
+	var created int
+	skipped := make([]skippedFile, 0)
+
+	for _, archive := range form.File["archive"] {
+		n, skip, err := h.importArchive(ds, archive, labels, userID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": fmt.Sprintf("解析归档文件失败: %v", err)})
+			return
+		}
+		created += n
+		skipped = append(skipped, skip...)
+	}
+
+	for _, file := range form.File["files"] {
+		if reason := checkSampleFile(file.Filename, file.Size); reason != "" {
+			skipped = append(skipped, skippedFile{File: file.Filename, Reason: reason})
+			continue
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			skipped = append(skipped, skippedFile{File: file.Filename, Reason: "读取文件失败"})
+			continue
+		}
+		err = h.saveSample(ds, &model.DatasetSample{FileName: file.Filename, Labels: labels, UserID: userID}, src)
+		src.Close()
+		if err != nil {
+			skipped = append(skipped, skippedFile{File: file.Filename, Reason: err.Error()})
+			continue
+		}
+		created++
+	}
+
+	if created == 0 && len(skipped) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "未上传任何文件"})
+		return
+	}
+
+	total, err := h.refreshTotalCount(ds.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新数据集总数失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "上传成功",
+		"data": gin.H{
+			"created":    created,
+			"skipped":    skipped,
+			"totalCount": total,
+		},
+	})
+}
+
+// ListSamples 分页浏览样本
+func (h *DatasetHandler) ListSamples(c *gin.Context) {
+	ds, ok := h.findDataset(c)
+	if !ok {
+		return
+	}
+
+	page, size := pageParams(c)
+	samples, total, err := h.sampleRepo.List(ds.ID, c.Query("label"), page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取样本列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"total":    total,
+			"page":     page,
+			"pageSize": size,
+			"list":     samples,
+		},
+	})
+}
+
+// GetSampleImage 获取样本图像文件
+func (h *DatasetHandler) GetSampleImage(c *gin.Context) {
+	sample, ok := h.findSample(c)
+	if !ok {
+		return
+	}
+
+	c.File(sample.FilePath)
+}
+
+// UpdateSampleLabels 更新样本的图像级标签，已通过审核的标注对应的标签始终保留
+// 请求中的updateTime为客户端读取样本时的更新时间，样本已被修改时返回409
+func (h *DatasetHandler) UpdateSampleLabels(c *gin.Context) {
+	sample, ok := h.findSample(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Labels     []string   `json:"labels"`
+		UpdateTime *time.Time `json:"updateTime"` // 为空时以本次读取的样本为准
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+	expected := sample.UpdateTime
+	if req.UpdateTime != nil {
+		if !req.UpdateTime.Equal(sample.UpdateTime) {
+			c.JSON(http.StatusConflict, gin.H{"code": 409, "message": "样本已被其他用户修改，请刷新后重试"})
+			return
+		}
+		expected = *req.UpdateTime
+	}
+
+	previous := sample.Labels
+	labels := dataset.SyncLabels(dataset.ParseLabels(strings.Join(req.Labels, ",")), nil, sample.Annotations)
+	updated, err := h.sampleRepo.UpdateAnnotations(sample.ID, expected, sample.Annotations, labels)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新样本标签失败"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "message": "样本已被其他用户修改，请刷新后重试"})
+		return
+	}
+	sample.Labels = labels
+
+	h.auditSample(c, "labels_update", sample, map[string]interface{}{
+		"previousLabels": previous,
+		"labels":         labels,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+		"data":    sample,
+	})
+}
+
+// DeleteSample 删除样本
+func (h *DatasetHandler) DeleteSample(c *gin.Context) {
+	sample, ok := h.findSample(c)
+	if !ok {
+		return
+	}
+
+	if err := h.sampleRepo.Delete(sample.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "删除样本失败"})
+		return
+	}
+	os.Remove(sample.FilePath)
+
+	total, err := h.refreshTotalCount(sample.DatasetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新数据集总数失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "删除成功",
+		"data":    gin.H{"totalCount": total},
+	})
+}
+
+// GetLabelStats 获取标签分布及类别不平衡统计
+func (h *DatasetHandler) GetLabelStats(c *gin.Context) {
+	ds, ok := h.findDataset(c)
+	if !ok {
+		return
+	}
+
+	counts, err := h.sampleRepo.CountByLabel(ds.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "统计标签分布失败"})
+		return
+	}
+
+	total, err := h.sampleRepo.Count(ds.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "统计样本数失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data":    dataset.BuildLabelStats(ds.ID, counts, total),
+	})
+}
+
+// ImportDataset 按COCO、VOC或ImageNet格式导入数据集
+// 表单字段：archive为zip归档，format为格式名，name/description/type为数据集信息；
+// dryRun为true时只校验归档并返回报告，不创建数据集
+func (h *DatasetHandler) ImportDataset(c *gin.Context) {
+	format, err := dataset.ParseFormat(c.PostForm("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.PostForm("dryRun"))
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" && !dryRun {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "数据集名称不能为空"})
+		return
+	}
+
+	file, err := c.FormFile("archive")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请上传zip归档"})
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "读取归档文件失败"})
+		return
+	}
+	defer f.Close()
+
+	reader, err := zip.NewReader(f, file.Size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": fmt.Sprintf("解析归档文件失败: %v", err)})
+		return
+	}
+
+	samples, report, err := dataset.Import(format, reader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"code":    200,
+			"message": "校验完成",
+			"data":    gin.H{"report": report},
+		})
+		return
+	}
+
+	ds := &model.Dataset{
+		Name:        name,
+		Description: c.PostForm("description"),
+		Type:        c.PostForm("type"),
+		UserID:      currentUserID(c),
+	}
+	id, err := h.datasetRepo.Create(ds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建数据集失败"})
+		return
+	}
+	ds.ID = id
+	ds.StoragePath = filepath.Join(h.storagePath, "datasets", id)
+	if err := h.datasetRepo.Update(ds); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建数据集失败"})
+		return
+	}
+
+	// 保存失败的样本计入报告，不影响其余样本导入
+	for _, s := range samples {
+		src, err := s.Entry.Open()
+		if err != nil {
+			report.RejectSample(s.Entry.Name, "读取文件失败")
+			continue
+		}
+		err = h.saveSample(ds, &model.DatasetSample{
+			FileName:    s.FileName,
+			Width:       s.Width,
+			Height:      s.Height,
+			Labels:      s.Labels,
+			Annotations: s.Annotations,
+			UserID:      ds.UserID,
+		}, src)
+		src.Close()
+		if err != nil {
+			report.RejectSample(s.Entry.Name, err.Error())
+		}
+	}
+
+	total, err := h.refreshTotalCount(ds.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新数据集总数失败"})
+		return
+	}
+	ds.TotalCount = int(total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "导入成功",
+		"data": gin.H{
+			"dataset": ds,
+			"report":  report,
+		},
+	})
+}
+
+// ExportDataset 按COCO、VOC或ImageNet格式导出数据集，返回zip归档
+// 归档根目录附带export_report.json校验报告
+func (h *DatasetHandler) ExportDataset(c *gin.Context) {
+	format, err := dataset.ParseFormat(c.DefaultQuery("format", string(dataset.FormatCOCO)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	ds, ok := h.findDataset(c)
+	if !ok {
+		return
+	}
+
+	samples, err := h.sampleRepo.ListAll(ds.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取样本列表失败"})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("dataset_%s_%s.zip", ds.ID, format)))
+	c.Status(http.StatusOK)
+
+	// 响应已开始写出，之后的错误只能中断连接
+	archive := zip.NewWriter(c.Writer)
+	report, err := dataset.Export(format, archive, ds, samples, func(s *model.DatasetSample) (io.ReadCloser, error) {
+		return os.Open(s.FilePath)
+	})
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+		return
+	}
+	w, err := archive.Create("export_report.json")
+	if err == nil {
+		err = json.NewEncoder(w).Encode(report)
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		c.Error(err)
+		c.Abort()
+	}
+}
+
+// SplitDataset 将数据集拆分为训练/验证/测试集
+// 按比例和种子确定性拆分，默认按主标签分层；内容相同的重复图像总是进入同一拆分，
+// 派生数据集记录源数据集、种子和比例，样本文件复制到各自的存储目录
+func (h *DatasetHandler) SplitDataset(c *gin.Context) {
+	ds, ok := h.findDataset(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		model.SplitRatios
+		Seed     *int64 `json:"seed"`     // 为空时随机生成并记录
+		Stratify *bool  `json:"stratify"` // 默认分层
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+	if err := dataset.ValidateRatios(req.SplitRatios); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	seed := time.Now().UnixNano()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	stratify := req.Stratify == nil || *req.Stratify
+
+	samples, err := h.sampleRepo.ListAll(ds.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取样本列表失败"})
+		return
+	}
+	if len(samples) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "数据集没有样本"})
+		return
+	}
+
+	// 按文件内容分组，避免重复图像分散到不同拆分
+	digests := make(map[string]string, len(samples))
+	for _, s := range samples {
+		digest, err := fileDigest(s.FilePath)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": fmt.Sprintf("读取样本文件失败: %s", s.FileName)})
+			return
+		}
+		digests[s.ID] = digest
+	}
+
+	splits, err := dataset.Split(samples, req.SplitRatios, seed, stratify, func(s *model.DatasetSample) string {
+		return digests[s.ID]
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+
+	ratios := map[string]float64{
+		model.DatasetTypeTrain:      req.Train,
+		model.DatasetTypeValidation: req.Validation,
+		model.DatasetTypeTest:       req.Test,
+	}
+	now := time.Now()
+	created := make([]*model.Dataset, 0, len(ratios))
+	for _, name := range []string{model.DatasetTypeTrain, model.DatasetTypeValidation, model.DatasetTypeTest} {
+		if ratios[name] == 0 {
+			continue
+		}
+		derived, err := h.createDerivedDataset(ds, name, splits[name], &model.DatasetLineage{
+			ParentID:   ds.ID,
+			Split:      name,
+			Seed:       seed,
+			Ratios:     req.SplitRatios,
+			Stratified: stratify,
+			SplitTime:  now,
+		})
+		if derived != nil {
+			created = append(created, derived)
+		}
+		if err != nil {
+			// 拆分需整体成功，回滚已创建的派生数据集
+			for _, d := range created {
+				h.removeDataset(d)
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "拆分成功",
+		"data": gin.H{
+			"seed":     seed,
+			"datasets": created,
+		},
+	})
+}
+
+// GetLineage 获取数据集血缘：源数据集及由其派生的数据集
+func (h *DatasetHandler) GetLineage(c *gin.Context) {
+	ds, ok := h.findDataset(c)
+	if !ok {
+		return
+	}
+
+	var parent *model.Dataset
+	if ds.Lineage != nil {
+		p, err := h.datasetRepo.FindByID(ds.Lineage.ParentID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询源数据集失败"})
+			return
+		}
+		parent = p // 源数据集可能已被删除
+	}
+
+	children, err := h.datasetRepo.FindByParent(ds.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询派生数据集失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"dataset":  ds,
+			"parent":   parent,
+			"children": children,
+		},
+	})
+}
+
+// createDerivedDataset 创建派生数据集并复制样本，出错时返回已创建的数据集以便回滚
+func (h *DatasetHandler) createDerivedDataset(parent *model.Dataset, split string, samples []*model.DatasetSample, lineage *model.DatasetLineage) (*model.Dataset, error) {
+	ds := &model.Dataset{
+		Name:        fmt.Sprintf("%s-%s", parent.Name, split),
+		Description: fmt.Sprintf("由数据集%s拆分生成(seed=%d)", parent.Name, lineage.Seed),
+		Type:        split,
+		UserID:      parent.UserID,
+		Lineage:     lineage,
+	}
+	id, err := h.datasetRepo.Create(ds)
+	if err != nil {
+		return nil, fmt.Errorf("创建%s数据集失败", split)
+	}
+	ds.ID = id
+	ds.StoragePath = filepath.Join(h.storagePath, "datasets", id)
+	if err := h.datasetRepo.Update(ds); err != nil {
+		return ds, fmt.Errorf("创建%s数据集失败", split)
+	}
+	if err := os.MkdirAll(ds.StoragePath, 0755); err != nil {
+		return ds, fmt.Errorf("创建存储目录失败")
+	}
+
+	for _, s := range samples {
+		dst := filepath.Join(ds.StoragePath, filepath.Base(s.FilePath))
+		if err := linkOrCopy(s.FilePath, dst); err != nil {
+			return ds, fmt.Errorf("复制样本文件失败: %s", s.FileName)
+		}
+		copied := &model.DatasetSample{
+			DatasetID:   ds.ID,
+			FileName:    s.FileName,
+			FilePath:    dst,
+			FileSize:    s.FileSize,
+			Width:       s.Width,
+			Height:      s.Height,
+			Labels:      s.Labels,
+			Annotations: s.Annotations,
+			SourceID:    s.ID,
+			UserID:      s.UserID,
+		}
+		if _, err := h.sampleRepo.Create(copied); err != nil {
+			return ds, fmt.Errorf("创建样本记录失败")
+		}
+	}
+
+	total, err := h.refreshTotalCount(ds.ID)
+	if err != nil {
+		return ds, fmt.Errorf("更新数据集总数失败")
+	}
+	ds.TotalCount = int(total)
+	return ds, nil
+}
+
+// removeDataset 删除数据集记录、样本记录及文件
+func (h *DatasetHandler) removeDataset(ds *model.Dataset) {
+	h.sampleRepo.DeleteByDataset(ds.ID)
+	h.datasetRepo.Delete(ds.ID)
+	if ds.StoragePath != "" {
+		os.RemoveAll(ds.StoragePath)
+	}
+}
+
+// fileDigest 计算文件SHA-256摘要
+func fileDigest(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// linkOrCopy 优先创建硬链接，跨文件系统时退回复制
+func linkOrCopy(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// annotationRequest 新增或修改标注的请求参数
+type annotationRequest struct {
+	Label string             `json:"label"`
+	BBox  *model.BoundingBox `json:"bbox"`
+}
+
+// annotationError 标注操作的业务错误
+type annotationError struct {
+	status  int
+	message string
+}
+
+func (e *annotationError) Error() string {
+	return e.message
+}
+
+// ListAnnotations 获取样本标注，status参数可按审核状态过滤
+func (h *DatasetHandler) ListAnnotations(c *gin.Context) {
+	sample, ok := h.findSample(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	annotations := make([]model.Annotation, 0, len(sample.Annotations))
+	for _, a := range sample.Annotations {
+		if status == "" || a.Status == status || (status == model.ReviewApproved && dataset.IsApproved(a)) {
+			annotations = append(annotations, a)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data":    annotations,
+	})
+}
+
+// CreateAnnotation 为样本新增标注，标注人为当前用户，初始状态为待审核
+func (h *DatasetHandler) CreateAnnotation(c *gin.Context) {
+	sample, ok := h.findSample(c)
+	if !ok {
+		return
+	}
+
+	var req annotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+	if msg := checkAnnotation(&req, sample); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": msg})
+		return
+	}
+
+	now := time.Now()
+	annotation := model.Annotation{
+		ID:          uuid.New().String(),
+		Label:       req.Label,
+		BBox:        req.BBox,
+		AnnotatorID: currentUserID(c),
+		Annotator:   c.GetString("username"),
+		Status:      model.ReviewPending,
+		CreateTime:  &now,
+		UpdateTime:  &now,
+	}
+	sample, err := h.mutateAnnotations(sample, func(annotations []model.Annotation) ([]model.Annotation, error) {
+		return append(annotations, annotation), nil
+	})
+	if !h.writeAnnotationError(c, err) {
+		return
+	}
+
+	h.auditAnnotation(c, "annotation_create", sample, annotation, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "创建成功",
+		"data":    annotation,
+	})
+}
+
+// UpdateAnnotation 修改标注，修改后重新进入待审核状态
+// 只能修改本人的标注；导入的标注没有标注人，修改后归属当前用户
+func (h *DatasetHandler) UpdateAnnotation(c *gin.Context) {
+	sample, ok := h.findSample(c)
+	if !ok {
+		return
+	}
+
+	var req annotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+	if msg := checkAnnotation(&req, sample); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": msg})
+		return
+	}
+
+	userID := currentUserID(c)
+	annotationID := c.Param("annotationId")
+	var previous, updated model.Annotation
+	sample, err := h.mutateAnnotations(sample, func(annotations []model.Annotation) ([]model.Annotation, error) {
+		i, err := findAnnotation(annotations, annotationID)
+		if err != nil {
+			return nil, err
+		}
+		if annotations[i].AnnotatorID != 0 && annotations[i].AnnotatorID != userID {
+			return nil, &annotationError{http.StatusForbidden, "只能修改本人的标注"}
+		}
+		previous = annotations[i]
+
+		now := time.Now()
+		a := annotations[i]
+		a.Label = req.Label
+		a.BBox = req.BBox
+		a.AnnotatorID = userID
+		a.Annotator = c.GetString("username")
+		a.Status = model.ReviewPending
+		a.ReviewerID = 0
+		a.ReviewComment = ""
+		a.ReviewTime = nil
+		a.UpdateTime = &now
+		if a.CreateTime == nil {
+			a.CreateTime = &now
+		}
+		annotations[i] = a
+		updated = a
+		return annotations, nil
+	})
+	if !h.writeAnnotationError(c, err) {
+		return
+	}
+
+	h.auditAnnotation(c, "annotation_update", sample, updated, map[string]interface{}{
+		"previousLabel": previous.Label,
+		"previousBBox":  previous.BBox,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "更新成功",
+		"data":    updated,
+	})
+}
+
+// DeleteAnnotation 删除标注，只能删除本人或导入的标注
+func (h *DatasetHandler) DeleteAnnotation(c *gin.Context) {
+	sample, ok := h.findSample(c)
+	if !ok {
+		return
+	}
+
+	userID := currentUserID(c)
+	annotationID := c.Param("annotationId")
+	var removed model.Annotation
+	sample, err := h.mutateAnnotations(sample, func(annotations []model.Annotation) ([]model.Annotation, error) {
+		i, err := findAnnotation(annotations, annotationID)
+		if err != nil {
+			return nil, err
+		}
+		if annotations[i].AnnotatorID != 0 && annotations[i].AnnotatorID != userID {
+			return nil, &annotationError{http.StatusForbidden, "只能删除本人的标注"}
+		}
+		removed = annotations[i]
+		return append(annotations[:i], annotations[i+1:]...), nil
+	})
+	if !h.writeAnnotationError(c, err) {
+		return
+	}
+
+	h.auditAnnotation(c, "annotation_delete", sample, removed, nil)
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "删除成功",
+	})
+}
+
+// ReviewAnnotation 审核标注，不能审核本人的标注
+// 通过审核的标注计入样本标签，驳回的标注从样本标签中移除
+func (h *DatasetHandler) ReviewAnnotation(c *gin.Context) {
+	sample, ok := h.findSample(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status  string `json:"status"`
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || !dataset.ValidReviewStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的审核状态"})
+		return
+	}
+
+	userID := currentUserID(c)
+	annotationID := c.Param("annotationId")
+	var previousStatus string
+	var reviewed model.Annotation
+	sample, err := h.mutateAnnotations(sample, func(annotations []model.Annotation) ([]model.Annotation, error) {
+		i, err := findAnnotation(annotations, annotationID)
+		if err != nil {
+			return nil, err
+		}
+		if annotations[i].AnnotatorID != 0 && annotations[i].AnnotatorID == userID {
+			return nil, &annotationError{http.StatusForbidden, "不能审核本人的标注"}
+		}
+		previousStatus = annotations[i].Status
+
+		now := time.Now()
+		annotations[i].Status = req.Status
+		annotations[i].ReviewerID = userID
+		annotations[i].ReviewComment = req.Comment
+		annotations[i].ReviewTime = &now
+		reviewed = annotations[i]
+		return annotations, nil
+	})
+	if !h.writeAnnotationError(c, err) {
+		return
+	}
+
+	h.auditAnnotation(c, "annotation_review", sample, reviewed, map[string]interface{}{
+		"previousStatus": previousStatus,
+		"comment":        req.Comment,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "审核成功",
+		"data":    reviewed,
+	})
+}
+
+// GetAnnotationHistory 获取样本标注操作的审计记录
+func (h *DatasetHandler) GetAnnotationHistory(c *gin.Context) {
+	sample, ok := h.findSample(c)
+	if !ok {
+		return
+	}
+	if h.auditLog == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "message": "审计日志未启用"})
+		return
+	}
+
+	page, size := pageParams(c)
+	logs, total, err := h.auditLog.GetResourceLogs(c.Request.Context(), "dataset_sample", sample.ID, time.Time{}, time.Time{}, page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取标注历史失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"total":    total,
+			"page":     page,
+			"pageSize": size,
+			"list":     logs,
+		},
+	})
+}
+
+// GetAnnotationAgreement 获取数据集标注人一致性报告
+func (h *DatasetHandler) GetAnnotationAgreement(c *gin.Context) {
+	ds, ok := h.findDataset(c)
+	if !ok {
+		return
+	}
+
+	samples, err := h.sampleRepo.ListAll(ds.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取样本列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data":    dataset.Agreement(ds.ID, samples),
+	})
+}
+
+// mutateAnnotations 修改样本标注并同步样本标签
+// 以样本更新时间做乐观锁，并发冲突时重新读取样本后重试
+func (h *DatasetHandler) mutateAnnotations(sample *model.DatasetSample, mutate func([]model.Annotation) ([]model.Annotation, error)) (*model.DatasetSample, error) {
+	const maxAttempts = 3
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		before := sample.Annotations
+		working := make([]model.Annotation, len(before))
+		copy(working, before)
+
+		after, err := mutate(working)
+		if err != nil {
+			return nil, err
+		}
+		labels := dataset.SyncLabels(sample.Labels, before, after)
+
+		updated, err := h.sampleRepo.UpdateAnnotations(sample.ID, sample.UpdateTime, after, labels)
+		if err != nil {
+			return nil, &annotationError{http.StatusInternalServerError, "更新样本标注失败"}
+		}
+		if updated {
+			sample.Annotations = after
+			sample.Labels = labels
+			return sample, nil
+		}
+
+		// 样本已被其他请求修改，重新读取
+		sample, err = h.sampleRepo.FindByID(sample.ID)
+		if err != nil || sample == nil {
+			return nil, &annotationError{http.StatusNotFound, "样本不存在"}
+		}
+	}
+	return nil, &annotationError{http.StatusConflict, "样本正在被其他用户修改，请稍后重试"}
+}
+
+// writeAnnotationError 写入标注操作错误响应，无错误时返回true
+func (h *DatasetHandler) writeAnnotationError(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	if ae, ok := err.(*annotationError); ok {
+		c.JSON(ae.status, gin.H{"code": ae.status, "message": ae.message})
+		return false
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+	return false
+}
+
+// auditAnnotation 记录标注操作审计日志，失败不影响请求结果
+func (h *DatasetHandler) auditAnnotation(c *gin.Context, action string, sample *model.DatasetSample, annotation model.Annotation, extra map[string]interface{}) {
+	details := map[string]interface{}{
+		"annotationId": annotation.ID,
+		"label":        annotation.Label,
+		"bbox":         annotation.BBox,
+		"status":       annotation.Status,
+		"annotatorId":  annotation.AnnotatorID,
+	}
+	for k, v := range extra {
+		details[k] = v
+	}
+	h.auditSample(c, action, sample, details)
+}
+
+// auditSample 记录样本修改的审计日志，失败不影响请求结果
+func (h *DatasetHandler) auditSample(c *gin.Context, action string, sample *model.DatasetSample, details map[string]interface{}) {
+	if h.auditLog == nil {
+		return
+	}
+
+	details["datasetId"] = sample.DatasetID
+	h.auditLog.LogUserAction(c.Request.Context(), currentUserID(c), c.GetString("username"), action,
+		"dataset_sample", sample.ID, c.ClientIP(), c.Request.UserAgent(), "success", details)
+}
+
+// findAnnotation 查找标注位置
+func findAnnotation(annotations []model.Annotation, id string) (int, error) {
+	for i, a := range annotations {
+		if a.ID == id {
+			return i, nil
+		}
+	}
+	return -1, &annotationError{http.StatusNotFound, "标注不存在"}
+}
+
+// checkAnnotation 校验标注参数，返回错误信息
+func checkAnnotation(req *annotationRequest, sample *model.DatasetSample) string {
+	req.Label = strings.TrimSpace(req.Label)
+	if req.Label == "" {
+		return "标注类别不能为空"
+	}
+	if req.BBox != nil {
+		b := req.BBox
+		if b.Width <= 0 || b.Height <= 0 || b.X < 0 || b.Y < 0 {
+			return "无效的目标框"
+		}
+		if (sample.Width > 0 && b.X+b.Width > float64(sample.Width)) || (sample.Height > 0 && b.Y+b.Height > float64(sample.Height)) {
+			return "目标框超出图像范围"
+		}
+	}
+	return ""
+}
+
+// importArchive 导入zip归档中的图像，一级子目录名作为标签，根目录下的文件使用表单标签
+func (h *DatasetHandler) importArchive(ds *model.Dataset, archive *multipart.FileHeader, labels []string, userID int64) (int, []skippedFile, error) {
+	f, err := archive.Open()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	reader, err := zip.NewReader(f, archive.Size)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// 压缩工具常将全部内容包在一个顶层文件夹中，按去掉该文件夹后的第一级目录确定标签
+	names := make([]string, 0, len(reader.File))
+	for _, entry := range reader.File {
+		names = append(names, entry.Name)
+	}
+	root := dataset.ArchiveRoot(names)
+
+	var created int
+	skipped := make([]skippedFile, 0)
+	for _, entry := range reader.File {
+		if entry.FileInfo().IsDir() || dataset.IsHiddenEntry(entry.Name) {
+			continue
+		}
+		if reason := checkSampleFile(entry.Name, int64(entry.UncompressedSize64)); reason != "" {
+			skipped = append(skipped, skippedFile{File: entry.Name, Reason: reason})
+			continue
+		}
+
+		entryLabels := labels
+		if label := dataset.ArchiveLabel(entry.Name, root); label != "" {
+			entryLabels = []string{label}
+		}
+
+		src, err := entry.Open()
+		if err != nil {
+			skipped = append(skipped, skippedFile{File: entry.Name, Reason: "读取文件失败"})
+			continue
+		}
+		err = h.saveSample(ds, &model.DatasetSample{FileName: path.Base(entry.Name), Labels: entryLabels, UserID: userID}, src)
+		src.Close()
+		if err != nil {
+			skipped = append(skipped, skippedFile{File: entry.Name, Reason: err.Error()})
+			continue
+		}
+		created++
+	}
+
+	return created, skipped, nil
+}
+
+// saveSample 保存样本文件并创建样本记录，sample需预先填写文件名、标签等元数据
+func (h *DatasetHandler) saveSample(ds *model.Dataset, sample *model.DatasetSample, src io.Reader) error {
+	dir := ds.StoragePath
+	if dir == "" {
+		dir = filepath.Join(h.storagePath, "datasets", ds.ID)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建存储目录失败")
+	}
+
+	// 使用随机文件名，避免归档条目路径穿越和重名覆盖
+	dst := filepath.Join(dir, uuid.New().String()+strings.ToLower(filepath.Ext(sample.FileName)))
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("保存文件失败")
+	}
+	size, err := io.Copy(out, io.LimitReader(src, dataset.MaxSampleSize+1))
+	out.Close()
+	if err != nil || size > dataset.MaxSampleSize {
+		os.Remove(dst)
+		return fmt.Errorf("保存文件失败")
+	}
+
+	sample.DatasetID = ds.ID
+	sample.FilePath = dst
+	sample.FileSize = size
+	id, err := h.sampleRepo.Create(sample)
+	if err != nil {
+		os.Remove(dst)
+		return fmt.Errorf("创建样本记录失败")
+	}
+	sample.ID = id
+
+	return nil
+}
+
+// refreshTotalCount 按实际样本数刷新数据集总数
+func (h *DatasetHandler) refreshTotalCount(datasetID string) (int64, error) {
+	total, err := h.sampleRepo.Count(datasetID)
+	if err != nil {
+		return 0, err
+	}
+	if err := h.datasetRepo.UpdateTotalCount(datasetID, int(total)); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// findDataset 根据路径参数查找数据集，未找到时写入错误响应
+func (h *DatasetHandler) findDataset(c *gin.Context) (*model.Dataset, bool) {
+	ds, err := h.datasetRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的数据集ID"})
+		return nil, false
+	}
+	if ds == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "数据集不存在"})
+		return nil, false
+	}
+	return ds, true
+}
+
+// findSample 根据路径参数查找数据集下的样本，未找到时写入错误响应
+func (h *DatasetHandler) findSample(c *gin.Context) (*model.DatasetSample, bool) {
+	sample, err := h.sampleRepo.FindByID(c.Param("sampleId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的样本ID"})
+		return nil, false
+	}
+	if sample == nil || sample.DatasetID != c.Param("id") {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "样本不存在"})
+		return nil, false
+	}
+	return sample, true
+}
+
+// checkSampleFile 校验样本文件类型和大小，返回跳过原因
+func checkSampleFile(name string, size int64) string {
+	if !dataset.IsImageFile(name) {
+		return "不支持的文件类型"
+	}
+	if size > dataset.MaxSampleSize {
+		return "文件大小超过限制"
+	}
+	return ""
+}
+
+// pageParams 解析分页参数
+func pageParams(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 || size > 100 {
+		size = 20
+	}
+	return page, size
+}
+
+// currentUserID 获取当前登录用户ID
+func currentUserID(c *gin.Context) int64 {
+	if v, exists := c.Get("userId"); exists {
+		if id, ok := v.(int64); ok {
+			return id
+		}
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/drift"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// DriftHandler 模型漂移检测处理器
+type DriftHandler struct {
+	detector *drift.Detector
+	alerts   model.DriftAlertRepository
+}
+
+// NewDriftHandler 创建模型漂移检测处理器
+func NewDriftHandler(detector *drift.Detector, alerts model.DriftAlertRepository) *DriftHandler {
+	return &DriftHandler{
+		detector: detector,
+		alerts:   alerts,
+	}
+}
+
+// GetReport 获取模型的漂移报告，endTime为空时截至当前时间
+func (h *DriftHandler) GetReport(c *gin.Context) {
+	end, ok := driftEndTime(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.detector.Report(c.Request.Context(), c.Param("id"), end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成漂移报告失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": report})
+}
+
+// Check 立即检测模型的漂移并同步告警
+func (h *DriftHandler) Check(c *gin.Context) {
+	report, err := h.detector.Check(c.Request.Context(), c.Param("id"), time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "漂移检测失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "检测完成", "data": report})
+}
+
+// GetThresholds 获取当前生效的漂移检测阈值
+func (h *DriftHandler) GetThresholds(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": h.detector.Thresholds()})
+}
+
+// ListAlerts 分页获取漂移告警
+func (h *DriftHandler) ListAlerts(c *gin.Context) {
+	page, size := pageParams(c)
+
+	alerts, total, err := h.alerts.List(c.Query("modelId"), c.Query("status"), page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取漂移告警失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"total":    total,
+			"page":     page,
+			"pageSize": size,
+			"list":     alerts,
+		},
+	})
+}
+
+// AcknowledgeAlert 确认漂移告警，确认后的告警在恢复正常时仍会自动关闭
+func (h *DriftHandler) AcknowledgeAlert(c *gin.Context) {
+	ok, err := h.alerts.TransitionStatus(c.Param("id"), model.DriftAlertOpen, model.DriftAlertAcknowledged, currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "确认漂移告警失败"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusConflict, gin.H{"code": 409, "message": "告警不存在或已被处理"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "确认成功"})
+}
+
+// driftEndTime 解析漂移报告的截止时间
+func driftEndTime(c *gin.Context) (time.Time, bool) {
+	v := c.Query("endTime")
+	if v == "" {
+		return time.Now(), true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "结束时间格式错误"})
+		return time.Time{}, false
+	}
+	return t, true
+}
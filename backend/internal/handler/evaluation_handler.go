@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/evaluation"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// EvaluationHandler 模型评估处理器
+type EvaluationHandler struct {
+	service  *evaluation.Service
+	evalRepo model.ModelEvaluationRepository
+}
+
+// NewEvaluationHandler 创建模型评估处理器
+func NewEvaluationHandler(service *evaluation.Service, evalRepo model.ModelEvaluationRepository) *EvaluationHandler {
+	return &EvaluationHandler{
+		service:  service,
+		evalRepo: evalRepo,
+	}
+}
+
+// createEvaluationRequest 创建评估请求
+type createEvaluationRequest struct {
+	ModelID      string `json:"modelId" binding:"required"`
+	ModelVersion string `json:"modelVersion"` // 为空时使用模型当前版本
+	DatasetID    string `json:"datasetId" binding:"required"`
+	TopK         int    `json:"topK"`
+}
+
+// CreateEvaluation 创建评估任务
+func (h *EvaluationHandler) CreateEvaluation(c *gin.Context) {
+	var req createEvaluationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		return
+	}
+
+	e, err := h.service.CreateEvaluation(c.Request.Context(), &model.ModelEvaluation{
+		ModelID:      req.ModelID,
+		ModelVersion: req.ModelVersion,
+		DatasetID:    req.DatasetID,
+		TopK:         req.TopK,
+		UserID:       currentUserID(c),
+	})
+	if err != nil {
+		writeAppError(c, err, "创建评估任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "创建成功", "data": e})
+}
+
+// ListEvaluations 获取评估记录列表
+func (h *EvaluationHandler) ListEvaluations(c *gin.Context) {
+	page, size := pageParams(c)
+
+	evaluations, total, err := h.evalRepo.List(c.Query("modelId"), page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取评估记录列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"total": total,
+			"items": evaluations,
+		},
+	})
+}
+
+// GetEvaluation 获取评估详情，包含混淆矩阵、各类别指标及校准分箱
+func (h *EvaluationHandler) GetEvaluation(c *gin.Context) {
+	e, err := h.evalRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的评估ID"})
+		return
+	}
+	if e == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "评估记录不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": e})
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/feedback"
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/repository"
+)
+
+// defaultFeedbackWindow 未指定时间范围时导出最近30天的反馈
+const defaultFeedbackWindow = 30 * 24 * time.Hour
+
+// FeedbackHandler 客户反馈管理处理器
+type FeedbackHandler struct {
+	records     repository.RecognitionRepository
+	datasetRepo model.DatasetRepository
+	sampleRepo  model.DatasetSampleRepository
+	storagePath string // 数据集文件存储根目录
+}
+
+// NewFeedbackHandler 创建客户反馈管理处理器
+func NewFeedbackHandler(records repository.RecognitionRepository, datasetRepo model.DatasetRepository, sampleRepo model.DatasetSampleRepository, storagePath string) *FeedbackHandler {
+	return &FeedbackHandler{
+		records:     records,
+		datasetRepo: datasetRepo,
+		sampleRepo:  sampleRepo,
+		storagePath: storagePath,
+	}
+}
+
+// ExportDataset 将模型的客户反馈样本导出为新的数据集，用于重新训练
+// 默认只导出客户修正过的样本，includeConfirmed为true时同时导出客户确认正确的样本
+func (h *FeedbackHandler) ExportDataset(c *gin.Context) {
+	var req struct {
+		Name             string     `json:"name"`
+		Description      string     `json:"description"`
+		Type             string     `json:"type"`
+		StartTime        *time.Time `json:"startTime"`
+		EndTime          *time.Time `json:"endTime"`
+		IncludeConfirmed bool       `json:"includeConfirmed"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+	if req.Type == "" {
+		req.Type = model.DatasetTypeTrain
+	}
+	if req.Type != model.DatasetTypeTrain && req.Type != model.DatasetTypeValidation && req.Type != model.DatasetTypeTest {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的数据集类型"})
+		return
+	}
+	end := time.Now()
+	if req.EndTime != nil {
+		end = *req.EndTime
+	}
+	start := end.Add(-defaultFeedbackWindow)
+	if req.StartTime != nil {
+		start = *req.StartTime
+	}
+
+	modelID := c.Param("id")
+	records, err := h.records.GetWithFeedback(c.Request.Context(), modelID, start, end, !req.IncludeConfirmed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取反馈样本失败"})
+		return
+	}
+	if len(records) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "时间范围内没有可导出的反馈样本"})
+		return
+	}
+
+	description := req.Description
+	if description == "" {
+		description = fmt.Sprintf("由模型%s在%s至%s的客户反馈导出", modelID, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+	ds := &model.Dataset{
+		Name:        strings.TrimSpace(req.Name),
+		Description: description,
+		Type:        req.Type,
+		UserID:      currentUserID(c),
+	}
+	skipped, err := h.exportSamples(ds, records)
+	if err != nil {
+		if ds.ID != "" {
+			h.sampleRepo.DeleteByDataset(ds.ID)
+			h.datasetRepo.Delete(ds.ID)
+			os.RemoveAll(ds.StoragePath)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "导出成功",
+		"data": gin.H{
+			"dataset": ds,
+			"skipped": skipped,
+		},
+	})
+}
+
+// exportSamples 创建数据集并复制反馈样本，原图已被清理的记录被跳过
+func (h *FeedbackHandler) exportSamples(ds *model.Dataset, records []*model.RecognitionRecord) ([]skippedFile, error) {
+	id, err := h.datasetRepo.Create(ds)
+	if err != nil {
+		return nil, fmt.Errorf("创建数据集失败")
+	}
+	ds.ID = id
+	ds.StoragePath = filepath.Join(h.storagePath, "datasets", id)
+	if err := h.datasetRepo.Update(ds); err != nil {
+		return nil, fmt.Errorf("创建数据集失败")
+	}
+	if err := os.MkdirAll(ds.StoragePath, 0755); err != nil {
+		return nil, fmt.Errorf("创建存储目录失败")
+	}
+
+	skipped := make([]skippedFile, 0)
+	for _, r := range records {
+		if _, err := os.Stat(r.ImageURL); err != nil {
+			skipped = append(skipped, skippedFile{File: r.ID.Hex(), Reason: "识别原图不存在"})
+			continue
+		}
+		sample := feedback.ToSample(r)
+		dst := filepath.Join(ds.StoragePath, r.ID.Hex()+strings.ToLower(filepath.Ext(r.ImageURL)))
+		if err := linkOrCopy(r.ImageURL, dst); err != nil {
+			return nil, fmt.Errorf("复制样本文件失败: %s", r.ID.Hex())
+		}
+		sample.DatasetID = ds.ID
+		sample.FilePath = dst
+		sample.UserID = ds.UserID
+		if _, err := h.sampleRepo.Create(sample); err != nil {
+			return nil, fmt.Errorf("创建样本记录失败")
+		}
+	}
+
+	total, err := h.sampleRepo.Count(ds.ID)
+	if err != nil {
+		return nil, fmt.Errorf("更新数据集总数失败")
+	}
+	if err := h.datasetRepo.UpdateTotalCount(ds.ID, int(total)); err != nil {
+		return nil, fmt.Errorf("更新数据集总数失败")
+	}
+	ds.TotalCount = int(total)
+	return skipped, nil
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/modelcard"
+	"github.com/image-recognition-engine/internal/taxonomy"
+)
+
+// ModelCardHandler 模型卡片及标签映射处理器
+type ModelCardHandler struct {
+	generator *modelcard.Generator
+	labels    *taxonomy.Service
+	info      model.ModelCardInfoRepository
+}
+
+// NewModelCardHandler 创建模型卡片及标签映射处理器
+func NewModelCardHandler(generator *modelcard.Generator, labels *taxonomy.Service, info model.ModelCardInfoRepository) *ModelCardHandler {
+	return &ModelCardHandler{
+		generator: generator,
+		labels:    labels,
+		info:      info,
+	}
+}
+
+// GetCard 生成模型卡片
+// 查询参数：version模型版本，labelMapVersion标签映射版本，locale显示语言(默认取Accept-Language)，
+// format为json或markdown，download为true时作为附件下载
+func (h *ModelCardHandler) GetCard(c *gin.Context) {
+	labelMapVersion, err := strconv.Atoi(c.DefaultQuery("labelMapVersion", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的标签映射版本"})
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "markdown" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "不支持的格式，仅支持json和markdown"})
+		return
+	}
+	locale := c.Query("locale")
+	if locale == "" {
+		locale = taxonomy.ParseLocale(c.GetHeader("Accept-Language"))
+	}
+
+	card, err := h.generator.Generate(c.Request.Context(), c.Param("id"), c.Query("version"), labelMapVersion, locale)
+	if err != nil {
+		writeAppError(c, err, "生成模型卡片失败")
+		return
+	}
+
+	download := c.Query("download") == "true"
+	name := fmt.Sprintf("model_card_%s_%s", card.ModelID, card.Version)
+	if format == "markdown" {
+		if download {
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".md"))
+		}
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(modelcard.Markdown(card)))
+		return
+	}
+	if download {
+		data, err := json.MarshalIndent(card, "", "  ")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成模型卡片失败"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".json"))
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": card})
+}
+
+// SaveCardInfo 保存模型卡片中人工填写的预期用途、局限性和训练数据集
+func (h *ModelCardHandler) SaveCardInfo(c *gin.Context) {
+	var req struct {
+		IntendedUse        string   `json:"intendedUse"`
+		OutOfScopeUse      string   `json:"outOfScopeUse"`
+		Limitations        []string `json:"limitations"`
+		TrainingDatasetIDs []string `json:"trainingDatasetIds"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		return
+	}
+
+	info := &model.ModelCardInfo{
+		ModelID:            c.Param("id"),
+		IntendedUse:        strings.TrimSpace(req.IntendedUse),
+		OutOfScopeUse:      strings.TrimSpace(req.OutOfScopeUse),
+		Limitations:        nonEmpty(req.Limitations),
+		TrainingDatasetIDs: nonEmpty(req.TrainingDatasetIDs),
+		UserID:             currentUserID(c),
+	}
+	if err := h.info.Save(info); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "保存模型卡片信息失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": info})
+}
+
+// GetLabelMap 获取模型标签映射，version为空时返回最新版本
+func (h *ModelCardHandler) GetLabelMap(c *gin.Context) {
+	version, err := strconv.Atoi(c.DefaultQuery("version", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的标签映射版本"})
+		return
+	}
+
+	labelMap, err := h.labels.LabelMap(c.Param("id"), version)
+	if err != nil {
+		writeAppError(c, err, "获取标签映射失败")
+		return
+	}
+	if labelMap == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "模型未配置标签映射"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": labelMap})
+}
+
+// ListLabelMapVersions 获取模型标签映射的版本历史
+func (h *ModelCardHandler) ListLabelMapVersions(c *gin.Context) {
+	versions, err := h.labels.Versions(c.Param("id"))
+	if err != nil {
+		writeAppError(c, err, "获取标签映射版本失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": versions})
+}
+
+// PublishLabelMap 发布模型标签映射的新版本
+func (h *ModelCardHandler) PublishLabelMap(c *gin.Context) {
+	var req struct {
+		Labels  []model.LabelEntry `json:"labels"`
+		Comment string             `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		return
+	}
+
+	labelMap := &model.LabelMap{
+		ModelID: c.Param("id"),
+		Labels:  req.Labels,
+		Comment: req.Comment,
+		UserID:  currentUserID(c),
+	}
+	if err := h.labels.Publish(labelMap); err != nil {
+		writeAppError(c, err, "发布标签映射失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "发布成功", "data": labelMap})
+}
+
+// nonEmpty 去除空白项
+func nonEmpty(items []string) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/performance"
 	"github.com/image-recognition-engine/internal/repository"
 )
 
@@ -74,7 +75,8 @@ func (h *ModelHandler) SaveModelPerformance(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": perf})
 }
 
-// GetModelPerformance 获取模型性能数据
+// GetModelPerformance 获取从识别记录自动采集的模型性能序列，默认统计最近24小时
+// step为降采样步长(如5m、1h)，未指定或过小时自动选择，保证每条序列的数据点不超过上限
 func (h *ModelHandler) GetModelPerformance(c *gin.Context) {
 	modelIDStr := c.Query("modelId")
 	modelID, err := primitive.ObjectIDFromHex(modelIDStr)
@@ -86,7 +88,8 @@ func (h *ModelHandler) GetModelPerformance(c *gin.Context) {
 	startTimeStr := c.Query("startTime")
 	endTimeStr := c.Query("endTime")
 
-	var startTime, endTime time.Time
+	endTime := time.Now()
+	startTime := endTime.Add(-24 * time.Hour)
 	if startTimeStr != "" {
 		startTime, err = time.Parse(time.RFC3339, startTimeStr)
 		if err != nil {
@@ -101,12 +104,35 @@ func (h *ModelHandler) GetModelPerformance(c *gin.Context) {
 			return
 		}
 	}
+	if !endTime.After(startTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "结束时间必须晚于开始时间"})
+		return
+	}
+	startTime = startTime.Truncate(performance.DefaultBucket)
+
+	step := performance.AutoStep(startTime, endTime, performance.DefaultBucket)
+	if v := c.Query("step"); v != "" {
+		requested, err := time.ParseDuration(v)
+		if err != nil || requested <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "步长格式错误"})
+			return
+		}
+		if requested > step {
+			step = requested.Truncate(performance.DefaultBucket)
+		}
+	}
 
-	perf, err := h.modelRepo.GetModelPerformance(c.Request.Context(), modelID, startTime, endTime)
+	buckets, err := h.modelRepo.GetPerformanceBuckets(c.Request.Context(), modelID, c.Query("version"), startTime, endTime)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取模型性能数据失败"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": perf})
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": gin.H{
+		"modelId":   modelIDStr,
+		"startTime": startTime,
+		"endTime":   endTime,
+		"step":      int64(step / time.Second),
+		"series":    performance.Downsample(buckets, startTime, step),
+	}})
 }
\ No newline at end of file
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/inference"
+)
+
+// ModelManagerHandler 已加载模型管理处理器
+type ModelManagerHandler struct {
+	manager *inference.Manager
+}
+
+// NewModelManagerHandler 创建已加载模型管理处理器
+func NewModelManagerHandler(manager *inference.Manager) *ModelManagerHandler {
+	return &ModelManagerHandler{manager: manager}
+}
+
+// GetStatus 获取已加载模型的版本、内存占用、最近使用及加载时间
+func (h *ModelManagerHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": h.manager.Status()})
+}
+
+// Prewarm 预先加载指定模型版本
+func (h *ModelManagerHandler) Prewarm(c *gin.Context) {
+	var req struct {
+		ModelID string `json:"modelId" binding:"required"`
+		Version string `json:"version" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		return
+	}
+
+	if err := h.manager.Prewarm(c.Request.Context(), req.ModelID, req.Version); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "message": "加载模型失败", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "加载成功", "data": h.manager.Status()})
+}
+
+// Unload 卸载模型版本，正在处理的请求完成后释放
+func (h *ModelManagerHandler) Unload(c *gin.Context) {
+	if !h.manager.Unload(c.Param("modelId"), c.Param("version")) {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "模型未加载或正在加载"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "卸载成功"})
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	stderrors "errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/security"
+)
+
+// OAuthHandler OAuth2客户端凭证模式处理器
+// client_id为应用的AppID，client_secret为应用下的API密钥，签发的令牌只包含该密钥的访问范围
+// 响应格式遵循RFC 6749、RFC 7662和RFC 7009，便于客户端直接使用标准OAuth2库
+type OAuthHandler struct {
+	keys   *security.KeyStore
+	tokens *security.AccessTokens
+}
+
+// NewOAuthHandler 创建OAuth2处理器
+func NewOAuthHandler(keys *security.KeyStore, tokens *security.AccessTokens) *OAuthHandler {
+	return &OAuthHandler{
+		keys:   keys,
+		tokens: tokens,
+	}
+}
+
+// Token 签发访问令牌
+// 表单参数：grant_type(client_credentials)、scope(可选，以空格分隔)，客户端凭证通过HTTP Basic或表单参数传递
+func (h *OAuthHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	if c.PostForm("grant_type") != "client_credentials" {
+		oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "仅支持client_credentials授权模式")
+		return
+	}
+	key, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	scopes, err := security.GrantScopes(key, strings.Fields(c.PostForm("scope")))
+	if err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_scope", err.Error())
+		return
+	}
+	token, expiresAt, err := h.tokens.Issue(key, scopes)
+	if err != nil {
+		log.Printf("签发应用%s的访问令牌失败: %v", key.AppID, err)
+		oauthError(c, http.StatusInternalServerError, "server_error", "签发访问令牌失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(time.Until(expiresAt).Seconds()),
+		"scope":        strings.Join(scopes, " "),
+	})
+}
+
+// Introspect 查询访问令牌状态，只能查询本应用签发的令牌
+// 表单参数：token
+func (h *OAuthHandler) Introspect(c *gin.Context) {
+	key, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	claims, err := h.tokens.Parse(c.Request.Context(), c.PostForm("token"))
+	if err != nil || claims.AppID != key.AppID {
+		c.JSON(http.StatusOK, gin.H{"active": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"active":     true,
+		"client_id":  claims.AppID,
+		"scope":      claims.Scope,
+		"sub":        claims.Subject,
+		"token_type": "Bearer",
+		"exp":        claims.ExpiresAt.Unix(),
+		"iat":        claims.IssuedAt.Unix(),
+		"jti":        claims.ID,
+	})
+}
+
+// Revoke 吊销本应用签发的访问令牌，令牌无效或已过期时同样返回成功
+// 表单参数：token
+func (h *OAuthHandler) Revoke(c *gin.Context) {
+	key, ok := h.authenticateClient(c)
+	if !ok {
+		return
+	}
+
+	claims, err := h.tokens.Parse(c.Request.Context(), c.PostForm("token"))
+	if err == nil && claims.AppID == key.AppID {
+		if err := h.tokens.Revoke(c.Request.Context(), claims); err != nil {
+			log.Printf("吊销访问令牌%s失败: %v", claims.ID, err)
+			oauthError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "吊销访问令牌失败")
+			return
+		}
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// authenticateClient 校验客户端凭证，优先使用HTTP Basic认证
+func (h *OAuthHandler) authenticateClient(c *gin.Context) (*model.APIKey, bool) {
+	clientID, clientSecret, basic := c.Request.BasicAuth()
+	if !basic {
+		clientID, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if clientID == "" || clientSecret == "" {
+		oauthClientError(c, basic, "缺少客户端凭证")
+		return nil, false
+	}
+
+	key, err := h.keys.Authenticate(clientID, clientSecret, c.ClientIP(), time.Now())
+	if err != nil {
+		switch {
+		case stderrors.Is(err, security.ErrInvalidAPIKey):
+			oauthClientError(c, basic, "无效的客户端凭证")
+		case stderrors.Is(err, security.ErrAPIKeyExpired), stderrors.Is(err, security.ErrAPIKeyRotated),
+			stderrors.Is(err, security.ErrApplicationDisabled), stderrors.Is(err, security.ErrIPNotAllowed):
+			oauthClientError(c, basic, err.Error())
+		default:
+			log.Printf("验证应用%s的客户端凭证失败: %v", clientID, err)
+			oauthError(c, http.StatusInternalServerError, "server_error", "验证客户端凭证失败")
+		}
+		return nil, false
+	}
+	return key, true
+}
+
+// oauthClientError 返回invalid_client错误，使用HTTP Basic认证时附带WWW-Authenticate头
+func oauthClientError(c *gin.Context, basic bool, description string) {
+	if basic {
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	oauthError(c, http.StatusUnauthorized, "invalid_client", description)
+}
+
+// oauthError 按RFC 6749返回错误
+func oauthError(c *gin.Context, status int, code, description string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error":             code,
+		"error_description": description,
+	})
+}
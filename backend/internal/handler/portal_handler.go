@@ -0,0 +1,675 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/image-recognition-engine/internal/billing"
+	"github.com/image-recognition-engine/internal/handler/auth"
+	"github.com/image-recognition-engine/internal/handler/client"
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/quota"
+	"github.com/image-recognition-engine/internal/repository"
+	"github.com/image-recognition-engine/internal/security"
+)
+
+// portalTokenTTL 客户门户令牌有效期
+const portalTokenTTL = 12 * time.Hour
+
+// PortalHandler 客户自助门户处理器，客户只能访问自己名下的数据
+type PortalHandler struct {
+	customers    model.CustomerRepository
+	plans        model.ServicePlanRepository
+	applications model.ApplicationRepository
+	keys         model.APIKeyRepository
+	store        *security.KeyStore // 为nil时吊销密钥或停用应用最多延迟密钥缓存时长生效
+	rotator      *security.KeyRotator
+	encryption   *security.EncryptionService // 为nil时新建的密钥不支持签名认证
+	records      repository.RecognitionRepository
+	usage        model.UsageEventRepository
+	meter        *quota.Meter
+	jwtSecret    string
+}
+
+// NewPortalHandler 创建客户自助门户处理器
+func NewPortalHandler(customers model.CustomerRepository, plans model.ServicePlanRepository, applications model.ApplicationRepository, keys model.APIKeyRepository, store *security.KeyStore, rotator *security.KeyRotator, encryption *security.EncryptionService, records repository.RecognitionRepository, usage model.UsageEventRepository, meter *quota.Meter, jwtSecret string) *PortalHandler {
+	return &PortalHandler{
+		customers:    customers,
+		plans:        plans,
+		applications: applications,
+		keys:         keys,
+		store:        store,
+		rotator:      rotator,
+		encryption:   encryption,
+		records:      records,
+		usage:        usage,
+		meter:        meter,
+		jwtSecret:    jwtSecret,
+	}
+}
+
+// portalLoginRequest 客户登录请求
+type portalLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// applicationRequest 创建或更新应用请求
+type applicationRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Status      string `json:"status"` // active或disabled，为空时保持不变，新建时为active
+}
+
+// apiKeyRequest 创建API密钥请求
+type apiKeyRequest struct {
+	Name       string     `json:"name" binding:"required"`
+	Scopes     []string   `json:"scopes"`     // 如recognition:write、history:read
+	AllowedIPs []string   `json:"allowedIps"` // 允许的IP或CIDR，为空时不限制
+	ExpireAt   *time.Time `json:"expireAt"`   // 为空时永不过期
+}
+
+// rotateKeyRequest 轮换API密钥请求
+type rotateKeyRequest struct {
+	GraceHours *int `json:"graceHours"` // 旧密钥继续有效的小时数，为空时使用默认宽限期，为0时立即失效
+}
+
+// Login 客户登录，签发客户门户令牌
+func (h *PortalHandler) Login(c *gin.Context) {
+	var req portalLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+
+	customer, err := h.customers.FindByUsername(req.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "系统错误"})
+		return
+	}
+	if customer == nil || bcrypt.CompareHashAndPassword([]byte(customer.Password), []byte(req.Password)) != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "用户名或密码错误"})
+		return
+	}
+	if customer.Status != 1 {
+		c.JSON(http.StatusForbidden, gin.H{"code": 403, "message": "账号已被禁用"})
+		return
+	}
+
+	token, expiresAt, err := auth.IssueCustomerToken(h.jwtSecret, customer.ID, customer.Username, portalTokenTTL)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成令牌失败"})
+		return
+	}
+	// 登录时间更新失败不影响登录
+	if err := h.customers.UpdateLastLogin(customer.ID); err != nil {
+		log.Printf("更新客户%d登录时间失败: %v", customer.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "登录成功",
+		"data": gin.H{
+			"token":     token,
+			"expiresAt": expiresAt.Unix(),
+			"customer":  portalCustomer(customer),
+		},
+	})
+}
+
+// Profile 获取当前客户的基本信息
+func (h *PortalHandler) Profile(c *gin.Context) {
+	customer, ok := h.currentCustomer(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": portalCustomer(customer)})
+}
+
+// Plan 获取当前客户订阅的服务套餐及包含的特性
+func (h *PortalHandler) Plan(c *gin.Context) {
+	customer, ok := h.currentCustomer(c)
+	if !ok {
+		return
+	}
+	if customer.PlanID == 0 {
+		c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": nil})
+		return
+	}
+
+	plan, err := h.plans.FindByID(customer.PlanID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取服务套餐失败"})
+		return
+	}
+	if plan == nil {
+		c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": nil})
+		return
+	}
+
+	features := make([]gin.H, 0, len(plan.Features))
+	for _, feature := range plan.Features {
+		features = append(features, gin.H{"code": feature, "name": model.FeatureName(feature)})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"plan":       plan,
+			"features":   features,
+			"expireTime": customer.ExpireTime,
+		},
+	})
+}
+
+// Usage 获取当前客户本月的用量和剩余额度
+func (h *PortalHandler) Usage(c *gin.Context) {
+	customer, ok := h.currentCustomer(c)
+	if !ok {
+		return
+	}
+
+	var limit int64
+	if customer.PlanID != 0 {
+		plan, err := h.plans.FindByID(customer.PlanID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取服务套餐失败"})
+			return
+		}
+		if plan != nil {
+			limit = plan.RequestLimit
+		}
+	}
+
+	month := quota.MonthOf(time.Now())
+	usage, err := h.meter.Usage(c.Request.Context(), customer.ID, month)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取用量失败"})
+		return
+	}
+
+	// 不限额度时剩余额度为-1
+	remaining := int64(-1)
+	if limit > 0 {
+		remaining = limit - usage.RequestCount
+		if remaining < 0 {
+			remaining = 0
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"month":        month.Format(billing.MonthFormat),
+			"requestCount": usage.RequestCount,
+			"successCount": usage.SuccessCount,
+			"failCount":    usage.FailCount,
+			"avgLatency":   usage.AvgLatency(),
+			"limit":        limit,
+			"remaining":    remaining,
+			"resetAt":      month.AddDate(0, 1, 0),
+		},
+	})
+}
+
+// ListApplications 获取当前客户的应用
+func (h *PortalHandler) ListApplications(c *gin.Context) {
+	customerID, ok := portalCustomerID(c)
+	if !ok {
+		return
+	}
+
+	apps, err := h.applications.ListByCustomer(customerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取应用列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": apps})
+}
+
+// CreateApplication 为当前客户创建应用，AppID由系统生成
+func (h *PortalHandler) CreateApplication(c *gin.Context) {
+	customerID, ok := portalCustomerID(c)
+	if !ok {
+		return
+	}
+
+	var req applicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+	if req.Status == "" {
+		req.Status = model.ApplicationActive
+	}
+	if !validApplicationStatus(req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的应用状态"})
+		return
+	}
+
+	appID, err := generateAppID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成AppID失败"})
+		return
+	}
+	app := &model.Application{
+		CustomerID:  customerID,
+		AppID:       appID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Status:      req.Status,
+	}
+	if err := h.applications.Create(app); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建应用失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "创建成功", "data": app})
+}
+
+// UpdateApplication 更新当前客户的应用
+func (h *PortalHandler) UpdateApplication(c *gin.Context) {
+	app, ok := h.findApplication(c)
+	if !ok {
+		return
+	}
+
+	var req applicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+	if req.Status != "" {
+		if !validApplicationStatus(req.Status) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的应用状态"})
+			return
+		}
+		app.Status = req.Status
+	}
+	app.Name = strings.TrimSpace(req.Name)
+	app.Description = req.Description
+
+	if err := h.applications.Update(app); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新应用失败"})
+		return
+	}
+	if req.Status != "" {
+		h.invalidateKeys()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "更新成功", "data": app})
+}
+
+// DeleteApplication 删除当前客户的应用
+func (h *PortalHandler) DeleteApplication(c *gin.Context) {
+	customerID, ok := portalCustomerID(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.applications.Delete(c.Param("id"), customerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "删除应用失败"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "应用不存在"})
+		return
+	}
+	h.invalidateKeys()
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功"})
+}
+
+// ListAPIKeys 获取当前客户某个应用下的API密钥，不包含密钥明文
+func (h *PortalHandler) ListAPIKeys(c *gin.Context) {
+	app, ok := h.findApplication(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.keys.ListByApp(app.AppID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取API密钥列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": keys})
+}
+
+// CreateAPIKey 在当前客户的应用下创建API密钥，密钥明文只在本次响应中返回
+func (h *PortalHandler) CreateAPIKey(c *gin.Context) {
+	app, ok := h.findApplication(c)
+	if !ok {
+		return
+	}
+
+	var req apiKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+	if err := model.ValidateScopes(req.Scopes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	if err := model.ValidateIPRanges(req.AllowedIPs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+		return
+	}
+	if req.ExpireAt != nil && !req.ExpireAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "过期时间必须晚于当前时间"})
+		return
+	}
+
+	secret, prefix, err := security.NewAPIKeySecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成API密钥失败"})
+		return
+	}
+	signing, err := security.SealSigningSecret(h.encryption, secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成API密钥失败"})
+		return
+	}
+	key := &model.APIKey{
+		CustomerID:    app.CustomerID,
+		AppID:         app.AppID,
+		Name:          strings.TrimSpace(req.Name),
+		KeyHash:       security.HashAPIKey(secret),
+		SigningSecret: signing,
+		Prefix:        prefix,
+		Scopes:        req.Scopes,
+		AllowedIPs:    req.AllowedIPs,
+		Status:        model.APIKeyActive,
+		ExpireAt:      req.ExpireAt,
+	}
+	if key.AllowedIPs == nil {
+		key.AllowedIPs = []string{}
+	}
+	if err := h.keys.Create(key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "创建API密钥失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "API密钥已创建，请妥善保存",
+		"data": gin.H{
+			"key":    key,
+			"secret": secret,
+		},
+	})
+}
+
+// RotateAppAPIKey 轮换当前客户应用下的API密钥
+// 新密钥立即生效，旧密钥在宽限期内继续有效，新密钥明文只在本次响应中返回
+func (h *PortalHandler) RotateAppAPIKey(c *gin.Context) {
+	app, ok := h.findApplication(c)
+	if !ok {
+		return
+	}
+
+	// 请求体为空时使用默认宽限期
+	var req rotateKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !stderrors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
+		return
+	}
+	var grace *time.Duration
+	if req.GraceHours != nil {
+		d := time.Duration(*req.GraceHours) * time.Hour
+		grace = &d
+	}
+
+	old, err := h.keys.FindByID(c.Param("keyId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取API密钥失败"})
+		return
+	}
+	if old == nil || old.AppID != app.AppID {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "API密钥不存在"})
+		return
+	}
+
+	key, secret, err := h.rotator.Rotate(c.Request.Context(), old, grace, time.Now())
+	if err != nil {
+		if stderrors.Is(err, security.ErrInvalidGrace) || stderrors.Is(err, security.ErrKeyNotRotatable) {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "轮换API密钥失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "API密钥已轮换，请妥善保存新密钥",
+		"data": gin.H{
+			"key":          key,
+			"secret":       secret,
+			"previousKey":  old.ID,
+			"overlapUntil": key.OverlapUntil,
+		},
+	})
+}
+
+// RevokeAPIKey 吊销当前客户的API密钥
+func (h *PortalHandler) RevokeAPIKey(c *gin.Context) {
+	customerID, ok := portalCustomerID(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.keys.Revoke(c.Param("keyId"), customerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "吊销API密钥失败"})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "API密钥不存在"})
+		return
+	}
+	h.invalidateKeys()
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "API密钥已吊销"})
+}
+
+// invalidateKeys 清除密钥缓存，使吊销密钥和停用、删除应用立即生效
+func (h *PortalHandler) invalidateKeys() {
+	if h.store != nil {
+		h.store.Invalidate()
+	}
+}
+
+// Recognitions 分页获取当前客户的识别历史
+func (h *PortalHandler) Recognitions(c *gin.Context) {
+	customerID, ok := portalCustomerID(c)
+	if !ok {
+		return
+	}
+	page, size := pageParams(c)
+
+	records, total, err := h.records.GetByCustomerID(c.Request.Context(), customerID, page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取识别历史失败"})
+		return
+	}
+
+	// 只返回客户端接口同样的字段，不暴露图片存储路径、灰度分组和内部错误信息
+	items := make([]client.RecognitionResponse, 0, len(records))
+	for _, r := range records {
+		items = append(items, client.NewRecognitionResponse(r))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"total": total,
+			"items": items,
+		},
+	})
+}
+
+// DownloadUsageReport 下载当前客户某月按天和模型类型汇总的用量报表
+// 查询参数：month(如2024-05，默认当前月份)、format(csv或json，默认csv)
+func (h *PortalHandler) DownloadUsageReport(c *gin.Context) {
+	customerID, ok := portalCustomerID(c)
+	if !ok {
+		return
+	}
+
+	month, err := billing.ParseMonth(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的月份，格式为2006-01"})
+		return
+	}
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "不支持的报表格式，仅支持csv和json"})
+		return
+	}
+
+	start, end := billing.MonthRange(month)
+	rows, err := h.usage.Daily(customerID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成用量报表失败"})
+		return
+	}
+
+	var data []byte
+	contentType := "application/json; charset=utf-8"
+	if format == "csv" {
+		data, err = usageReportCSV(rows)
+		contentType = "text/csv; charset=utf-8"
+	} else {
+		data, err = json.MarshalIndent(rows, "", "  ")
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "生成用量报表失败"})
+		return
+	}
+	filename := fmt.Sprintf("usage_%s.%s", start.Format("200601"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// currentCustomer 查询当前登录的客户，客户不存在或已禁用时返回错误响应
+func (h *PortalHandler) currentCustomer(c *gin.Context) (*model.Customer, bool) {
+	customerID, ok := portalCustomerID(c)
+	if !ok {
+		return nil, false
+	}
+
+	customer, err := h.customers.FindByID(customerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取客户信息失败"})
+		return nil, false
+	}
+	if customer == nil || customer.Status != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "账号不存在或已被禁用"})
+		return nil, false
+	}
+	return customer, true
+}
+
+// findApplication 查找当前客户的应用，其他客户的应用按不存在处理
+func (h *PortalHandler) findApplication(c *gin.Context) (*model.Application, bool) {
+	customerID, ok := portalCustomerID(c)
+	if !ok {
+		return nil, false
+	}
+
+	app, err := h.applications.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取应用失败"})
+		return nil, false
+	}
+	if app == nil || app.CustomerID != customerID {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "应用不存在"})
+		return nil, false
+	}
+	return app, true
+}
+
+// portalCustomerID 获取客户门户认证后的客户ID
+func portalCustomerID(c *gin.Context) (int64, bool) {
+	if v, exists := c.Get("customerId"); exists {
+		if id, ok := v.(int64); ok && id != 0 {
+			return id, true
+		}
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "未授权的访问"})
+	return 0, false
+}
+
+// portalCustomer 返回给客户的账号信息，不包含密码，API密钥只显示末4位
+func portalCustomer(customer *model.Customer) model.Customer {
+	masked := *customer
+	masked.Password = ""
+	masked.APIKey = maskAPIKey(customer.APIKey)
+	return masked
+}
+
+// maskAPIKey API密钥脱敏
+func maskAPIKey(apiKey string) string {
+	if len(apiKey) <= 4 {
+		return strings.Repeat("*", len(apiKey))
+	}
+	return strings.Repeat("*", len(apiKey)-4) + apiKey[len(apiKey)-4:]
+}
+
+// validApplicationStatus 判断应用状态是否有效
+func validApplicationStatus(status string) bool {
+	return status == model.ApplicationActive || status == model.ApplicationDisabled
+}
+
+// generateAppID 生成随机AppID
+func generateAppID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "app_" + hex.EncodeToString(buf), nil
+}
+
+// usageReportCSV 将每日用量转换为CSV报表
+func usageReportCSV(rows []*model.DailyUsage) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"date", "model_type", "requests", "megapixels"}); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		record := []string{
+			row.Date,
+			row.ModelType,
+			strconv.FormatInt(row.Requests, 10),
+			strconv.FormatFloat(row.Megapixels, 'f', 2, 64),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/promotion"
+	"github.com/image-recognition-engine/internal/security"
+)
+
+// PromotionHandler 模型版本上线流程处理器
+type PromotionHandler struct {
+	service  *promotion.Service
+	auditLog *security.AuditLogService
+}
+
+// NewPromotionHandler 创建模型版本上线流程处理器
+func NewPromotionHandler(service *promotion.Service, auditLog *security.AuditLogService) *PromotionHandler {
+	return &PromotionHandler{
+		service:  service,
+		auditLog: auditLog,
+	}
+}
+
+// GetThresholds 获取当前生效的上线门槛
+func (h *PromotionHandler) GetThresholds(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": h.service.Thresholds()})
+}
+
+// Submit 提交测试
+func (h *PromotionHandler) Submit(c *gin.Context) {
+	v, err := h.service.Submit(c.Request.Context(), actorFromContext(c), c.Param("id"))
+	if err != nil {
+		writeAppError(c, err, "提交测试失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已提交测试", "data": v})
+}
+
+// RequestApproval 申请上线
+func (h *PromotionHandler) RequestApproval(c *gin.Context) {
+	var req struct {
+		EvaluationID string `json:"evaluationId"` // 为空时使用最近一次完成的评估
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+			return
+		}
+	}
+
+	v, err := h.service.RequestApproval(c.Request.Context(), actorFromContext(c), c.Param("id"), req.EvaluationID)
+	if err != nil {
+		writeAppError(c, err, "申请上线失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已提交上线申请", "data": v})
+}
+
+// Approve 审批上线
+func (h *PromotionHandler) Approve(c *gin.Context) {
+	v, err := h.service.Approve(c.Request.Context(), actorFromContext(c), c.Param("id"))
+	if err != nil {
+		writeAppError(c, err, "审批上线失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已上线", "data": v})
+}
+
+// Reject 驳回上线申请
+func (h *PromotionHandler) Reject(c *gin.Context) {
+	var req struct {
+		Comment string `json:"comment" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "请填写驳回原因"})
+		return
+	}
+
+	v, err := h.service.Reject(c.Request.Context(), actorFromContext(c), c.Param("id"), req.Comment)
+	if err != nil {
+		writeAppError(c, err, "驳回上线申请失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已驳回", "data": v})
+}
+
+// Rollback 回滚到上一个生产版本
+func (h *PromotionHandler) Rollback(c *gin.Context) {
+	v, err := h.service.Rollback(c.Request.Context(), actorFromContext(c), c.Param("id"))
+	if err != nil {
+		writeAppError(c, err, "回滚失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已回滚", "data": v})
+}
+
+// GetHistory 获取模型版本的流转记录
+func (h *PromotionHandler) GetHistory(c *gin.Context) {
+	if h.auditLog == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"code": 503, "message": "审计日志未启用"})
+		return
+	}
+
+	page, size := pageParams(c)
+	logs, total, err := h.auditLog.GetResourceLogs(c.Request.Context(), "model_version", c.Param("id"), time.Time{}, time.Time{}, page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取流转记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"total":    total,
+			"page":     page,
+			"pageSize": size,
+			"list":     logs,
+		},
+	})
+}
+
+// actorFromContext 从登录信息构造操作人
+func actorFromContext(c *gin.Context) promotion.Actor {
+	return promotion.Actor{
+		UserID:    currentUserID(c),
+		Username:  c.GetString("username"),
+		ClientIP:  c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
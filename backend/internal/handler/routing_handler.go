@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/repository"
+	"github.com/image-recognition-engine/internal/routing"
+)
+
+// RoutingHandler 模型流量分配处理器
+type RoutingHandler struct {
+	service *routing.Service
+	records repository.RecognitionRepository
+	shadows model.ShadowResultRepository
+}
+
+// NewRoutingHandler 创建模型流量分配处理器
+func NewRoutingHandler(service *routing.Service, records repository.RecognitionRepository, shadows model.ShadowResultRepository) *RoutingHandler {
+	return &RoutingHandler{
+		service: service,
+		records: records,
+		shadows: shadows,
+	}
+}
+
+// GetPolicy 获取模型的流量分配策略
+func (h *RoutingHandler) GetPolicy(c *gin.Context) {
+	policy, err := h.service.Policy(c.Param("id"))
+	if err != nil {
+		writeAppError(c, err, "获取流量分配策略失败")
+		return
+	}
+	if policy == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "模型未配置流量分配策略"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": policy})
+}
+
+// SavePolicy 创建或更新模型的流量分配策略
+func (h *RoutingHandler) SavePolicy(c *gin.Context) {
+	var req struct {
+		CandidateVersion string  `json:"candidateVersion"`
+		CandidatePercent float64 `json:"candidatePercent"`
+		StickyBy         string  `json:"stickyBy"`
+		ShadowVersion    string  `json:"shadowVersion"`
+		ShadowPercent    float64 `json:"shadowPercent"`
+		Enabled          bool    `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		return
+	}
+
+	policy := &model.RoutingPolicy{
+		ModelID:          c.Param("id"),
+		CandidateVersion: req.CandidateVersion,
+		CandidatePercent: req.CandidatePercent,
+		StickyBy:         req.StickyBy,
+		ShadowVersion:    req.ShadowVersion,
+		ShadowPercent:    req.ShadowPercent,
+		Enabled:          req.Enabled,
+		UserID:           currentUserID(c),
+	}
+	if err := h.service.SavePolicy(c.Request.Context(), policy); err != nil {
+		writeAppError(c, err, "保存流量分配策略失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "保存成功", "data": policy})
+}
+
+// DeletePolicy 删除模型的流量分配策略
+func (h *RoutingHandler) DeletePolicy(c *gin.Context) {
+	if err := h.service.DeletePolicy(c.Param("id")); err != nil {
+		writeAppError(c, err, "删除流量分配策略失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "删除成功"})
+}
+
+// GetComparison 获取生产版本与候选版本的并排对比报告，默认统计最近24小时
+func (h *RoutingHandler) GetComparison(c *gin.Context) {
+	modelID := c.Param("id")
+
+	start, end, ok := reportRange(c)
+	if !ok {
+		return
+	}
+
+	records, err := h.records.GetByModelID(c.Request.Context(), modelID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取识别记录失败"})
+		return
+	}
+	policy, err := h.service.Policy(modelID)
+	if err != nil {
+		writeAppError(c, err, "获取流量分配策略失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": &model.VersionComparison{
+		ModelID:   modelID,
+		StartTime: start,
+		EndTime:   end,
+		Policy:    policy,
+		Versions:  routing.Compare(records),
+	}})
+}
+
+// GetShadowReport 获取影子版本与生产版本的差异报告，默认统计最近24小时
+func (h *RoutingHandler) GetShadowReport(c *gin.Context) {
+	modelID := c.Param("id")
+	start, end, ok := reportRange(c)
+	if !ok {
+		return
+	}
+
+	shadowVersion := c.Query("shadowVersion")
+	results, err := h.shadows.FindByModel(modelID, shadowVersion, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取影子复跑结果失败"})
+		return
+	}
+
+	report := routing.SummarizeShadow(results)
+	report.ModelID = modelID
+	report.ShadowVersion = shadowVersion
+	report.StartTime = start
+	report.EndTime = end
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": report})
+}
+
+// reportRange 解析报告的统计时间范围，默认最近24小时
+func reportRange(c *gin.Context) (time.Time, time.Time, bool) {
+	end := time.Now()
+	start := end.Add(-24 * time.Hour)
+	if v := c.Query("startTime"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "开始时间格式错误"})
+			return start, end, false
+		}
+		start = t
+	}
+	if v := c.Query("endTime"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "结束时间格式错误"})
+			return start, end, false
+		}
+		end = t
+	}
+	return start, end, true
+}
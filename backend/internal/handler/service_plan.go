@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/security"
 )
 
 type ServicePlanHandler struct {
@@ -27,6 +28,10 @@ func (h *ServicePlanHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
 		return
 	}
+	if msg := validatePlan(&plan); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": msg})
+		return
+	}
 
 	id, err := h.planRepo.Create(&plan)
 	if err != nil {
@@ -64,6 +69,10 @@ func (h *ServicePlanHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的请求参数"})
 		return
 	}
+	if msg := validatePlan(plan); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": msg})
+		return
+	}
 
 	if err := h.planRepo.Update(plan); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "更新服务套餐失败"})
@@ -152,4 +161,18 @@ func (h *ServicePlanHandler) GetByID(c *gin.Context) {
 		"message": "成功",
 		"data":    plan,
 	})
+}
+
+// validatePlan 校验套餐的限流配置，返回错误提示
+func validatePlan(plan *model.ServicePlan) string {
+	if plan.RateLimit < 0 || plan.RateBurst < 0 || plan.Concurrent < 0 {
+		return "限流和并发配置不能为负数"
+	}
+	if plan.RateBurst > 0 && plan.RateLimit == 0 {
+		return "设置突发请求数时必须设置每分钟请求数限制"
+	}
+	if err := security.ValidateRateAlgorithm(plan.RateAlgorithm); err != nil {
+		return err.Error()
+	}
+	return ""
 }
\ No newline at end of file
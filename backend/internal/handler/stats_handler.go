@@ -83,6 +83,7 @@ func (h *StatsHandler) GetSystemStats(c *gin.Context) {
 func (h *StatsHandler) GetAccuracyStats(c *gin.Context) {
 	modelID := c.Query("modelId")
 	category := c.Query("category")
+	source := c.Query("source") // evaluation或feedback，为空时返回全部来源
 	startTime := c.Query("startTime")
 	endTime := c.Query("endTime")
 
@@ -100,29 +101,38 @@ func (h *StatsHandler) GetAccuracyStats(c *gin.Context) {
 	}
 
 	// 获取统计数据
-	stats, err := h.statsRepo.GetAccuracyStats(c.Request.Context(), modelID, category, start, end)
+	stats, err := h.statsRepo.GetAccuracyStats(c.Request.Context(), modelID, category, source, start, end)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": errors.Wrap(err, "获取准确率统计数据失败").Error()})
 		return
 	}
 
 	// 处理统计数据
-	var totalAccuracy float64
+	// 带样本数的记录(如模型评估写入的记录)按样本数加权，否则按记录数平均
+	var totalAccuracy, totalWeight float64
 	categoryStats := make(map[string]float64)
-	categoryCount := make(map[string]int)
+	categoryWeight := make(map[string]float64)
 
 	for _, stat := range stats {
-		totalAccuracy += stat.Accuracy
-		categoryStats[stat.Category] += stat.Accuracy
-		categoryCount[stat.Category]++
+		weight := 1.0
+		if stat.TotalSamples > 0 {
+			weight = float64(stat.TotalSamples)
+		}
+		totalAccuracy += stat.Accuracy * weight
+		totalWeight += weight
+		categoryStats[stat.Category] += stat.Accuracy * weight
+		categoryWeight[stat.Category] += weight
 	}
 
 	// 计算平均准确率
-	overallAccuracy := totalAccuracy / float64(len(stats))
+	var overallAccuracy float64
+	if totalWeight > 0 {
+		overallAccuracy = totalAccuracy / totalWeight
+	}
 	categoryAccuracy := make([]map[string]interface{}, 0)
 
 	for category, total := range categoryStats {
-		avg := total / float64(categoryCount[category])
+		avg := total / categoryWeight[category]
 		categoryAccuracy = append(categoryAccuracy, map[string]interface{}{
 			"category": category,
 			"accuracy": avg,
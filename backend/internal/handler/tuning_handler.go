@@ -0,0 +1,187 @@
+package handler
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/tuning"
+)
+
+// TuningHandler 超参数搜索处理器
+type TuningHandler struct {
+	service     *tuning.Service
+	jobRepo     model.TuningJobRepository
+	trialRepo   model.TuningTrialRepository
+	datasetRepo model.DatasetRepository
+}
+
+// NewTuningHandler 创建超参数搜索处理器
+func NewTuningHandler(service *tuning.Service, jobRepo model.TuningJobRepository, trialRepo model.TuningTrialRepository, datasetRepo model.DatasetRepository) *TuningHandler {
+	return &TuningHandler{
+		service:     service,
+		jobRepo:     jobRepo,
+		trialRepo:   trialRepo,
+		datasetRepo: datasetRepo,
+	}
+}
+
+// createTuningJobRequest 创建调参任务请求
+type createTuningJobRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	ModelID       string   `json:"modelId" binding:"required"`
+	DatasetID     string   `json:"datasetId" binding:"required"`
+	Strategy      string   `json:"strategy"`
+	Params        []string `json:"params"`
+	MaxTrials     int      `json:"maxTrials"`
+	MaxConcurrent int      `json:"maxConcurrent"`
+	Seed          int64    `json:"seed"`
+	Objective     string   `json:"objective"`
+	Maximize      *bool    `json:"maximize"` // 未指定时目标指标越大越好
+}
+
+// CreateJob 创建调参任务
+func (h *TuningHandler) CreateJob(c *gin.Context) {
+	var req createTuningJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		return
+	}
+
+	ds, err := h.datasetRepo.FindByID(req.DatasetID)
+	if err != nil || ds == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "数据集不存在"})
+		return
+	}
+
+	job := &model.TuningJob{
+		Name:          req.Name,
+		ModelID:       req.ModelID,
+		DatasetID:     req.DatasetID,
+		Strategy:      req.Strategy,
+		Params:        req.Params,
+		MaxTrials:     req.MaxTrials,
+		MaxConcurrent: req.MaxConcurrent,
+		Seed:          req.Seed,
+		Objective:     req.Objective,
+		Maximize:      req.Maximize == nil || *req.Maximize,
+		UserID:        currentUserID(c),
+	}
+
+	job, err = h.service.CreateJob(c.Request.Context(), job)
+	if err != nil {
+		writeAppError(c, err, "创建调参任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "创建成功", "data": job})
+}
+
+// ListJobs 获取调参任务列表
+func (h *TuningHandler) ListJobs(c *gin.Context) {
+	page, size := pageParams(c)
+
+	jobs, total, err := h.jobRepo.List(c.Query("modelId"), page, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取调参任务列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"total": total,
+			"items": jobs,
+		},
+	})
+}
+
+// GetJob 获取调参任务详情及全部试验
+func (h *TuningHandler) GetJob(c *gin.Context) {
+	job, err := h.jobRepo.FindByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "无效的调参任务ID"})
+		return
+	}
+	if job == nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 404, "message": "调参任务不存在"})
+		return
+	}
+
+	trials, err := h.trialRepo.ListByJob(job.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "获取试验列表失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    200,
+		"message": "成功",
+		"data": gin.H{
+			"job":    job,
+			"trials": trials,
+		},
+	})
+}
+
+// CancelJob 取消调参任务，已提交的试验继续执行，未调度的试验不再提交
+func (h *TuningHandler) CancelJob(c *gin.Context) {
+	job, err := h.service.CancelJob(c.Param("id"))
+	if err != nil {
+		writeAppError(c, err, "取消调参任务失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "已取消", "data": job})
+}
+
+// StartTrial 训练端开始执行试验时调用
+func (h *TuningHandler) StartTrial(c *gin.Context) {
+	if err := h.service.StartTrial(c.Param("id")); err != nil {
+		writeAppError(c, err, "更新试验状态失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功"})
+}
+
+// trialResultRequest 试验结果上报请求
+type trialResultRequest struct {
+	Metrics map[string]float64 `json:"metrics"`
+	Error   string             `json:"error"` // 训练失败时的错误信息
+}
+
+// ReportTrialResult 训练端上报试验结果
+func (h *TuningHandler) ReportTrialResult(c *gin.Context) {
+	var req trialResultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400, "message": "参数错误"})
+		return
+	}
+
+	trial, err := h.service.ReportResult(c.Request.Context(), c.Param("id"), req.Metrics, req.Error)
+	if err != nil {
+		writeAppError(c, err, "上报试验结果失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"code": 200, "message": "成功", "data": trial})
+}
+
+// writeAppError 将服务层返回的AppError转换为响应，其他错误按服务器错误处理
+func writeAppError(c *gin.Context, err error, fallback string) {
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) {
+		resp := gin.H{"code": appErr.HTTPCode, "message": appErr.Message}
+		if appErr.Details != "" {
+			resp["details"] = appErr.Details
+		}
+		c.JSON(appErr.HTTPCode, resp)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"code": 500, "message": fallback})
+}
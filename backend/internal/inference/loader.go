@@ -0,0 +1,35 @@
+package inference
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+var (
+	loadersMu sync.RWMutex
+	loaders   = make(map[string]Loader)
+)
+
+// RegisterLoader 注册模型格式对应的加载器，由具体的推理运行时在init中调用
+func RegisterLoader(format string, loader Loader) {
+	loadersMu.Lock()
+	defer loadersMu.Unlock()
+	loaders[format] = loader
+}
+
+// FormatLoader 按清单中的模型格式选择已注册的加载器
+type FormatLoader struct{}
+
+// Load 使用模型格式对应的加载器加载模型，格式未注册时返回错误
+func (FormatLoader) Load(ctx context.Context, manifest *model.ArtifactManifest, path string) (LoadedModel, error) {
+	loadersMu.RLock()
+	loader, ok := loaders[manifest.Format]
+	loadersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("不支持的模型格式: %s", manifest.Format)
+	}
+	return loader.Load(ctx, manifest, path)
+}
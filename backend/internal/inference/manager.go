@@ -0,0 +1,392 @@
+package inference
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+const (
+	// loadTimeout 单次加载模型的超时时间，加载与触发加载的请求解耦，请求取消不影响加载
+	loadTimeout = 5 * time.Minute
+	// DefaultMaxMemory 未配置时已加载模型的内存上限
+	DefaultMaxMemory = 2 * 1024 * 1024 * 1024
+	// RetireGrace 切换版本后旧版本保留的时间，应大于路由缓存时间，避免旧版本被卸载后又被重新加载
+	RetireGrace = 30 * time.Second
+)
+
+// LoadedModel 已加载到内存的模型
+type LoadedModel interface {
+	// Predict 对单张图片推理
+	Predict(ctx context.Context, image []byte) ([]Prediction, error)
+	// MemoryBytes 模型占用的内存，无法统计时返回0，按模型文件大小估算
+	MemoryBytes() int64
+	// Close 释放模型占用的资源
+	Close() error
+}
+
+// Loader 模型加载器，由具体的推理运行时实现
+type Loader interface {
+	Load(ctx context.Context, manifest *model.ArtifactManifest, path string) (LoadedModel, error)
+}
+
+// ArtifactSource 模型文件来源，由artifact.Store实现，加载前校验文件完整性
+type ArtifactSource interface {
+	Verify(ctx context.Context, modelID, version string) (*model.ArtifactManifest, error)
+	ArtifactPath(manifest *model.ArtifactManifest) string
+}
+
+// LoadedModelStatus 已加载模型的状态
+type LoadedModelStatus struct {
+	ModelID      string    `json:"modelId"`
+	Version      string    `json:"version"`
+	Loading      bool      `json:"loading"`
+	MemoryBytes  int64     `json:"memoryBytes"`
+	LoadTime     time.Time `json:"loadTime"`
+	LoadDuration int64     `json:"loadDuration"` // 加载耗时(毫秒)
+	LastUsed     time.Time `json:"lastUsed"`
+	InFlight     int       `json:"inFlight"` // 正在处理的请求数
+	Requests     int64     `json:"requests"` // 加载后处理的请求数
+	Retiring     bool      `json:"retiring"` // 已被新版本替换，空闲后卸载
+}
+
+// ManagerStatus 模型管理器状态
+type ManagerStatus struct {
+	UsedBytes int64                `json:"usedBytes"`
+	MaxBytes  int64                `json:"maxBytes"`
+	Models    []*LoadedModelStatus `json:"models"`
+}
+
+// entry 模型缓存项
+type entry struct {
+	modelID  string
+	version  string
+	model    LoadedModel
+	memory   int64
+	ready    chan struct{} // 加载结束后关闭
+	err      error
+	elem     *list.Element
+	removed  bool
+	inFlight int
+	requests int64
+	loadTime time.Time
+	loadCost time.Duration
+	lastUsed time.Time
+	retireAt time.Time // 非零时表示已被替换，到期且空闲后卸载
+}
+
+// Manager 模型管理器：首次使用时加载模型，按内存上限LRU淘汰空闲模型
+// 每个请求在推理期间持有模型引用，被淘汰或替换的模型在引用归零后才释放，切换版本不会中断进行中的请求
+type Manager struct {
+	loader    Loader
+	artifacts ArtifactSource
+	maxMemory int64
+	grace     time.Duration // 被替换版本的保留时间
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	lru     *list.List // 前端为最近使用
+	used    int64
+}
+
+// NewManager 创建模型管理器，maxMemory为已加载模型的内存上限(字节)，不大于0时使用DefaultMaxMemory
+func NewManager(loader Loader, artifacts ArtifactSource, maxMemory int64) *Manager {
+	if maxMemory <= 0 {
+		maxMemory = DefaultMaxMemory
+	}
+	return &Manager{
+		loader:    loader,
+		artifacts: artifacts,
+		maxMemory: maxMemory,
+		grace:     RetireGrace,
+		entries:   make(map[string]*entry),
+		lru:       list.New(),
+	}
+}
+
+// Predict 使用指定版本推理，模型未加载时先加载，实现Predictor接口
+func (m *Manager) Predict(ctx context.Context, modelID, modelVersion string, image []byte) ([]Prediction, error) {
+	e, err := m.acquire(ctx, modelID, modelVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer m.release(e)
+
+	return e.model.Predict(ctx, image)
+}
+
+// Prewarm 预先加载模型
+func (m *Manager) Prewarm(ctx context.Context, modelID, version string) error {
+	e, err := m.acquire(ctx, modelID, version)
+	if err != nil {
+		return err
+	}
+	m.release(e)
+	return nil
+}
+
+// VersionResolver 查询模型的生产版本，由repository.ModelRepository实现
+type VersionResolver interface {
+	GetProductionVersion(ctx context.Context, modelID string) (*model.ModelVersion, error)
+}
+
+// PrewarmDefault 启动时预热默认模型，spec为"模型ID"或"模型ID@版本号"，未指定版本时使用生产版本
+func (m *Manager) PrewarmDefault(ctx context.Context, spec string, versions VersionResolver) error {
+	if spec == "" {
+		return nil
+	}
+	modelID, version, _ := strings.Cut(spec, "@")
+	if version == "" {
+		v, err := versions.GetProductionVersion(ctx, modelID)
+		if err != nil {
+			return fmt.Errorf("查询默认模型生产版本失败: %w", err)
+		}
+		if v == nil {
+			return fmt.Errorf("默认模型%s没有生产版本", modelID)
+		}
+		version = v.Version
+	}
+	return m.Prewarm(ctx, modelID, version)
+}
+
+// Swap 加载新上线的版本，并将被替换的版本标记为待卸载
+// 旧版本在RetireGrace内仍可使用，之后空闲时卸载，已在处理的请求不受影响
+func (m *Manager) Swap(ctx context.Context, modelID, version, previous string) error {
+	if err := m.Prewarm(ctx, modelID, version); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// 回滚到仍在保留期内的版本时取消其卸载
+	if e, ok := m.entries[key(modelID, version)]; ok {
+		e.retireAt = time.Time{}
+	}
+	if previous != "" && previous != version {
+		if e, ok := m.entries[key(modelID, previous)]; ok {
+			e.retireAt = time.Now().Add(m.grace)
+		}
+	}
+	return nil
+}
+
+// Unload 卸载模型，正在处理的请求完成后释放
+func (m *Manager) Unload(modelID, version string) bool {
+	m.mu.Lock()
+	e, ok := m.entries[key(modelID, version)]
+	var closing []*entry
+	if ok {
+		select {
+		case <-e.ready:
+			closing = m.removeLocked(e, closing)
+		default:
+			ok = false // 加载中的模型不能卸载
+		}
+	}
+	m.mu.Unlock()
+
+	closeAll(closing)
+	return ok
+}
+
+// Status 获取已加载模型的状态，按最近使用时间排序
+func (m *Manager) Status() *ManagerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := &ManagerStatus{UsedBytes: m.used, MaxBytes: m.maxMemory, Models: make([]*LoadedModelStatus, 0, len(m.entries))}
+	for _, e := range m.entries {
+		s := &LoadedModelStatus{ModelID: e.modelID, Version: e.version}
+		select {
+		case <-e.ready:
+			s.MemoryBytes = e.memory
+			s.LoadTime = e.loadTime
+			s.LoadDuration = e.loadCost.Milliseconds()
+			s.LastUsed = e.lastUsed
+			s.InFlight = e.inFlight
+			s.Requests = e.requests
+			s.Retiring = !e.retireAt.IsZero()
+		default:
+			s.Loading = true
+		}
+		status.Models = append(status.Models, s)
+	}
+	sort.Slice(status.Models, func(i, j int) bool {
+		return status.Models[i].LastUsed.After(status.Models[j].LastUsed)
+	})
+	return status
+}
+
+// Close 卸载全部模型
+func (m *Manager) Close() {
+	m.mu.Lock()
+	var closing []*entry
+	for _, e := range m.entries {
+		select {
+		case <-e.ready:
+			closing = m.removeLocked(e, closing)
+		default:
+		}
+	}
+	m.mu.Unlock()
+
+	closeAll(closing)
+}
+
+// acquire 获取已加载的模型并增加引用，同一版本并发请求只加载一次
+func (m *Manager) acquire(ctx context.Context, modelID, version string) (*entry, error) {
+	k := key(modelID, version)
+	for {
+		m.mu.Lock()
+		e, ok := m.entries[k]
+		if !ok {
+			e = &entry{modelID: modelID, version: version, ready: make(chan struct{})}
+			m.entries[k] = e
+			go m.load(e)
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-e.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if e.err != nil {
+			return nil, e.err
+		}
+
+		m.mu.Lock()
+		if e.removed {
+			// 加载完成后已被淘汰，重新加载
+			m.mu.Unlock()
+			continue
+		}
+		e.inFlight++
+		e.requests++
+		e.lastUsed = time.Now()
+		m.lru.MoveToFront(e.elem)
+		m.mu.Unlock()
+		return e, nil
+	}
+}
+
+// release 释放模型引用，已淘汰的模型在引用归零时关闭
+func (m *Manager) release(e *entry) {
+	m.mu.Lock()
+	e.inFlight--
+	var closing []*entry
+	if e.removed && e.inFlight == 0 {
+		closing = append(closing, e)
+	} else {
+		closing = m.evictLocked(closing)
+	}
+	m.mu.Unlock()
+
+	closeAll(closing)
+}
+
+// load 校验模型文件并加载，失败时移除缓存项，后续请求会重新尝试加载
+func (m *Manager) load(e *entry) {
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+
+	start := time.Now()
+	loaded, memory, err := m.loadModel(ctx, e.modelID, e.version)
+
+	m.mu.Lock()
+	var closing []*entry
+	if err != nil {
+		log.Printf("加载模型%s版本%s失败: %v", e.modelID, e.version, err)
+		e.err = err
+		delete(m.entries, key(e.modelID, e.version))
+	} else {
+		e.model = loaded
+		e.memory = memory
+		e.loadTime = time.Now()
+		e.loadCost = e.loadTime.Sub(start)
+		e.lastUsed = e.loadTime
+		e.elem = m.lru.PushFront(e)
+		m.used += memory
+		closing = m.evictLocked(closing)
+	}
+	close(e.ready)
+	m.mu.Unlock()
+
+	closeAll(closing)
+}
+
+func (m *Manager) loadModel(ctx context.Context, modelID, version string) (LoadedModel, int64, error) {
+	manifest, err := m.artifacts.Verify(ctx, modelID, version)
+	if err != nil {
+		return nil, 0, fmt.Errorf("模型文件校验失败: %w", err)
+	}
+	loaded, err := m.loader.Load(ctx, manifest, m.artifacts.ArtifactPath(manifest))
+	if err != nil {
+		return nil, 0, fmt.Errorf("加载模型失败: %w", err)
+	}
+
+	memory := loaded.MemoryBytes()
+	if memory <= 0 {
+		memory = manifest.Size
+	}
+	return loaded, memory, nil
+}
+
+// evictLocked 卸载到期的旧版本，并在超出内存上限时从最久未使用的空闲模型开始淘汰
+// 最近使用的模型不会被淘汰，单个模型超过上限时允许暂时超出
+func (m *Manager) evictLocked(closing []*entry) []*entry {
+	now := time.Now()
+	for elem := m.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		e := elem.Value.(*entry)
+		if !e.retireAt.IsZero() && now.After(e.retireAt) && e.inFlight == 0 {
+			closing = m.removeLocked(e, closing)
+		}
+		elem = prev
+	}
+
+	for elem := m.lru.Back(); elem != nil && m.used > m.maxMemory; {
+		prev := elem.Prev()
+		e := elem.Value.(*entry)
+		if elem != m.lru.Front() && e.inFlight == 0 {
+			closing = m.removeLocked(e, closing)
+		}
+		elem = prev
+	}
+	return closing
+}
+
+// removeLocked 将模型移出缓存，空闲时加入待关闭列表，否则在最后一个请求释放时关闭
+func (m *Manager) removeLocked(e *entry, closing []*entry) []*entry {
+	if e.removed {
+		return closing
+	}
+	e.removed = true
+	delete(m.entries, key(e.modelID, e.version))
+	m.lru.Remove(e.elem)
+	m.used -= e.memory
+	if e.inFlight == 0 {
+		closing = append(closing, e)
+	}
+	return closing
+}
+
+// closeAll 在锁外关闭模型，避免慢速释放阻塞其他请求
+func closeAll(entries []*entry) {
+	for _, e := range entries {
+		if err := e.model.Close(); err != nil {
+			log.Printf("卸载模型%s版本%s失败: %v", e.modelID, e.version, err)
+		}
+	}
+}
+
+func key(modelID, version string) string {
+	return modelID + "@" + version
+}
@@ -0,0 +1,216 @@
+package inference
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+type fakeModel struct {
+	version string
+	memory  int64
+	block   chan struct{} // 非nil时推理阻塞直到关闭
+	closed  int32
+}
+
+func (f *fakeModel) Predict(ctx context.Context, image []byte) ([]Prediction, error) {
+	if f.block != nil {
+		<-f.block
+	}
+	if atomic.LoadInt32(&f.closed) == 1 {
+		return nil, fmt.Errorf("模型已关闭")
+	}
+	return []Prediction{{Label: f.version, Confidence: 1}}, nil
+}
+
+func (f *fakeModel) MemoryBytes() int64 { return f.memory }
+
+func (f *fakeModel) Close() error {
+	atomic.StoreInt32(&f.closed, 1)
+	return nil
+}
+
+type fakeLoader struct {
+	mu     sync.Mutex
+	loads  map[string]int
+	models map[string]*fakeModel
+	block  map[string]chan struct{}
+}
+
+func newFakeLoader() *fakeLoader {
+	return &fakeLoader{loads: map[string]int{}, models: map[string]*fakeModel{}, block: map[string]chan struct{}{}}
+}
+
+func (l *fakeLoader) Load(ctx context.Context, manifest *model.ArtifactManifest, path string) (LoadedModel, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if manifest.Version == "broken" {
+		return nil, fmt.Errorf("格式错误")
+	}
+	l.loads[manifest.Version]++
+	m := &fakeModel{version: manifest.Version, memory: 40, block: l.block[manifest.Version]}
+	l.models[manifest.Version] = m
+	return m, nil
+}
+
+func (l *fakeLoader) model(version string) *fakeModel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.models[version]
+}
+
+type fakeArtifacts struct{}
+
+func (fakeArtifacts) Verify(ctx context.Context, modelID, version string) (*model.ArtifactManifest, error) {
+	if version == "missing" {
+		return nil, fmt.Errorf("文件不存在")
+	}
+	return &model.ArtifactManifest{ModelID: modelID, Version: version, FileName: "model.onnx", Size: 10}, nil
+}
+
+func (fakeArtifacts) ArtifactPath(manifest *model.ArtifactManifest) string {
+	return manifest.ModelID + "/" + manifest.Version + "/" + manifest.FileName
+}
+
+func TestManagerLoadsOnce(t *testing.T) {
+	loader := newFakeLoader()
+	m := NewManager(loader, fakeArtifacts{}, 100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			preds, err := m.Predict(context.Background(), "m1", "v1", nil)
+			assert.NoError(t, err)
+			assert.Equal(t, "v1", preds[0].Label)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, loader.loads["v1"])
+
+	status := m.Status()
+	require.Len(t, status.Models, 1)
+	assert.Equal(t, int64(20), status.Models[0].Requests)
+	assert.Equal(t, int64(40), status.UsedBytes)
+
+	_, err := m.Predict(context.Background(), "m1", "missing", nil)
+	assert.Error(t, err)
+	_, err = m.Predict(context.Background(), "m1", "broken", nil)
+	assert.Error(t, err)
+	assert.Len(t, m.Status().Models, 1, "加载失败的版本不保留")
+}
+
+func TestManagerEvictsLeastRecentlyUsed(t *testing.T) {
+	loader := newFakeLoader()
+	m := NewManager(loader, fakeArtifacts{}, 100)
+	ctx := context.Background()
+
+	require.NoError(t, m.Prewarm(ctx, "m1", "v1"))
+	require.NoError(t, m.Prewarm(ctx, "m1", "v2"))
+	_, err := m.Predict(ctx, "m1", "v1", nil) // v1变为最近使用
+	require.NoError(t, err)
+	require.NoError(t, m.Prewarm(ctx, "m1", "v3"))
+
+	status := m.Status()
+	assert.Len(t, status.Models, 2)
+	assert.Equal(t, int64(80), status.UsedBytes)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loader.model("v2").closed))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&loader.model("v1").closed))
+}
+
+func TestManagerKeepsInFlightModels(t *testing.T) {
+	loader := newFakeLoader()
+	loader.block["v1"] = make(chan struct{})
+	m := NewManager(loader, fakeArtifacts{}, 50)
+	ctx := context.Background()
+
+	done := make(chan error)
+	go func() {
+		_, err := m.Predict(ctx, "m1", "v1", nil)
+		done <- err
+	}()
+	require.Eventually(t, func() bool {
+		s := m.Status()
+		return len(s.Models) == 1 && s.Models[0].InFlight == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// 内存不足但v1正在处理请求，不能淘汰
+	require.NoError(t, m.Prewarm(ctx, "m1", "v2"))
+	assert.Len(t, m.Status().Models, 2)
+
+	// 卸载正在使用的模型，请求完成后才关闭
+	assert.True(t, m.Unload("m1", "v1"))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&loader.model("v1").closed))
+	close(loader.block["v1"])
+	require.NoError(t, <-done)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loader.model("v1").closed))
+}
+
+func TestManagerSwap(t *testing.T) {
+	loader := newFakeLoader()
+	m := NewManager(loader, fakeArtifacts{}, 1000)
+	m.grace = 0
+	ctx := context.Background()
+
+	require.NoError(t, m.Prewarm(ctx, "m1", "v1"))
+	require.NoError(t, m.Swap(ctx, "m1", "v2", "v1"))
+	assert.Equal(t, 1, loader.loads["v2"], "新版本上线时预先加载")
+
+	// 保留期结束后的下一次释放卸载旧版本
+	_, err := m.Predict(ctx, "m1", "v2", nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&loader.model("v1").closed))
+	require.Len(t, m.Status().Models, 1)
+	assert.Equal(t, "v2", m.Status().Models[0].Version)
+}
+
+type productionVersions map[string]string
+
+func (p productionVersions) GetProductionVersion(ctx context.Context, modelID string) (*model.ModelVersion, error) {
+	v, ok := p[modelID]
+	if !ok {
+		return nil, nil
+	}
+	return &model.ModelVersion{ModelID: modelID, Version: v}, nil
+}
+
+func TestPrewarmDefault(t *testing.T) {
+	loader := newFakeLoader()
+	m := NewManager(loader, fakeArtifacts{}, 0)
+	ctx := context.Background()
+	versions := productionVersions{"m1": "v3"}
+
+	require.NoError(t, m.PrewarmDefault(ctx, "m1", versions))
+	require.NoError(t, m.PrewarmDefault(ctx, "m2@v1", versions))
+	assert.Equal(t, 1, loader.loads["v3"])
+	assert.Equal(t, 1, loader.loads["v1"])
+	assert.Error(t, m.PrewarmDefault(ctx, "m3", versions))
+	assert.Equal(t, int64(DefaultMaxMemory), m.Status().MaxBytes)
+}
+
+func TestFormatLoader(t *testing.T) {
+	loader := newFakeLoader()
+	RegisterLoader("test-format", loader)
+	defer func() {
+		loadersMu.Lock()
+		delete(loaders, "test-format")
+		loadersMu.Unlock()
+	}()
+
+	_, err := FormatLoader{}.Load(context.Background(), &model.ArtifactManifest{Version: "v1", Format: "unknown"}, "")
+	assert.Error(t, err)
+
+	loaded, err := FormatLoader{}.Load(context.Background(), &model.ArtifactManifest{Version: "v1", Format: "test-format"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, loader.loads["v1"])
+	assert.Equal(t, int64(40), loaded.MemoryBytes())
+}
@@ -0,0 +1,27 @@
+package inference
+
+import (
+	"context"
+	"sort"
+)
+
+// Prediction 单个类别的预测结果
+type Prediction struct {
+	Label      string  `json:"label"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Predictor 模型推理接口，返回按置信度降序排列的预测结果
+type Predictor interface {
+	Predict(ctx context.Context, modelID, modelVersion string, image []byte) ([]Prediction, error)
+}
+
+// SortPredictions 按置信度降序排列预测结果，置信度相同时按标签排序保证结果稳定
+func SortPredictions(predictions []Prediction) {
+	sort.SliceStable(predictions, func(i, j int) bool {
+		if predictions[i].Confidence != predictions[j].Confidence {
+			return predictions[i].Confidence > predictions[j].Confidence
+		}
+		return predictions[i].Label < predictions[j].Label
+	})
+}
@@ -103,7 +103,8 @@ func handleJWTAuth(c *gin.Context) {
 	}
 
 	// 验证令牌
-	if claims, ok := token.Claims.(*auth.JWTClaims); ok && token.Valid {
+	// 客户门户令牌和客户端访问令牌使用同一密钥签发并带有受众，不能用于访问管理端
+	if claims, ok := token.Claims.(*auth.JWTClaims); ok && token.Valid && len(claims.Audience) == 0 {
 		// 将用户信息存储到上下文
 		c.Set("userId", claims.UserID)
 		c.Set("username", claims.Username)
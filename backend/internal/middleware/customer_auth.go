@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler/auth"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// CustomerAuth 客户门户认证中间件，校验客户令牌并将客户ID存入上下文的customerId
+// 令牌有效期内被禁用或删除的客户同样拒绝访问
+func CustomerAuth(secret string, customers model.CustomerRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "未提供认证令牌",
+				"data":    nil,
+			})
+			return
+		}
+
+		claims, err := auth.ParseCustomerToken(secret, parts[1])
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "无效的认证令牌",
+				"data":    nil,
+			})
+			return
+		}
+
+		customer, err := customers.FindByID(claims.CustomerID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "获取客户信息失败",
+				"data":    nil,
+			})
+			return
+		}
+		if customer == nil || customer.Status != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "账号不存在或已被禁用",
+				"data":    nil,
+			})
+			return
+		}
+
+		c.Set("customerId", claims.CustomerID)
+		c.Set("username", claims.Username)
+		c.Next()
+	}
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// API密钥的访问范围
+const (
+	ScopeRecognitionWrite = "recognition:write" // 提交识别请求
+	ScopeHistoryRead      = "history:read"      // 查询识别历史和识别结果
+	ScopeFeedbackWrite    = "feedback:write"    // 提交识别结果反馈
+)
+
+// ScopeNames 访问范围的显示名称
+var ScopeNames = map[string]string{
+	ScopeRecognitionWrite: "提交识别请求",
+	ScopeHistoryRead:      "查询识别历史",
+	ScopeFeedbackWrite:    "提交识别反馈",
+}
+
+// API密钥状态
+const (
+	APIKeyActive  = "active"
+	APIKeyRevoked = "revoked"
+)
+
+// APIKey 应用下的API密钥，以密钥的SHA-256摘要认证，签名密钥加密保存，明文只在创建时返回一次
+type APIKey struct {
+	ID         string     `json:"id" bson:"_id,omitempty"`
+	CustomerID int64      `json:"customerId" bson:"customer_id"`
+	AppID      string     `json:"appId" bson:"app_id"`
+	Name       string     `json:"name" bson:"name"`
+	KeyHash    string     `json:"-" bson:"key_hash"`
+	Prefix     string     `json:"prefix" bson:"prefix"` // 密钥明文的前几位，便于客户辨认
+	Scopes     []string   `json:"scopes" bson:"scopes"`
+	AllowedIPs []string   `json:"allowedIps" bson:"allowed_ips"` // 允许的IP或CIDR，为空时不限制
+	Status     string     `json:"status" bson:"status"`
+	ExpireAt   *time.Time `json:"expireAt,omitempty" bson:"expire_at,omitempty"` // 为空时永不过期
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" bson:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"lastUsedIp,omitempty" bson:"last_used_ip,omitempty"`
+	// 加密保存的密钥明文，用于验证请求签名；key_hash不能用于签名，为空时该密钥不支持签名认证
+	SigningSecret string `json:"-" bson:"signing_secret,omitempty"`
+	// 轮换信息：旧密钥记录ReplacedBy，新密钥记录RotatedFrom，两者的OverlapUntil相同，
+	// 旧密钥在OverlapUntil之后失效
+	ReplacedBy   string     `json:"replacedBy,omitempty" bson:"replaced_by,omitempty"`
+	RotatedFrom  string     `json:"rotatedFrom,omitempty" bson:"rotated_from,omitempty"`
+	OverlapUntil *time.Time `json:"overlapUntil,omitempty" bson:"overlap_until,omitempty"`
+	CreateTime   time.Time  `json:"createTime" bson:"create_time"`
+	UpdateTime   time.Time  `json:"updateTime" bson:"update_time"`
+}
+
+// Expired 密钥在now时是否已过期
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpireAt != nil && !now.Before(*k.ExpireAt)
+}
+
+// Retired 密钥是否已被轮换且宽限期已过
+func (k *APIKey) Retired(now time.Time) bool {
+	return k.ReplacedBy != "" && k.OverlapUntil != nil && !now.Before(*k.OverlapUntil)
+}
+
+// InOverlap 密钥是否处于新旧密钥同时有效的轮换重叠期
+func (k *APIKey) InOverlap(now time.Time) bool {
+	return k.OverlapUntil != nil && now.Before(*k.OverlapUntil)
+}
+
+// HasScope 密钥是否包含指定访问范围
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIP 密钥是否允许来自ip的请求，未配置IP范围时不限制
+func (k *APIKey) AllowsIP(ip string) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, allowed := range k.AllowedIPs {
+		if strings.Contains(allowed, "/") {
+			if _, network, err := net.ParseCIDR(allowed); err == nil && network.Contains(addr) {
+				return true
+			}
+			continue
+		}
+		if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateScopes 校验访问范围，至少包含一个且都已登记
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("至少需要一个访问范围")
+	}
+	for _, scope := range scopes {
+		if _, ok := ScopeNames[scope]; !ok {
+			return fmt.Errorf("未知的访问范围: %s", scope)
+		}
+	}
+	return nil
+}
+
+// ValidateIPRanges 校验IP或CIDR列表
+func ValidateIPRanges(ranges []string) error {
+	for _, r := range ranges {
+		if strings.Contains(r, "/") {
+			if _, _, err := net.ParseCIDR(r); err != nil {
+				return fmt.Errorf("无效的CIDR: %s", r)
+			}
+			continue
+		}
+		if net.ParseIP(r) == nil {
+			return fmt.Errorf("无效的IP地址: %s", r)
+		}
+	}
+	return nil
+}
+
+// APIKeyRepository API密钥数据访问接口
+type APIKeyRepository interface {
+	// 创建密钥
+	Create(key *APIKey) error
+	// 根据ID获取密钥，不存在时返回nil
+	FindByID(id string) (*APIKey, error)
+	// 根据密钥摘要获取密钥，不存在时返回nil
+	FindByHash(hash string) (*APIKey, error)
+	// 获取应用的全部密钥
+	ListByApp(appID string) ([]*APIKey, error)
+	// 吊销客户的密钥，返回是否吊销成功
+	Revoke(id string, customerID int64) (bool, error)
+	// 将客户的有效密钥标记为已被replacedBy轮换，旧密钥在overlapUntil之后失效
+	// 密钥已吊销或已被轮换时返回false
+	MarkRotated(id string, customerID int64, replacedBy string, overlapUntil time.Time) (bool, error)
+	// 记录密钥最近一次使用的时间和来源IP
+	TouchLastUsed(id, ip string, at time.Time) error
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+)
+
+// 应用状态
+const (
+	ApplicationActive   = "active"
+	ApplicationDisabled = "disabled" // 停用后该应用的请求全部拒绝
+)
+
+// Application 客户名下的应用，客户端请求通过X-App-ID标识所属应用
+type Application struct {
+	ID          string    `json:"id" bson:"_id,omitempty"`
+	CustomerID  int64     `json:"customerId" bson:"customer_id"`
+	AppID       string    `json:"appId" bson:"app_id"`
+	Name        string    `json:"name" bson:"name"`
+	Description string    `json:"description" bson:"description"`
+	Status      string    `json:"status" bson:"status"`
+	CreateTime  time.Time `json:"createTime" bson:"create_time"`
+	UpdateTime  time.Time `json:"updateTime" bson:"update_time"`
+}
+
+// ApplicationRepository 应用数据访问接口
+type ApplicationRepository interface {
+	// 创建应用
+	Create(app *Application) error
+	// 更新应用的名称、描述和状态
+	Update(app *Application) error
+	// 删除客户的应用，返回是否删除成功
+	Delete(id string, customerID int64) (bool, error)
+	// 根据ID获取应用，不存在时返回nil
+	FindByID(id string) (*Application, error)
+	// 根据AppID获取应用，不存在时返回nil
+	FindByAppID(appID string) (*Application, error)
+	// 获取客户的全部应用
+	ListByCustomer(customerID int64) ([]*Application, error)
+}
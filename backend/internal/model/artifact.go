@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+)
+
+// 模型文件上传状态
+const (
+	ArtifactUploadUploading = "uploading"
+	ArtifactUploadCompleted = "completed"
+	ArtifactUploadAborted   = "aborted"
+)
+
+// ArtifactManifest 模型文件清单，与模型文件存放在同一目录，由服务端签名防止篡改
+type ArtifactManifest struct {
+	ModelID    string            `json:"modelId"`
+	Version    string            `json:"version"`
+	FileName   string            `json:"fileName"`
+	Format     string            `json:"format"` // onnx、pt、savedmodel等
+	Size       int64             `json:"size"`
+	SHA256     string            `json:"sha256"`
+	InputShape []int             `json:"inputShape"`
+	LabelMap   map[string]string `json:"labelMap"` // 输出下标到标签的映射
+	UploadedBy int64             `json:"uploadedBy"`
+	CreateTime time.Time         `json:"createTime"`
+	Signature  string            `json:"signature"` // HMAC-SHA256签名，覆盖除签名外的全部字段
+}
+
+// ArtifactUpload 分块上传会话
+type ArtifactUpload struct {
+	ID          string            `json:"id" bson:"_id,omitempty"`
+	ModelID     string            `json:"modelId" bson:"model_id"`
+	Version     string            `json:"version" bson:"version"`
+	FileName    string            `json:"fileName" bson:"file_name"`
+	Format      string            `json:"format" bson:"format"`
+	TotalSize   int64             `json:"totalSize" bson:"total_size"`
+	ChunkSize   int64             `json:"chunkSize" bson:"chunk_size"`
+	TotalChunks int               `json:"totalChunks" bson:"total_chunks"`
+	Received    []int             `json:"received" bson:"received"` // 已接收的分块序号
+	InputShape  []int             `json:"inputShape" bson:"input_shape"`
+	LabelMap    map[string]string `json:"labelMap" bson:"label_map"`
+	Status      string            `json:"status" bson:"status"`
+	UserID      int64             `json:"userId" bson:"user_id"`
+	CreateTime  time.Time         `json:"createTime" bson:"create_time"`
+	UpdateTime  time.Time         `json:"updateTime" bson:"update_time"`
+}
+
+// ArtifactUploadRepository 分块上传会话数据访问接口
+type ArtifactUploadRepository interface {
+	// 创建上传会话
+	Create(upload *ArtifactUpload) error
+	// 根据ID获取上传会话，不存在时返回nil
+	FindByID(id string) (*ArtifactUpload, error)
+	// 记录已接收的分块
+	AddChunk(id string, index int) error
+	// 仅当会话处于from状态时更新为to，返回是否更新成功
+	TransitionStatus(id, from, to string) (bool, error)
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"time"
+)
+
+// 账单明细类型
+const (
+	InvoiceLineBase      = "base"       // 套餐月费，按订阅时长折算
+	InvoiceLineOverage   = "overage"    // 超出套餐额度的请求，按阶梯计价
+	InvoiceLineModelType = "model_type" // 按模型类型收取的识别附加费
+	InvoiceLineMegapixel = "megapixel"  // 按图片百万像素数计费
+)
+
+// 账单状态
+const (
+	InvoiceDraft  = "draft"  // 草稿，可以重新生成
+	InvoiceIssued = "issued" // 已开具，不再变更
+)
+
+// UsageEvent 一次可计费的识别请求
+type UsageEvent struct {
+	ID            string    `json:"id" bson:"_id,omitempty"`
+	CustomerID    int64     `json:"customerId" bson:"customer_id"`
+	RecognitionID string    `json:"recognitionId" bson:"recognition_id"`
+	ModelID       string    `json:"modelId" bson:"model_id"`
+	ModelType     string    `json:"modelType" bson:"model_type"`
+	Megapixels    float64   `json:"megapixels" bson:"megapixels"` // 无法解码图片尺寸时为0
+	CreateTime    time.Time `json:"createTime" bson:"create_time"`
+}
+
+// UsageSummary 按模型类型汇总的用量
+type UsageSummary struct {
+	ModelType  string  `json:"modelType" bson:"_id"`
+	Requests   int64   `json:"requests" bson:"requests"`
+	Megapixels float64 `json:"megapixels" bson:"megapixels"`
+}
+
+// DailyUsage 按天和模型类型汇总的用量
+type DailyUsage struct {
+	Date       string  `json:"date" bson:"date"` // 如2024-05-01
+	ModelType  string  `json:"modelType" bson:"model_type"`
+	Requests   int64   `json:"requests" bson:"requests"`
+	Megapixels float64 `json:"megapixels" bson:"megapixels"`
+}
+
+// PriceTier 超额请求的阶梯价格
+type PriceTier struct {
+	UpTo      int64   `json:"upTo" bson:"up_to"`           // 本档覆盖的累计超额请求数上限，0表示不限
+	UnitPrice float64 `json:"unitPrice" bson:"unit_price"` // 每次请求的价格
+}
+
+// PlanPricing 服务套餐的用量计价规则，月费和包含的请求数取自ServicePlan
+type PlanPricing struct {
+	PlanID          int64              `json:"planId" bson:"plan_id"`
+	Currency        string             `json:"currency" bson:"currency"`
+	OverageTiers    []PriceTier        `json:"overageTiers" bson:"overage_tiers"`        // 按UpTo升序
+	ModelTypePrices map[string]float64 `json:"modelTypePrices" bson:"model_type_prices"` // 模型类型到每次识别附加费
+	MegapixelPrice  float64            `json:"megapixelPrice" bson:"megapixel_price"`    // 每百万像素的价格
+	UpdateTime      time.Time          `json:"updateTime" bson:"update_time"`
+}
+
+// PlanChange 客户套餐变更记录，用于按订阅时长折算月费
+type PlanChange struct {
+	ID             string    `json:"id" bson:"_id,omitempty"`
+	CustomerID     int64     `json:"customerId" bson:"customer_id"`
+	PreviousPlanID int64     `json:"previousPlanId" bson:"previous_plan_id"` // 变更前的套餐，0表示未订阅
+	PlanID         int64     `json:"planId" bson:"plan_id"`                  // 0表示取消订阅
+	EffectiveAt    time.Time `json:"effectiveAt" bson:"effective_at"`
+	CreateTime     time.Time `json:"createTime" bson:"create_time"`
+}
+
+// InvoiceLine 账单明细
+type InvoiceLine struct {
+	Kind        string    `json:"kind" bson:"kind"`
+	Description string    `json:"description" bson:"description"`
+	PlanID      int64     `json:"planId" bson:"plan_id"`
+	PeriodStart time.Time `json:"periodStart" bson:"period_start"`
+	PeriodEnd   time.Time `json:"periodEnd" bson:"period_end"`
+	Quantity    float64   `json:"quantity" bson:"quantity"`
+	UnitPrice   float64   `json:"unitPrice" bson:"unit_price"`
+	Amount      float64   `json:"amount" bson:"amount"`
+}
+
+// Invoice 客户月度账单
+type Invoice struct {
+	ID         string         `json:"id" bson:"_id,omitempty"`
+	CustomerID int64          `json:"customerId" bson:"customer_id"`
+	Month      time.Time      `json:"month" bson:"month"` // 账单月份的第一天
+	Currency   string         `json:"currency" bson:"currency"`
+	Lines      []*InvoiceLine `json:"lines" bson:"lines"`
+	Total      float64        `json:"total" bson:"total"`
+	Status     string         `json:"status" bson:"status"`
+	IssuedAt   *time.Time     `json:"issuedAt,omitempty" bson:"issued_at,omitempty"`
+	CreateTime time.Time      `json:"createTime" bson:"create_time"`
+	UpdateTime time.Time      `json:"updateTime" bson:"update_time"`
+}
+
+// UsageEventRepository 计费用量事件数据访问接口
+type UsageEventRepository interface {
+	// 记录用量事件
+	Create(event *UsageEvent) error
+	// 按模型类型汇总客户在[start, end)内的用量
+	Summarize(customerID int64, start, end time.Time) ([]*UsageSummary, error)
+	// 获取[start, end)内有用量的客户
+	Customers(start, end time.Time) ([]int64, error)
+	// 按天和模型类型汇总客户在[start, end)内的用量，日期按本地时区划分
+	Daily(customerID int64, start, end time.Time) ([]*DailyUsage, error)
+}
+
+// PlanPricingRepository 套餐计价规则数据访问接口
+type PlanPricingRepository interface {
+	// 保存套餐的计价规则，已存在时覆盖
+	Save(pricing *PlanPricing) error
+	// 获取套餐的计价规则，不存在时返回nil
+	FindByPlan(planID int64) (*PlanPricing, error)
+}
+
+// PlanChangeRepository 客户套餐变更记录数据访问接口
+type PlanChangeRepository interface {
+	// 记录套餐变更
+	Create(change *PlanChange) error
+	// 按生效时间升序获取客户在before之前生效的变更
+	ListByCustomer(customerID int64, before time.Time) ([]*PlanChange, error)
+	// 获取客户在after及之后生效的第一次变更，不存在时返回nil
+	FindFirstAfter(customerID int64, after time.Time) (*PlanChange, error)
+}
+
+// InvoiceRepository 账单数据访问接口
+type InvoiceRepository interface {
+	// 保存草稿账单，覆盖同一客户同一月份的草稿；该月账单已开具时返回false
+	SaveDraft(invoice *Invoice) (bool, error)
+	// 根据ID获取账单，不存在时返回nil
+	FindByID(id string) (*Invoice, error)
+	// 分页获取账单，customerID为0、month为零值、status为空时不过滤
+	List(customerID int64, month time.Time, status string, page, size int) ([]*Invoice, int64, error)
+	// 将草稿账单标记为已开具，返回是否更新成功
+	Issue(id string) (bool, error)
+}
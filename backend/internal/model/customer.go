@@ -22,7 +22,10 @@ type ServicePlan struct {
 	Price        float64   `json:"price" db:"price"`
 	RequestLimit int64     `json:"requestLimit" db:"request_limit"` // 每月请求次数限制
 	Concurrent   int       `json:"concurrent" db:"concurrent"`      // 并发请求数限制
-	Features     []string  `json:"features" db:"features"`        // 支持的特性
+	RateLimit    int       `json:"rateLimit" db:"rate_limit"`       // 每分钟请求数限制，0表示使用API密钥的配置
+	RateBurst    int       `json:"rateBurst" db:"rate_burst"`       // 令牌桶容量，允许的突发请求数，0表示等于RateLimit
+	RateAlgorithm string   `json:"rateAlgorithm" db:"rate_algorithm"` // 限流算法：token_bucket或sliding_window，为空时使用令牌桶
+	Features     StringList `json:"features" db:"features"`        // 支持的特性，见Feature常量
 	CreateTime   time.Time `json:"createTime" db:"create_time"`
 	UpdateTime   time.Time `json:"updateTime" db:"update_time"`
 }
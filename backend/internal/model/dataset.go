@@ -0,0 +1,151 @@
+package model
+
+import (
+	"time"
+)
+
+// DatasetSample 数据集样本
+type DatasetSample struct {
+	ID          string       `json:"id" bson:"_id,omitempty"`
+	DatasetID   string       `json:"datasetId" bson:"dataset_id"`
+	FileName    string       `json:"fileName" bson:"file_name"`                     // 原始文件名
+	FilePath    string       `json:"filePath" bson:"file_path"`                     // 存储路径
+	FileSize    int64        `json:"fileSize" bson:"file_size"`                     // 文件大小(bytes)
+	Width       int          `json:"width" bson:"width"`                            // 图像宽度(像素)，未知时为0
+	Height      int          `json:"height" bson:"height"`                          // 图像高度(像素)，未知时为0
+	Labels      []string     `json:"labels" bson:"labels"`                          // 样本标签
+	Annotations []Annotation `json:"annotations" bson:"annotations"`                // 目标标注
+	SourceID    string       `json:"sourceId,omitempty" bson:"source_id,omitempty"` // 派生样本对应的父数据集样本ID
+	UserID      int64        `json:"userId" bson:"user_id"`                         // 上传者ID
+	CreateTime  time.Time    `json:"createTime" bson:"create_time"`
+	UpdateTime  time.Time    `json:"updateTime" bson:"update_time"`
+}
+
+// 数据集类型
+const (
+	DatasetTypeTrain      = "train"      // 训练集
+	DatasetTypeValidation = "validation" // 验证集
+	DatasetTypeTest       = "test"       // 测试集
+)
+
+// SplitRatios 训练/验证/测试集拆分比例，三者之和为1
+type SplitRatios struct {
+	Train      float64 `json:"train" bson:"train"`
+	Validation float64 `json:"validation" bson:"validation"`
+	Test       float64 `json:"test" bson:"test"`
+}
+
+// DatasetLineage 派生数据集血缘信息
+type DatasetLineage struct {
+	ParentID   string      `json:"parentId" bson:"parent_id"`    // 源数据集ID
+	Split      string      `json:"split" bson:"split"`           // 所属拆分：train/validation/test
+	Seed       int64       `json:"seed" bson:"seed"`             // 随机种子，相同种子和样本可复现拆分
+	Ratios     SplitRatios `json:"ratios" bson:"ratios"`         // 拆分比例
+	Stratified bool        `json:"stratified" bson:"stratified"` // 是否按类别分层
+	SplitTime  time.Time   `json:"splitTime" bson:"split_time"`
+}
+
+// BoundingBox 目标框，单位为像素，(X, Y)为左上角坐标
+type BoundingBox struct {
+	X      float64 `json:"x" bson:"x"`
+	Y      float64 `json:"y" bson:"y"`
+	Width  float64 `json:"width" bson:"width"`
+	Height float64 `json:"height" bson:"height"`
+}
+
+// 标注审核状态，导入的标注没有审核状态，视为已通过
+const (
+	ReviewPending  = "pending"  // 待审核
+	ReviewApproved = "approved" // 已通过
+	ReviewRejected = "rejected" // 已驳回
+)
+
+// Annotation 样本标注，BBox为空时表示图像级分类标签
+type Annotation struct {
+	ID            string       `json:"id" bson:"id"`
+	Label         string       `json:"label" bson:"label"`
+	BBox          *BoundingBox `json:"bbox,omitempty" bson:"bbox,omitempty"`
+	AnnotatorID   int64        `json:"annotatorId,omitempty" bson:"annotator_id,omitempty"` // 标注人ID
+	Annotator     string       `json:"annotator,omitempty" bson:"annotator,omitempty"`      // 标注人用户名
+	Status        string       `json:"status,omitempty" bson:"status,omitempty"`            // 审核状态
+	ReviewerID    int64        `json:"reviewerId,omitempty" bson:"reviewer_id,omitempty"`   // 审核人ID
+	ReviewComment string       `json:"reviewComment,omitempty" bson:"review_comment,omitempty"`
+	ReviewTime    *time.Time   `json:"reviewTime,omitempty" bson:"review_time,omitempty"`
+	CreateTime    *time.Time   `json:"createTime,omitempty" bson:"create_time,omitempty"`
+	UpdateTime    *time.Time   `json:"updateTime,omitempty" bson:"update_time,omitempty"`
+}
+
+// AnnotatorPairAgreement 两名标注人之间的一致性
+type AnnotatorPairAgreement struct {
+	AnnotatorA    string  `json:"annotatorA"`
+	AnnotatorB    string  `json:"annotatorB"`
+	SharedSamples int     `json:"sharedSamples"` // 两人都标注过的样本数
+	Agreement     float64 `json:"agreement"`     // 标签集合完全一致的比例
+	Kappa         float64 `json:"kappa"`         // Cohen's kappa，按标签集合计算
+	MeanIoU       float64 `json:"meanIoU"`       // 同类别目标框匹配的平均IoU，无目标框时为0
+	MatchedBoxes  int     `json:"matchedBoxes"`
+}
+
+// SampleDisagreement 标注人之间存在分歧的样本
+type SampleDisagreement struct {
+	SampleID string              `json:"sampleId"`
+	FileName string              `json:"fileName"`
+	Labels   map[string][]string `json:"labels"` // 标注人 -> 标签集合
+}
+
+// AnnotationAgreementReport 数据集标注一致性报告
+type AnnotationAgreementReport struct {
+	DatasetID         string                   `json:"datasetId"`
+	AnnotatedSamples  int                      `json:"annotatedSamples"`  // 有人工标注的样本数
+	MultiRaterSamples int                      `json:"multiRaterSamples"` // 两名及以上标注人的样本数
+	Annotators        int                      `json:"annotators"`        // 标注人数
+	StatusCount       map[string]int           `json:"statusCount"`       // 按审核状态统计标注数
+	OverallAgreement  float64                  `json:"overallAgreement"`  // 按共同样本数加权的平均一致率
+	OverallKappa      float64                  `json:"overallKappa"`      // 按共同样本数加权的平均kappa
+	Pairs             []AnnotatorPairAgreement `json:"pairs"`
+	Disagreements     []SampleDisagreement     `json:"disagreements"` // 最多返回前100个
+}
+
+// LabelCount 标签样本数
+type LabelCount struct {
+	Label string  `json:"label"`
+	Count int64   `json:"count"`
+	Ratio float64 `json:"ratio"` // 占已标注样本的比例
+}
+
+// DatasetLabelStats 数据集标签分布及类别不平衡统计
+type DatasetLabelStats struct {
+	DatasetID         string       `json:"datasetId"`
+	TotalSamples      int64        `json:"totalSamples"`
+	UnlabeledSamples  int64        `json:"unlabeledSamples"`
+	LabelCount        int          `json:"labelCount"`        // 类别数
+	Labels            []LabelCount `json:"labels"`            // 按样本数降序
+	MajorityLabel     string       `json:"majorityLabel"`     // 样本最多的类别
+	MinorityLabel     string       `json:"minorityLabel"`     // 样本最少的类别
+	ImbalanceRatio    float64      `json:"imbalanceRatio"`    // 最多类别与最少类别样本数之比
+	NormalizedEntropy float64      `json:"normalizedEntropy"` // 归一化熵，1表示完全均衡
+}
+
+// DatasetSampleRepository 数据集样本数据访问接口
+type DatasetSampleRepository interface {
+	// 创建样本
+	Create(sample *DatasetSample) (string, error)
+	// 更新样本
+	Update(sample *DatasetSample) error
+	// 删除样本
+	Delete(id string) error
+	// 删除数据集下所有样本
+	DeleteByDataset(datasetID string) error
+	// 获取样本列表，label为空时不按标签过滤
+	List(datasetID, label string, page, size int) ([]*DatasetSample, int64, error)
+	// 获取数据集全部样本
+	ListAll(datasetID string) ([]*DatasetSample, error)
+	// 根据ID查找样本
+	FindByID(id string) (*DatasetSample, error)
+	// 统计数据集样本数
+	Count(datasetID string) (int64, error)
+	// 按标签统计样本数，未标注样本计入空字符串
+	CountByLabel(datasetID string) (map[string]int64, error)
+	// 更新样本标注和标签，仅当样本自expected之后未被修改时生效，返回是否更新成功
+	UpdateAnnotations(id string, expected time.Time, annotations []Annotation, labels []string) (bool, error)
+}
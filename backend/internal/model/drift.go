@@ -0,0 +1,106 @@
+package model
+
+import (
+	"time"
+)
+
+// 漂移检测的特征
+const (
+	DriftFeatureCategory    = "category"     // 预测类别
+	DriftFeatureConfidence  = "confidence"   // top-1置信度
+	DriftFeatureImageSize   = "image_size"   // 图片字节数
+	DriftFeatureBrightness  = "brightness"   // 平均亮度
+	DriftFeatureAspectRatio = "aspect_ratio" // 宽高比
+)
+
+// 漂移程度
+const (
+	DriftSeverityNone    = "none"
+	DriftSeverityWarning = "warning" // 超过预警阈值
+	DriftSeverityAlert   = "alert"   // 超过告警阈值
+)
+
+// 版本漂移检测结果
+const (
+	DriftStatusOK               = "ok"
+	DriftStatusDrift            = "drift"
+	DriftStatusInsufficientData = "insufficient_data" // 基线或当前窗口样本不足
+)
+
+// 漂移告警状态
+const (
+	DriftAlertOpen         = "open"
+	DriftAlertAcknowledged = "acknowledged"
+	DriftAlertResolved     = "resolved" // 后续检测恢复正常后自动关闭
+)
+
+// DriftBin 漂移检测的分箱计数，由识别记录按版本、时间窗口和特征在数据库中汇总
+type DriftBin struct {
+	ModelVersion string `bson:"model_version"`
+	Current      bool   `bson:"current"`  // 属于当前窗口，否则属于基线窗口
+	Feature      string `bson:"feature"`  // 为空时Count为样本数
+	Category     string `bson:"category"` // 类别特征的类别
+	Bin          int    `bson:"bin"`      // 数值特征的分箱序号，-1表示缺少该特征
+	Count        int64  `bson:"count"`
+}
+
+// FeatureDrift 单个特征在基线窗口与当前窗口之间的分布差异
+type FeatureDrift struct {
+	Feature       string    `json:"feature"`
+	PSI           float64   `json:"psi"`
+	KL            float64   `json:"kl"` // KL(当前||基线)
+	Severity      string    `json:"severity"`
+	Bins          []string  `json:"bins"`
+	BaselineShare []float64 `json:"baselineShare"`
+	CurrentShare  []float64 `json:"currentShare"`
+	Missing       int64     `json:"missing"` // 当前窗口缺少该特征的样本数，如无法解码的图片
+}
+
+// VersionDrift 单个模型版本的漂移检测结果
+type VersionDrift struct {
+	ModelVersion    string          `json:"modelVersion"`
+	BaselineSamples int64           `json:"baselineSamples"`
+	CurrentSamples  int64           `json:"currentSamples"`
+	Status          string          `json:"status"`
+	Features        []*FeatureDrift `json:"features"`
+}
+
+// DriftReport 模型的漂移报告
+type DriftReport struct {
+	ModelID       string          `json:"modelId"`
+	BaselineStart time.Time       `json:"baselineStart"`
+	BaselineEnd   time.Time       `json:"baselineEnd"`
+	CurrentStart  time.Time       `json:"currentStart"`
+	CurrentEnd    time.Time       `json:"currentEnd"`
+	Versions      []*VersionDrift `json:"versions"`
+}
+
+// DriftAlert 漂移告警，同一模型版本的同一特征同时只有一条未关闭的告警
+type DriftAlert struct {
+	ID             string    `json:"id" bson:"_id,omitempty"`
+	ModelID        string    `json:"modelId" bson:"model_id"`
+	ModelVersion   string    `json:"modelVersion" bson:"model_version"`
+	Feature        string    `json:"feature" bson:"feature"`
+	PSI            float64   `json:"psi" bson:"psi"`
+	KL             float64   `json:"kl" bson:"kl"`
+	Threshold      float64   `json:"threshold" bson:"threshold"` // 触发告警的PSI阈值
+	Message        string    `json:"message" bson:"message"`
+	Status         string    `json:"status" bson:"status"`
+	AcknowledgedBy int64     `json:"acknowledgedBy,omitempty" bson:"acknowledged_by,omitempty"`
+	CreateTime     time.Time `json:"createTime" bson:"create_time"`
+	UpdateTime     time.Time `json:"updateTime" bson:"update_time"`
+}
+
+// DriftAlertRepository 漂移告警数据访问接口
+type DriftAlertRepository interface {
+	// 创建告警
+	Create(alert *DriftAlert) error
+	// 获取模型版本特征未关闭(open或acknowledged)的告警，不存在时返回nil
+	FindActive(modelID, modelVersion, feature string) (*DriftAlert, error)
+	// 分页获取告警，modelID和status为空时不过滤
+	List(modelID, status string, page, size int) ([]*DriftAlert, int64, error)
+	// 更新告警的PSI、KL和说明
+	UpdateMetrics(id string, psi, kl float64, message string) error
+	// 仅当告警处于from状态时更新为to，返回是否更新成功
+	TransitionStatus(id, from, to string, userID int64) (bool, error)
+}
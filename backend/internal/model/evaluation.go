@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+)
+
+// 评估任务状态
+const (
+	EvaluationStatusPending   = "pending"   // 已提交，等待执行
+	EvaluationStatusRunning   = "running"   // 执行中
+	EvaluationStatusCompleted = "completed" // 已完成
+	EvaluationStatusFailed    = "failed"    // 失败
+)
+
+// ClassMetrics 单个类别的评估指标
+type ClassMetrics struct {
+	Label     string  `json:"label" bson:"label"`
+	Support   int64   `json:"support" bson:"support"`     // 真实标签为该类别的样本数
+	Predicted int64   `json:"predicted" bson:"predicted"` // 预测为该类别的样本数
+	Correct   int64   `json:"correct" bson:"correct"`     // 预测正确的样本数
+	Precision float64 `json:"precision" bson:"precision"`
+	Recall    float64 `json:"recall" bson:"recall"`
+	F1        float64 `json:"f1" bson:"f1"`
+}
+
+// CalibrationBin 置信度校准分箱
+type CalibrationBin struct {
+	Lower      float64 `json:"lower" bson:"lower"`
+	Upper      float64 `json:"upper" bson:"upper"`
+	Count      int64   `json:"count" bson:"count"`
+	Confidence float64 `json:"confidence" bson:"confidence"` // 箱内平均置信度
+	Accuracy   float64 `json:"accuracy" bson:"accuracy"`     // 箱内准确率
+}
+
+// ModelEvaluation 模型在测试数据集上的评估记录
+type ModelEvaluation struct {
+	ID              string           `json:"id" bson:"_id,omitempty"`
+	ModelID         string           `json:"modelId" bson:"model_id"`
+	ModelVersion    string           `json:"modelVersion" bson:"model_version"`
+	DatasetID       string           `json:"datasetId" bson:"dataset_id"`
+	TopK            int              `json:"topK" bson:"top_k"`
+	Status          string           `json:"status" bson:"status"`
+	TotalSamples    int64            `json:"totalSamples" bson:"total_samples"`        // 数据集样本数
+	Evaluated       int64            `json:"evaluated" bson:"evaluated"`               // 参与评估的样本数
+	Skipped         int64            `json:"skipped" bson:"skipped"`                   // 无标签或读取失败而跳过的样本数
+	Labels          []string         `json:"labels,omitempty" bson:"labels,omitempty"` // 混淆矩阵的行列顺序
+	ConfusionMatrix [][]int64        `json:"confusionMatrix,omitempty" bson:"confusion_matrix,omitempty"`
+	Classes         []ClassMetrics   `json:"classes,omitempty" bson:"classes,omitempty"`
+	Accuracy        float64          `json:"accuracy" bson:"accuracy"` // top-1准确率
+	TopKAccuracy    float64          `json:"topKAccuracy" bson:"top_k_accuracy"`
+	MacroF1         float64          `json:"macroF1" bson:"macro_f1"`
+	WeightedF1      float64          `json:"weightedF1" bson:"weighted_f1"`
+	ECE             float64          `json:"ece" bson:"ece"` // 期望校准误差
+	Calibration     []CalibrationBin `json:"calibration,omitempty" bson:"calibration,omitempty"`
+	ErrorMsg        string           `json:"errorMsg,omitempty" bson:"error_msg,omitempty"`
+	UserID          int64            `json:"userId" bson:"user_id"`
+	StartTime       *time.Time       `json:"startTime,omitempty" bson:"start_time,omitempty"`
+	EndTime         *time.Time       `json:"endTime,omitempty" bson:"end_time,omitempty"`
+	CreateTime      time.Time        `json:"createTime" bson:"create_time"`
+	UpdateTime      time.Time        `json:"updateTime" bson:"update_time"`
+}
+
+// ModelEvaluationRepository 模型评估记录数据访问接口
+type ModelEvaluationRepository interface {
+	// 创建评估记录
+	Create(evaluation *ModelEvaluation) (string, error)
+	// 更新评估记录
+	Update(evaluation *ModelEvaluation) error
+	// 根据ID查找评估记录
+	FindByID(id string) (*ModelEvaluation, error)
+	// 获取评估记录列表，modelID为空时不按模型过滤
+	List(modelID string, page, size int) ([]*ModelEvaluation, int64, error)
+	// 获取模型版本最近一次完成的评估，没有时返回nil
+	FindLatestCompleted(modelID, modelVersion string) (*ModelEvaluation, error)
+	// 将状态为from的记录更新为to，返回是否更新成功
+	TransitionStatus(id, from, to string) (bool, error)
+}
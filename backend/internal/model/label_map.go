@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+)
+
+// LabelEntry 标签映射中的一个标签
+// Parent为上级标签，构成层级分类体系，例如cat的上级为animal，animal的上级为pet
+type LabelEntry struct {
+	Name         string            `json:"name" bson:"name"`                                      // 模型输出的标签
+	Parent       string            `json:"parent,omitempty" bson:"parent,omitempty"`              // 上级标签，顶层标签为空
+	DisplayNames map[string]string `json:"displayNames,omitempty" bson:"display_names,omitempty"` // 按语言区域的显示名称，如zh-CN、en
+	Description  string            `json:"description,omitempty" bson:"description,omitempty"`
+}
+
+// LabelMap 模型的标签映射，每次修改生成新版本，历史版本只读
+type LabelMap struct {
+	ID         string       `json:"id" bson:"_id,omitempty"`
+	ModelID    string       `json:"modelId" bson:"model_id"`
+	Version    int          `json:"version" bson:"version"` // 同一模型从1开始递增
+	Labels     []LabelEntry `json:"labels" bson:"labels"`
+	Comment    string       `json:"comment,omitempty" bson:"comment,omitempty"` // 本次修改说明
+	UserID     int64        `json:"userId" bson:"user_id"`
+	CreateTime time.Time    `json:"createTime" bson:"create_time"`
+}
+
+// LabelMapRepository 标签映射数据访问接口
+type LabelMapRepository interface {
+	// 创建新版本，版本号为该模型当前最大版本号加1
+	Create(labelMap *LabelMap) error
+	// 获取模型最新版本，不存在时返回nil
+	FindLatest(modelID string) (*LabelMap, error)
+	// 获取模型指定版本，不存在时返回nil
+	FindByVersion(modelID string, version int) (*LabelMap, error)
+	// 获取模型全部版本，按版本号降序，不含标签列表
+	ListVersions(modelID string) ([]*LabelMap, error)
+}
@@ -6,17 +6,35 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// 模型版本状态
+const (
+	ModelVersionDevelopment     = "development"      // 开发中
+	ModelVersionTesting         = "testing"          // 测试中
+	ModelVersionPendingApproval = "pending_approval" // 已通过评估门槛，等待管理员审批
+	ModelVersionProduction      = "production"       // 生产版本，每个模型同时只有一个
+	ModelVersionArchived        = "archived"         // 已下线
+)
+
 // ModelVersion 模型版本信息
 type ModelVersion struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Version     string            `bson:"version" json:"version"`
-	Description string            `bson:"description" json:"description"`
-	ReleaseDate string            `bson:"releaseDate" json:"releaseDate"`
-	Status      string            `bson:"status" json:"status"` // development/testing/production
-	Accuracy    float64           `bson:"accuracy" json:"accuracy"`
-	Parameters  ModelParameters   `bson:"parameters" json:"parameters"`
-	CreateTime  time.Time         `bson:"createTime" json:"createTime"`
-	UpdateTime  time.Time         `bson:"updateTime" json:"updateTime"`
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ModelID           string             `bson:"modelId" json:"modelId"` // 所属模型
+	Version           string             `bson:"version" json:"version"`
+	Description       string             `bson:"description" json:"description"`
+	ReleaseDate       string             `bson:"releaseDate" json:"releaseDate"`
+	Status            string             `bson:"status" json:"status"` // development/testing/pending_approval/production/archived
+	Accuracy          float64            `bson:"accuracy" json:"accuracy"`
+	Parameters        ModelParameters    `bson:"parameters" json:"parameters"`
+	FilePath          string             `bson:"filePath,omitempty" json:"filePath,omitempty"`                   // 模型文件路径，上传完成后写入
+	Checksum          string             `bson:"checksum,omitempty" json:"checksum,omitempty"`                   // 模型文件SHA-256
+	ArtifactSize      int64              `bson:"artifactSize,omitempty" json:"artifactSize,omitempty"`           // 模型文件大小(字节)
+	EvaluationID      string             `bson:"evaluationId,omitempty" json:"evaluationId,omitempty"`           // 申请上线时依据的评估记录
+	RequestedBy       int64              `bson:"requestedBy,omitempty" json:"requestedBy,omitempty"`             // 申请上线的用户
+	ApprovedBy        int64              `bson:"approvedBy,omitempty" json:"approvedBy,omitempty"`               // 审批上线的管理员
+	PreviousVersionID string             `bson:"previousVersionId,omitempty" json:"previousVersionId,omitempty"` // 上线时被替换的生产版本，用于回滚
+	PromoteTime       *time.Time         `bson:"promoteTime,omitempty" json:"promoteTime,omitempty"`
+	CreateTime        time.Time          `bson:"createTime" json:"createTime"`
+	UpdateTime        time.Time          `bson:"updateTime" json:"updateTime"`
 }
 
 // ModelParameters 模型训练参数
@@ -27,23 +45,43 @@ type ModelParameters struct {
 }
 
 // ModelPerformance 模型性能数据
+// 由识别记录自动采集时每个文档对应一个模型版本的一个采集桶，汇总字段用于查询时合并相邻的桶
 type ModelPerformance struct {
-	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
-	ModelID     primitive.ObjectID  `bson:"modelId" json:"modelId"`
-	ModelVersion string             `bson:"modelVersion" json:"modelVersion"`
-	Metrics      ModelMetrics       `bson:"metrics" json:"metrics"`
-	CreateTime   time.Time          `bson:"createTime" json:"createTime"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ModelID       primitive.ObjectID `bson:"modelId" json:"modelId"`
+	ModelVersion  string             `bson:"modelVersion" json:"modelVersion"`
+	Metrics       ModelMetrics       `bson:"metrics" json:"metrics"`
+	BucketStart   time.Time          `bson:"bucketStart,omitempty" json:"bucketStart,omitempty"`     // 采集桶起始时间
+	BucketSeconds int64              `bson:"bucketSeconds,omitempty" json:"bucketSeconds,omitempty"` // 采集桶长度(秒)
+	Requests      int64              `bson:"requests,omitempty" json:"requests,omitempty"`
+	Failures      int64              `bson:"failures,omitempty" json:"failures,omitempty"`
+	Succeeded     int64              `bson:"succeeded,omitempty" json:"-"`
+	LatencySum    float64            `bson:"latencySum,omitempty" json:"-"`    // 成功请求处理耗时之和(毫秒)
+	ConfidenceSum float64            `bson:"confidenceSum,omitempty" json:"-"` // 成功请求top-1置信度之和
+	CreateTime    time.Time          `bson:"createTime" json:"createTime"`
 }
 
 // ModelMetrics 模型性能指标
 type ModelMetrics struct {
 	Accuracy   []MetricPoint `bson:"accuracy" json:"accuracy"`
-	Latency    []MetricPoint `bson:"latency" json:"latency"`
-	Throughput []MetricPoint `bson:"throughput" json:"throughput"`
+	Latency    []MetricPoint `bson:"latency" json:"latency"`       // 平均处理耗时(毫秒)
+	Throughput []MetricPoint `bson:"throughput" json:"throughput"` // 每秒请求数
+	Confidence []MetricPoint `bson:"confidence,omitempty" json:"confidence,omitempty"`
+}
+
+// PerformanceTotals 时间范围内单个模型版本的识别汇总
+type PerformanceTotals struct {
+	ModelID       string  `bson:"modelId"`
+	ModelVersion  string  `bson:"modelVersion"`
+	Requests      int64   `bson:"requests"`
+	Failures      int64   `bson:"failures"`
+	Succeeded     int64   `bson:"succeeded"`
+	LatencySum    float64 `bson:"latencySum"`
+	ConfidenceSum float64 `bson:"confidenceSum"`
 }
 
 // MetricPoint 性能指标数据点
 type MetricPoint struct {
 	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
 	Value     float64   `bson:"value" json:"value"`
-}
\ No newline at end of file
+}
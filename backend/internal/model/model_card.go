@@ -0,0 +1,80 @@
+package model
+
+import (
+	"time"
+)
+
+// ModelCardInfo 模型卡片中需要人工填写的部分，其余内容由模型、评估和数据集信息生成
+type ModelCardInfo struct {
+	ID                 string    `json:"id" bson:"_id,omitempty"`
+	ModelID            string    `json:"modelId" bson:"model_id"`
+	IntendedUse        string    `json:"intendedUse" bson:"intended_use"`                // 预期用途
+	OutOfScopeUse      string    `json:"outOfScopeUse" bson:"out_of_scope_use"`          // 不适用的场景
+	Limitations        []string  `json:"limitations" bson:"limitations"`                 // 已知局限
+	TrainingDatasetIDs []string  `json:"trainingDatasetIds" bson:"training_dataset_ids"` // 训练使用的数据集
+	UserID             int64     `json:"userId" bson:"user_id"`
+	CreateTime         time.Time `json:"createTime" bson:"create_time"`
+	UpdateTime         time.Time `json:"updateTime" bson:"update_time"`
+}
+
+// ModelCardInfoRepository 模型卡片人工信息数据访问接口
+type ModelCardInfoRepository interface {
+	// 按模型ID创建或更新
+	Save(info *ModelCardInfo) error
+	// 获取模型的卡片信息，不存在时返回nil
+	FindByModel(modelID string) (*ModelCardInfo, error)
+}
+
+// CardDataset 模型卡片中的数据集及其派生来源
+type CardDataset struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	TotalCount int             `json:"totalCount"`
+	Lineage    *DatasetLineage `json:"lineage,omitempty"`
+	Parents    []*CardDataset  `json:"parents,omitempty"` // 由近及远的源数据集
+	Missing    bool            `json:"missing,omitempty"` // 数据集已被删除
+}
+
+// CardEvaluation 模型卡片中的评估指标
+type CardEvaluation struct {
+	EvaluationID string         `json:"evaluationId"`
+	Dataset      *CardDataset   `json:"dataset,omitempty"`
+	Evaluated    int64          `json:"evaluated"`
+	TopK         int            `json:"topK"`
+	Accuracy     float64        `json:"accuracy"`
+	TopKAccuracy float64        `json:"topKAccuracy"`
+	MacroF1      float64        `json:"macroF1"`
+	WeightedF1   float64        `json:"weightedF1"`
+	ECE          float64        `json:"ece"`
+	Classes      []ClassMetrics `json:"classes,omitempty"`
+	EndTime      *time.Time     `json:"endTime,omitempty"`
+}
+
+// CardLabel 模型卡片中的标签，含本地化名称和上级分类
+type CardLabel struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"displayName"`
+	Ancestors   []string `json:"ancestors,omitempty"` // 由近及远的上级标签
+}
+
+// ModelCard 生成的模型卡片
+type ModelCard struct {
+	ModelID         string          `json:"modelId"`
+	ModelName       string          `json:"modelName"`
+	ModelType       string          `json:"modelType"`
+	Description     string          `json:"description"`
+	Version         string          `json:"version"`
+	VersionStatus   string          `json:"versionStatus,omitempty"`
+	Checksum        string          `json:"checksum,omitempty"`
+	Parameters      ModelParameters `json:"parameters"`
+	IntendedUse     string          `json:"intendedUse"`
+	OutOfScopeUse   string          `json:"outOfScopeUse"`
+	Limitations     []string        `json:"limitations"`
+	TrainingData    []*CardDataset  `json:"trainingData"`
+	Evaluation      *CardEvaluation `json:"evaluation,omitempty"`
+	LabelMapVersion int             `json:"labelMapVersion,omitempty"`
+	Labels          []CardLabel     `json:"labels"`
+	Locale          string          `json:"locale"`
+	GeneratedAt     time.Time       `json:"generatedAt"`
+}
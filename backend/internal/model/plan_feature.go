@@ -0,0 +1,80 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// 套餐功能，ServicePlan.Features中包含对应功能时客户才能使用
+const (
+	FeatureBatchRecognition = "batch_recognition" // 批量识别
+	FeatureDetectionModels  = "detection_models"  // 目标检测类模型
+	FeatureWebhooks         = "webhooks"          // 识别结果回调
+	FeatureAsyncJobs        = "async_jobs"        // 异步识别任务
+)
+
+// FeatureNames 功能的显示名称
+var FeatureNames = map[string]string{
+	FeatureBatchRecognition: "批量识别",
+	FeatureDetectionModels:  "目标检测模型",
+	FeatureWebhooks:         "Webhook回调",
+	FeatureAsyncJobs:        "异步识别任务",
+}
+
+// FeatureName 功能的显示名称，未登记的功能返回原名
+func FeatureName(feature string) string {
+	if name, ok := FeatureNames[feature]; ok {
+		return name
+	}
+	return feature
+}
+
+// HasFeature 套餐是否包含指定功能
+func (p *ServicePlan) HasFeature(feature string) bool {
+	if p == nil {
+		return false
+	}
+	for _, f := range p.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// StringList 以JSON数组保存到数据库的字符串列表
+type StringList []string
+
+// Value 实现driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现sql.Scanner
+func (l *StringList) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("无法将%T转换为StringList", src)
+	}
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
@@ -43,6 +43,7 @@ type Dataset struct {
 	TotalCount  int       `json:"totalCount" bson:"total_count"` // 数据总数
 	StoragePath string    `json:"storagePath" bson:"storage_path"` // 存储路径
 	UserID      int64     `json:"userId" bson:"user_id"` // 创建者ID
+	Lineage     *DatasetLineage `json:"lineage,omitempty" bson:"lineage,omitempty"` // 派生来源，拆分生成的数据集才有
 	CreateTime  time.Time `json:"createTime" bson:"create_time"`
 	UpdateTime  time.Time `json:"updateTime" bson:"update_time"`
 }
@@ -95,4 +96,6 @@ type DatasetRepository interface {
 	FindByID(id string) (*Dataset, error)
 	// 更新数据集总数
 	UpdateTotalCount(id string, totalCount int) error
+	// 查找由指定数据集派生的数据集
+	FindByParent(parentID string) ([]*Dataset, error)
 }
\ No newline at end of file
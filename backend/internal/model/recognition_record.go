@@ -6,18 +6,60 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// 识别记录状态
+const (
+	RecognitionProcessing = 0 // 处理中
+	RecognitionSucceeded  = 1 // 成功
+	RecognitionFailed     = 2 // 失败
+)
+
 // RecognitionRecord 图像识别记录模型
 type RecognitionRecord struct {
-	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	CustomerID    int64             `json:"customerId" bson:"customer_id"`
-	ModelVersion  string            `json:"modelVersion" bson:"model_version"`
-	ImageURL      string            `json:"imageUrl" bson:"image_url"`
-	ResultURL     string            `json:"resultUrl" bson:"result_url"`
-	Category      string            `json:"category" bson:"category"`
-	Confidence    float64           `json:"confidence" bson:"confidence"`
-	ProcessTime   int64             `json:"processTime" bson:"process_time"` // 处理时间(毫秒)
-	Status        int               `json:"status" bson:"status"`           // 0-处理中 1-成功 2-失败
-	ErrorMessage  string            `json:"errorMessage" bson:"error_message"`
-	CreateTime    time.Time         `json:"createTime" bson:"create_time"`
-	UpdateTime    time.Time         `json:"updateTime" bson:"update_time"`
-}
\ No newline at end of file
+	ID           primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	CustomerID   int64                `json:"customerId" bson:"customer_id"`
+	ModelID      string               `json:"modelId" bson:"model_id"`
+	ModelVersion string               `json:"modelVersion" bson:"model_version"` // 实际提供服务的版本
+	Variant      string               `json:"variant" bson:"variant"`            // production或candidate
+	ImageURL     string               `json:"imageUrl" bson:"image_url"`
+	ResultURL    string               `json:"resultUrl" bson:"result_url"`
+	Category     string               `json:"category" bson:"category"`
+	Labels       []string             `json:"labels,omitempty" bson:"labels,omitempty"` // 按置信度降序的预测标签
+	Confidence   float64              `json:"confidence" bson:"confidence"`
+	ImageBytes   int64                `json:"imageBytes,omitempty" bson:"image_bytes,omitempty"`
+	ImageWidth   int                  `json:"imageWidth,omitempty" bson:"image_width,omitempty"` // 无法解码时为0
+	ImageHeight  int                  `json:"imageHeight,omitempty" bson:"image_height,omitempty"`
+	Brightness   float64              `json:"brightness,omitempty" bson:"brightness,omitempty"` // 平均亮度，0-255
+	ProcessTime  int64                `json:"processTime" bson:"process_time"`                  // 处理时间(毫秒)
+	Status       int                  `json:"status" bson:"status"`                             // 0-处理中 1-成功 2-失败
+	ErrorMessage string               `json:"errorMessage" bson:"error_message"`
+	Feedback     *RecognitionFeedback `json:"feedback,omitempty" bson:"feedback,omitempty"` // 客户反馈，未反馈时为空
+	CreateTime   time.Time            `json:"createTime" bson:"create_time"`
+	UpdateTime   time.Time            `json:"updateTime" bson:"update_time"`
+}
+
+// RecognitionFeedback 客户对识别结果的反馈
+// Label为空表示预测类别正确；Correct为false且Label为空时，客户只修正了目标框
+type RecognitionFeedback struct {
+	Correct    bool         `json:"correct" bson:"correct"`
+	Label      string       `json:"label,omitempty" bson:"label,omitempty"` // 客户提供的正确类别
+	BBox       *BoundingBox `json:"bbox,omitempty" bson:"bbox,omitempty"`   // 客户提供的目标框
+	Comment    string       `json:"comment,omitempty" bson:"comment,omitempty"`
+	CreateTime time.Time    `json:"createTime" bson:"create_time"`
+	UpdateTime time.Time    `json:"updateTime" bson:"update_time"`
+}
+
+// TrueCategory 反馈确认后的真实类别
+func (f *RecognitionFeedback) TrueCategory(predicted string) string {
+	if f.Label != "" {
+		return f.Label
+	}
+	return predicted
+}
+
+// FeedbackTotals 按模型和真实类别汇总的客户反馈
+type FeedbackTotals struct {
+	ModelID  string `bson:"modelId"`
+	Category string `bson:"category"`
+	Total    int64  `bson:"total"`
+	Correct  int64  `bson:"correct"` // 预测类别正确的反馈数
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"time"
+)
+
+// 流量分配的粘性依据
+const (
+	StickyByCustomer = "customer" // 同一客户始终命中同一版本
+	StickyByRequest  = "request"  // 按请求内容哈希分配，相同图片命中同一版本
+)
+
+// 识别请求命中的版本类型
+const (
+	VariantProduction = "production" // 生产版本
+	VariantCandidate  = "candidate"  // 灰度/AB测试的候选版本
+)
+
+// RoutingPolicy 模型的流量分配策略，每个模型一条
+type RoutingPolicy struct {
+	ID               string    `json:"id" bson:"_id,omitempty"`
+	ModelID          string    `json:"modelId" bson:"model_id"`
+	CandidateVersion string    `json:"candidateVersion" bson:"candidate_version"` // 候选版本号
+	CandidatePercent float64   `json:"candidatePercent" bson:"candidate_percent"` // 分配给候选版本的流量百分比，0-100
+	StickyBy         string    `json:"stickyBy" bson:"sticky_by"`                 // customer或request
+	ShadowVersion    string    `json:"shadowVersion" bson:"shadow_version"`       // 影子版本号，异步复跑生产请求，不影响响应
+	ShadowPercent    float64   `json:"shadowPercent" bson:"shadow_percent"`       // 复跑到影子版本的生产请求百分比，0-100
+	Enabled          bool      `json:"enabled" bson:"enabled"`
+	UserID           int64     `json:"userId" bson:"user_id"` // 最后修改人
+	CreateTime       time.Time `json:"createTime" bson:"create_time"`
+	UpdateTime       time.Time `json:"updateTime" bson:"update_time"`
+}
+
+// DistributionStats 数值分布统计
+type DistributionStats struct {
+	Mean      float64 `json:"mean"`
+	P50       float64 `json:"p50"`
+	P90       float64 `json:"p90"`
+	P99       float64 `json:"p99"`
+	Histogram []int64 `json:"histogram,omitempty"` // 置信度按0.1等宽分箱的样本数
+}
+
+// VersionServingStats 单个模型版本的线上表现
+type VersionServingStats struct {
+	ModelVersion string            `json:"modelVersion"`
+	Variant      string            `json:"variant"`
+	Requests     int64             `json:"requests"`
+	Failures     int64             `json:"failures"`
+	ErrorRate    float64           `json:"errorRate"`
+	TrafficShare float64           `json:"trafficShare"` // 占统计期内请求的比例
+	LatencyMs    DistributionStats `json:"latencyMs"`    // 成功请求的处理耗时
+	Confidence   DistributionStats `json:"confidence"`   // 成功请求的top-1置信度
+	Categories   map[string]int64  `json:"categories"`   // 识别结果类别分布
+}
+
+// VersionComparison 生产版本与候选版本的并排对比报告
+type VersionComparison struct {
+	ModelID   string                 `json:"modelId"`
+	StartTime time.Time              `json:"startTime"`
+	EndTime   time.Time              `json:"endTime"`
+	Policy    *RoutingPolicy         `json:"policy,omitempty"`
+	Versions  []*VersionServingStats `json:"versions"`
+}
+
+// ShadowResult 一次生产请求在影子版本上的复跑结果
+type ShadowResult struct {
+	ID                   string    `json:"id" bson:"_id,omitempty"`
+	ModelID              string    `json:"modelId" bson:"model_id"`
+	RecordID             string    `json:"recordId" bson:"record_id"` // 对应的生产识别记录
+	ProductionVersion    string    `json:"productionVersion" bson:"production_version"`
+	ShadowVersion        string    `json:"shadowVersion" bson:"shadow_version"`
+	ProductionCategory   string    `json:"productionCategory" bson:"production_category"`
+	ProductionConfidence float64   `json:"productionConfidence" bson:"production_confidence"`
+	ProductionLabels     []string  `json:"productionLabels" bson:"production_labels"`
+	ShadowCategory       string    `json:"shadowCategory" bson:"shadow_category"`
+	ShadowConfidence     float64   `json:"shadowConfidence" bson:"shadow_confidence"`
+	ShadowLabels         []string  `json:"shadowLabels" bson:"shadow_labels"`
+	Agree                bool      `json:"agree" bson:"agree"`                  // top-1类别是否一致
+	AddedLabels          []string  `json:"addedLabels" bson:"added_labels"`     // 影子版本多出的标签
+	RemovedLabels        []string  `json:"removedLabels" bson:"removed_labels"` // 影子版本缺少的标签
+	ShadowLatency        int64     `json:"shadowLatency" bson:"shadow_latency"` // 影子版本处理耗时(毫秒)
+	ErrorMessage         string    `json:"errorMessage,omitempty" bson:"error_message,omitempty"`
+	CreateTime           time.Time `json:"createTime" bson:"create_time"`
+}
+
+// ShadowCategoryStats 按生产版本识别类别统计的影子版本差异
+type ShadowCategoryStats struct {
+	Category         string           `json:"category"` // 生产版本的top-1类别
+	Compared         int64            `json:"compared"`
+	Disagreements    int64            `json:"disagreements"`
+	DisagreementRate float64          `json:"disagreementRate"`
+	ShadowCategories map[string]int64 `json:"shadowCategories"` // 不一致时影子版本给出的类别
+	AddedLabels      map[string]int64 `json:"addedLabels"`      // 影子版本多出的标签及次数
+	RemovedLabels    map[string]int64 `json:"removedLabels"`    // 影子版本缺少的标签及次数
+}
+
+// ShadowReport 影子版本与生产版本的差异报告
+type ShadowReport struct {
+	ModelID          string                 `json:"modelId"`
+	ShadowVersion    string                 `json:"shadowVersion,omitempty"`
+	StartTime        time.Time              `json:"startTime"`
+	EndTime          time.Time              `json:"endTime"`
+	Total            int64                  `json:"total"`    // 复跑请求数
+	Failures         int64                  `json:"failures"` // 影子版本识别失败数
+	Compared         int64                  `json:"compared"`
+	Disagreements    int64                  `json:"disagreements"`
+	DisagreementRate float64                `json:"disagreementRate"`
+	LatencyMs        DistributionStats      `json:"latencyMs"` // 影子版本处理耗时
+	Categories       []*ShadowCategoryStats `json:"categories"`
+}
+
+// ShadowResultRepository 影子复跑结果数据访问接口
+type ShadowResultRepository interface {
+	// 保存影子复跑结果
+	Create(result *ShadowResult) error
+	// 获取模型在时间范围内的影子复跑结果，shadowVersion为空时不按版本过滤
+	FindByModel(modelID, shadowVersion string, start, end time.Time) ([]*ShadowResult, error)
+}
+
+// RoutingPolicyRepository 流量分配策略数据访问接口
+type RoutingPolicyRepository interface {
+	// 创建或更新模型的流量分配策略
+	Save(policy *RoutingPolicy) error
+	// 获取模型的流量分配策略，不存在时返回nil
+	FindByModel(modelID string) (*RoutingPolicy, error)
+	// 获取全部流量分配策略
+	List() ([]*RoutingPolicy, error)
+	// 删除模型的流量分配策略
+	DeleteByModel(modelID string) error
+}
@@ -17,14 +17,22 @@ type SystemStats struct {
 	CreatedAt     time.Time         `bson:"created_at" json:"createdAt"`
 }
 
+// 准确率统计来源
+const (
+	AccuracySourceEvaluation = "evaluation" // 测试集评估
+	AccuracySourceFeedback   = "feedback"   // 客户反馈，按识别日期每天一条
+)
+
 // AccuracyStats 识别准确率统计
 type AccuracyStats struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ModelID         string            `bson:"model_id" json:"modelId"`
+	ModelVersion    string            `bson:"model_version,omitempty" json:"modelVersion,omitempty"` // 评估的模型版本，反馈统计不区分版本
 	Category        string            `bson:"category" json:"category"`
 	Accuracy        float64           `bson:"accuracy" json:"accuracy"`
 	TotalSamples    int64             `bson:"total_samples" json:"totalSamples"`
 	CorrectSamples  int64             `bson:"correct_samples" json:"correctSamples"`
+	Source          string            `bson:"source,omitempty" json:"source,omitempty"`
 	Date            time.Time         `bson:"date" json:"date"`
 	CreatedAt       time.Time         `bson:"created_at" json:"createdAt"`
 }
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"time"
+)
+
+// 调参搜索策略
+const (
+	TuningStrategyGrid   = "grid"   // 网格搜索，遍历全部组合
+	TuningStrategyRandom = "random" // 随机搜索，按种子抽样
+)
+
+// 调参任务及试验状态
+const (
+	TuningStatusPending   = "pending"   // 等待调度
+	TuningStatusQueued    = "queued"    // 已提交到训练队列
+	TuningStatusRunning   = "running"   // 执行中
+	TuningStatusCompleted = "completed" // 已完成
+	TuningStatusFailed    = "failed"    // 失败
+	TuningStatusCancelled = "cancelled" // 已取消
+)
+
+// TuningJob 超参数搜索任务
+type TuningJob struct {
+	ID              string            `json:"id" bson:"_id,omitempty"`
+	Name            string            `json:"name" bson:"name"`
+	ModelID         string            `json:"modelId" bson:"model_id"`
+	DatasetID       string            `json:"datasetId" bson:"dataset_id"`
+	Strategy        string            `json:"strategy" bson:"strategy"`            // grid或random
+	Params          []string          `json:"params" bson:"params"`                // 参与搜索的训练参数名，为空时使用所有声明了Range的参数
+	MaxTrials       int               `json:"maxTrials" bson:"max_trials"`         // 最大试验数，网格搜索超出时截断
+	MaxConcurrent   int               `json:"maxConcurrent" bson:"max_concurrent"` // 同时执行的试验数上限
+	Seed            int64             `json:"seed" bson:"seed"`                    // 随机搜索种子
+	Objective       string            `json:"objective" bson:"objective"`          // 优化目标指标，如accuracy
+	Maximize        bool              `json:"maximize" bson:"maximize"`            // 目标指标越大越好
+	Status          string            `json:"status" bson:"status"`
+	ActiveTrials    int               `json:"activeTrials" bson:"active_trials"` // 已提交未结束的试验数
+	TotalTrials     int               `json:"totalTrials" bson:"total_trials"`
+	CompletedTrials int               `json:"completedTrials" bson:"completed_trials"`
+	FailedTrials    int               `json:"failedTrials" bson:"failed_trials"`
+	BestTrialID     string            `json:"bestTrialId,omitempty" bson:"best_trial_id,omitempty"`
+	BestParams      map[string]string `json:"bestParams,omitempty" bson:"best_params,omitempty"`
+	BestScore       *float64          `json:"bestScore,omitempty" bson:"best_score,omitempty"`
+	UserID          int64             `json:"userId" bson:"user_id"`
+	CreateTime      time.Time         `json:"createTime" bson:"create_time"`
+	UpdateTime      time.Time         `json:"updateTime" bson:"update_time"`
+}
+
+// TuningTrial 超参数搜索中的单次试验
+type TuningTrial struct {
+	ID         string             `json:"id" bson:"_id,omitempty"`
+	JobID      string             `json:"jobId" bson:"job_id"`
+	Index      int                `json:"index" bson:"index"`                        // 试验序号，调度按序号进行
+	Params     map[string]string  `json:"params" bson:"params"`                      // 本次试验的参数取值
+	TaskID     string             `json:"taskId,omitempty" bson:"task_id,omitempty"` // 训练队列任务ID
+	Status     string             `json:"status" bson:"status"`
+	Metrics    map[string]float64 `json:"metrics,omitempty" bson:"metrics,omitempty"`
+	Score      *float64           `json:"score,omitempty" bson:"score,omitempty"` // 目标指标取值
+	IsBest     bool               `json:"isBest" bson:"is_best"`
+	ErrorMsg   string             `json:"errorMsg,omitempty" bson:"error_msg,omitempty"`
+	StartTime  *time.Time         `json:"startTime,omitempty" bson:"start_time,omitempty"`
+	EndTime    *time.Time         `json:"endTime,omitempty" bson:"end_time,omitempty"`
+	CreateTime time.Time          `json:"createTime" bson:"create_time"`
+	UpdateTime time.Time          `json:"updateTime" bson:"update_time"`
+}
+
+// TuningJobRepository 调参任务数据访问接口
+type TuningJobRepository interface {
+	// 创建调参任务
+	Create(job *TuningJob) (string, error)
+	// 根据ID查找调参任务
+	FindByID(id string) (*TuningJob, error)
+	// 获取调参任务列表
+	List(modelID string, page, size int) ([]*TuningJob, int64, error)
+	// 更新任务状态
+	UpdateStatus(id, status string) error
+	// 更新试验统计及最优试验，不修改任务状态
+	UpdateProgress(job *TuningJob) error
+	// 占用一个并发名额，已达上限时返回false
+	AcquireSlot(id string, max int) (bool, error)
+	// 释放一个并发名额
+	ReleaseSlot(id string) error
+}
+
+// TuningTrialRepository 调参试验数据访问接口
+type TuningTrialRepository interface {
+	// 批量创建试验
+	CreateMany(trials []*TuningTrial) error
+	// 根据ID查找试验
+	FindByID(id string) (*TuningTrial, error)
+	// 获取任务的全部试验，按序号升序
+	ListByJob(jobID string) ([]*TuningTrial, error)
+	// 将序号最小的待调度试验标记为已提交并返回，没有待调度试验时返回nil
+	ClaimNext(jobID string) (*TuningTrial, error)
+	// 记录试验对应的训练队列任务ID
+	SetTaskID(id, taskID string) error
+	// 将状态为from的试验更新为to，返回是否更新成功
+	TransitionStatus(id, from, to string) (bool, error)
+	// 记录已提交或执行中试验的结果，试验已结束时返回false，避免重复上报
+	Finish(trial *TuningTrial) (bool, error)
+	// 取消任务下所有待调度试验
+	CancelPending(jobID string) (int64, error)
+	// 获取更新时间早于before仍处于已提交或执行中的试验
+	ListStale(before time.Time) ([]*TuningTrial, error)
+	// 标记最优试验
+	MarkBest(jobID, trialID string) error
+}
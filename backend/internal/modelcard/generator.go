@@ -0,0 +1,215 @@
+package modelcard
+
+import (
+	"context"
+	"time"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/taxonomy"
+)
+
+// maxLineageDepth 追溯数据集派生来源的最大层数
+const maxLineageDepth = 5
+
+// VersionStore 模型版本存储，由repository.ModelRepository实现
+type VersionStore interface {
+	FindModelVersion(ctx context.Context, modelID, version string) (*model.ModelVersion, error)
+	GetProductionVersion(ctx context.Context, modelID string) (*model.ModelVersion, error)
+}
+
+// Generator 由模型、版本、评估、数据集和标签映射生成模型卡片
+type Generator struct {
+	models      model.ModelRepository
+	versions    VersionStore
+	evaluations model.ModelEvaluationRepository
+	datasets    model.DatasetRepository
+	labels      model.LabelMapRepository
+	info        model.ModelCardInfoRepository
+}
+
+// NewGenerator 创建模型卡片生成器
+func NewGenerator(models model.ModelRepository, versions VersionStore, evaluations model.ModelEvaluationRepository,
+	datasets model.DatasetRepository, labels model.LabelMapRepository, info model.ModelCardInfoRepository) *Generator {
+	return &Generator{
+		models:      models,
+		versions:    versions,
+		evaluations: evaluations,
+		datasets:    datasets,
+		labels:      labels,
+		info:        info,
+	}
+}
+
+// Generate 生成模型卡片
+// version为空时使用生产版本，没有生产版本时使用模型登记的版本；labelMapVersion不大于0时使用最新标签映射
+func (g *Generator) Generate(ctx context.Context, modelID, version string, labelMapVersion int, locale string) (*model.ModelCard, error) {
+	m, err := g.models.FindByID(modelID)
+	if err != nil || m == nil {
+		return nil, errors.NewNotFoundError("模型不存在")
+	}
+	if locale == "" {
+		locale = taxonomy.DefaultLocale
+	}
+
+	card := &model.ModelCard{
+		ModelID:      modelID,
+		ModelName:    m.Name,
+		ModelType:    m.Type,
+		Description:  m.Description,
+		Version:      m.Version,
+		Limitations:  []string{},
+		TrainingData: []*model.CardDataset{},
+		Labels:       []model.CardLabel{},
+		Locale:       locale,
+		GeneratedAt:  time.Now(),
+	}
+
+	v, err := g.resolveVersion(ctx, modelID, version)
+	if err != nil {
+		return nil, err
+	}
+	if v != nil {
+		card.Version = v.Version
+		card.VersionStatus = v.Status
+		card.Checksum = v.Checksum
+		card.Parameters = v.Parameters
+		if v.Description != "" {
+			card.Description = v.Description
+		}
+	}
+
+	info, err := g.info.FindByModel(modelID)
+	if err != nil {
+		return nil, errors.NewServerError("查询模型卡片信息失败")
+	}
+	if info != nil {
+		card.IntendedUse = info.IntendedUse
+		card.OutOfScopeUse = info.OutOfScopeUse
+		if info.Limitations != nil {
+			card.Limitations = info.Limitations
+		}
+		for _, id := range info.TrainingDatasetIDs {
+			card.TrainingData = append(card.TrainingData, g.dataset(id, maxLineageDepth))
+		}
+	}
+
+	evaluation, err := g.evaluations.FindLatestCompleted(modelID, card.Version)
+	if err != nil {
+		return nil, errors.NewServerError("查询评估记录失败")
+	}
+	if evaluation != nil {
+		card.Evaluation = g.cardEvaluation(evaluation)
+	}
+
+	if err := g.fillLabels(card, labelMapVersion, evaluation); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// resolveVersion 查找卡片对应的模型版本，没有登记版本时返回nil
+func (g *Generator) resolveVersion(ctx context.Context, modelID, version string) (*model.ModelVersion, error) {
+	if version != "" {
+		v, err := g.versions.FindModelVersion(ctx, modelID, version)
+		if err != nil {
+			return nil, errors.NewServerError("查询模型版本失败")
+		}
+		if v == nil {
+			return nil, errors.NewNotFoundError("模型版本不存在")
+		}
+		return v, nil
+	}
+
+	v, err := g.versions.GetProductionVersion(ctx, modelID)
+	if err != nil {
+		return nil, errors.NewServerError("查询生产版本失败")
+	}
+	return v, nil
+}
+
+// dataset 查找数据集并沿血缘追溯源数据集，数据集已删除时标记为Missing
+func (g *Generator) dataset(id string, depth int) *model.CardDataset {
+	ds, err := g.datasets.FindByID(id)
+	if err != nil || ds == nil {
+		return &model.CardDataset{ID: id, Missing: true}
+	}
+
+	card := &model.CardDataset{
+		ID:         ds.ID,
+		Name:       ds.Name,
+		Type:       ds.Type,
+		TotalCount: ds.TotalCount,
+		Lineage:    ds.Lineage,
+	}
+	for lineage := ds.Lineage; lineage != nil && len(card.Parents) < depth; {
+		parent, err := g.datasets.FindByID(lineage.ParentID)
+		if err != nil || parent == nil {
+			card.Parents = append(card.Parents, &model.CardDataset{ID: lineage.ParentID, Missing: true})
+			break
+		}
+		card.Parents = append(card.Parents, &model.CardDataset{
+			ID:         parent.ID,
+			Name:       parent.Name,
+			Type:       parent.Type,
+			TotalCount: parent.TotalCount,
+			Lineage:    parent.Lineage,
+		})
+		lineage = parent.Lineage
+	}
+	return card
+}
+
+func (g *Generator) cardEvaluation(e *model.ModelEvaluation) *model.CardEvaluation {
+	return &model.CardEvaluation{
+		EvaluationID: e.ID,
+		Dataset:      g.dataset(e.DatasetID, maxLineageDepth),
+		Evaluated:    e.Evaluated,
+		TopK:         e.TopK,
+		Accuracy:     e.Accuracy,
+		TopKAccuracy: e.TopKAccuracy,
+		MacroF1:      e.MacroF1,
+		WeightedF1:   e.WeightedF1,
+		ECE:          e.ECE,
+		Classes:      e.Classes,
+		EndTime:      e.EndTime,
+	}
+}
+
+// fillLabels 填写标签及其显示名称和上级分类，未配置标签映射时使用评估中出现的标签
+func (g *Generator) fillLabels(card *model.ModelCard, labelMapVersion int, evaluation *model.ModelEvaluation) error {
+	var labelMap *model.LabelMap
+	var err error
+	if labelMapVersion > 0 {
+		labelMap, err = g.labels.FindByVersion(card.ModelID, labelMapVersion)
+		if err == nil && labelMap == nil {
+			return errors.NewNotFoundError("标签映射版本不存在")
+		}
+	} else {
+		labelMap, err = g.labels.FindLatest(card.ModelID)
+	}
+	if err != nil {
+		return errors.NewServerError("查询标签映射失败")
+	}
+
+	var t *taxonomy.Taxonomy
+	var names []string
+	if labelMap != nil {
+		if t, err = taxonomy.New(labelMap); err != nil {
+			return errors.NewServerError("标签映射无效: " + err.Error())
+		}
+		card.LabelMapVersion = labelMap.Version
+		names = t.Labels()
+	} else if evaluation != nil {
+		names = evaluation.Labels
+	}
+
+	for _, name := range names {
+		card.Labels = append(card.Labels, model.CardLabel{
+			Name:        name,
+			DisplayName: t.DisplayName(name, card.Locale),
+			Ancestors:   t.Ancestors(name),
+		})
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package modelcard
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+type fakeModels struct {
+	model.ModelRepository
+	m *model.Model
+}
+
+func (f *fakeModels) FindByID(id string) (*model.Model, error) {
+	if f.m == nil || f.m.ID != id {
+		return nil, nil
+	}
+	return f.m, nil
+}
+
+type fakeVersions struct {
+	production *model.ModelVersion
+}
+
+func (f *fakeVersions) FindModelVersion(ctx context.Context, modelID, version string) (*model.ModelVersion, error) {
+	if f.production != nil && f.production.Version == version {
+		return f.production, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeVersions) GetProductionVersion(ctx context.Context, modelID string) (*model.ModelVersion, error) {
+	return f.production, nil
+}
+
+type fakeEvaluations struct {
+	model.ModelEvaluationRepository
+	latest map[string]*model.ModelEvaluation
+}
+
+func (f *fakeEvaluations) FindLatestCompleted(modelID, modelVersion string) (*model.ModelEvaluation, error) {
+	return f.latest[modelVersion], nil
+}
+
+type fakeDatasets struct {
+	model.DatasetRepository
+	datasets map[string]*model.Dataset
+}
+
+func (f *fakeDatasets) FindByID(id string) (*model.Dataset, error) {
+	return f.datasets[id], nil
+}
+
+type fakeLabels struct {
+	model.LabelMapRepository
+	maps []*model.LabelMap
+}
+
+func (f *fakeLabels) FindLatest(modelID string) (*model.LabelMap, error) {
+	if len(f.maps) == 0 {
+		return nil, nil
+	}
+	return f.maps[len(f.maps)-1], nil
+}
+
+func (f *fakeLabels) FindByVersion(modelID string, version int) (*model.LabelMap, error) {
+	for _, m := range f.maps {
+		if m.Version == version {
+			return m, nil
+		}
+	}
+	return nil, nil
+}
+
+type fakeInfo struct {
+	info *model.ModelCardInfo
+}
+
+func (f *fakeInfo) Save(info *model.ModelCardInfo) error { f.info = info; return nil }
+
+func (f *fakeInfo) FindByModel(modelID string) (*model.ModelCardInfo, error) { return f.info, nil }
+
+func newGenerator() *Generator {
+	datasets := &fakeDatasets{datasets: map[string]*model.Dataset{
+		"raw":   {ID: "raw", Name: "pets-raw", Type: model.DatasetTypeTrain, TotalCount: 1000},
+		"train": {ID: "train", Name: "pets-raw-train", Type: model.DatasetTypeTrain, TotalCount: 800, Lineage: &model.DatasetLineage{ParentID: "raw", Seed: 42}},
+		"test":  {ID: "test", Name: "pets-raw-test", Type: model.DatasetTypeTest, TotalCount: 100, Lineage: &model.DatasetLineage{ParentID: "raw", Seed: 42}},
+	}}
+	return NewGenerator(
+		&fakeModels{m: &model.Model{ID: "m1", Name: "pets", Type: "classification", Version: "v0"}},
+		&fakeVersions{production: &model.ModelVersion{ModelID: "m1", Version: "v2", Status: model.ModelVersionProduction, Checksum: "abc"}},
+		&fakeEvaluations{latest: map[string]*model.ModelEvaluation{
+			"v2": {ID: "e1", DatasetID: "test", Evaluated: 100, TopK: 5, Accuracy: 0.93, Labels: []string{"cat", "dog"},
+				Classes: []model.ClassMetrics{{Label: "cat", Support: 50, Precision: 0.9, Recall: 0.95, F1: 0.92}}},
+		}},
+		datasets,
+		&fakeLabels{maps: []*model.LabelMap{
+			{ModelID: "m1", Version: 1, Labels: []model.LabelEntry{{Name: "cat"}}},
+			{ModelID: "m1", Version: 2, Labels: []model.LabelEntry{
+				{Name: "animal", DisplayNames: map[string]string{"zh-CN": "动物"}},
+				{Name: "cat", Parent: "animal", DisplayNames: map[string]string{"zh-CN": "猫", "en": "Cat"}},
+			}},
+		}},
+		&fakeInfo{info: &model.ModelCardInfo{
+			ModelID:            "m1",
+			IntendedUse:        "宠物图片分类",
+			Limitations:        []string{"夜间图片准确率较低"},
+			TrainingDatasetIDs: []string{"train", "deleted"},
+		}},
+	)
+}
+
+func TestGenerate(t *testing.T) {
+	card, err := newGenerator().Generate(context.Background(), "m1", "", 0, "zh-CN")
+	require.NoError(t, err)
+
+	assert.Equal(t, "v2", card.Version)
+	assert.Equal(t, model.ModelVersionProduction, card.VersionStatus)
+	assert.Equal(t, "宠物图片分类", card.IntendedUse)
+
+	require.Len(t, card.TrainingData, 2)
+	require.Len(t, card.TrainingData[0].Parents, 1)
+	assert.Equal(t, "pets-raw", card.TrainingData[0].Parents[0].Name)
+	assert.True(t, card.TrainingData[1].Missing)
+
+	require.NotNil(t, card.Evaluation)
+	assert.Equal(t, 0.93, card.Evaluation.Accuracy)
+	assert.Equal(t, "pets-raw-test", card.Evaluation.Dataset.Name)
+
+	assert.Equal(t, 2, card.LabelMapVersion)
+	require.Len(t, card.Labels, 2)
+	assert.Equal(t, model.CardLabel{Name: "cat", DisplayName: "猫", Ancestors: []string{"animal"}}, card.Labels[1])
+}
+
+func TestGenerateVersionNotFound(t *testing.T) {
+	_, err := newGenerator().Generate(context.Background(), "m1", "v9", 0, "")
+	assert.Error(t, err)
+
+	_, err = newGenerator().Generate(context.Background(), "m1", "", 7, "")
+	assert.Error(t, err)
+
+	_, err = newGenerator().Generate(context.Background(), "missing", "", 0, "")
+	assert.Error(t, err)
+}
+
+func TestMarkdown(t *testing.T) {
+	card, err := newGenerator().Generate(context.Background(), "m1", "", 1, "en")
+	require.NoError(t, err)
+
+	md := Markdown(card)
+	assert.True(t, strings.HasPrefix(md, "# 模型卡片：pets\n"))
+	assert.Contains(t, md, "- 版本：v2（production）")
+	assert.Contains(t, md, "- 标签映射版本：1")
+	assert.Contains(t, md, "- pets-raw-train（train，800个样本） ← pets-raw，拆分seed=42")
+	assert.Contains(t, md, "- deleted（已删除）")
+	assert.Contains(t, md, "| Top-1准确率 | 0.9300 |")
+	assert.Contains(t, md, "| cat | 50 | 0.9000 | 0.9500 | 0.9200 |")
+	assert.Contains(t, md, "- 夜间图片准确率较低")
+}
@@ -0,0 +1,130 @@
+package modelcard
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// Markdown 将模型卡片渲染为Markdown文档
+func Markdown(card *model.ModelCard) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# 模型卡片：%s\n\n", escape(card.ModelName))
+	fmt.Fprintf(&b, "- 模型ID：%s\n", card.ModelID)
+	fmt.Fprintf(&b, "- 模型类型：%s\n", orDash(card.ModelType))
+	fmt.Fprintf(&b, "- 版本：%s", orDash(card.Version))
+	if card.VersionStatus != "" {
+		fmt.Fprintf(&b, "（%s）", card.VersionStatus)
+	}
+	b.WriteString("\n")
+	if card.Checksum != "" {
+		fmt.Fprintf(&b, "- 模型文件SHA-256：`%s`\n", card.Checksum)
+	}
+	if card.LabelMapVersion > 0 {
+		fmt.Fprintf(&b, "- 标签映射版本：%d\n", card.LabelMapVersion)
+	}
+	fmt.Fprintf(&b, "- 生成时间：%s\n\n", card.GeneratedAt.Format("2006-01-02 15:04:05"))
+	if card.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", card.Description)
+	}
+
+	b.WriteString("## 预期用途\n\n")
+	fmt.Fprintf(&b, "%s\n\n", orDash(card.IntendedUse))
+	if card.OutOfScopeUse != "" {
+		b.WriteString("### 不适用的场景\n\n")
+		fmt.Fprintf(&b, "%s\n\n", card.OutOfScopeUse)
+	}
+
+	b.WriteString("## 训练数据\n\n")
+	if len(card.TrainingData) == 0 {
+		b.WriteString("-\n\n")
+	}
+	for _, ds := range card.TrainingData {
+		writeDataset(&b, ds)
+	}
+	if len(card.TrainingData) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("### 训练参数\n\n")
+	fmt.Fprintf(&b, "| batchSize | learningRate | epochs |\n|---|---|---|\n| %d | %g | %d |\n\n",
+		card.Parameters.BatchSize, card.Parameters.LearningRate, card.Parameters.Epochs)
+
+	b.WriteString("## 评估指标\n\n")
+	if e := card.Evaluation; e != nil {
+		if e.Dataset != nil {
+			b.WriteString("测试集：")
+			writeDataset(&b, e.Dataset)
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "| 指标 | 数值 |\n|---|---|\n")
+		fmt.Fprintf(&b, "| 评估样本数 | %d |\n", e.Evaluated)
+		fmt.Fprintf(&b, "| Top-1准确率 | %.4f |\n", e.Accuracy)
+		fmt.Fprintf(&b, "| Top-%d准确率 | %.4f |\n", e.TopK, e.TopKAccuracy)
+		fmt.Fprintf(&b, "| 宏平均F1 | %.4f |\n", e.MacroF1)
+		fmt.Fprintf(&b, "| 加权F1 | %.4f |\n", e.WeightedF1)
+		fmt.Fprintf(&b, "| 校准误差ECE | %.4f |\n\n", e.ECE)
+		if len(e.Classes) > 0 {
+			b.WriteString("| 类别 | 样本数 | 精确率 | 召回率 | F1 |\n|---|---|---|---|---|\n")
+			for _, c := range e.Classes {
+				fmt.Fprintf(&b, "| %s | %d | %.4f | %.4f | %.4f |\n", escape(c.Label), c.Support, c.Precision, c.Recall, c.F1)
+			}
+			b.WriteString("\n")
+		}
+	} else {
+		b.WriteString("该版本尚无完成的评估。\n\n")
+	}
+
+	b.WriteString("## 标签\n\n")
+	if len(card.Labels) == 0 {
+		b.WriteString("-\n\n")
+	} else {
+		b.WriteString("| 标签 | 显示名称 | 上级分类 |\n|---|---|---|\n")
+		for _, l := range card.Labels {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", escape(l.Name), escape(l.DisplayName), orDash(escape(strings.Join(l.Ancestors, " → "))))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## 局限性\n\n")
+	if len(card.Limitations) == 0 {
+		b.WriteString("-\n")
+	}
+	for _, l := range card.Limitations {
+		fmt.Fprintf(&b, "- %s\n", l)
+	}
+	return b.String()
+}
+
+// writeDataset 输出数据集及其派生来源
+func writeDataset(b *strings.Builder, ds *model.CardDataset) {
+	if ds.Missing {
+		fmt.Fprintf(b, "- %s（已删除）\n", ds.ID)
+		return
+	}
+	fmt.Fprintf(b, "- %s（%s，%d个样本）", escape(ds.Name), orDash(ds.Type), ds.TotalCount)
+	for _, p := range ds.Parents {
+		if p.Missing {
+			fmt.Fprintf(b, " ← %s（已删除）", p.ID)
+			continue
+		}
+		fmt.Fprintf(b, " ← %s", escape(p.Name))
+	}
+	if ds.Lineage != nil {
+		fmt.Fprintf(b, "，拆分seed=%d", ds.Lineage.Seed)
+	}
+	b.WriteString("\n")
+}
+
+// escape 转义表格中的竖线和换行
+func escape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
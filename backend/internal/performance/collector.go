@@ -0,0 +1,119 @@
+package performance
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// DefaultBucket 默认采集桶长度
+const DefaultBucket = time.Minute
+
+// RecordAggregator 识别记录汇总，由repository.RecognitionRepository实现
+type RecordAggregator interface {
+	AggregateByVersion(ctx context.Context, startTime, endTime time.Time) ([]*model.PerformanceTotals, error)
+}
+
+// BucketStore 性能数据存储，由repository.ModelRepository实现
+type BucketStore interface {
+	UpsertPerformanceBucket(ctx context.Context, perf *model.ModelPerformance) error
+}
+
+// Collector 从识别记录定时采集各模型版本的延迟、吞吐量和平均置信度
+type Collector struct {
+	records RecordAggregator
+	store   BucketStore
+	bucket  time.Duration
+}
+
+// NewCollector 创建性能数据采集器，bucket不大于0时使用DefaultBucket
+func NewCollector(records RecordAggregator, store BucketStore, bucket time.Duration) *Collector {
+	if bucket <= 0 {
+		bucket = DefaultBucket
+	}
+	return &Collector{
+		records: records,
+		store:   store,
+		bucket:  bucket,
+	}
+}
+
+// Run 每个采集桶结束后采集一次，直到ctx取消
+// 每次同时重新采集上一个桶，补上桶结束后才写入的识别记录
+func (c *Collector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.bucket)
+	defer ticker.Stop()
+
+	for {
+		current := time.Now().Truncate(c.bucket)
+		for _, start := range []time.Time{current.Add(-2 * c.bucket), current.Add(-c.bucket)} {
+			if err := c.Collect(ctx, start); err != nil {
+				log.Printf("采集模型性能数据失败: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect 采集以start开始的一个桶
+func (c *Collector) Collect(ctx context.Context, start time.Time) error {
+	totals, err := c.records.AggregateByVersion(ctx, start, start.Add(c.bucket))
+	if err != nil {
+		return err
+	}
+	for _, perf := range BuildBuckets(totals, start, c.bucket) {
+		if err := c.store.UpsertPerformanceBucket(ctx, perf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildBuckets 将一个桶内的识别汇总转换为性能数据，模型ID不是有效ObjectID的记录被忽略
+func BuildBuckets(totals []*model.PerformanceTotals, start time.Time, bucket time.Duration) []*model.ModelPerformance {
+	buckets := make([]*model.ModelPerformance, 0, len(totals))
+	for _, t := range totals {
+		modelID, err := primitive.ObjectIDFromHex(t.ModelID)
+		if err != nil || t.Requests == 0 {
+			continue
+		}
+		perf := &model.ModelPerformance{
+			ModelID:       modelID,
+			ModelVersion:  t.ModelVersion,
+			BucketStart:   start,
+			BucketSeconds: int64(bucket / time.Second),
+			Requests:      t.Requests,
+			Failures:      t.Failures,
+			Succeeded:     t.Succeeded,
+			LatencySum:    t.LatencySum,
+			ConfidenceSum: t.ConfidenceSum,
+		}
+		perf.Metrics = metricsAt(start, perf.Requests, perf.Succeeded, perf.LatencySum, perf.ConfidenceSum, bucket)
+		buckets = append(buckets, perf)
+	}
+	return buckets
+}
+
+// metricsAt 计算一个时间窗口的指标数据点，窗口内没有成功请求时只有吞吐量
+func metricsAt(at time.Time, requests, succeeded int64, latencySum, confidenceSum float64, window time.Duration) model.ModelMetrics {
+	metrics := model.ModelMetrics{
+		Accuracy:   []model.MetricPoint{},
+		Latency:    []model.MetricPoint{},
+		Throughput: []model.MetricPoint{{Timestamp: at, Value: float64(requests) / window.Seconds()}},
+		Confidence: []model.MetricPoint{},
+	}
+	if succeeded > 0 {
+		metrics.Latency = append(metrics.Latency, model.MetricPoint{Timestamp: at, Value: latencySum / float64(succeeded)})
+		metrics.Confidence = append(metrics.Confidence, model.MetricPoint{Timestamp: at, Value: confidenceSum / float64(succeeded)})
+	}
+	return metrics
+}
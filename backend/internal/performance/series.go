@@ -0,0 +1,91 @@
+package performance
+
+import (
+	"sort"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// MaxPoints 自动选择降采样步长时每条序列的最大数据点数
+const MaxPoints = 500
+
+// AutoStep 选择降采样步长，使[start, end)内的数据点不超过MaxPoints，步长为bucket的整数倍
+func AutoStep(start, end time.Time, bucket time.Duration) time.Duration {
+	if bucket <= 0 {
+		bucket = DefaultBucket
+	}
+	span := end.Sub(start)
+	if span <= 0 {
+		return bucket
+	}
+	buckets := int64((span + bucket - 1) / bucket)
+	factor := (buckets + MaxPoints - 1) / MaxPoints
+	if factor < 1 {
+		factor = 1
+	}
+	return time.Duration(factor) * bucket
+}
+
+// Downsample 将采集桶按step合并为各版本的指标序列，按版本号排序
+// 延迟和置信度按成功请求数加权平均，吞吐量为窗口内的每秒请求数；没有数据的窗口不输出数据点
+func Downsample(buckets []*model.ModelPerformance, start time.Time, step time.Duration) []*model.ModelPerformance {
+	type window struct {
+		requests, failures, succeeded int64
+		latencySum, confidenceSum     float64
+	}
+
+	windows := make(map[string]map[int64]*window)
+	series := make(map[string]*model.ModelPerformance)
+	for _, b := range buckets {
+		if b.BucketStart.Before(start) {
+			continue
+		}
+		s, ok := series[b.ModelVersion]
+		if !ok {
+			s = &model.ModelPerformance{ModelID: b.ModelID, ModelVersion: b.ModelVersion, BucketStart: start, BucketSeconds: int64(step / time.Second)}
+			series[b.ModelVersion] = s
+			windows[b.ModelVersion] = make(map[int64]*window)
+		}
+		s.Requests += b.Requests
+		s.Failures += b.Failures
+
+		index := int64(b.BucketStart.Sub(start) / step)
+		w, ok := windows[b.ModelVersion][index]
+		if !ok {
+			w = &window{}
+			windows[b.ModelVersion][index] = w
+		}
+		w.requests += b.Requests
+		w.failures += b.Failures
+		w.succeeded += b.Succeeded
+		w.latencySum += b.LatencySum
+		w.confidenceSum += b.ConfidenceSum
+	}
+
+	result := make([]*model.ModelPerformance, 0, len(series))
+	for version, s := range series {
+		indexes := make([]int64, 0, len(windows[version]))
+		for i := range windows[version] {
+			indexes = append(indexes, i)
+		}
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+		s.Metrics = model.ModelMetrics{
+			Accuracy:   []model.MetricPoint{},
+			Latency:    []model.MetricPoint{},
+			Throughput: []model.MetricPoint{},
+			Confidence: []model.MetricPoint{},
+		}
+		for _, i := range indexes {
+			w := windows[version][i]
+			m := metricsAt(start.Add(time.Duration(i)*step), w.requests, w.succeeded, w.latencySum, w.confidenceSum, step)
+			s.Metrics.Latency = append(s.Metrics.Latency, m.Latency...)
+			s.Metrics.Throughput = append(s.Metrics.Throughput, m.Throughput...)
+			s.Metrics.Confidence = append(s.Metrics.Confidence, m.Confidence...)
+		}
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ModelVersion < result[j].ModelVersion })
+	return result
+}
@@ -0,0 +1,98 @@
+package performance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+type fakeAggregator struct {
+	totals []*model.PerformanceTotals
+	ranges [][2]time.Time
+}
+
+func (f *fakeAggregator) AggregateByVersion(ctx context.Context, startTime, endTime time.Time) ([]*model.PerformanceTotals, error) {
+	f.ranges = append(f.ranges, [2]time.Time{startTime, endTime})
+	return f.totals, nil
+}
+
+type memoryBuckets struct {
+	buckets map[string]*model.ModelPerformance
+}
+
+func (m *memoryBuckets) UpsertPerformanceBucket(ctx context.Context, perf *model.ModelPerformance) error {
+	m.buckets[perf.ModelVersion+perf.BucketStart.String()] = perf
+	return nil
+}
+
+func TestCollect(t *testing.T) {
+	modelID := primitive.NewObjectID()
+	agg := &fakeAggregator{totals: []*model.PerformanceTotals{
+		{ModelID: modelID.Hex(), ModelVersion: "v1", Requests: 120, Failures: 20, Succeeded: 100, LatencySum: 5000, ConfidenceSum: 90},
+		{ModelID: "not-an-object-id", ModelVersion: "v1", Requests: 5},
+	}}
+	store := &memoryBuckets{buckets: map[string]*model.ModelPerformance{}}
+	c := NewCollector(agg, store, 0)
+
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, c.Collect(context.Background(), start))
+	require.NoError(t, c.Collect(context.Background(), start)) // 重复采集覆盖同一个桶
+	assert.Equal(t, start.Add(time.Minute), agg.ranges[0][1])
+
+	require.Len(t, store.buckets, 1)
+	var perf *model.ModelPerformance
+	for _, b := range store.buckets {
+		perf = b
+	}
+	assert.Equal(t, modelID, perf.ModelID)
+	assert.Equal(t, int64(60), perf.BucketSeconds)
+	assert.InDelta(t, 2.0, perf.Metrics.Throughput[0].Value, 1e-9)
+	assert.InDelta(t, 50.0, perf.Metrics.Latency[0].Value, 1e-9)
+	assert.InDelta(t, 0.9, perf.Metrics.Confidence[0].Value, 1e-9)
+}
+
+func TestDownsample(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	bucket := func(version string, minute int, requests, succeeded int64, latencySum, confidenceSum float64) *model.ModelPerformance {
+		return &model.ModelPerformance{
+			ModelVersion: version, BucketStart: start.Add(time.Duration(minute) * time.Minute), BucketSeconds: 60,
+			Requests: requests, Succeeded: succeeded, LatencySum: latencySum, ConfidenceSum: confidenceSum,
+		}
+	}
+	buckets := []*model.ModelPerformance{
+		bucket("v1", 0, 60, 60, 600, 54),    // 平均10ms
+		bucket("v1", 1, 240, 240, 7200, 48), // 平均30ms，请求更多，权重更大
+		bucket("v1", 7, 30, 0, 0, 0),        // 全部失败
+		bucket("v2", 2, 30, 30, 300, 27),
+	}
+
+	series := Downsample(buckets, start, 5*time.Minute)
+	require.Len(t, series, 2)
+
+	v1 := series[0]
+	assert.Equal(t, "v1", v1.ModelVersion)
+	assert.Equal(t, int64(330), v1.Requests)
+	require.Len(t, v1.Metrics.Throughput, 2)
+	assert.Equal(t, start, v1.Metrics.Throughput[0].Timestamp)
+	assert.InDelta(t, 1.0, v1.Metrics.Throughput[0].Value, 1e-9)
+	assert.InDelta(t, 26.0, v1.Metrics.Latency[0].Value, 1e-9)
+	assert.InDelta(t, 0.34, v1.Metrics.Confidence[0].Value, 1e-9)
+	assert.Equal(t, start.Add(5*time.Minute), v1.Metrics.Throughput[1].Timestamp)
+	assert.Len(t, v1.Metrics.Latency, 1, "没有成功请求的窗口不输出延迟")
+
+	assert.Equal(t, "v2", series[1].ModelVersion)
+	assert.Len(t, series[1].Metrics.Throughput, 1)
+}
+
+func TestAutoStep(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Minute, AutoStep(start, start.Add(time.Hour), time.Minute))
+	assert.Equal(t, 3*time.Minute, AutoStep(start, start.Add(24*time.Hour), time.Minute))
+	assert.Equal(t, 21*time.Minute, AutoStep(start, start.Add(7*24*time.Hour), time.Minute))
+}
@@ -0,0 +1,78 @@
+package promotion
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// 上线门槛对应的系统参数键
+const (
+	ParamMinAccuracy = "model.promotion.min_accuracy"
+	ParamMinMacroF1  = "model.promotion.min_macro_f1"
+	ParamMaxECE      = "model.promotion.max_ece"
+	ParamMinSamples  = "model.promotion.min_samples"
+)
+
+// Thresholds 模型版本申请上线需满足的评估门槛
+type Thresholds struct {
+	MinAccuracy float64 `json:"minAccuracy"`
+	MinMacroF1  float64 `json:"minMacroF1"`
+	MaxECE      float64 `json:"maxEce"`
+	MinSamples  int64   `json:"minSamples"` // 评估样本数下限，避免用过小的测试集上线
+}
+
+// DefaultThresholds 未配置系统参数时使用的默认门槛
+var DefaultThresholds = Thresholds{
+	MinAccuracy: 0.9,
+	MinMacroF1:  0.85,
+	MaxECE:      0.1,
+	MinSamples:  100,
+}
+
+// LoadThresholds 从系统参数读取上线门槛，未配置或格式错误的项使用默认值
+func LoadThresholds(params model.SystemParamRepository) Thresholds {
+	t := DefaultThresholds
+	if params == nil {
+		return t
+	}
+
+	number := func(key string, dst *float64) {
+		p, err := params.FindByKey(key)
+		if err != nil || p == nil {
+			return
+		}
+		if v, err := strconv.ParseFloat(p.Value, 64); err == nil {
+			*dst = v
+		}
+	}
+	number(ParamMinAccuracy, &t.MinAccuracy)
+	number(ParamMinMacroF1, &t.MinMacroF1)
+	number(ParamMaxECE, &t.MaxECE)
+
+	if p, err := params.FindByKey(ParamMinSamples); err == nil && p != nil {
+		if v, err := strconv.ParseInt(p.Value, 10, 64); err == nil {
+			t.MinSamples = v
+		}
+	}
+	return t
+}
+
+// Check 检查评估结果是否满足门槛，返回未满足的项
+func (t Thresholds) Check(e *model.ModelEvaluation) []string {
+	var violations []string
+	if e.Evaluated < t.MinSamples {
+		violations = append(violations, fmt.Sprintf("评估样本数%d低于%d", e.Evaluated, t.MinSamples))
+	}
+	if e.Accuracy < t.MinAccuracy {
+		violations = append(violations, fmt.Sprintf("准确率%.4f低于%.4f", e.Accuracy, t.MinAccuracy))
+	}
+	if e.MacroF1 < t.MinMacroF1 {
+		violations = append(violations, fmt.Sprintf("宏平均F1 %.4f低于%.4f", e.MacroF1, t.MinMacroF1))
+	}
+	if e.ECE > t.MaxECE {
+		violations = append(violations, fmt.Sprintf("校准误差ECE %.4f高于%.4f", e.ECE, t.MaxECE))
+	}
+	return violations
+}
@@ -0,0 +1,354 @@
+package promotion
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/repository"
+)
+
+// 版本流转动作
+const (
+	ActionSubmit   = "submit"           // development -> testing
+	ActionRequest  = "request_approval" // testing -> pending_approval，需满足评估门槛
+	ActionReject   = "reject"           // pending_approval -> testing
+	ActionApprove  = "approve"          // pending_approval -> production，原生产版本下线
+	ActionRollback = "rollback"         // 当前生产版本下线，恢复上一个生产版本
+	ActionArchive  = "archive"          // 被替换或回滚的生产版本 -> archived
+)
+
+// transitions 各动作允许的起始状态及目标状态
+var transitions = map[string]struct {
+	from string
+	to   string
+}{
+	ActionSubmit:  {model.ModelVersionDevelopment, model.ModelVersionTesting},
+	ActionRequest: {model.ModelVersionTesting, model.ModelVersionPendingApproval},
+	ActionReject:  {model.ModelVersionPendingApproval, model.ModelVersionTesting},
+	ActionApprove: {model.ModelVersionPendingApproval, model.ModelVersionProduction},
+	ActionArchive: {model.ModelVersionProduction, model.ModelVersionArchived},
+}
+
+// CanTransition 判断版本在当前状态下能否执行动作
+func CanTransition(status, action string) bool {
+	t, ok := transitions[action]
+	return ok && t.from == status
+}
+
+// VersionStore 模型版本存储，由repository.ModelRepository实现
+type VersionStore interface {
+	GetModelVersion(ctx context.Context, id string) (*model.ModelVersion, error)
+	GetProductionVersion(ctx context.Context, modelID string) (*model.ModelVersion, error)
+	TransitionModelVersion(ctx context.Context, version *model.ModelVersion, from string) (bool, error)
+	SwapProductionVersion(ctx context.Context, current, next *model.ModelVersion, from string) (bool, error)
+}
+
+// AuditLogger 审计日志记录接口，由security.AuditLogService实现
+type AuditLogger interface {
+	LogUserAction(ctx context.Context, userID int64, username, action, resource, resourceID, clientIP, userAgent, status string, details map[string]interface{}) error
+}
+
+// Deployer 版本上线或回滚后切换已加载的模型，由inference.Manager实现
+type Deployer interface {
+	Swap(ctx context.Context, modelID, version, previous string) error
+}
+
+// RouteReleaser 版本上线后从流量分配策略中移除该版本，由routing.Service实现
+type RouteReleaser interface {
+	ReleaseVersion(ctx context.Context, modelID, version string) error
+}
+
+// deployTimeout 上线后后台加载新版本的超时时间
+const deployTimeout = 5 * time.Minute
+
+// Actor 执行流转操作的用户
+type Actor struct {
+	UserID    int64
+	Username  string
+	ClientIP  string
+	UserAgent string
+}
+
+// Service 模型版本上线流程服务
+// 版本须先通过评估门槛申请上线，再由其他管理员审批；
+// 每个模型同时只有一个生产版本，由数据库部分唯一索引保证，审批和回滚在同一事务中下线原生产版本并上线新版本
+type Service struct {
+	versions    VersionStore
+	evaluations model.ModelEvaluationRepository
+	params      model.SystemParamRepository
+	audit       AuditLogger
+	deployer    Deployer      // 为nil时不切换已加载的模型
+	routes      RouteReleaser // 为nil时不调整流量分配策略
+}
+
+// NewService 创建模型版本上线流程服务
+func NewService(versions VersionStore, evaluations model.ModelEvaluationRepository, params model.SystemParamRepository, audit AuditLogger, deployer Deployer, routes RouteReleaser) *Service {
+	return &Service{
+		versions:    versions,
+		evaluations: evaluations,
+		params:      params,
+		audit:       audit,
+		deployer:    deployer,
+		routes:      routes,
+	}
+}
+
+// Thresholds 当前生效的上线门槛
+func (s *Service) Thresholds() Thresholds {
+	return LoadThresholds(s.params)
+}
+
+// Submit 将开发中的版本提交测试
+func (s *Service) Submit(ctx context.Context, actor Actor, id string) (*model.ModelVersion, error) {
+	v, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.move(ctx, actor, v, ActionSubmit, nil); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// RequestApproval 申请上线，evaluationID为空时使用该版本最近一次完成的评估
+func (s *Service) RequestApproval(ctx context.Context, actor Actor, id, evaluationID string) (*model.ModelVersion, error) {
+	v, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !CanTransition(v.Status, ActionRequest) {
+		return nil, errors.NewConflictError(fmt.Sprintf("%s状态的版本不能申请上线", v.Status))
+	}
+
+	e, err := s.evaluation(v, evaluationID)
+	if err != nil {
+		return nil, err
+	}
+	thresholds := s.Thresholds()
+	if violations := thresholds.Check(e); len(violations) > 0 {
+		s.log(ctx, actor, ActionRequest, v, "failed", map[string]interface{}{
+			"evaluationId": e.ID,
+			"violations":   violations,
+		})
+		return nil, errors.NewAppError(errors.ValidationError, "评估结果未达到上线门槛", strings.Join(violations, "; "))
+	}
+
+	v.EvaluationID = e.ID
+	v.RequestedBy = actor.UserID
+	if err := s.move(ctx, actor, v, ActionRequest, map[string]interface{}{
+		"evaluationId": e.ID,
+		"accuracy":     e.Accuracy,
+		"macroF1":      e.MacroF1,
+		"ece":          e.ECE,
+	}); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Reject 驳回上线申请，版本回到测试状态
+func (s *Service) Reject(ctx context.Context, actor Actor, id, comment string) (*model.ModelVersion, error) {
+	v, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	v.RequestedBy = 0
+	if err := s.move(ctx, actor, v, ActionReject, map[string]interface{}{"comment": comment}); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Approve 审批上线，原生产版本下线并记录为可回滚的上一个版本
+func (s *Service) Approve(ctx context.Context, actor Actor, id string) (*model.ModelVersion, error) {
+	v, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !CanTransition(v.Status, ActionApprove) {
+		return nil, errors.NewConflictError(fmt.Sprintf("%s状态的版本不能审批上线", v.Status))
+	}
+	if v.RequestedBy != 0 && v.RequestedBy == actor.UserID {
+		return nil, errors.NewPermissionError("不能审批自己提交的上线申请")
+	}
+
+	current, err := s.versions.GetProductionVersion(ctx, v.ModelID)
+	if err != nil {
+		return nil, errors.NewServerError("查询当前生产版本失败")
+	}
+
+	v.ApprovedBy = actor.UserID
+	v.PreviousVersionID = ""
+	if current != nil {
+		v.PreviousVersionID = current.ID.Hex()
+	}
+	if err := s.swap(ctx, actor, current, v, ActionApprove); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Rollback 将模型回滚到上一个生产版本
+func (s *Service) Rollback(ctx context.Context, actor Actor, modelID string) (*model.ModelVersion, error) {
+	current, err := s.versions.GetProductionVersion(ctx, modelID)
+	if err != nil {
+		return nil, errors.NewServerError("查询当前生产版本失败")
+	}
+	if current == nil {
+		return nil, errors.NewNotFoundError("模型没有生产版本")
+	}
+	if current.PreviousVersionID == "" {
+		return nil, errors.NewConflictError("没有可回滚的上一个生产版本")
+	}
+
+	previous, err := s.find(ctx, current.PreviousVersionID)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Status != model.ModelVersionArchived {
+		return nil, errors.NewConflictError(fmt.Sprintf("上一个生产版本当前为%s状态，不能回滚", previous.Status))
+	}
+
+	// 恢复的版本保留其自身的PreviousVersionID，可继续向前回滚
+	previous.ApprovedBy = actor.UserID
+	if err := s.swap(ctx, actor, current, previous, ActionRollback); err != nil {
+		return nil, err
+	}
+	return previous, nil
+}
+
+// swap 下线当前生产版本并将next设为生产版本，两者在同一事务中更新，任一失败时均保持原状态
+func (s *Service) swap(ctx context.Context, actor Actor, current, next *model.ModelVersion, action string) error {
+	from := next.Status
+	now := time.Now()
+	next.Status = model.ModelVersionProduction
+	next.PromoteTime = &now
+	if current != nil {
+		current.Status = model.ModelVersionArchived
+	}
+
+	ok, err := s.versions.SwapProductionVersion(ctx, current, next, from)
+	if err != nil || !ok {
+		next.Status = from
+		if current != nil {
+			current.Status = model.ModelVersionProduction
+		}
+		s.log(ctx, actor, action, next, "failed", map[string]interface{}{"from": from})
+		if err == nil || stderrors.Is(err, repository.ErrProductionConflict) {
+			return errors.NewConflictError("模型版本状态已被其他操作修改，请刷新后重试")
+		}
+		return errors.NewServerError("更新模型版本状态失败")
+	}
+
+	details := map[string]interface{}{"from": from, "to": next.Status}
+	var previous string
+	if current != nil {
+		s.log(ctx, actor, ActionArchive, current, "success", map[string]interface{}{
+			"from":          model.ModelVersionProduction,
+			"to":            current.Status,
+			"reason":        action,
+			"replacementId": next.ID.Hex(),
+		})
+		details["replacedId"] = current.ID.Hex()
+		previous = current.Version
+	}
+	s.log(ctx, actor, action, next, "success", details)
+	// 候选版本已成为生产版本，不再按候选流量百分比分流
+	if s.routes != nil {
+		if err := s.routes.ReleaseVersion(ctx, next.ModelID, next.Version); err != nil {
+			log.Printf("清除模型%s版本%s的流量分配策略失败: %v", next.ModelID, next.Version, err)
+		}
+	}
+	if s.deployer != nil {
+		go s.deploy(next.ModelID, next.Version, previous)
+	}
+	return nil
+}
+
+// deploy 后台加载新的生产版本并替换旧版本，不阻塞审批请求
+func (s *Service) deploy(modelID, version, previous string) {
+	ctx, cancel := context.WithTimeout(context.Background(), deployTimeout)
+	defer cancel()
+
+	if err := s.deployer.Swap(ctx, modelID, version, previous); err != nil {
+		log.Printf("切换模型%s到版本%s失败: %v", modelID, version, err)
+	}
+}
+
+// move 按动作更新版本状态并记录审计日志
+func (s *Service) move(ctx context.Context, actor Actor, v *model.ModelVersion, action string, details map[string]interface{}) error {
+	t := transitions[action]
+	if v.Status != t.from {
+		return errors.NewConflictError(fmt.Sprintf("%s状态的版本不能执行%s", v.Status, action))
+	}
+
+	v.Status = t.to
+	ok, err := s.versions.TransitionModelVersion(ctx, v, t.from)
+	if err != nil || !ok {
+		v.Status = t.from
+		if err != nil && !stderrors.Is(err, repository.ErrProductionConflict) {
+			return errors.NewServerError("更新模型版本状态失败")
+		}
+		return errors.NewConflictError("模型版本状态已被其他操作修改，请刷新后重试")
+	}
+
+	if details == nil {
+		details = make(map[string]interface{})
+	}
+	details["from"] = t.from
+	details["to"] = t.to
+	s.log(ctx, actor, action, v, "success", details)
+	return nil
+}
+
+// evaluation 查找申请上线依据的评估记录
+func (s *Service) evaluation(v *model.ModelVersion, evaluationID string) (*model.ModelEvaluation, error) {
+	var e *model.ModelEvaluation
+	var err error
+	if evaluationID != "" {
+		e, err = s.evaluations.FindByID(evaluationID)
+	} else {
+		e, err = s.evaluations.FindLatestCompleted(v.ModelID, v.Version)
+	}
+	if err != nil {
+		return nil, errors.NewServerError("查询评估记录失败")
+	}
+	if e == nil {
+		return nil, errors.NewValidationError("该版本没有已完成的评估，请先执行评估")
+	}
+	if e.Status != model.EvaluationStatusCompleted || e.ModelID != v.ModelID || e.ModelVersion != v.Version {
+		return nil, errors.NewValidationError("评估记录与模型版本不匹配或尚未完成")
+	}
+	return e, nil
+}
+
+// find 查找模型版本
+func (s *Service) find(ctx context.Context, id string) (*model.ModelVersion, error) {
+	v, err := s.versions.GetModelVersion(ctx, id)
+	if err != nil {
+		return nil, errors.NewValidationError("无效的模型版本ID")
+	}
+	if v == nil {
+		return nil, errors.NewNotFoundError("模型版本不存在")
+	}
+	return v, nil
+}
+
+// log 记录版本流转审计日志
+func (s *Service) log(ctx context.Context, actor Actor, action string, v *model.ModelVersion, status string, details map[string]interface{}) {
+	if s.audit == nil {
+		return
+	}
+	details["modelId"] = v.ModelID
+	details["version"] = v.Version
+	if err := s.audit.LogUserAction(ctx, actor.UserID, actor.Username, "model_version."+action,
+		"model_version", v.ID.Hex(), actor.ClientIP, actor.UserAgent, status, details); err != nil {
+		log.Printf("记录模型版本审计日志失败: %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package promotion
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/repository"
+)
+
+// memoryVersions 内存版本存储，模拟每个模型唯一生产版本的索引约束
+type memoryVersions struct {
+	versions map[string]*model.ModelVersion
+}
+
+func (m *memoryVersions) GetModelVersion(ctx context.Context, id string) (*model.ModelVersion, error) {
+	if v, ok := m.versions[id]; ok {
+		copied := *v
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *memoryVersions) GetProductionVersion(ctx context.Context, modelID string) (*model.ModelVersion, error) {
+	for id, v := range m.versions {
+		if v.ModelID == modelID && v.Status == model.ModelVersionProduction {
+			return m.GetModelVersion(ctx, id)
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryVersions) TransitionModelVersion(ctx context.Context, version *model.ModelVersion, from string) (bool, error) {
+	stored := m.versions[version.ID.Hex()]
+	if stored == nil || stored.Status != from {
+		return false, nil
+	}
+	if version.Status == model.ModelVersionProduction {
+		if current, _ := m.GetProductionVersion(ctx, version.ModelID); current != nil {
+			return false, repository.ErrProductionConflict
+		}
+	}
+	copied := *version
+	m.versions[version.ID.Hex()] = &copied
+	return true, nil
+}
+
+func (m *memoryVersions) SwapProductionVersion(ctx context.Context, current, next *model.ModelVersion, from string) (bool, error) {
+	if current != nil {
+		if stored := m.versions[current.ID.Hex()]; stored == nil || stored.Status != model.ModelVersionProduction {
+			return false, nil
+		}
+	}
+	stored := m.versions[next.ID.Hex()]
+	if stored == nil || stored.Status != from {
+		return false, nil
+	}
+	if current == nil {
+		if existing, _ := m.GetProductionVersion(ctx, next.ModelID); existing != nil {
+			return false, repository.ErrProductionConflict
+		}
+	}
+	if current != nil {
+		copied := *current
+		m.versions[current.ID.Hex()] = &copied
+	}
+	copied := *next
+	m.versions[next.ID.Hex()] = &copied
+	return true, nil
+}
+
+func (m *memoryVersions) add(status string) *model.ModelVersion {
+	v := &model.ModelVersion{ID: primitive.NewObjectID(), ModelID: "m1", Version: "v" + status, Status: status}
+	m.versions[v.ID.Hex()] = v
+	return v
+}
+
+// memoryEvaluations 内存评估记录
+type memoryEvaluations struct {
+	model.ModelEvaluationRepository
+	latest *model.ModelEvaluation
+}
+
+func (m *memoryEvaluations) FindLatestCompleted(modelID, modelVersion string) (*model.ModelEvaluation, error) {
+	return m.latest, nil
+}
+
+type auditRecorder struct {
+	actions []string
+}
+
+func (a *auditRecorder) LogUserAction(ctx context.Context, userID int64, username, action, resource, resourceID, clientIP, userAgent, status string, details map[string]interface{}) error {
+	a.actions = append(a.actions, action+":"+status)
+	return nil
+}
+
+type routeRecorder struct {
+	released []string
+}
+
+func (r *routeRecorder) ReleaseVersion(ctx context.Context, modelID, version string) error {
+	r.released = append(r.released, modelID+"@"+version)
+	return nil
+}
+
+func TestRequestApprovalThresholds(t *testing.T) {
+	store := &memoryVersions{versions: map[string]*model.ModelVersion{}}
+	v := store.add(model.ModelVersionTesting)
+	evals := &memoryEvaluations{latest: &model.ModelEvaluation{
+		ID: "e1", ModelID: "m1", ModelVersion: v.Version, Status: model.EvaluationStatusCompleted,
+		Evaluated: 500, Accuracy: 0.8, MacroF1: 0.9, ECE: 0.05,
+	}}
+	audit := &auditRecorder{}
+	s := NewService(store, evals, nil, audit, nil, nil)
+	ctx := context.Background()
+
+	_, err := s.RequestApproval(ctx, Actor{UserID: 1}, v.ID.Hex(), "")
+	require.Error(t, err)
+	assert.True(t, errors.IsBadRequest(err))
+	assert.Contains(t, err.(*errors.AppError).Details, "准确率")
+	assert.Equal(t, []string{"model_version.request_approval:failed"}, audit.actions)
+
+	evals.latest.Accuracy = 0.95
+	got, err := s.RequestApproval(ctx, Actor{UserID: 1}, v.ID.Hex(), "")
+	require.NoError(t, err)
+	assert.Equal(t, model.ModelVersionPendingApproval, got.Status)
+	assert.Equal(t, "e1", got.EvaluationID)
+
+	// 状态已变化，不能重复申请
+	_, err = s.RequestApproval(ctx, Actor{UserID: 1}, v.ID.Hex(), "")
+	assert.Error(t, err)
+}
+
+func TestApproveAndRollback(t *testing.T) {
+	store := &memoryVersions{versions: map[string]*model.ModelVersion{}}
+	old := store.add(model.ModelVersionProduction)
+	candidate := store.add(model.ModelVersionPendingApproval)
+	candidate.RequestedBy = 1
+	audit := &auditRecorder{}
+	routes := &routeRecorder{}
+	s := NewService(store, nil, nil, audit, nil, routes)
+	ctx := context.Background()
+
+	_, err := s.Approve(ctx, Actor{UserID: 1}, candidate.ID.Hex())
+	assert.True(t, errors.IsAuthError(err), "申请人不能审批自己的申请")
+
+	promoted, err := s.Approve(ctx, Actor{UserID: 2}, candidate.ID.Hex())
+	require.NoError(t, err)
+	assert.Equal(t, model.ModelVersionProduction, promoted.Status)
+	assert.Equal(t, old.ID.Hex(), promoted.PreviousVersionID)
+	assert.Equal(t, model.ModelVersionArchived, store.versions[old.ID.Hex()].Status)
+	assert.Equal(t, []string{"m1@" + candidate.Version}, routes.released)
+
+	restored, err := s.Rollback(ctx, Actor{UserID: 2}, "m1")
+	require.NoError(t, err)
+	assert.Equal(t, old.ID, restored.ID)
+	assert.Equal(t, model.ModelVersionProduction, store.versions[old.ID.Hex()].Status)
+	assert.Equal(t, model.ModelVersionArchived, store.versions[candidate.ID.Hex()].Status)
+
+	// 原生产版本没有更早的版本，不能继续回滚
+	_, err = s.Rollback(ctx, Actor{UserID: 2}, "m1")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{
+		"model_version.archive:success", "model_version.approve:success",
+		"model_version.archive:success", "model_version.rollback:success",
+	}, audit.actions)
+}
+
+func TestApproveConflictKeepsProduction(t *testing.T) {
+	store := &memoryVersions{versions: map[string]*model.ModelVersion{}}
+	old := store.add(model.ModelVersionProduction)
+	candidate := store.add(model.ModelVersionPendingApproval)
+	s := NewService(store, nil, nil, nil, nil, nil)
+
+	// 模拟审批期间候选版本被驳回
+	store.versions[candidate.ID.Hex()].Status = model.ModelVersionTesting
+	current, stale := *old, *candidate
+	stale.Status = model.ModelVersionPendingApproval
+	err := s.swap(context.Background(), Actor{UserID: 2}, &current, &stale, ActionApprove)
+	require.Error(t, err)
+	assert.Equal(t, errors.ConflictError, err.(*errors.AppError).Code)
+	assert.Equal(t, model.ModelVersionProduction, store.versions[old.ID.Hex()].Status)
+	assert.Equal(t, model.ModelVersionProduction, current.Status)
+	assert.Equal(t, model.ModelVersionTesting, store.versions[candidate.ID.Hex()].Status)
+}
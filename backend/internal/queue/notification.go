@@ -16,6 +16,9 @@ type NotificationType string
 const (
 	NotificationTypeTaskComplete NotificationType = "task_complete"
 	NotificationTypeTaskFailed   NotificationType = "task_failed"
+	NotificationTypeDriftAlert   NotificationType = "drift_alert"
+	NotificationTypeQuotaWarning NotificationType = "quota_warning"
+	NotificationTypeKeyRotated   NotificationType = "api_key_rotated"
 )
 
 // Notification 定义通知结构
@@ -16,6 +16,8 @@ const (
 	TaskTypeImageRecognition TaskType = "image_recognition"
 	TaskTypeModelTraining    TaskType = "model_training"
 	TaskTypeDataAnalysis     TaskType = "data_analysis"
+	TaskTypeModelEvaluation  TaskType = "model_evaluation"
+	TaskTypeImageStats       TaskType = "image_stats"
 )
 
 // Task 定义任务结构
@@ -44,9 +46,15 @@ func NewQueue(client *redis.Client, prefix string) *Queue {
 
 // Push 将任务推送到队列
 func (q *Queue) Push(ctx context.Context, taskType TaskType, data interface{}) error {
+	_, err := q.Enqueue(ctx, taskType, data)
+	return err
+}
+
+// Enqueue 将任务推送到队列并返回任务ID，调用方可据此跟踪任务状态
+func (q *Queue) Enqueue(ctx context.Context, taskType TaskType, data interface{}) (string, error) {
 	taskData, err := json.Marshal(data)
 	if err != nil {
-		return fmt.Errorf("marshal task data error: %v", err)
+		return "", fmt.Errorf("marshal task data error: %v", err)
 	}
 
 	task := &Task{
@@ -60,16 +68,16 @@ func (q *Queue) Push(ctx context.Context, taskType TaskType, data interface{}) e
 
 	taskBytes, err := json.Marshal(task)
 	if err != nil {
-		return fmt.Errorf("marshal task error: %v", err)
+		return "", fmt.Errorf("marshal task error: %v", err)
 	}
 
 	key := fmt.Sprintf("%s:%s", q.prefix, taskType)
 	err = q.redis.LPush(ctx, key, taskBytes).Err()
 	if err != nil {
-		return fmt.Errorf("push task error: %v", err)
+		return "", fmt.Errorf("push task error: %v", err)
 	}
 
-	return nil
+	return task.ID, nil
 }
 
 // Pop 从队列中获取任务
@@ -82,8 +82,7 @@ func (w *Worker) process() {
 				}
 
 				// 执行任务处理
-				handler := w.handlers[task.Type]
-				err = handler(w.ctx, task)
+				err = w.run(w.handlers[task.Type], task)
 
 				// 更新任务状态
 				status := "completed"
@@ -115,6 +114,16 @@ func (w *Worker) process() {
 	}
 }
 
+// run 执行任务处理函数，处理函数panic时转换为错误，避免工作协程退出
+func (w *Worker) run(handler TaskHandler, task *Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task panic: %v", r)
+		}
+	}()
+	return handler(w.ctx, task)
+}
+
 // HandleImageRecognition 处理图像识别任务的示例处理器
 func HandleImageRecognition(ctx context.Context, task *Task) error {
 	// 解析任务数据
@@ -136,24 +145,84 @@ func HandleImageRecognition(ctx context.Context, task *Task) error {
 	return nil
 }
 
-// HandleModelTraining 处理模型训练任务的示例处理器
+// TrialReporter 调参试验状态上报接口，由调参服务实现
+type TrialReporter interface {
+	// 训练开始执行时标记试验执行中
+	StartTrial(trialID string) error
+	// 上报试验结果，errMsg不为空表示训练失败
+	FinishTrial(ctx context.Context, trialID string, metrics map[string]float64, errMsg string) error
+}
+
+// trainingTaskData 模型训练任务数据
+// 调参试验提交的任务带有TuningJobID和TrialID，训练结束后需上报试验结果
+type trainingTaskData struct {
+	DatasetID   string                 `json:"dataset_id"`
+	ModelID     string                 `json:"model_id"`
+	Params      map[string]interface{} `json:"params"`
+	TuningJobID string                 `json:"tuning_job_id,omitempty"`
+	TrialID     string                 `json:"trial_id,omitempty"`
+}
+
+// trainFunc 执行训练并返回评估指标
+type trainFunc func(ctx context.Context, data *trainingTaskData) (map[string]float64, error)
+
+// HandleModelTraining 处理模型训练任务的示例处理器，不上报调参试验结果
 func HandleModelTraining(ctx context.Context, task *Task) error {
-	// 解析任务数据
-	var data struct {
-		DatasetID string `json:"dataset_id"`
-		ModelID   string `json:"model_id"`
-		Params    map[string]interface{} `json:"params"`
-	}
+	return newTrainingHandler(nil, trainModel)(ctx, task)
+}
 
-	if err := json.Unmarshal(task.Data, &data); err != nil {
-		return fmt.Errorf("unmarshal task data error: %v", err)
+// NewModelTrainingHandler 创建模型训练任务处理器
+// 调参试验的任务无论训练成功、失败还是panic都会上报结果，以释放试验占用的并发名额
+func NewModelTrainingHandler(reporter TrialReporter) TaskHandler {
+	return newTrainingHandler(reporter, trainModel)
+}
+
+// newTrainingHandler 创建使用指定训练函数的训练任务处理器
+func newTrainingHandler(reporter TrialReporter, train trainFunc) TaskHandler {
+	return func(ctx context.Context, task *Task) (err error) {
+		var data trainingTaskData
+		if err := json.Unmarshal(task.Data, &data); err != nil {
+			return fmt.Errorf("unmarshal task data error: %v", err)
+		}
+		if reporter == nil || data.TrialID == "" {
+			_, err := train(ctx, &data)
+			return err
+		}
+
+		// 试验已结束（如已被回收）时不再训练
+		if err := reporter.StartTrial(data.TrialID); err != nil {
+			return fmt.Errorf("start tuning trial %s error: %v", data.TrialID, err)
+		}
+
+		var metrics map[string]float64
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("training panic: %v", r)
+			}
+			errMsg := ""
+			if err != nil {
+				errMsg = err.Error()
+			}
+			// 使用新的上下文上报，避免工作器停止时丢失结果
+			reportCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if reportErr := reporter.FinishTrial(reportCtx, data.TrialID, metrics, errMsg); reportErr != nil {
+				log.Printf("Error reporting tuning trial %s: %v", data.TrialID, reportErr)
+			}
+		}()
+
+		metrics, err = train(ctx, &data)
+		return err
 	}
+}
 
+// trainModel 执行模型训练
+func trainModel(ctx context.Context, data *trainingTaskData) (map[string]float64, error) {
 	// TODO: 实现模型训练逻辑
 	// 1. 加载数据集
 	// 2. 准备训练环境
 	// 3. 执行训练
 	// 4. 保存模型
 
-	return nil
-}
\ No newline at end of file
+	return nil, nil
+}
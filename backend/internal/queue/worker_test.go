@@ -0,0 +1,101 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTrialReporter struct {
+	started  []string
+	finished map[string]string
+	metrics  map[string]map[string]float64
+	startErr error
+}
+
+func newFakeTrialReporter() *fakeTrialReporter {
+	return &fakeTrialReporter{
+		finished: make(map[string]string),
+		metrics:  make(map[string]map[string]float64),
+	}
+}
+
+func (r *fakeTrialReporter) StartTrial(trialID string) error {
+	if r.startErr != nil {
+		return r.startErr
+	}
+	r.started = append(r.started, trialID)
+	return nil
+}
+
+func (r *fakeTrialReporter) FinishTrial(ctx context.Context, trialID string, metrics map[string]float64, errMsg string) error {
+	r.finished[trialID] = errMsg
+	r.metrics[trialID] = metrics
+	return nil
+}
+
+func trainingTask(t *testing.T, trialID string) *Task {
+	data, err := json.Marshal(trainingTaskData{ModelID: "m1", TuningJobID: "job", TrialID: trialID})
+	require.NoError(t, err)
+	return &Task{ID: "task", Type: TaskTypeModelTraining, Data: data}
+}
+
+func TestTrainingHandlerReportsResult(t *testing.T) {
+	reporter := newFakeTrialReporter()
+	handler := newTrainingHandler(reporter, func(ctx context.Context, data *trainingTaskData) (map[string]float64, error) {
+		return map[string]float64{"accuracy": 0.9}, nil
+	})
+
+	require.NoError(t, handler(context.Background(), trainingTask(t, "t1")))
+	assert.Equal(t, []string{"t1"}, reporter.started)
+	assert.Equal(t, "", reporter.finished["t1"])
+	assert.Equal(t, 0.9, reporter.metrics["t1"]["accuracy"])
+}
+
+func TestTrainingHandlerReportsFailure(t *testing.T) {
+	reporter := newFakeTrialReporter()
+	handler := newTrainingHandler(reporter, func(ctx context.Context, data *trainingTaskData) (map[string]float64, error) {
+		return nil, errors.New("out of memory")
+	})
+
+	err := handler(context.Background(), trainingTask(t, "t1"))
+	require.Error(t, err)
+	assert.Contains(t, reporter.finished["t1"], "out of memory")
+}
+
+func TestTrainingHandlerReportsPanic(t *testing.T) {
+	reporter := newFakeTrialReporter()
+	handler := newTrainingHandler(reporter, func(ctx context.Context, data *trainingTaskData) (map[string]float64, error) {
+		panic("cuda error")
+	})
+
+	err := handler(context.Background(), trainingTask(t, "t1"))
+	require.Error(t, err)
+	assert.Contains(t, reporter.finished["t1"], "cuda error")
+}
+
+func TestTrainingHandlerSkipsFinishedTrial(t *testing.T) {
+	reporter := newFakeTrialReporter()
+	reporter.startErr = errors.New("试验当前状态不能开始执行: failed")
+	trained := false
+	handler := newTrainingHandler(reporter, func(ctx context.Context, data *trainingTaskData) (map[string]float64, error) {
+		trained = true
+		return nil, nil
+	})
+
+	require.Error(t, handler(context.Background(), trainingTask(t, "t1")))
+	assert.False(t, trained)
+	assert.Empty(t, reporter.finished)
+}
+
+func TestWorkerRunRecoversPanic(t *testing.T) {
+	w := NewWorker(nil, nil, 1)
+	err := w.run(func(ctx context.Context, task *Task) error {
+		panic("boom")
+	}, &Task{ID: "task"})
+	assert.EqualError(t, err, "task panic: boom")
+}
@@ -0,0 +1,94 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// DefaultFlushInterval 用量落库的默认间隔
+const DefaultFlushInterval = time.Minute
+
+// flushBatch 每次从待落库集合取出的数量
+const flushBatch = 100
+
+// UsageStore 客户使用统计存储，由model.CustomerRepository实现
+type UsageStore interface {
+	UpdateUsage(usage *model.CustomerUsage) error
+}
+
+// Flush 将有变化的客户月度用量写入客户使用统计
+// Redis中保存的是整月累计值，重复写入结果相同；写入失败的客户会放回待落库集合
+func (m *Meter) Flush(ctx context.Context, store UsageStore) (int, error) {
+	flushed := 0
+	for {
+		members, err := m.redis.SPopN(ctx, dirtyKey(), flushBatch).Result()
+		if err != nil {
+			return flushed, fmt.Errorf("获取待落库用量失败: %w", err)
+		}
+		if len(members) == 0 {
+			return flushed, nil
+		}
+
+		for i, member := range members {
+			if err := m.flushOne(ctx, store, member); err != nil {
+				// 放回本批次未完成的客户，下次重试
+				rest := make([]interface{}, 0, len(members)-i)
+				for _, pending := range members[i:] {
+					rest = append(rest, pending)
+				}
+				if addErr := m.redis.SAdd(ctx, dirtyKey(), rest...).Err(); addErr != nil {
+					log.Printf("放回待落库用量失败: %v", addErr)
+				}
+				return flushed, err
+			}
+			flushed++
+		}
+	}
+}
+
+func (m *Meter) flushOne(ctx context.Context, store UsageStore, member string) error {
+	customerID, month, err := parseMember(member, time.Local)
+	if err != nil {
+		// 无法解析的键直接丢弃
+		log.Printf("跳过用量落库: %v", err)
+		return nil
+	}
+	usage, err := m.Usage(ctx, customerID, month)
+	if err != nil {
+		return err
+	}
+	if err := store.UpdateUsage(usage.CustomerUsage()); err != nil {
+		return fmt.Errorf("保存客户%d用量失败: %w", customerID, err)
+	}
+	return nil
+}
+
+// RunFlusher 定期将用量落库，ctx取消时再落库一次后返回
+func (m *Meter) RunFlusher(ctx context.Context, store UsageStore, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// 使用新的上下文完成最后一次落库
+			finalCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if _, err := m.Flush(finalCtx, store); err != nil {
+				log.Printf("用量落库失败: %v", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if _, err := m.Flush(ctx, store); err != nil {
+				log.Printf("用量落库失败: %v", err)
+			}
+		}
+	}
+}
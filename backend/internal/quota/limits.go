@@ -0,0 +1,6 @@
+package quota
+
+// LimitSource 查询客户每月的请求次数额度，0表示不限，由security.CustomerPlans实现
+type LimitSource interface {
+	RequestLimitFor(customerID int64) (int64, error)
+}
@@ -0,0 +1,222 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/queue"
+)
+
+// keyPrefix Redis键前缀
+const keyPrefix = "quota"
+
+// usageTTL 月度计数保留时长，覆盖当月和下月的落库
+const usageTTL = 70 * 24 * time.Hour
+
+// WarningLevels 用量达到套餐额度的这些比例时发出提醒，每个客户每月每个比例只提醒一次
+var WarningLevels = []float64{0.8, 0.9}
+
+// reserveScript 额度未用完时原子地计入一次请求，并记录需要落库的客户月份
+// 返回{是否允许, 已用次数}
+var reserveScript = redis.NewScript(`
+local used = tonumber(redis.call('HGET', KEYS[1], 'requests') or '0')
+local limit = tonumber(ARGV[1])
+if limit > 0 and used >= limit then
+	return {0, used}
+end
+used = redis.call('HINCRBY', KEYS[1], 'requests', 1)
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+redis.call('SADD', KEYS[2], ARGV[3])
+return {1, used}
+`)
+
+// Notifier 额度提醒通知，由queue.NotificationService实现
+type Notifier interface {
+	SendNotification(ctx context.Context, taskID string, nType queue.NotificationType, message string) error
+}
+
+// Reservation 一次计入额度的请求
+type Reservation struct {
+	CustomerID int64
+	Month      time.Time // 所属月份的第一天
+	Limit      int64     // 套餐每月请求次数，0表示不限
+	Used       int64     // 本次请求计入后的已用次数
+	Warning    float64   // 已达到的最高提醒比例，未达到时为0
+	Metered    bool      // Redis不可用时为false，本次请求未计量
+}
+
+// Remaining 剩余额度，不限额度时为-1
+func (r *Reservation) Remaining() int64 {
+	if r.Limit <= 0 {
+		return -1
+	}
+	if r.Used >= r.Limit {
+		return 0
+	}
+	return r.Limit - r.Used
+}
+
+// ResetAt 额度重置时间，即下个月第一天
+func (r *Reservation) ResetAt() time.Time {
+	return r.Month.AddDate(0, 1, 0)
+}
+
+// Meter 按客户和月份在Redis中计量可计费的识别请求
+type Meter struct {
+	redis    *redis.Client
+	notifier Notifier
+}
+
+// NewMeter 创建计量器，notifier可以为nil
+func NewMeter(redisClient *redis.Client, notifier Notifier) *Meter {
+	return &Meter{redis: redisClient, notifier: notifier}
+}
+
+// MonthOf 时间所属月份的第一天
+func MonthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// Reserve 计入一次请求，额度用完时返回QuotaExceeded错误和当前用量
+// Redis不可用时放行请求并返回未计量的Reservation，避免计量故障影响识别服务
+func (m *Meter) Reserve(ctx context.Context, customerID, limit int64, now time.Time) (*Reservation, error) {
+	month := MonthOf(now)
+	r := &Reservation{CustomerID: customerID, Month: month, Limit: limit}
+
+	member := usageMember(customerID, month)
+	values, err := reserveScript.Run(ctx, m.redis, []string{usageKey(member), dirtyKey()},
+		limit, int64(usageTTL.Seconds()), member).Int64Slice()
+	if err != nil || len(values) != 2 {
+		log.Printf("客户%d请求计量失败，本次不计量: %v", customerID, err)
+		return r, nil
+	}
+
+	r.Used = values[1]
+	r.Metered = true
+	if values[0] == 0 {
+		return r, errors.NewQuotaExceededError(fmt.Sprintf("本月请求额度已用完，共%d次", limit))
+	}
+
+	r.Warning = warningLevel(r.Used, limit)
+	if crossed := crossedLevel(r.Used, limit); crossed > 0 {
+		m.warn(ctx, r, crossed)
+	}
+	return r, nil
+}
+
+// Complete 记录请求结果，不计费的请求退回额度
+func (m *Meter) Complete(ctx context.Context, r *Reservation, billable bool, latency time.Duration) {
+	if r == nil || !r.Metered {
+		return
+	}
+
+	member := usageMember(r.CustomerID, r.Month)
+	key := usageKey(member)
+	pipe := m.redis.TxPipeline()
+	if billable {
+		pipe.HIncrBy(ctx, key, "success", 1)
+		pipe.HIncrBy(ctx, key, "latency_ms", latency.Milliseconds())
+	} else {
+		pipe.HIncrBy(ctx, key, "requests", -1)
+		pipe.HIncrBy(ctx, key, "fail", 1)
+	}
+	pipe.SAdd(ctx, dirtyKey(), member)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("客户%d记录请求结果失败: %v", r.CustomerID, err)
+	}
+}
+
+// Usage 查询客户某月的实时用量
+func (m *Meter) Usage(ctx context.Context, customerID int64, month time.Time) (*Usage, error) {
+	month = MonthOf(month)
+	fields, err := m.redis.HGetAll(ctx, usageKey(usageMember(customerID, month))).Result()
+	if err != nil {
+		return nil, fmt.Errorf("查询客户用量失败: %w", err)
+	}
+	usage := parseUsage(fields)
+	usage.CustomerID = customerID
+	usage.Month = month
+	return usage, nil
+}
+
+// warn 发送额度提醒，同一比例每月只发送一次
+func (m *Meter) warn(ctx context.Context, r *Reservation, level float64) {
+	if m.notifier == nil {
+		return
+	}
+	percent := int(math.Round(level * 100))
+	key := fmt.Sprintf("%s:warned:%s:%d", keyPrefix, usageMember(r.CustomerID, r.Month), percent)
+	first, err := m.redis.SetNX(ctx, key, 1, usageTTL).Result()
+	if err != nil || !first {
+		return
+	}
+
+	message := fmt.Sprintf("客户%d本月已使用%d次请求，达到套餐额度%d次的%d%%", r.CustomerID, r.Used, r.Limit, percent)
+	if err := m.notifier.SendNotification(ctx, fmt.Sprintf("customer:%d", r.CustomerID), queue.NotificationTypeQuotaWarning, message); err != nil {
+		log.Printf("发送额度提醒失败: %v", err)
+	}
+}
+
+// warningLevel 已用次数达到的最高提醒比例
+func warningLevel(used, limit int64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	level := 0.0
+	for _, l := range WarningLevels {
+		if float64(used) >= float64(limit)*l {
+			level = l
+		}
+	}
+	return level
+}
+
+// crossedLevel 本次请求恰好达到的提醒比例，计数逐次加一，每个比例只会被一次请求达到
+func crossedLevel(used, limit int64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	for _, l := range WarningLevels {
+		if used == int64(math.Ceil(float64(limit)*l)) {
+			return l
+		}
+	}
+	return 0
+}
+
+func usageMember(customerID int64, month time.Time) string {
+	return fmt.Sprintf("%d:%s", customerID, month.Format("200601"))
+}
+
+// parseMember 解析客户ID和月份
+func parseMember(member string, loc *time.Location) (int64, time.Time, error) {
+	parts := strings.SplitN(member, ":", 2)
+	if len(parts) != 2 {
+		return 0, time.Time{}, fmt.Errorf("无效的用量键: %s", member)
+	}
+	customerID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("无效的用量键: %s", member)
+	}
+	month, err := time.ParseInLocation("200601", parts[1], loc)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("无效的用量键: %s", member)
+	}
+	return customerID, month, nil
+}
+
+func usageKey(member string) string {
+	return fmt.Sprintf("%s:usage:%s", keyPrefix, member)
+}
+
+func dirtyKey() string {
+	return keyPrefix + ":dirty"
+}
@@ -0,0 +1,95 @@
+package quota
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarningLevels(t *testing.T) {
+	assert.Equal(t, 0.0, warningLevel(79, 100))
+	assert.Equal(t, 0.8, warningLevel(80, 100))
+	assert.Equal(t, 0.9, warningLevel(95, 100))
+	assert.Equal(t, 0.0, warningLevel(1000, 0))
+
+	assert.Equal(t, 0.8, crossedLevel(80, 100))
+	assert.Equal(t, 0.0, crossedLevel(81, 100))
+	assert.Equal(t, 0.9, crossedLevel(90, 100))
+	// 额度不能整除时向上取整
+	assert.Equal(t, 0.8, crossedLevel(3, 3))
+	assert.Equal(t, 0.0, crossedLevel(5, 0))
+}
+
+func TestReservation(t *testing.T) {
+	month := MonthOf(time.Date(2024, 12, 15, 10, 0, 0, 0, time.Local))
+	assert.Equal(t, time.Date(2024, 12, 1, 0, 0, 0, 0, time.Local), month)
+
+	r := &Reservation{Month: month, Limit: 10, Used: 4}
+	assert.Equal(t, int64(6), r.Remaining())
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local), r.ResetAt())
+
+	r.Used = 12
+	assert.Equal(t, int64(0), r.Remaining())
+	r.Limit = 0
+	assert.Equal(t, int64(-1), r.Remaining())
+}
+
+func TestMemberRoundTrip(t *testing.T) {
+	month := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	member := usageMember(42, month)
+	assert.Equal(t, "42:202403", member)
+
+	customerID, parsed, err := parseMember(member, time.Local)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), customerID)
+	assert.True(t, parsed.Equal(month))
+
+	_, _, err = parseMember("bad", time.Local)
+	assert.Error(t, err)
+}
+
+func TestUsageToCustomerUsage(t *testing.T) {
+	usage := parseUsage(map[string]string{"requests": "12", "success": "10", "fail": "3", "latency_ms": "1500"})
+	usage.CustomerID = 7
+	record := usage.CustomerUsage()
+	assert.Equal(t, int64(7), record.CustomerID)
+	assert.Equal(t, int64(12), record.RequestCount)
+	assert.Equal(t, int64(3), record.FailCount)
+	assert.Equal(t, 150.0, record.AvgLatency)
+	assert.Equal(t, 0.0, (&Usage{}).AvgLatency())
+}
+
+type fixedLimit int64
+
+func (f fixedLimit) RequestLimitFor(int64) (int64, error) { return int64(f), nil }
+
+func TestEnforceAllowsWhenRedisUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	defer client.Close()
+	meter := NewMeter(client, nil)
+
+	r := gin.New()
+	r.POST("/recognize", func(c *gin.Context) {
+		c.Set("customerId", int64(1))
+		c.Next()
+	}, Enforce(meter, fixedLimit(10)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/recognize", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-Quota-Limit"))
+
+	reservation, err := meter.Reserve(context.Background(), 1, 10, time.Now())
+	require.NoError(t, err)
+	assert.False(t, reservation.Metered)
+}
@@ -0,0 +1,73 @@
+package quota
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/security"
+)
+
+// Enforce 计量可计费的识别请求，额度用完时拒绝请求
+// 响应状态为2xx的请求计费，其余请求退回额度；无法识别客户时不计量
+func Enforce(meter *Meter, limits LimitSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerID, ok := security.CustomerIDFrom(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		limit, err := limits.RequestLimitFor(customerID)
+		if err != nil {
+			log.Printf("获取客户%d的套餐额度失败，本次不限额: %v", customerID, err)
+			limit = 0
+		}
+
+		ctx := c.Request.Context()
+		reservation, err := meter.Reserve(ctx, customerID, limit, time.Now())
+		setHeaders(c, reservation)
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
+			c.AbortWithStatusJSON(appErr.HTTPCode, gin.H{
+				"code":    appErr.HTTPCode,
+				"message": appErr.Message,
+				"data": gin.H{
+					"limit":   reservation.Limit,
+					"used":    reservation.Used,
+					"resetAt": reservation.ResetAt(),
+				},
+			})
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		// 客户端断开后请求上下文已取消，仍需记录结果
+		status := c.Writer.Status()
+		completeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		meter.Complete(completeCtx, reservation, status >= http.StatusOK && status < http.StatusMultipleChoices, time.Since(start))
+	}
+}
+
+// setHeaders 写入额度相关的响应头，不限额度时不写入
+func setHeaders(c *gin.Context, r *Reservation) {
+	if r == nil || r.Limit <= 0 || !r.Metered {
+		return
+	}
+	c.Header("X-Quota-Limit", strconv.FormatInt(r.Limit, 10))
+	c.Header("X-Quota-Remaining", strconv.FormatInt(r.Remaining(), 10))
+	c.Header("X-Quota-Reset", strconv.FormatInt(r.ResetAt().Unix(), 10))
+	if r.Warning > 0 {
+		c.Header("X-Quota-Warning", fmt.Sprintf("%.0f%%", r.Warning*100))
+	}
+}
@@ -0,0 +1,52 @@
+package quota
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// Usage 客户某月的用量
+type Usage struct {
+	CustomerID   int64     `json:"customerId"`
+	Month        time.Time `json:"month"`
+	RequestCount int64     `json:"requestCount"` // 计费请求次数，包括处理中的请求
+	SuccessCount int64     `json:"successCount"`
+	FailCount    int64     `json:"failCount"`
+	LatencyMS    int64     `json:"-"` // 成功请求的总耗时
+}
+
+// AvgLatency 成功请求的平均耗时，单位毫秒
+func (u *Usage) AvgLatency() float64 {
+	if u.SuccessCount == 0 {
+		return 0
+	}
+	return float64(u.LatencyMS) / float64(u.SuccessCount)
+}
+
+// CustomerUsage 转换为客户使用统计
+func (u *Usage) CustomerUsage() *model.CustomerUsage {
+	return &model.CustomerUsage{
+		CustomerID:   u.CustomerID,
+		RequestCount: u.RequestCount,
+		SuccessCount: u.SuccessCount,
+		FailCount:    u.FailCount,
+		AvgLatency:   u.AvgLatency(),
+		Month:        u.Month,
+	}
+}
+
+// parseUsage 解析Redis中的用量计数
+func parseUsage(fields map[string]string) *Usage {
+	value := func(name string) int64 {
+		v, _ := strconv.ParseInt(fields[name], 10, 64)
+		return v
+	}
+	return &Usage{
+		RequestCount: value("requests"),
+		SuccessCount: value("success"),
+		FailCount:    value("fail"),
+		LatencyMS:    value("latency_ms"),
+	}
+}
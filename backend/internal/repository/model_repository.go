@@ -13,6 +13,12 @@ import (
 	"github.com/image-recognition-engine/internal/model"
 )
 
+// ErrProductionConflict 同一模型已存在其他生产版本
+var ErrProductionConflict = errors.New("model already has a production version")
+
+// errVersionChanged 事务中版本状态已被其他操作修改，用于中止事务
+var errVersionChanged = errors.New("model version status changed")
+
 type ModelRepository struct {
 	db *mongo.Database
 }
@@ -60,6 +66,137 @@ func (r *ModelRepository) GetModelVersions(ctx context.Context, status string, p
 	return versions, total, nil
 }
 
+// GetModelVersion 根据ID获取模型版本，不存在时返回nil
+func (r *ModelRepository) GetModelVersion(ctx context.Context, id string) (*model.ModelVersion, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid model version id")
+	}
+
+	var version model.ModelVersion
+	err = r.db.Collection("model_versions").FindOne(ctx, bson.M{"_id": objectID}).Decode(&version)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "get model version failed")
+	}
+
+	return &version, nil
+}
+
+// GetProductionVersion 获取模型当前的生产版本，没有时返回nil
+func (r *ModelRepository) GetProductionVersion(ctx context.Context, modelID string) (*model.ModelVersion, error) {
+	filter := bson.M{"modelId": modelID, "status": model.ModelVersionProduction}
+
+	var version model.ModelVersion
+	err := r.db.Collection("model_versions").FindOne(ctx, filter).Decode(&version)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "get production version failed")
+	}
+
+	return &version, nil
+}
+
+// FindModelVersion 根据模型ID和版本号获取模型版本，不存在时返回nil
+func (r *ModelRepository) FindModelVersion(ctx context.Context, modelID, version string) (*model.ModelVersion, error) {
+	filter := bson.M{"modelId": modelID, "version": version}
+
+	var v model.ModelVersion
+	err := r.db.Collection("model_versions").FindOne(ctx, filter).Decode(&v)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "find model version failed")
+	}
+
+	return &v, nil
+}
+
+// TransitionModelVersion 仅当版本状态为from时将其更新为version.Status，并保存审批相关字段
+// 同一模型已有生产版本时，部分唯一索引会拒绝第二个production，返回ErrProductionConflict
+func (r *ModelRepository) TransitionModelVersion(ctx context.Context, version *model.ModelVersion, from string) (bool, error) {
+	version.UpdateTime = time.Now()
+	filter := bson.M{"_id": version.ID, "status": from}
+	update := bson.M{"$set": bson.M{
+		"status":            version.Status,
+		"evaluationId":      version.EvaluationID,
+		"requestedBy":       version.RequestedBy,
+		"approvedBy":        version.ApprovedBy,
+		"previousVersionId": version.PreviousVersionID,
+		"promoteTime":       version.PromoteTime,
+		"updateTime":        version.UpdateTime,
+	}}
+
+	result, err := r.db.Collection("model_versions").UpdateOne(ctx, filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, ErrProductionConflict
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "update model version status failed")
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// SwapProductionVersion 在同一事务中将current从生产版本更新为current.Status，并将状态为from的next更新为next.Status
+// 任一版本的状态已被其他操作修改时回滚整个事务并返回false，不会出现模型没有生产版本的中间状态；current为nil时只更新next
+func (r *ModelRepository) SwapProductionVersion(ctx context.Context, current, next *model.ModelVersion, from string) (bool, error) {
+	session, err := r.db.Client().StartSession()
+	if err != nil {
+		return false, errors.Wrap(err, "start session failed")
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if current != nil {
+			ok, err := r.TransitionModelVersion(sc, current, model.ModelVersionProduction)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, errVersionChanged
+			}
+		}
+		ok, err := r.TransitionModelVersion(sc, next, from)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errVersionChanged
+		}
+		return nil, nil
+	})
+	if errors.Is(err, errVersionChanged) {
+		return false, nil
+	}
+	if err != nil {
+		if errors.Is(err, ErrProductionConflict) {
+			return false, ErrProductionConflict
+		}
+		return false, errors.Wrap(err, "swap production version failed")
+	}
+
+	return true, nil
+}
+
+// SetModelVersionArtifact 保存模型版本的文件路径、校验和及大小
+func (r *ModelRepository) SetModelVersionArtifact(ctx context.Context, id primitive.ObjectID, filePath, checksum string, size int64) error {
+	update := bson.M{"$set": bson.M{
+		"filePath":     filePath,
+		"checksum":     checksum,
+		"artifactSize": size,
+		"updateTime":   time.Now(),
+	}}
+
+	_, err := r.db.Collection("model_versions").UpdateOne(ctx, bson.M{"_id": id}, update)
+	return errors.Wrap(err, "update model version artifact failed")
+}
+
 // SaveModelPerformance 保存模型性能数据
 func (r *ModelRepository) SaveModelPerformance(ctx context.Context, perf *model.ModelPerformance) error {
 	perf.CreateTime = time.Now()
@@ -67,18 +204,62 @@ func (r *ModelRepository) SaveModelPerformance(ctx context.Context, perf *model.
 	return errors.Wrap(err, "save model performance failed")
 }
 
-// GetModelPerformance 获取模型性能数据
+// UpsertPerformanceBucket 按模型、版本和采集桶起始时间保存性能数据，重复采集同一个桶时覆盖
+func (r *ModelRepository) UpsertPerformanceBucket(ctx context.Context, perf *model.ModelPerformance) error {
+	perf.CreateTime = time.Now()
+	filter := bson.M{
+		"modelId":      perf.ModelID,
+		"modelVersion": perf.ModelVersion,
+		"bucketStart":  perf.BucketStart,
+	}
+	doc := *perf
+	doc.ID = primitive.NilObjectID
+
+	_, err := r.db.Collection("model_performance").ReplaceOne(ctx, filter, doc, options.Replace().SetUpsert(true))
+	return errors.Wrap(err, "upsert model performance bucket failed")
+}
+
+// GetPerformanceBuckets 获取[startTime, endTime)内自动采集的性能数据，version为空时返回全部版本
+func (r *ModelRepository) GetPerformanceBuckets(ctx context.Context, modelID primitive.ObjectID, version string, startTime, endTime time.Time) ([]*model.ModelPerformance, error) {
+	filter := bson.M{
+		"modelId":     modelID,
+		"bucketStart": bson.M{"$gte": startTime, "$lt": endTime},
+	}
+	if version != "" {
+		filter["modelVersion"] = version
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "bucketStart", Value: 1}})
+	cursor, err := r.db.Collection("model_performance").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "find model performance buckets failed")
+	}
+	defer cursor.Close(ctx)
+
+	buckets := make([]*model.ModelPerformance, 0)
+	if err := cursor.All(ctx, &buckets); err != nil {
+		return nil, errors.Wrap(err, "decode model performance buckets failed")
+	}
+	return buckets, nil
+}
+
+// GetModelPerformance 获取时间范围内最新的模型性能数据
 func (r *ModelRepository) GetModelPerformance(ctx context.Context, modelID primitive.ObjectID, startTime, endTime time.Time) (*model.ModelPerformance, error) {
 	filter := bson.M{"modelId": modelID}
+	createTime := bson.M{}
 	if !startTime.IsZero() {
-		filter["createTime"] = bson.M{"$gte": startTime}
+		createTime["$gte"] = startTime
 	}
 	if !endTime.IsZero() {
-		filter["createTime"] = bson.M{"$lte": endTime}
+		createTime["$lte"] = endTime
+	}
+	if len(createTime) > 0 {
+		filter["createTime"] = createTime
 	}
 
 	var perf model.ModelPerformance
-	err := r.db.Collection("model_performance").FindOne(ctx, filter).Decode(&perf)
+	opts := options.FindOne().SetSort(bson.D{{Key: "createTime", Value: -1}})
+	err := r.db.Collection("model_performance").FindOne(ctx, filter, opts).Decode(&perf)
 	if err != nil {
 		return nil, errors.Wrap(err, "get model performance failed")
 	}
@@ -0,0 +1,168 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// APIKeyRepositoryImpl API密钥数据访问实现
+type APIKeyRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewAPIKeyRepository 创建API密钥数据访问实例
+func NewAPIKeyRepository() model.APIKeyRepository {
+	return &APIKeyRepositoryImpl{
+		collection: database.MongoDB.Collection("api_keys"),
+	}
+}
+
+// Create 创建密钥
+func (r *APIKeyRepositoryImpl) Create(key *model.APIKey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 设置创建和更新时间
+	now := time.Now()
+	key.CreateTime = now
+	key.UpdateTime = now
+
+	result, err := r.collection.InsertOne(ctx, key)
+	if err != nil {
+		return fmt.Errorf("创建API密钥失败: %w", err)
+	}
+	key.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	return nil
+}
+
+// FindByID 根据ID获取密钥
+func (r *APIKeyRepositoryImpl) FindByID(id string) (*model.APIKey, error) {
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	return r.findOne(bson.M{"_id": objectID})
+}
+
+// FindByHash 根据密钥摘要获取密钥
+func (r *APIKeyRepositoryImpl) FindByHash(hash string) (*model.APIKey, error) {
+	return r.findOne(bson.M{"key_hash": hash})
+}
+
+func (r *APIKeyRepositoryImpl) findOne(filter bson.M) (*model.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var key model.APIKey
+	err := r.collection.FindOne(ctx, filter).Decode(&key)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询API密钥失败: %w", err)
+	}
+
+	return &key, nil
+}
+
+// ListByApp 获取应用的全部密钥
+func (r *APIKeyRepositoryImpl) ListByApp(appID string) ([]*model.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"create_time": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"app_id": appID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询API密钥列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	keys := make([]*model.APIKey, 0)
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("解析API密钥数据失败: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke 吊销客户的密钥
+func (r *APIKeyRepositoryImpl) Revoke(id string, customerID int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	filter := bson.M{"_id": objectID, "customer_id": customerID}
+	update := bson.M{"$set": bson.M{"status": model.APIKeyRevoked, "update_time": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("吊销API密钥失败: %w", err)
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// MarkRotated 将客户的有效密钥标记为已轮换
+func (r *APIKeyRepositoryImpl) MarkRotated(id string, customerID int64, replacedBy string, overlapUntil time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	// 只有未被轮换过的有效密钥可以轮换，避免并发轮换产生多个新密钥
+	filter := bson.M{
+		"_id":         objectID,
+		"customer_id": customerID,
+		"status":      model.APIKeyActive,
+		"replaced_by": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{
+		"replaced_by":   replacedBy,
+		"overlap_until": overlapUntil,
+		"update_time":   time.Now(),
+	}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("标记API密钥轮换失败: %w", err)
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// TouchLastUsed 记录密钥最近一次使用的时间和来源IP
+func (r *APIKeyRepositoryImpl) TouchLastUsed(id, ip string, at time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	update := bson.M{"$set": bson.M{"last_used_at": at, "last_used_ip": ip}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update); err != nil {
+		return fmt.Errorf("更新API密钥使用时间失败: %w", err)
+	}
+
+	return nil
+}
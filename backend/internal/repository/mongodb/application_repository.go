@@ -0,0 +1,141 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ApplicationRepositoryImpl 应用数据访问实现
+type ApplicationRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewApplicationRepository 创建应用数据访问实例
+func NewApplicationRepository() model.ApplicationRepository {
+	return &ApplicationRepositoryImpl{
+		collection: database.MongoDB.Collection("applications"),
+	}
+}
+
+// Create 创建应用
+func (r *ApplicationRepositoryImpl) Create(app *model.Application) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 设置创建和更新时间
+	now := time.Now()
+	app.CreateTime = now
+	app.UpdateTime = now
+
+	result, err := r.collection.InsertOne(ctx, app)
+	if err != nil {
+		return fmt.Errorf("创建应用失败: %w", err)
+	}
+	app.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	return nil
+}
+
+// Update 更新应用的名称、描述和状态
+func (r *ApplicationRepositoryImpl) Update(app *model.Application) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(app.ID)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	app.UpdateTime = time.Now()
+	update := bson.M{"$set": bson.M{
+		"name":        app.Name,
+		"description": app.Description,
+		"status":      app.Status,
+		"update_time": app.UpdateTime,
+	}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID, "customer_id": app.CustomerID}, update); err != nil {
+		return fmt.Errorf("更新应用失败: %w", err)
+	}
+
+	return nil
+}
+
+// Delete 删除客户的应用
+func (r *ApplicationRepositoryImpl) Delete(id string, customerID int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID, "customer_id": customerID})
+	if err != nil {
+		return false, fmt.Errorf("删除应用失败: %w", err)
+	}
+
+	return result.DeletedCount == 1, nil
+}
+
+// FindByID 根据ID获取应用
+func (r *ApplicationRepositoryImpl) FindByID(id string) (*model.Application, error) {
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	return r.findOne(bson.M{"_id": objectID})
+}
+
+// FindByAppID 根据AppID获取应用
+func (r *ApplicationRepositoryImpl) FindByAppID(appID string) (*model.Application, error) {
+	return r.findOne(bson.M{"app_id": appID})
+}
+
+func (r *ApplicationRepositoryImpl) findOne(filter bson.M) (*model.Application, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var app model.Application
+	err := r.collection.FindOne(ctx, filter).Decode(&app)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询应用失败: %w", err)
+	}
+
+	return &app, nil
+}
+
+// ListByCustomer 获取客户的全部应用
+func (r *ApplicationRepositoryImpl) ListByCustomer(customerID int64) ([]*model.Application, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"create_time": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"customer_id": customerID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询应用列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	apps := make([]*model.Application, 0)
+	if err := cursor.All(ctx, &apps); err != nil {
+		return nil, fmt.Errorf("解析应用数据失败: %w", err)
+	}
+
+	return apps, nil
+}
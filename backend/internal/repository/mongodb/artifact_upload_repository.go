@@ -0,0 +1,113 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ArtifactUploadRepositoryImpl 分块上传会话数据访问实现
+type ArtifactUploadRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewArtifactUploadRepository 创建分块上传会话数据访问实例
+func NewArtifactUploadRepository() model.ArtifactUploadRepository {
+	return &ArtifactUploadRepositoryImpl{
+		collection: database.MongoDB.Collection("artifact_uploads"),
+	}
+}
+
+// Create 创建上传会话
+func (r *ArtifactUploadRepositoryImpl) Create(upload *model.ArtifactUpload) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 设置创建和更新时间
+	now := time.Now()
+	upload.CreateTime = now
+	upload.UpdateTime = now
+	if upload.Received == nil {
+		upload.Received = []int{}
+	}
+
+	result, err := r.collection.InsertOne(ctx, upload)
+	if err != nil {
+		return fmt.Errorf("创建上传会话失败: %w", err)
+	}
+	upload.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	return nil
+}
+
+// FindByID 根据ID获取上传会话
+func (r *ArtifactUploadRepositoryImpl) FindByID(id string) (*model.ArtifactUpload, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	var upload model.ArtifactUpload
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&upload)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 会话不存在
+		}
+		return nil, fmt.Errorf("查询上传会话失败: %w", err)
+	}
+
+	return &upload, nil
+}
+
+// AddChunk 记录已接收的分块，重复上传同一分块不会重复记录
+func (r *ArtifactUploadRepositoryImpl) AddChunk(id string, index int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	update := bson.M{
+		"$addToSet": bson.M{"received": index},
+		"$set":      bson.M{"update_time": time.Now()},
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update); err != nil {
+		return fmt.Errorf("记录上传分块失败: %w", err)
+	}
+
+	return nil
+}
+
+// TransitionStatus 条件更新上传会话状态
+func (r *ArtifactUploadRepositoryImpl) TransitionStatus(id, from, to string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	filter := bson.M{"_id": objectID, "status": from}
+	update := bson.M{"$set": bson.M{"status": to, "update_time": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("更新上传会话状态失败: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,217 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DatasetRepositoryImpl 数据集数据访问实现
+type DatasetRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewDatasetRepository 创建数据集数据访问实例
+func NewDatasetRepository() model.DatasetRepository {
+	return &DatasetRepositoryImpl{
+		collection: database.MongoDB.Collection("datasets"),
+	}
+}
+
+// Create 创建数据集
+func (r *DatasetRepositoryImpl) Create(dataset *model.Dataset) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 设置创建和更新时间
+	now := time.Now()
+	dataset.CreateTime = now
+	dataset.UpdateTime = now
+
+	// 插入文档
+	result, err := r.collection.InsertOne(ctx, dataset)
+	if err != nil {
+		return "", fmt.Errorf("创建数据集失败: %w", err)
+	}
+
+	// 获取插入的ID
+	id := result.InsertedID.(primitive.ObjectID).Hex()
+	return id, nil
+}
+
+// Update 更新数据集
+func (r *DatasetRepositoryImpl) Update(dataset *model.Dataset) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 设置更新时间
+	dataset.UpdateTime = time.Now()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(dataset.ID)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	// 更新文档，样本总数由UpdateTotalCount维护
+	filter := bson.M{"_id": objectID}
+	update := bson.M{"$set": bson.M{
+		"name":         dataset.Name,
+		"description":  dataset.Description,
+		"type":         dataset.Type,
+		"storage_path": dataset.StoragePath,
+		"update_time":  dataset.UpdateTime,
+	}}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("更新数据集失败: %w", err)
+	}
+
+	return nil
+}
+
+// Delete 删除数据集
+func (r *DatasetRepositoryImpl) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	// 删除文档
+	filter := bson.M{"_id": objectID}
+	_, err = r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("删除数据集失败: %w", err)
+	}
+
+	return nil
+}
+
+// List 获取数据集列表
+func (r *DatasetRepositoryImpl) List(userID int64, page, size int) ([]*model.Dataset, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 构建查询条件
+	filter := bson.M{}
+	if userID > 0 {
+		filter["user_id"] = userID
+	}
+
+	// 计算总数
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取数据集总数失败: %w", err)
+	}
+
+	// 分页查询
+	opts := options.Find()
+	opts.SetSort(bson.M{"create_time": -1}) // 按创建时间降序
+	opts.SetSkip(int64((page - 1) * size))
+	opts.SetLimit(int64(size))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询数据集列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	// 解析结果
+	datasets := make([]*model.Dataset, 0)
+	for cursor.Next(ctx) {
+		var d model.Dataset
+		if err := cursor.Decode(&d); err != nil {
+			return nil, 0, fmt.Errorf("解析数据集数据失败: %w", err)
+		}
+		datasets = append(datasets, &d)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, 0, fmt.Errorf("遍历数据集数据失败: %w", err)
+	}
+
+	return datasets, total, nil
+}
+
+// FindByID 根据ID查找数据集
+func (r *DatasetRepositoryImpl) FindByID(id string) (*model.Dataset, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	// 查询文档
+	filter := bson.M{"_id": objectID}
+	var d model.Dataset
+	err = r.collection.FindOne(ctx, filter).Decode(&d)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 数据集不存在
+		}
+		return nil, fmt.Errorf("查询数据集失败: %w", err)
+	}
+
+	return &d, nil
+}
+
+// UpdateTotalCount 更新数据集总数
+func (r *DatasetRepositoryImpl) UpdateTotalCount(id string, totalCount int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	// 更新文档
+	filter := bson.M{"_id": objectID}
+	update := bson.M{"$set": bson.M{
+		"total_count": totalCount,
+		"update_time": time.Now(),
+	}}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("更新数据集总数失败: %w", err)
+	}
+
+	return nil
+}
+
+// FindByParent 查找由指定数据集派生的数据集
+func (r *DatasetRepositoryImpl) FindByParent(parentID string) ([]*model.Dataset, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"create_time": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"lineage.parent_id": parentID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询派生数据集失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	datasets := make([]*model.Dataset, 0)
+	if err := cursor.All(ctx, &datasets); err != nil {
+		return nil, fmt.Errorf("解析数据集数据失败: %w", err)
+	}
+
+	return datasets, nil
+}
@@ -0,0 +1,306 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DatasetSampleRepositoryImpl 数据集样本数据访问实现
+type DatasetSampleRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewDatasetSampleRepository 创建数据集样本数据访问实例
+func NewDatasetSampleRepository() model.DatasetSampleRepository {
+	return &DatasetSampleRepositoryImpl{
+		collection: database.MongoDB.Collection("dataset_samples"),
+	}
+}
+
+// Create 创建样本
+func (r *DatasetSampleRepositoryImpl) Create(sample *model.DatasetSample) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 设置创建和更新时间
+	now := time.Now()
+	sample.CreateTime = now
+	sample.UpdateTime = now
+	if sample.Labels == nil {
+		sample.Labels = []string{}
+	}
+	if sample.Annotations == nil {
+		sample.Annotations = []model.Annotation{}
+	}
+
+	// 插入文档
+	result, err := r.collection.InsertOne(ctx, sample)
+	if err != nil {
+		return "", fmt.Errorf("创建样本失败: %w", err)
+	}
+
+	// 获取插入的ID
+	id := result.InsertedID.(primitive.ObjectID).Hex()
+	return id, nil
+}
+
+// Update 更新样本
+func (r *DatasetSampleRepositoryImpl) Update(sample *model.DatasetSample) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 设置更新时间
+	sample.UpdateTime = time.Now()
+	if sample.Labels == nil {
+		sample.Labels = []string{}
+	}
+	if sample.Annotations == nil {
+		sample.Annotations = []model.Annotation{}
+	}
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(sample.ID)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	// 更新文档
+	filter := bson.M{"_id": objectID}
+	update := bson.M{"$set": bson.M{
+		"file_name":   sample.FileName,
+		"file_path":   sample.FilePath,
+		"file_size":   sample.FileSize,
+		"width":       sample.Width,
+		"height":      sample.Height,
+		"labels":      sample.Labels,
+		"annotations": sample.Annotations,
+		"update_time": sample.UpdateTime,
+	}}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("更新样本失败: %w", err)
+	}
+
+	return nil
+}
+
+// Delete 删除样本
+func (r *DatasetSampleRepositoryImpl) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	// 删除文档
+	filter := bson.M{"_id": objectID}
+	_, err = r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("删除样本失败: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByDataset 删除数据集下所有样本
+func (r *DatasetSampleRepositoryImpl) DeleteByDataset(datasetID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{"dataset_id": datasetID})
+	if err != nil {
+		return fmt.Errorf("删除数据集样本失败: %w", err)
+	}
+
+	return nil
+}
+
+// List 获取样本列表
+func (r *DatasetSampleRepositoryImpl) List(datasetID, label string, page, size int) ([]*model.DatasetSample, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 构建查询条件
+	filter := bson.M{"dataset_id": datasetID}
+	if label != "" {
+		filter["labels"] = label
+	}
+
+	// 计算总数
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取样本总数失败: %w", err)
+	}
+
+	// 分页查询
+	opts := options.Find()
+	opts.SetSort(bson.M{"create_time": -1}) // 按创建时间降序
+	opts.SetSkip(int64((page - 1) * size))
+	opts.SetLimit(int64(size))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询样本列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	// 解析结果
+	samples := make([]*model.DatasetSample, 0)
+	for cursor.Next(ctx) {
+		var s model.DatasetSample
+		if err := cursor.Decode(&s); err != nil {
+			return nil, 0, fmt.Errorf("解析样本数据失败: %w", err)
+		}
+		samples = append(samples, &s)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, 0, fmt.Errorf("遍历样本数据失败: %w", err)
+	}
+
+	return samples, total, nil
+}
+
+// ListAll 获取数据集全部样本
+func (r *DatasetSampleRepositoryImpl) ListAll(datasetID string) ([]*model.DatasetSample, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// 按创建时间升序，保证导出和拆分结果稳定
+	opts := options.Find().SetSort(bson.D{{Key: "create_time", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"dataset_id": datasetID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询样本列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	samples := make([]*model.DatasetSample, 0)
+	if err := cursor.All(ctx, &samples); err != nil {
+		return nil, fmt.Errorf("解析样本数据失败: %w", err)
+	}
+
+	return samples, nil
+}
+
+// FindByID 根据ID查找样本
+func (r *DatasetSampleRepositoryImpl) FindByID(id string) (*model.DatasetSample, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	// 查询文档
+	filter := bson.M{"_id": objectID}
+	var s model.DatasetSample
+	err = r.collection.FindOne(ctx, filter).Decode(&s)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 样本不存在
+		}
+		return nil, fmt.Errorf("查询样本失败: %w", err)
+	}
+
+	return &s, nil
+}
+
+// Count 统计数据集样本数
+func (r *DatasetSampleRepositoryImpl) Count(datasetID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"dataset_id": datasetID})
+	if err != nil {
+		return 0, fmt.Errorf("统计样本数失败: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountByLabel 按标签统计样本数
+func (r *DatasetSampleRepositoryImpl) CountByLabel(datasetID string) (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// 展开标签后分组计数，未标注样本的标签为null
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"dataset_id": datasetID}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$labels", "preserveNullAndEmptyArrays": true}}},
+		{{Key: "$group", Value: bson.M{"_id": "$labels", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("统计标签分布失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[string]int64)
+	for cursor.Next(ctx) {
+		var row struct {
+			Label *string `bson:"_id"`
+			Count int64   `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, fmt.Errorf("解析标签统计失败: %w", err)
+		}
+		label := ""
+		if row.Label != nil {
+			label = *row.Label
+		}
+		counts[label] += row.Count
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("遍历标签统计失败: %w", err)
+	}
+
+	return counts, nil
+}
+
+// UpdateAnnotations 更新样本标注和标签，以update_time做乐观锁，避免并发标注互相覆盖
+func (r *DatasetSampleRepositoryImpl) UpdateAnnotations(id string, expected time.Time, annotations []model.Annotation, labels []string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("无效的ID格式: %w", err)
+	}
+	if annotations == nil {
+		annotations = []model.Annotation{}
+	}
+	if labels == nil {
+		labels = []string{}
+	}
+
+	filter := bson.M{"_id": objectID, "update_time": expected}
+	update := bson.M{"$set": bson.M{
+		"annotations": annotations,
+		"labels":      labels,
+		"update_time": time.Now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("更新样本标注失败: %w", err)
+	}
+
+	return result.MatchedCount == 1, nil
+}
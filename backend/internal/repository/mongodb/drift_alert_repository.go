@@ -0,0 +1,158 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DriftAlertRepositoryImpl 漂移告警数据访问实现
+type DriftAlertRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewDriftAlertRepository 创建漂移告警数据访问实例
+func NewDriftAlertRepository() model.DriftAlertRepository {
+	return &DriftAlertRepositoryImpl{
+		collection: database.MongoDB.Collection("drift_alerts"),
+	}
+}
+
+// Create 创建告警
+func (r *DriftAlertRepositoryImpl) Create(alert *model.DriftAlert) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 设置创建和更新时间
+	now := time.Now()
+	alert.CreateTime = now
+	alert.UpdateTime = now
+
+	result, err := r.collection.InsertOne(ctx, alert)
+	if err != nil {
+		return fmt.Errorf("创建漂移告警失败: %w", err)
+	}
+	alert.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	return nil
+}
+
+// FindActive 获取模型版本特征未关闭的告警
+func (r *DriftAlertRepositoryImpl) FindActive(modelID, modelVersion, feature string) (*model.DriftAlert, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"model_id":      modelID,
+		"model_version": modelVersion,
+		"feature":       feature,
+		"status":        bson.M{"$in": bson.A{model.DriftAlertOpen, model.DriftAlertAcknowledged}},
+	}
+
+	var alert model.DriftAlert
+	err := r.collection.FindOne(ctx, filter).Decode(&alert)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 没有未关闭的告警
+		}
+		return nil, fmt.Errorf("查询漂移告警失败: %w", err)
+	}
+
+	return &alert, nil
+}
+
+// List 分页获取告警
+func (r *DriftAlertRepositoryImpl) List(modelID, status string, page, size int) ([]*model.DriftAlert, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 构建查询条件
+	filter := bson.M{}
+	if modelID != "" {
+		filter["model_id"] = modelID
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	// 计算总数
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("计算漂移告警总数失败: %w", err)
+	}
+
+	// 设置分页选项
+	skip := int64((page - 1) * size)
+	limit := int64(size)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.M{"create_time": -1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询漂移告警列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	alerts := make([]*model.DriftAlert, 0)
+	if err := cursor.All(ctx, &alerts); err != nil {
+		return nil, 0, fmt.Errorf("解析漂移告警数据失败: %w", err)
+	}
+
+	return alerts, total, nil
+}
+
+// UpdateMetrics 更新告警的PSI、KL和说明
+func (r *DriftAlertRepositoryImpl) UpdateMetrics(id string, psi, kl float64, message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	update := bson.M{"$set": bson.M{
+		"psi":         psi,
+		"kl":          kl,
+		"message":     message,
+		"update_time": time.Now(),
+	}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update); err != nil {
+		return fmt.Errorf("更新漂移告警失败: %w", err)
+	}
+
+	return nil
+}
+
+// TransitionStatus 条件更新告警状态
+func (r *DriftAlertRepositoryImpl) TransitionStatus(id, from, to string, userID int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	set := bson.M{"status": to, "update_time": time.Now()}
+	if to == model.DriftAlertAcknowledged {
+		set["acknowledged_by"] = userID
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID, "status": from}, bson.M{"$set": set})
+	if err != nil {
+		return false, fmt.Errorf("更新漂移告警状态失败: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,177 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ModelEvaluationRepositoryImpl 模型评估记录数据访问实现
+type ModelEvaluationRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewModelEvaluationRepository 创建模型评估记录数据访问实例
+func NewModelEvaluationRepository() model.ModelEvaluationRepository {
+	return &ModelEvaluationRepositoryImpl{
+		collection: database.MongoDB.Collection("model_evaluations"),
+	}
+}
+
+// Create 创建评估记录
+func (r *ModelEvaluationRepositoryImpl) Create(evaluation *model.ModelEvaluation) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 设置创建和更新时间
+	now := time.Now()
+	evaluation.CreateTime = now
+	evaluation.UpdateTime = now
+
+	// 插入文档
+	result, err := r.collection.InsertOne(ctx, evaluation)
+	if err != nil {
+		return "", fmt.Errorf("创建评估记录失败: %w", err)
+	}
+
+	// 获取插入的ID
+	id := result.InsertedID.(primitive.ObjectID).Hex()
+	return id, nil
+}
+
+// Update 更新评估记录
+func (r *ModelEvaluationRepositoryImpl) Update(evaluation *model.ModelEvaluation) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(evaluation.ID)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	// 设置更新时间，_id不参与更新
+	evaluation.UpdateTime = time.Now()
+	doc := *evaluation
+	doc.ID = ""
+
+	_, err = r.collection.ReplaceOne(ctx, bson.M{"_id": objectID}, doc)
+	if err != nil {
+		return fmt.Errorf("更新评估记录失败: %w", err)
+	}
+
+	return nil
+}
+
+// FindByID 根据ID查找评估记录
+func (r *ModelEvaluationRepositoryImpl) FindByID(id string) (*model.ModelEvaluation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	var evaluation model.ModelEvaluation
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&evaluation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 评估记录不存在
+		}
+		return nil, fmt.Errorf("查询评估记录失败: %w", err)
+	}
+
+	return &evaluation, nil
+}
+
+// List 获取评估记录列表
+func (r *ModelEvaluationRepositoryImpl) List(modelID string, page, size int) ([]*model.ModelEvaluation, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 构建查询条件
+	filter := bson.M{}
+	if modelID != "" {
+		filter["model_id"] = modelID
+	}
+
+	// 计算总数
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取评估记录总数失败: %w", err)
+	}
+
+	// 分页查询，列表不返回混淆矩阵等明细
+	opts := options.Find()
+	opts.SetSort(bson.M{"create_time": -1}) // 按创建时间降序
+	opts.SetSkip(int64((page - 1) * size))
+	opts.SetLimit(int64(size))
+	opts.SetProjection(bson.M{"confusion_matrix": 0, "classes": 0, "calibration": 0})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询评估记录列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	evaluations := make([]*model.ModelEvaluation, 0)
+	if err := cursor.All(ctx, &evaluations); err != nil {
+		return nil, 0, fmt.Errorf("解析评估记录数据失败: %w", err)
+	}
+
+	return evaluations, total, nil
+}
+
+// FindLatestCompleted 获取模型版本最近一次完成的评估
+func (r *ModelEvaluationRepositoryImpl) FindLatestCompleted(modelID, modelVersion string) (*model.ModelEvaluation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"model_id":      modelID,
+		"model_version": modelVersion,
+		"status":        model.EvaluationStatusCompleted,
+	}
+	opts := options.FindOne().SetSort(bson.M{"end_time": -1})
+
+	var evaluation model.ModelEvaluation
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&evaluation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 没有完成的评估
+		}
+		return nil, fmt.Errorf("查询评估记录失败: %w", err)
+	}
+
+	return &evaluation, nil
+}
+
+// TransitionStatus 将状态为from的记录更新为to
+func (r *ModelEvaluationRepositoryImpl) TransitionStatus(id, from, to string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	filter := bson.M{"_id": objectID, "status": from}
+	update := bson.M{"$set": bson.M{"status": to, "update_time": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("更新评估状态失败: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,159 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// InvoiceRepositoryImpl 账单数据访问实现
+type InvoiceRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewInvoiceRepository 创建账单数据访问实例
+func NewInvoiceRepository() model.InvoiceRepository {
+	return &InvoiceRepositoryImpl{
+		collection: database.MongoDB.Collection("invoices"),
+	}
+}
+
+// SaveDraft 保存草稿账单
+// 依赖customer_id和month的唯一索引：该月账单已开具时upsert会因唯一索引冲突失败
+func (r *InvoiceRepositoryImpl) SaveDraft(invoice *model.Invoice) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	invoice.Status = model.InvoiceDraft
+	invoice.UpdateTime = now
+
+	filter := bson.M{
+		"customer_id": invoice.CustomerID,
+		"month":       invoice.Month,
+		"status":      model.InvoiceDraft,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"currency":    invoice.Currency,
+			"lines":       invoice.Lines,
+			"total":       invoice.Total,
+			"update_time": now,
+		},
+		"$setOnInsert": bson.M{"create_time": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved model.Invoice
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil // 该月账单已开具
+		}
+		return false, fmt.Errorf("保存账单失败: %w", err)
+	}
+	invoice.ID = saved.ID
+	invoice.CreateTime = saved.CreateTime
+
+	return true, nil
+}
+
+// FindByID 根据ID获取账单
+func (r *InvoiceRepositoryImpl) FindByID(id string) (*model.Invoice, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	var invoice model.Invoice
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&invoice)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询账单失败: %w", err)
+	}
+
+	return &invoice, nil
+}
+
+// List 分页获取账单
+func (r *InvoiceRepositoryImpl) List(customerID int64, month time.Time, status string, page, size int) ([]*model.Invoice, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 构建查询条件
+	filter := bson.M{}
+	if customerID != 0 {
+		filter["customer_id"] = customerID
+	}
+	if !month.IsZero() {
+		filter["month"] = month
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	// 计算总数
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("计算账单总数失败: %w", err)
+	}
+
+	// 设置分页选项
+	skip := int64((page - 1) * size)
+	limit := int64(size)
+	opts := options.Find().
+		SetSkip(skip).
+		SetLimit(limit).
+		SetSort(bson.D{{Key: "month", Value: -1}, {Key: "customer_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询账单列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	invoices := make([]*model.Invoice, 0)
+	if err := cursor.All(ctx, &invoices); err != nil {
+		return nil, 0, fmt.Errorf("解析账单数据失败: %w", err)
+	}
+
+	return invoices, total, nil
+}
+
+// Issue 将草稿账单标记为已开具
+func (r *InvoiceRepositoryImpl) Issue(id string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	now := time.Now()
+	update := bson.M{"$set": bson.M{
+		"status":      model.InvoiceIssued,
+		"issued_at":   now,
+		"update_time": now,
+	}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID, "status": model.InvoiceDraft}, update)
+	if err != nil {
+		return false, fmt.Errorf("开具账单失败: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
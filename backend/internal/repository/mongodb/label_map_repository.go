@@ -0,0 +1,125 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// labelMapCreateRetries 并发创建同一模型的新版本时，版本号唯一索引冲突后的重试次数
+const labelMapCreateRetries = 3
+
+// LabelMapRepositoryImpl 标签映射数据访问实现
+type LabelMapRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewLabelMapRepository 创建标签映射数据访问实例
+func NewLabelMapRepository() model.LabelMapRepository {
+	return &LabelMapRepositoryImpl{
+		collection: database.MongoDB.Collection("label_maps"),
+	}
+}
+
+// Create 创建新版本
+// 版本号由(model_id, version)唯一索引保证不重复，冲突时重新读取最大版本号
+func (r *LabelMapRepositoryImpl) Create(labelMap *model.LabelMap) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	labelMap.CreateTime = time.Now()
+	for attempt := 0; ; attempt++ {
+		latest, err := r.latestVersion(ctx, labelMap.ModelID)
+		if err != nil {
+			return err
+		}
+		labelMap.Version = latest + 1
+
+		result, err := r.collection.InsertOne(ctx, labelMap)
+		if mongo.IsDuplicateKeyError(err) && attempt < labelMapCreateRetries {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("创建标签映射失败: %w", err)
+		}
+		labelMap.ID = result.InsertedID.(primitive.ObjectID).Hex()
+		return nil
+	}
+}
+
+// latestVersion 获取模型当前最大版本号，没有版本时返回0
+func (r *LabelMapRepositoryImpl) latestVersion(ctx context.Context, modelID string) (int, error) {
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetProjection(bson.M{"version": 1})
+
+	var latest model.LabelMap
+	err := r.collection.FindOne(ctx, bson.M{"model_id": modelID}, opts).Decode(&latest)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("查询标签映射版本失败: %w", err)
+	}
+	return latest.Version, nil
+}
+
+// FindLatest 获取模型最新版本
+func (r *LabelMapRepositoryImpl) FindLatest(modelID string) (*model.LabelMap, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	return r.findOne(ctx, bson.M{"model_id": modelID}, opts)
+}
+
+// FindByVersion 获取模型指定版本
+func (r *LabelMapRepositoryImpl) FindByVersion(modelID string, version int) (*model.LabelMap, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.findOne(ctx, bson.M{"model_id": modelID, "version": version}, options.FindOne())
+}
+
+func (r *LabelMapRepositoryImpl) findOne(ctx context.Context, filter bson.M, opts *options.FindOneOptions) (*model.LabelMap, error) {
+	var labelMap model.LabelMap
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&labelMap)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 未配置标签映射
+		}
+		return nil, fmt.Errorf("查询标签映射失败: %w", err)
+	}
+
+	return &labelMap, nil
+}
+
+// ListVersions 获取模型全部版本
+func (r *LabelMapRepositoryImpl) ListVersions(modelID string) ([]*model.LabelMap, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "version", Value: -1}}).
+		SetProjection(bson.M{"labels": 0})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"model_id": modelID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询标签映射版本失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	versions := make([]*model.LabelMap, 0)
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, fmt.Errorf("解析标签映射版本失败: %w", err)
+	}
+
+	return versions, nil
+}
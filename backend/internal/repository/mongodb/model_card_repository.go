@@ -0,0 +1,74 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ModelCardInfoRepositoryImpl 模型卡片人工信息数据访问实现
+type ModelCardInfoRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewModelCardInfoRepository 创建模型卡片人工信息数据访问实例
+func NewModelCardInfoRepository() model.ModelCardInfoRepository {
+	return &ModelCardInfoRepositoryImpl{
+		collection: database.MongoDB.Collection("model_card_info"),
+	}
+}
+
+// Save 按模型ID创建或更新
+func (r *ModelCardInfoRepositoryImpl) Save(info *model.ModelCardInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	info.UpdateTime = now
+
+	filter := bson.M{"model_id": info.ModelID}
+	update := bson.M{
+		"$set": bson.M{
+			"intended_use":         info.IntendedUse,
+			"out_of_scope_use":     info.OutOfScopeUse,
+			"limitations":          info.Limitations,
+			"training_dataset_ids": info.TrainingDatasetIDs,
+			"user_id":              info.UserID,
+			"update_time":          now,
+		},
+		"$setOnInsert": bson.M{"create_time": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved model.ModelCardInfo
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved); err != nil {
+		return fmt.Errorf("保存模型卡片信息失败: %w", err)
+	}
+	info.ID = saved.ID
+	info.CreateTime = saved.CreateTime
+
+	return nil
+}
+
+// FindByModel 获取模型的卡片信息
+func (r *ModelCardInfoRepositoryImpl) FindByModel(modelID string) (*model.ModelCardInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var info model.ModelCardInfo
+	err := r.collection.FindOne(ctx, bson.M{"model_id": modelID}).Decode(&info)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 未填写卡片信息
+		}
+		return nil, fmt.Errorf("查询模型卡片信息失败: %w", err)
+	}
+
+	return &info, nil
+}
@@ -0,0 +1,92 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PlanChangeRepositoryImpl 客户套餐变更记录数据访问实现
+type PlanChangeRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewPlanChangeRepository 创建客户套餐变更记录数据访问实例
+func NewPlanChangeRepository() model.PlanChangeRepository {
+	return &PlanChangeRepositoryImpl{
+		collection: database.MongoDB.Collection("plan_changes"),
+	}
+}
+
+// Create 记录套餐变更
+func (r *PlanChangeRepositoryImpl) Create(change *model.PlanChange) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	change.CreateTime = time.Now()
+	if change.EffectiveAt.IsZero() {
+		change.EffectiveAt = change.CreateTime
+	}
+
+	result, err := r.collection.InsertOne(ctx, change)
+	if err != nil {
+		return fmt.Errorf("记录套餐变更失败: %w", err)
+	}
+	change.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	return nil
+}
+
+// ListByCustomer 按生效时间升序获取客户的套餐变更
+func (r *PlanChangeRepositoryImpl) ListByCustomer(customerID int64, before time.Time) ([]*model.PlanChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"customer_id":  customerID,
+		"effective_at": bson.M{"$lt": before},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "effective_at", Value: 1}, {Key: "create_time", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询套餐变更记录失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	changes := make([]*model.PlanChange, 0)
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, fmt.Errorf("解析套餐变更记录失败: %w", err)
+	}
+
+	return changes, nil
+}
+
+// FindFirstAfter 获取客户在after及之后生效的第一次变更
+func (r *PlanChangeRepositoryImpl) FindFirstAfter(customerID int64, after time.Time) (*model.PlanChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"customer_id":  customerID,
+		"effective_at": bson.M{"$gte": after},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "effective_at", Value: 1}, {Key: "create_time", Value: 1}})
+
+	var change model.PlanChange
+	if err := r.collection.FindOne(ctx, filter, opts).Decode(&change); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询套餐变更记录失败: %w", err)
+	}
+
+	return &change, nil
+}
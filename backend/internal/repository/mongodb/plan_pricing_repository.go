@@ -0,0 +1,56 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PlanPricingRepositoryImpl 套餐计价规则数据访问实现
+type PlanPricingRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewPlanPricingRepository 创建套餐计价规则数据访问实例
+func NewPlanPricingRepository() model.PlanPricingRepository {
+	return &PlanPricingRepositoryImpl{
+		collection: database.MongoDB.Collection("plan_pricing"),
+	}
+}
+
+// Save 保存套餐的计价规则
+func (r *PlanPricingRepositoryImpl) Save(pricing *model.PlanPricing) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pricing.UpdateTime = time.Now()
+	opts := options.Replace().SetUpsert(true)
+	if _, err := r.collection.ReplaceOne(ctx, bson.M{"plan_id": pricing.PlanID}, pricing, opts); err != nil {
+		return fmt.Errorf("保存套餐计价规则失败: %w", err)
+	}
+
+	return nil
+}
+
+// FindByPlan 获取套餐的计价规则
+func (r *PlanPricingRepositoryImpl) FindByPlan(planID int64) (*model.PlanPricing, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var pricing model.PlanPricing
+	err := r.collection.FindOne(ctx, bson.M{"plan_id": planID}).Decode(&pricing)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 套餐未配置计价规则
+		}
+		return nil, fmt.Errorf("查询套餐计价规则失败: %w", err)
+	}
+
+	return &pricing, nil
+}
@@ -0,0 +1,107 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RoutingPolicyRepositoryImpl 流量分配策略数据访问实现
+type RoutingPolicyRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewRoutingPolicyRepository 创建流量分配策略数据访问实例
+func NewRoutingPolicyRepository() model.RoutingPolicyRepository {
+	return &RoutingPolicyRepositoryImpl{
+		collection: database.MongoDB.Collection("routing_policies"),
+	}
+}
+
+// Save 按模型ID创建或更新流量分配策略
+func (r *RoutingPolicyRepositoryImpl) Save(policy *model.RoutingPolicy) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	policy.UpdateTime = now
+
+	filter := bson.M{"model_id": policy.ModelID}
+	update := bson.M{
+		"$set": bson.M{
+			"candidate_version": policy.CandidateVersion,
+			"candidate_percent": policy.CandidatePercent,
+			"sticky_by":         policy.StickyBy,
+			"shadow_version":    policy.ShadowVersion,
+			"shadow_percent":    policy.ShadowPercent,
+			"enabled":           policy.Enabled,
+			"user_id":           policy.UserID,
+			"update_time":       now,
+		},
+		"$setOnInsert": bson.M{"create_time": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved model.RoutingPolicy
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved); err != nil {
+		return fmt.Errorf("保存流量分配策略失败: %w", err)
+	}
+	policy.ID = saved.ID
+	policy.CreateTime = saved.CreateTime
+
+	return nil
+}
+
+// FindByModel 获取模型的流量分配策略
+func (r *RoutingPolicyRepositoryImpl) FindByModel(modelID string) (*model.RoutingPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var policy model.RoutingPolicy
+	err := r.collection.FindOne(ctx, bson.M{"model_id": modelID}).Decode(&policy)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 未配置策略
+		}
+		return nil, fmt.Errorf("查询流量分配策略失败: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// List 获取全部流量分配策略
+func (r *RoutingPolicyRepositoryImpl) List() ([]*model.RoutingPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"update_time": -1}))
+	if err != nil {
+		return nil, fmt.Errorf("查询流量分配策略列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	policies := make([]*model.RoutingPolicy, 0)
+	if err := cursor.All(ctx, &policies); err != nil {
+		return nil, fmt.Errorf("解析流量分配策略数据失败: %w", err)
+	}
+
+	return policies, nil
+}
+
+// DeleteByModel 删除模型的流量分配策略
+func (r *RoutingPolicyRepositoryImpl) DeleteByModel(modelID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"model_id": modelID}); err != nil {
+		return fmt.Errorf("删除流量分配策略失败: %w", err)
+	}
+
+	return nil
+}
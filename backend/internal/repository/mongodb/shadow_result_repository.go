@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ShadowResultRepositoryImpl 影子复跑结果数据访问实现
+type ShadowResultRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewShadowResultRepository 创建影子复跑结果数据访问实例
+func NewShadowResultRepository() model.ShadowResultRepository {
+	return &ShadowResultRepositoryImpl{
+		collection: database.MongoDB.Collection("shadow_results"),
+	}
+}
+
+// Create 保存影子复跑结果
+func (r *ShadowResultRepositoryImpl) Create(result *model.ShadowResult) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if result.CreateTime.IsZero() {
+		result.CreateTime = time.Now()
+	}
+
+	res, err := r.collection.InsertOne(ctx, result)
+	if err != nil {
+		return fmt.Errorf("保存影子复跑结果失败: %w", err)
+	}
+	result.ID = res.InsertedID.(primitive.ObjectID).Hex()
+
+	return nil
+}
+
+// FindByModel 获取模型在时间范围内的影子复跑结果
+func (r *ShadowResultRepositoryImpl) FindByModel(modelID, shadowVersion string, start, end time.Time) ([]*model.ShadowResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"model_id":    modelID,
+		"create_time": bson.M{"$gte": start, "$lte": end},
+	}
+	if shadowVersion != "" {
+		filter["shadow_version"] = shadowVersion
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.M{"create_time": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("查询影子复跑结果失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	results := make([]*model.ShadowResult, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("解析影子复跑结果数据失败: %w", err)
+	}
+
+	return results, nil
+}
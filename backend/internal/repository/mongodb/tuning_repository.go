@@ -0,0 +1,437 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TuningJobRepositoryImpl 调参任务数据访问实现
+type TuningJobRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewTuningJobRepository 创建调参任务数据访问实例
+func NewTuningJobRepository() model.TuningJobRepository {
+	return &TuningJobRepositoryImpl{
+		collection: database.MongoDB.Collection("tuning_jobs"),
+	}
+}
+
+// Create 创建调参任务
+func (r *TuningJobRepositoryImpl) Create(job *model.TuningJob) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 设置创建和更新时间
+	now := time.Now()
+	job.CreateTime = now
+	job.UpdateTime = now
+
+	// 插入文档
+	result, err := r.collection.InsertOne(ctx, job)
+	if err != nil {
+		return "", fmt.Errorf("创建调参任务失败: %w", err)
+	}
+
+	// 获取插入的ID
+	id := result.InsertedID.(primitive.ObjectID).Hex()
+	return id, nil
+}
+
+// FindByID 根据ID查找调参任务
+func (r *TuningJobRepositoryImpl) FindByID(id string) (*model.TuningJob, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	var job model.TuningJob
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 任务不存在
+		}
+		return nil, fmt.Errorf("查询调参任务失败: %w", err)
+	}
+
+	return &job, nil
+}
+
+// List 获取调参任务列表
+func (r *TuningJobRepositoryImpl) List(modelID string, page, size int) ([]*model.TuningJob, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 构建查询条件
+	filter := bson.M{}
+	if modelID != "" {
+		filter["model_id"] = modelID
+	}
+
+	// 计算总数
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取调参任务总数失败: %w", err)
+	}
+
+	// 分页查询
+	opts := options.Find()
+	opts.SetSort(bson.M{"create_time": -1}) // 按创建时间降序
+	opts.SetSkip(int64((page - 1) * size))
+	opts.SetLimit(int64(size))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询调参任务列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	jobs := make([]*model.TuningJob, 0)
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, 0, fmt.Errorf("解析调参任务数据失败: %w", err)
+	}
+
+	return jobs, total, nil
+}
+
+// UpdateStatus 更新任务状态
+func (r *TuningJobRepositoryImpl) UpdateStatus(id, status string) error {
+	return r.updateFields(id, bson.M{"status": status})
+}
+
+// UpdateProgress 更新试验统计及最优试验，不修改任务状态
+func (r *TuningJobRepositoryImpl) UpdateProgress(job *model.TuningJob) error {
+	return r.updateFields(job.ID, bson.M{
+		"total_trials":     job.TotalTrials,
+		"completed_trials": job.CompletedTrials,
+		"failed_trials":    job.FailedTrials,
+		"best_trial_id":    job.BestTrialID,
+		"best_params":      job.BestParams,
+		"best_score":       job.BestScore,
+	})
+}
+
+// AcquireSlot 占用一个并发名额，通过条件更新保证多实例下也不会超过上限
+func (r *TuningJobRepositoryImpl) AcquireSlot(id string, max int) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	filter := bson.M{"_id": objectID, "active_trials": bson.M{"$lt": max}}
+	update := bson.M{
+		"$inc": bson.M{"active_trials": 1},
+		"$set": bson.M{"update_time": time.Now()},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("占用并发名额失败: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// ReleaseSlot 释放一个并发名额
+func (r *TuningJobRepositoryImpl) ReleaseSlot(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	filter := bson.M{"_id": objectID, "active_trials": bson.M{"$gt": 0}}
+	update := bson.M{
+		"$inc": bson.M{"active_trials": -1},
+		"$set": bson.M{"update_time": time.Now()},
+	}
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("释放并发名额失败: %w", err)
+	}
+
+	return nil
+}
+
+// updateFields 更新任务字段
+func (r *TuningJobRepositoryImpl) updateFields(id string, fields bson.M) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	fields["update_time"] = time.Now()
+	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": fields})
+	if err != nil {
+		return fmt.Errorf("更新调参任务失败: %w", err)
+	}
+
+	return nil
+}
+
+// TuningTrialRepositoryImpl 调参试验数据访问实现
+type TuningTrialRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewTuningTrialRepository 创建调参试验数据访问实例
+func NewTuningTrialRepository() model.TuningTrialRepository {
+	return &TuningTrialRepositoryImpl{
+		collection: database.MongoDB.Collection("tuning_trials"),
+	}
+}
+
+// CreateMany 批量创建试验
+func (r *TuningTrialRepositoryImpl) CreateMany(trials []*model.TuningTrial) error {
+	if len(trials) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	docs := make([]interface{}, len(trials))
+	for i, t := range trials {
+		t.CreateTime = now
+		t.UpdateTime = now
+		docs[i] = t
+	}
+
+	result, err := r.collection.InsertMany(ctx, docs)
+	if err != nil {
+		return fmt.Errorf("创建调参试验失败: %w", err)
+	}
+	for i, id := range result.InsertedIDs {
+		trials[i].ID = id.(primitive.ObjectID).Hex()
+	}
+
+	return nil
+}
+
+// FindByID 根据ID查找试验
+func (r *TuningTrialRepositoryImpl) FindByID(id string) (*model.TuningTrial, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	var trial model.TuningTrial
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&trial)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 试验不存在
+		}
+		return nil, fmt.Errorf("查询调参试验失败: %w", err)
+	}
+
+	return &trial, nil
+}
+
+// ListByJob 获取任务的全部试验，按序号升序
+func (r *TuningTrialRepositoryImpl) ListByJob(jobID string) ([]*model.TuningTrial, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.M{"index": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"job_id": jobID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询调参试验列表失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	trials := make([]*model.TuningTrial, 0)
+	if err := cursor.All(ctx, &trials); err != nil {
+		return nil, fmt.Errorf("解析调参试验数据失败: %w", err)
+	}
+
+	return trials, nil
+}
+
+// ClaimNext 将序号最小的待调度试验标记为已提交并返回
+func (r *TuningTrialRepositoryImpl) ClaimNext(jobID string) (*model.TuningTrial, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"job_id": jobID, "status": model.TuningStatusPending}
+	update := bson.M{"$set": bson.M{
+		"status":      model.TuningStatusQueued,
+		"start_time":  now,
+		"update_time": now,
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.M{"index": 1}).
+		SetReturnDocument(options.After)
+
+	var trial model.TuningTrial
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&trial)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // 没有待调度试验
+		}
+		return nil, fmt.Errorf("调度调参试验失败: %w", err)
+	}
+
+	return &trial, nil
+}
+
+// SetTaskID 记录试验对应的训练队列任务ID，只更新task_id，避免覆盖并发上报的结果
+func (r *TuningTrialRepositoryImpl) SetTaskID(id, taskID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	update := bson.M{"$set": bson.M{"task_id": taskID, "update_time": time.Now()}}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update); err != nil {
+		return fmt.Errorf("更新调参试验失败: %w", err)
+	}
+
+	return nil
+}
+
+// TransitionStatus 将状态为from的试验更新为to
+func (r *TuningTrialRepositoryImpl) TransitionStatus(id, from, to string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	filter := bson.M{"_id": objectID, "status": from}
+	update := bson.M{"$set": bson.M{"status": to, "update_time": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("更新调参试验状态失败: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// Finish 记录已提交或执行中试验的结果
+func (r *TuningTrialRepositoryImpl) Finish(trial *model.TuningTrial) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(trial.ID)
+	if err != nil {
+		return false, fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	now := time.Now()
+	trial.EndTime = &now
+	trial.UpdateTime = now
+
+	filter := bson.M{
+		"_id":    objectID,
+		"status": bson.M{"$in": []string{model.TuningStatusQueued, model.TuningStatusRunning}},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":      trial.Status,
+		"metrics":     trial.Metrics,
+		"score":       trial.Score,
+		"error_msg":   trial.ErrorMsg,
+		"end_time":    trial.EndTime,
+		"update_time": trial.UpdateTime,
+	}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("记录调参试验结果失败: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// CancelPending 取消任务下所有待调度试验
+func (r *TuningTrialRepositoryImpl) CancelPending(jobID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"job_id": jobID, "status": model.TuningStatusPending}
+	update := bson.M{"$set": bson.M{"status": model.TuningStatusCancelled, "update_time": time.Now()}}
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("取消调参试验失败: %w", err)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// ListStale 获取更新时间早于before仍处于已提交或执行中的试验
+func (r *TuningTrialRepositoryImpl) ListStale(before time.Time) ([]*model.TuningTrial, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"status":      bson.M{"$in": []string{model.TuningStatusQueued, model.TuningStatusRunning}},
+		"update_time": bson.M{"$lt": before},
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.M{"update_time": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("查询超时调参试验失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	trials := make([]*model.TuningTrial, 0)
+	if err := cursor.All(ctx, &trials); err != nil {
+		return nil, fmt.Errorf("解析调参试验数据失败: %w", err)
+	}
+
+	return trials, nil
+}
+
+// MarkBest 标记最优试验，同时清除其他试验的标记
+func (r *TuningTrialRepositoryImpl) MarkBest(jobID, trialID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 转换ID为ObjectID
+	objectID, err := primitive.ObjectIDFromHex(trialID)
+	if err != nil {
+		return fmt.Errorf("无效的ID格式: %w", err)
+	}
+
+	clear := bson.M{"job_id": jobID, "is_best": true, "_id": bson.M{"$ne": objectID}}
+	if _, err := r.collection.UpdateMany(ctx, clear, bson.M{"$set": bson.M{"is_best": false}}); err != nil {
+		return fmt.Errorf("更新最优试验失败: %w", err)
+	}
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{"is_best": true}}); err != nil {
+		return fmt.Errorf("更新最优试验失败: %w", err)
+	}
+
+	return nil
+}
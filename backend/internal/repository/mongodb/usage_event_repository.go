@@ -0,0 +1,153 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UsageEventRepositoryImpl 计费用量事件数据访问实现
+type UsageEventRepositoryImpl struct {
+	collection *mongo.Collection
+}
+
+// NewUsageEventRepository 创建计费用量事件数据访问实例
+func NewUsageEventRepository() model.UsageEventRepository {
+	return &UsageEventRepositoryImpl{
+		collection: database.MongoDB.Collection("usage_events"),
+	}
+}
+
+// Create 记录用量事件
+func (r *UsageEventRepositoryImpl) Create(event *model.UsageEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if event.CreateTime.IsZero() {
+		event.CreateTime = time.Now()
+	}
+
+	result, err := r.collection.InsertOne(ctx, event)
+	if err != nil {
+		return fmt.Errorf("记录用量事件失败: %w", err)
+	}
+	event.ID = result.InsertedID.(primitive.ObjectID).Hex()
+
+	return nil
+}
+
+// Summarize 按模型类型汇总客户的用量
+func (r *UsageEventRepositoryImpl) Summarize(customerID int64, start, end time.Time) ([]*model.UsageSummary, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"customer_id": customerID,
+			"create_time": bson.M{"$gte": start, "$lt": end},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":        "$model_type",
+			"requests":   bson.M{"$sum": 1},
+			"megapixels": bson.M{"$sum": "$megapixels"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("汇总用量事件失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	summaries := make([]*model.UsageSummary, 0)
+	if err := cursor.All(ctx, &summaries); err != nil {
+		return nil, fmt.Errorf("解析用量汇总数据失败: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// Customers 获取有用量的客户
+func (r *UsageEventRepositoryImpl) Customers(start, end time.Time) ([]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	values, err := r.collection.Distinct(ctx, "customer_id", bson.M{
+		"create_time": bson.M{"$gte": start, "$lt": end},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询有用量的客户失败: %w", err)
+	}
+
+	customers := make([]int64, 0, len(values))
+	for _, v := range values {
+		switch id := v.(type) {
+		case int64:
+			customers = append(customers, id)
+		case int32:
+			customers = append(customers, int64(id))
+		}
+	}
+
+	return customers, nil
+}
+
+// Daily 按天和模型类型汇总客户的用量
+func (r *UsageEventRepositoryImpl) Daily(customerID int64, start, end time.Time) ([]*model.DailyUsage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 按start所在时区的UTC偏移划分日期
+	_, offset := start.Zone()
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	timezone := fmt.Sprintf("%s%02d:%02d", sign, offset/3600, (offset%3600)/60)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"customer_id": customerID,
+			"create_time": bson.M{"$gte": start, "$lt": end},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"date": bson.M{"$dateToString": bson.M{
+					"format":   "%Y-%m-%d",
+					"date":     "$create_time",
+					"timezone": timezone,
+				}},
+				"model_type": "$model_type",
+			},
+			"requests":   bson.M{"$sum": 1},
+			"megapixels": bson.M{"$sum": "$megapixels"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":        0,
+			"date":       "$_id.date",
+			"model_type": "$_id.model_type",
+			"requests":   1,
+			"megapixels": 1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "date", Value: 1}, {Key: "model_type", Value: 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("按天汇总用量事件失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	usage := make([]*model.DailyUsage, 0)
+	if err := cursor.All(ctx, &usage); err != nil {
+		return nil, fmt.Errorf("解析每日用量数据失败: %w", err)
+	}
+
+	return usage, nil
+}
@@ -24,8 +24,8 @@ func (r *servicePlanRepository) Create(plan *model.ServicePlan) (int64, error) {
 	plan.CreateTime = time.Now()
 	plan.UpdateTime = time.Now()
 
-	query := `INSERT INTO service_plans (name, description, price, request_limit, concurrent, features, create_time, update_time)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO service_plans (name, description, price, request_limit, concurrent, rate_limit, rate_burst, rate_algorithm, features, create_time, update_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	result, err := r.db.Exec(query,
 		plan.Name,
@@ -33,6 +33,9 @@ func (r *servicePlanRepository) Create(plan *model.ServicePlan) (int64, error) {
 		plan.Price,
 		plan.RequestLimit,
 		plan.Concurrent,
+		plan.RateLimit,
+		plan.RateBurst,
+		plan.RateAlgorithm,
 		plan.Features,
 		plan.CreateTime,
 		plan.UpdateTime,
@@ -60,6 +63,9 @@ func (r *servicePlanRepository) Update(plan *model.ServicePlan) error {
 		price = ?, 
 		request_limit = ?, 
 		concurrent = ?, 
+		rate_limit = ?, 
+		rate_burst = ?, 
+		rate_algorithm = ?, 
 		features = ?, 
 		update_time = ? 
 		WHERE id = ?`
@@ -70,6 +76,9 @@ func (r *servicePlanRepository) Update(plan *model.ServicePlan) error {
 		plan.Price,
 		plan.RequestLimit,
 		plan.Concurrent,
+		plan.RateLimit,
+		plan.RateBurst,
+		plan.RateAlgorithm,
 		plan.Features,
 		plan.UpdateTime,
 		plan.ID,
@@ -17,6 +17,13 @@ type RecognitionRepository interface {
 	GetByModelVersion(ctx context.Context, modelVersion string) ([]*model.RecognitionRecord, error)
 	GetStatsByModelVersion(ctx context.Context, modelVersion string) (float64, error)
 	GetStatsByCategory(ctx context.Context, category string) (float64, error)
+	GetByModelID(ctx context.Context, modelID string, startTime, endTime time.Time) ([]*model.RecognitionRecord, error)
+	AggregateByVersion(ctx context.Context, startTime, endTime time.Time) ([]*model.PerformanceTotals, error)
+	AggregateDriftBins(ctx context.Context, modelID string, baselineStart, currentStart, endTime time.Time, edges map[string][]float64) ([]*model.DriftBin, error)
+	SetImageStats(ctx context.Context, id primitive.ObjectID, width, height int, brightness float64) error
+	SaveFeedback(ctx context.Context, id primitive.ObjectID, customerID int64, feedback *model.RecognitionFeedback) (bool, error)
+	AggregateFeedback(ctx context.Context, modelID string, startTime, endTime time.Time) ([]*model.FeedbackTotals, error)
+	GetWithFeedback(ctx context.Context, modelID string, startTime, endTime time.Time, correctedOnly bool) ([]*model.RecognitionRecord, error)
 }
 
 // ModelVersionRepository 模型版本仓储接口
@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+type recognitionRepository struct {
+	db *mongo.Database
+}
+
+// NewRecognitionRepository 创建识别记录仓储
+func NewRecognitionRepository(db *mongo.Database) RecognitionRepository {
+	return &recognitionRepository{db: db}
+}
+
+func (r *recognitionRepository) coll() *mongo.Collection {
+	return r.db.Collection("recognition_records")
+}
+
+// Create 保存识别记录
+func (r *recognitionRepository) Create(ctx context.Context, record *model.RecognitionRecord) error {
+	now := time.Now()
+	if record.CreateTime.IsZero() {
+		record.CreateTime = now
+	}
+	record.UpdateTime = now
+
+	result, err := r.coll().InsertOne(ctx, record)
+	if err != nil {
+		return errors.Wrap(err, "保存识别记录失败")
+	}
+	record.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetByID 根据ID获取识别记录，不存在时返回nil
+func (r *recognitionRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*model.RecognitionRecord, error) {
+	var record model.RecognitionRecord
+	err := r.coll().FindOne(ctx, bson.M{"_id": id}).Decode(&record)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "查询识别记录失败")
+	}
+	return &record, nil
+}
+
+// GetByCustomerID 分页获取客户的识别记录
+func (r *recognitionRepository) GetByCustomerID(ctx context.Context, customerID int64, page, pageSize int) ([]*model.RecognitionRecord, int64, error) {
+	filter := bson.M{"customer_id": customerID}
+
+	total, err := r.coll().CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "统计识别记录失败")
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "create_time", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	records, err := r.find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+// GetByTimeRange 获取时间范围内的识别记录
+func (r *recognitionRepository) GetByTimeRange(ctx context.Context, startTime, endTime time.Time) ([]*model.RecognitionRecord, error) {
+	filter := bson.M{"create_time": bson.M{"$gte": startTime, "$lte": endTime}}
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "create_time", Value: 1}}))
+}
+
+// GetByModelVersion 获取模型版本的识别记录
+func (r *recognitionRepository) GetByModelVersion(ctx context.Context, modelVersion string) ([]*model.RecognitionRecord, error) {
+	return r.find(ctx, bson.M{"model_version": modelVersion}, options.Find().SetSort(bson.D{{Key: "create_time", Value: 1}}))
+}
+
+// GetByModelID 获取模型在时间范围内的识别记录
+func (r *recognitionRepository) GetByModelID(ctx context.Context, modelID string, startTime, endTime time.Time) ([]*model.RecognitionRecord, error) {
+	filter := bson.M{
+		"model_id":    modelID,
+		"create_time": bson.M{"$gte": startTime, "$lte": endTime},
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "create_time", Value: 1}}))
+}
+
+// driftSamplesFacet 漂移检测汇总中统计样本数的分组名称
+const driftSamplesFacet = "samples"
+
+// AggregateDriftBins 按模型版本和时间窗口汇总模型在[baselineStart, endTime]内成功识别的特征分布
+// 创建时间不早于currentStart的记录属于当前窗口；数值特征按edges分箱，值v落在第i箱表示有i个边界不大于v
+// 分箱在数据库中完成，返回的数据量只与版本数、类别数和分箱数有关，与识别请求量无关
+func (r *recognitionRepository) AggregateDriftBins(ctx context.Context, modelID string, baselineStart, currentStart, endTime time.Time, edges map[string][]float64) ([]*model.DriftBin, error) {
+	decoded := bson.M{"$and": bson.A{
+		bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$image_width", 0}}, 0}},
+		bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$image_height", 0}}, 0}},
+	}}
+	// 各数值特征的取值，缺少该特征时为null
+	values := map[string]interface{}{
+		model.DriftFeatureConfidence: bson.M{"$ifNull": bson.A{"$confidence", 0}},
+		model.DriftFeatureImageSize: bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$image_bytes", 0}}, 0}}, "$image_bytes", nil,
+		}},
+		model.DriftFeatureBrightness:  bson.M{"$cond": bson.A{decoded, bson.M{"$ifNull": bson.A{"$brightness", 0}}, nil}},
+		model.DriftFeatureAspectRatio: bson.M{"$cond": bson.A{decoded, bson.M{"$divide": bson.A{"$image_width", "$image_height"}}, nil}},
+	}
+
+	project := bson.M{
+		"_id":      0,
+		"version":  "$model_version",
+		"current":  bson.M{"$gte": bson.A{"$create_time", currentStart}},
+		"category": bson.M{"$ifNull": bson.A{"$category", ""}},
+	}
+	facets := bson.M{
+		driftSamplesFacet: bson.A{bson.M{"$group": bson.M{
+			"_id":   bson.M{"version": "$version", "current": "$current"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		model.DriftFeatureCategory: bson.A{bson.M{"$group": bson.M{
+			"_id":   bson.M{"version": "$version", "current": "$current", "category": "$category"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+	for feature, featureEdges := range edges {
+		value, ok := values[feature]
+		if !ok {
+			return nil, errors.Errorf("不支持的漂移检测特征: %s", feature)
+		}
+		// 与sort.SearchFloat64s(edges, v+1e-12)的分箱结果一致
+		project[feature] = bson.M{"$let": bson.M{
+			"vars": bson.M{"v": value},
+			"in": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$$v", nil}},
+				-1,
+				bson.M{"$size": bson.M{"$filter": bson.M{
+					"input": featureEdges,
+					"as":    "edge",
+					"cond":  bson.M{"$lt": bson.A{"$$edge", bson.M{"$add": bson.A{"$$v", 1e-12}}}},
+				}}},
+			}},
+		}}
+		facets[feature] = bson.A{bson.M{"$group": bson.M{
+			"_id":   bson.M{"version": "$version", "current": "$current", "bin": "$" + feature},
+			"count": bson.M{"$sum": 1},
+		}}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"model_id":    modelID,
+			"status":      model.RecognitionSucceeded,
+			"create_time": bson.M{"$gte": baselineStart, "$lte": endTime},
+		}}},
+		{{Key: "$project", Value: project}},
+		{{Key: "$facet", Value: facets}},
+	}
+	cursor, err := r.coll().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errors.Wrap(err, "汇总漂移检测分布失败")
+	}
+	defer cursor.Close(ctx)
+
+	type group struct {
+		ID struct {
+			Version  string `bson:"version"`
+			Current  bool   `bson:"current"`
+			Category string `bson:"category"`
+			Bin      int    `bson:"bin"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	var results []map[string][]group
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, errors.Wrap(err, "解析漂移检测分布失败")
+	}
+
+	bins := make([]*model.DriftBin, 0)
+	for _, result := range results {
+		for feature, groups := range result {
+			if feature == driftSamplesFacet {
+				feature = ""
+			}
+			for _, g := range groups {
+				bins = append(bins, &model.DriftBin{
+					ModelVersion: g.ID.Version,
+					Current:      g.ID.Current,
+					Feature:      feature,
+					Category:     g.ID.Category,
+					Bin:          g.ID.Bin,
+					Count:        g.Count,
+				})
+			}
+		}
+	}
+	return bins, nil
+}
+
+// SetImageStats 保存识别图片的尺寸和平均亮度
+func (r *recognitionRepository) SetImageStats(ctx context.Context, id primitive.ObjectID, width, height int, brightness float64) error {
+	update := bson.M{"$set": bson.M{
+		"image_width":  width,
+		"image_height": height,
+		"brightness":   brightness,
+		"update_time":  time.Now(),
+	}}
+	if _, err := r.coll().UpdateOne(ctx, bson.M{"_id": id}, update); err != nil {
+		return errors.Wrap(err, "保存图片统计信息失败")
+	}
+	return nil
+}
+
+// GetStatsByModelVersion 获取模型版本成功识别的平均置信度
+func (r *recognitionRepository) GetStatsByModelVersion(ctx context.Context, modelVersion string) (float64, error) {
+	return r.avgConfidence(ctx, bson.M{"model_version": modelVersion, "status": model.RecognitionSucceeded})
+}
+
+// GetStatsByCategory 获取类别成功识别的平均置信度
+func (r *recognitionRepository) GetStatsByCategory(ctx context.Context, category string) (float64, error) {
+	return r.avgConfidence(ctx, bson.M{"category": category, "status": model.RecognitionSucceeded})
+}
+
+// AggregateByVersion 按模型版本汇总[startTime, endTime)内的识别请求数、耗时和置信度
+func (r *recognitionRepository) AggregateByVersion(ctx context.Context, startTime, endTime time.Time) ([]*model.PerformanceTotals, error) {
+	succeeded := bson.M{"$eq": bson.A{"$status", model.RecognitionSucceeded}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"create_time": bson.M{"$gte": startTime, "$lt": endTime}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           bson.M{"modelId": "$model_id", "modelVersion": "$model_version"},
+			"requests":      bson.M{"$sum": 1},
+			"failures":      bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", model.RecognitionFailed}}, 1, 0}}},
+			"succeeded":     bson.M{"$sum": bson.M{"$cond": bson.A{succeeded, 1, 0}}},
+			"latencySum":    bson.M{"$sum": bson.M{"$cond": bson.A{succeeded, "$process_time", 0}}},
+			"confidenceSum": bson.M{"$sum": bson.M{"$cond": bson.A{succeeded, "$confidence", 0}}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":           0,
+			"modelId":       "$_id.modelId",
+			"modelVersion":  "$_id.modelVersion",
+			"requests":      1,
+			"failures":      1,
+			"succeeded":     1,
+			"latencySum":    1,
+			"confidenceSum": 1,
+		}}},
+	}
+	cursor, err := r.coll().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errors.Wrap(err, "汇总识别记录失败")
+	}
+	defer cursor.Close(ctx)
+
+	totals := make([]*model.PerformanceTotals, 0)
+	if err := cursor.All(ctx, &totals); err != nil {
+		return nil, errors.Wrap(err, "解析识别汇总失败")
+	}
+	return totals, nil
+}
+
+// SaveFeedback 保存客户对成功识别记录的反馈，记录不存在或不属于该客户时返回false
+func (r *recognitionRepository) SaveFeedback(ctx context.Context, id primitive.ObjectID, customerID int64, feedback *model.RecognitionFeedback) (bool, error) {
+	filter := bson.M{"_id": id, "customer_id": customerID, "status": model.RecognitionSucceeded}
+	update := bson.M{"$set": bson.M{"feedback": feedback, "update_time": time.Now()}}
+
+	result, err := r.coll().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, errors.Wrap(err, "保存识别反馈失败")
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// AggregateFeedback 按真实类别汇总模型在[startTime, endTime)内识别记录的客户反馈
+// 反馈未提供类别时真实类别即预测类别，计为预测正确
+func (r *recognitionRepository) AggregateFeedback(ctx context.Context, modelID string, startTime, endTime time.Time) ([]*model.FeedbackTotals, error) {
+	labelMissing := bson.M{"$in": bson.A{bson.M{"$ifNull": bson.A{"$feedback.label", ""}}, bson.A{"", "$category"}}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"model_id":    modelID,
+			"create_time": bson.M{"$gte": startTime, "$lt": endTime},
+			"feedback":    bson.M{"$exists": true},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     bson.M{"$cond": bson.A{labelMissing, "$category", "$feedback.label"}},
+			"total":   bson.M{"$sum": 1},
+			"correct": bson.M{"$sum": bson.M{"$cond": bson.A{labelMissing, 1, 0}}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":      0,
+			"modelId":  modelID,
+			"category": "$_id",
+			"total":    1,
+			"correct":  1,
+		}}},
+	}
+	cursor, err := r.coll().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errors.Wrap(err, "汇总识别反馈失败")
+	}
+	defer cursor.Close(ctx)
+
+	totals := make([]*model.FeedbackTotals, 0)
+	if err := cursor.All(ctx, &totals); err != nil {
+		return nil, errors.Wrap(err, "解析识别反馈汇总失败")
+	}
+	return totals, nil
+}
+
+// GetWithFeedback 获取模型在时间范围内有客户反馈的识别记录，correctedOnly为true时只返回客户修正过的记录
+func (r *recognitionRepository) GetWithFeedback(ctx context.Context, modelID string, startTime, endTime time.Time, correctedOnly bool) ([]*model.RecognitionRecord, error) {
+	filter := bson.M{
+		"model_id":    modelID,
+		"create_time": bson.M{"$gte": startTime, "$lte": endTime},
+		"feedback":    bson.M{"$exists": true},
+	}
+	if correctedOnly {
+		filter["feedback.correct"] = false
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "create_time", Value: 1}}))
+}
+
+// avgConfidence 计算平均置信度
+func (r *recognitionRepository) avgConfidence(ctx context.Context, filter bson.M) (float64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "avg": bson.M{"$avg": "$confidence"}}}},
+	}
+	cursor, err := r.coll().Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, errors.Wrap(err, "统计平均置信度失败")
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Avg float64 `bson:"avg"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return 0, errors.Wrap(err, "解析平均置信度失败")
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
+	return result[0].Avg, nil
+}
+
+// find 查询识别记录
+func (r *recognitionRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*model.RecognitionRecord, error) {
+	cursor, err := r.coll().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "查询识别记录失败")
+	}
+	defer cursor.Close(ctx)
+
+	records := make([]*model.RecognitionRecord, 0)
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, errors.Wrap(err, "解析识别记录失败")
+	}
+	return records, nil
+}
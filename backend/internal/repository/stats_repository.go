@@ -6,6 +6,7 @@ import (
 
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
@@ -47,8 +48,8 @@ func (r *StatsRepository) GetSystemStats(ctx context.Context, startTime, endTime
 	return stats, nil
 }
 
-// GetAccuracyStats 获取识别准确率统计数据
-func (r *StatsRepository) GetAccuracyStats(ctx context.Context, modelID string, category string, startTime, endTime time.Time) ([]*model.AccuracyStats, error) {
+// GetAccuracyStats 获取识别准确率统计数据，source为空时返回全部来源
+func (r *StatsRepository) GetAccuracyStats(ctx context.Context, modelID string, category string, source string, startTime, endTime time.Time) ([]*model.AccuracyStats, error) {
 	coll := r.db.Collection("accuracy_stats")
 
 	filter := bson.M{
@@ -64,6 +65,9 @@ func (r *StatsRepository) GetAccuracyStats(ctx context.Context, modelID string,
 	if category != "" {
 		filter["category"] = category
 	}
+	if source != "" {
+		filter["source"] = source
+	}
 
 	opts := options.Find().SetSort(bson.M{"date": 1})
 
@@ -134,6 +138,32 @@ func (r *StatsRepository) SaveAccuracyStats(ctx context.Context, stats *model.Ac
 	return nil
 }
 
+// ReplaceAccuracyStats 覆盖模型某一天指定来源的准确率统计，不在stats中的类别被删除
+func (r *StatsRepository) ReplaceAccuracyStats(ctx context.Context, modelID, source string, date time.Time, stats []*model.AccuracyStats) error {
+	coll := r.db.Collection("accuracy_stats")
+
+	categories := make([]string, 0, len(stats))
+	for _, s := range stats {
+		s.ModelID = modelID
+		s.Source = source
+		s.Date = date
+		s.CreatedAt = time.Now()
+		filter := bson.M{"model_id": modelID, "source": source, "date": date, "category": s.Category}
+		doc := *s
+		doc.ID = primitive.NilObjectID
+		if _, err := coll.ReplaceOne(ctx, filter, doc, options.Replace().SetUpsert(true)); err != nil {
+			return errors.Wrap(err, "保存准确率统计数据失败")
+		}
+		categories = append(categories, s.Category)
+	}
+
+	stale := bson.M{"model_id": modelID, "source": source, "date": date, "category": bson.M{"$nin": categories}}
+	if _, err := coll.DeleteMany(ctx, stale); err != nil {
+		return errors.Wrap(err, "删除过期准确率统计数据失败")
+	}
+	return nil
+}
+
 // SaveResourceUsage 保存资源使用统计数据
 func (r *StatsRepository) SaveResourceUsage(ctx context.Context, stats *model.ResourceUsage) error {
 	coll := r.db.Collection("resource_usage")
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/middleware"
+)
+
+// RegisterArtifactRoutes 注册模型文件上传与校验相关的路由
+func RegisterArtifactRoutes(r *gin.RouterGroup, artifactHandler *handler.ArtifactHandler) {
+	models := r.Group("/models")
+	models.Use(middleware.RequireAuth()) // 需要认证
+
+	models.POST("/:id/versions/:version/artifact/uploads", middleware.RequirePermission("model:manage"), artifactHandler.CreateUpload)
+	models.GET("/:id/versions/:version/artifact", middleware.RequirePermission("model:view"), artifactHandler.VerifyArtifact)
+
+	// 分块上传，分块可乱序、重复上传
+	uploads := r.Group("/artifact-uploads")
+	uploads.Use(middleware.RequireAuth())
+	uploads.PUT("/:uploadId/chunks/:index", middleware.RequirePermission("model:manage"), artifactHandler.UploadChunk)
+	uploads.POST("/:uploadId/complete", middleware.RequirePermission("model:manage"), artifactHandler.CompleteUpload)
+	uploads.DELETE("/:uploadId", middleware.RequirePermission("model:manage"), artifactHandler.AbortUpload)
+}
@@ -0,0 +1,26 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/middleware"
+)
+
+// RegisterBillingRoutes 注册账单及套餐计价路由
+func RegisterBillingRoutes(r *gin.RouterGroup, billingHandler *handler.BillingHandler) {
+	billing := r.Group("/billing")
+	billing.Use(middleware.RequireAuth()) // 需要认证
+
+	billing.GET("/invoices", middleware.RequirePermission("billing:view"), billingHandler.ListInvoices)
+	// 导出账单明细供财务系统导入
+	billing.GET("/invoices/export", middleware.RequirePermission("billing:view"), billingHandler.ExportInvoices)
+	billing.GET("/invoices/:id", middleware.RequirePermission("billing:view"), billingHandler.GetInvoice)
+	billing.POST("/invoices/generate", middleware.RequirePermission("billing:manage"), billingHandler.GenerateInvoices)
+	billing.POST("/invoices/:id/issue", middleware.RequirePermission("billing:manage"), billingHandler.IssueInvoice)
+
+	// 按当前用量预览客户的月度账单
+	billing.GET("/customers/:id/invoice-preview", middleware.RequirePermission("billing:view"), billingHandler.PreviewInvoice)
+
+	billing.GET("/plans/:id/pricing", middleware.RequirePermission("billing:view"), billingHandler.GetPricing)
+	billing.PUT("/plans/:id/pricing", middleware.RequirePermission("billing:manage"), billingHandler.SavePricing)
+}
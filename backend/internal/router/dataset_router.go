@@ -0,0 +1,48 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/middleware"
+)
+
+// RegisterDatasetRoutes 注册数据集相关路由
+func RegisterDatasetRoutes(r *gin.RouterGroup, datasetHandler *handler.DatasetHandler) {
+	// 数据集路由组
+	datasets := r.Group("/datasets")
+	datasets.Use(middleware.RequireAuth()) // 需要认证
+
+	// 数据集管理
+	datasets.GET("", middleware.RequirePermission("dataset:view"), datasetHandler.List)
+	datasets.POST("", middleware.RequirePermission("dataset:manage"), datasetHandler.Create)
+	datasets.POST("/import", middleware.RequirePermission("dataset:manage"), datasetHandler.ImportDataset)
+	datasets.GET("/:id", middleware.RequirePermission("dataset:view"), datasetHandler.GetByID)
+	datasets.PUT("/:id", middleware.RequirePermission("dataset:manage"), datasetHandler.Update)
+	datasets.DELETE("/:id", middleware.RequirePermission("dataset:manage"), datasetHandler.Delete)
+
+	// 按COCO、VOC、ImageNet格式导出
+	datasets.GET("/:id/export", middleware.RequirePermission("dataset:view"), datasetHandler.ExportDataset)
+
+	// 拆分训练/验证/测试集及血缘查询
+	datasets.POST("/:id/split", middleware.RequirePermission("dataset:manage"), datasetHandler.SplitDataset)
+	datasets.GET("/:id/lineage", middleware.RequirePermission("dataset:view"), datasetHandler.GetLineage)
+
+	// 标签分布统计
+	datasets.GET("/:id/stats", middleware.RequirePermission("dataset:view"), datasetHandler.GetLabelStats)
+
+	// 样本管理
+	datasets.GET("/:id/samples", middleware.RequirePermission("dataset:view"), datasetHandler.ListSamples)
+	datasets.POST("/:id/samples", middleware.RequirePermission("dataset:manage"), datasetHandler.UploadSamples)
+	datasets.GET("/:id/samples/:sampleId/image", middleware.RequirePermission("dataset:view"), datasetHandler.GetSampleImage)
+	datasets.PUT("/:id/samples/:sampleId/labels", middleware.RequirePermission("dataset:manage"), datasetHandler.UpdateSampleLabels)
+	datasets.DELETE("/:id/samples/:sampleId", middleware.RequirePermission("dataset:manage"), datasetHandler.DeleteSample)
+
+	// 样本标注与审核
+	datasets.GET("/:id/annotations/agreement", middleware.RequirePermission("dataset:view"), datasetHandler.GetAnnotationAgreement)
+	datasets.GET("/:id/samples/:sampleId/annotations", middleware.RequirePermission("dataset:view"), datasetHandler.ListAnnotations)
+	datasets.GET("/:id/samples/:sampleId/annotations/history", middleware.RequirePermission("dataset:view"), datasetHandler.GetAnnotationHistory)
+	datasets.POST("/:id/samples/:sampleId/annotations", middleware.RequirePermission("dataset:annotate"), datasetHandler.CreateAnnotation)
+	datasets.PUT("/:id/samples/:sampleId/annotations/:annotationId", middleware.RequirePermission("dataset:annotate"), datasetHandler.UpdateAnnotation)
+	datasets.DELETE("/:id/samples/:sampleId/annotations/:annotationId", middleware.RequirePermission("dataset:annotate"), datasetHandler.DeleteAnnotation)
+	datasets.POST("/:id/samples/:sampleId/annotations/:annotationId/review", middleware.RequirePermission("dataset:review"), datasetHandler.ReviewAnnotation)
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/middleware"
+)
+
+// RegisterDriftRoutes 注册模型漂移检测相关的路由
+func RegisterDriftRoutes(r *gin.RouterGroup, driftHandler *handler.DriftHandler) {
+	models := r.Group("/models")
+	models.Use(middleware.RequireAuth()) // 需要认证
+
+	models.GET("/:id/drift", middleware.RequirePermission("model:view"), driftHandler.GetReport)
+	models.POST("/:id/drift/check", middleware.RequirePermission("model:manage"), driftHandler.Check)
+
+	alerts := r.Group("/drift")
+	alerts.Use(middleware.RequireAuth())
+
+	alerts.GET("/thresholds", middleware.RequirePermission("model:view"), driftHandler.GetThresholds)
+	alerts.GET("/alerts", middleware.RequirePermission("model:view"), driftHandler.ListAlerts)
+	alerts.POST("/alerts/:id/acknowledge", middleware.RequirePermission("model:manage"), driftHandler.AcknowledgeAlert)
+}
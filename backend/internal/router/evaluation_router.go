@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/middleware"
+)
+
+// RegisterEvaluationRoutes 注册模型评估相关的路由
+func RegisterEvaluationRoutes(r *gin.RouterGroup, evaluationHandler *handler.EvaluationHandler) {
+	evaluations := r.Group("/evaluations")
+	evaluations.Use(middleware.RequireAuth()) // 需要认证
+
+	evaluations.POST("", middleware.RequirePermission("model:evaluate"), evaluationHandler.CreateEvaluation)
+	evaluations.GET("", middleware.RequirePermission("model:view"), evaluationHandler.ListEvaluations)
+	evaluations.GET("/:id", middleware.RequirePermission("model:view"), evaluationHandler.GetEvaluation)
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/handler/client"
+	"github.com/image-recognition-engine/internal/middleware"
+)
+
+// RegisterFeedbackRoutes 注册客户反馈管理路由
+func RegisterFeedbackRoutes(r *gin.RouterGroup, feedbackHandler *handler.FeedbackHandler) {
+	models := r.Group("/models")
+	models.Use(middleware.RequireAuth()) // 需要认证
+
+	// 将客户反馈导出为训练数据集
+	models.POST("/:id/feedback/export", middleware.RequirePermission("dataset:manage"), feedbackHandler.ExportDataset)
+}
+
+// RegisterClientFeedbackRoutes 注册客户端识别结果反馈路由，客户端由API密钥认证
+func RegisterClientFeedbackRoutes(r *gin.RouterGroup, feedbackHandler *client.FeedbackHandler) {
+	recognitions := r.Group("/client/recognitions")
+
+	recognitions.POST("/:id/feedback", feedbackHandler.SubmitFeedback)
+}
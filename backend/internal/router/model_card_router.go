@@ -0,0 +1,22 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/middleware"
+)
+
+// RegisterModelCardRoutes 注册模型卡片及标签映射相关的路由
+func RegisterModelCardRoutes(r *gin.RouterGroup, modelCardHandler *handler.ModelCardHandler) {
+	models := r.Group("/models")
+	models.Use(middleware.RequireAuth()) // 需要认证
+
+	// 模型卡片，支持导出为Markdown或JSON
+	models.GET("/:id/card", middleware.RequirePermission("model:view"), modelCardHandler.GetCard)
+	models.PUT("/:id/card", middleware.RequirePermission("model:manage"), modelCardHandler.SaveCardInfo)
+
+	// 标签映射，每次发布生成新版本
+	models.GET("/:id/labels", middleware.RequirePermission("model:view"), modelCardHandler.GetLabelMap)
+	models.GET("/:id/labels/versions", middleware.RequirePermission("model:view"), modelCardHandler.ListLabelMapVersions)
+	models.POST("/:id/labels", middleware.RequirePermission("model:manage"), modelCardHandler.PublishLabelMap)
+}
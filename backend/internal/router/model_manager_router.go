@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/middleware"
+)
+
+// RegisterModelManagerRoutes 注册已加载模型管理相关的路由
+func RegisterModelManagerRoutes(r *gin.RouterGroup, managerHandler *handler.ModelManagerHandler) {
+	loaded := r.Group("/admin/models/loaded")
+	loaded.Use(middleware.RequireAuth()) // 需要认证
+
+	loaded.GET("", middleware.RequirePermission("model:view"), managerHandler.GetStatus)
+	loaded.POST("/prewarm", middleware.RequirePermission("model:manage"), managerHandler.Prewarm)
+	loaded.DELETE("/:modelId/:version", middleware.RequirePermission("model:manage"), managerHandler.Unload)
+}
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+)
+
+// RegisterOAuthRoutes 注册OAuth2客户端凭证模式路由，客户端凭证由处理器校验
+func RegisterOAuthRoutes(r *gin.RouterGroup, oauthHandler *handler.OAuthHandler) {
+	oauth := r.Group("/oauth")
+
+	oauth.POST("/token", oauthHandler.Token)
+	// 查询访问令牌状态(RFC 7662)
+	oauth.POST("/introspect", oauthHandler.Introspect)
+	// 吊销访问令牌(RFC 7009)
+	oauth.POST("/revoke", oauthHandler.Revoke)
+}
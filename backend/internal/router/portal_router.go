@@ -0,0 +1,35 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/middleware"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// RegisterPortalRoutes 注册客户自助门户路由，登录外的接口需要有效客户的门户令牌
+func RegisterPortalRoutes(r *gin.RouterGroup, portalHandler *handler.PortalHandler, customers model.CustomerRepository, jwtSecret string) {
+	portal := r.Group("/portal")
+	portal.POST("/login", portalHandler.Login)
+
+	authorized := portal.Group("")
+	authorized.Use(middleware.CustomerAuth(jwtSecret, customers))
+
+	authorized.GET("/profile", portalHandler.Profile)
+	authorized.GET("/plan", portalHandler.Plan)
+	authorized.GET("/usage", portalHandler.Usage)
+	// 下载月度用量报表，支持csv和json格式
+	authorized.GET("/usage/report", portalHandler.DownloadUsageReport)
+
+	authorized.GET("/applications", portalHandler.ListApplications)
+	authorized.POST("/applications", portalHandler.CreateApplication)
+	authorized.PUT("/applications/:id", portalHandler.UpdateApplication)
+	authorized.DELETE("/applications/:id", portalHandler.DeleteApplication)
+	authorized.GET("/applications/:id/keys", portalHandler.ListAPIKeys)
+	authorized.POST("/applications/:id/keys", portalHandler.CreateAPIKey)
+	authorized.DELETE("/applications/:id/keys/:keyId", portalHandler.RevokeAPIKey)
+	// 轮换密钥，旧密钥在宽限期内继续有效
+	authorized.POST("/applications/:id/keys/:keyId/rotate", portalHandler.RotateAppAPIKey)
+
+	authorized.GET("/recognitions", portalHandler.Recognitions)
+}
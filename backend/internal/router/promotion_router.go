@@ -0,0 +1,29 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/middleware"
+)
+
+// RegisterPromotionRoutes 注册模型版本上线流程相关的路由
+func RegisterPromotionRoutes(r *gin.RouterGroup, promotionHandler *handler.PromotionHandler) {
+	versions := r.Group("/model-versions")
+	versions.Use(middleware.RequireAuth()) // 需要认证
+
+	versions.GET("/promotion/thresholds", middleware.RequirePermission("model:view"), promotionHandler.GetThresholds)
+	versions.GET("/:id/history", middleware.RequirePermission("model:view"), promotionHandler.GetHistory)
+
+	// 提交测试及申请上线
+	versions.POST("/:id/submit", middleware.RequirePermission("model:manage"), promotionHandler.Submit)
+	versions.POST("/:id/request-approval", middleware.RequirePermission("model:manage"), promotionHandler.RequestApproval)
+
+	// 管理员审批
+	versions.POST("/:id/approve", middleware.RequirePermission("model:approve"), promotionHandler.Approve)
+	versions.POST("/:id/reject", middleware.RequirePermission("model:approve"), promotionHandler.Reject)
+
+	// 一键回滚到上一个生产版本
+	models := r.Group("/models")
+	models.Use(middleware.RequireAuth())
+	models.POST("/:id/rollback", middleware.RequirePermission("model:approve"), promotionHandler.Rollback)
+}
@@ -0,0 +1,34 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/handler/client"
+	"github.com/image-recognition-engine/internal/middleware"
+)
+
+// RegisterRoutingRoutes 注册模型流量分配相关的路由
+func RegisterRoutingRoutes(r *gin.RouterGroup, routingHandler *handler.RoutingHandler) {
+	models := r.Group("/models")
+	models.Use(middleware.RequireAuth()) // 需要认证
+
+	models.GET("/:id/routing", middleware.RequirePermission("model:view"), routingHandler.GetPolicy)
+	models.PUT("/:id/routing", middleware.RequirePermission("model:approve"), routingHandler.SavePolicy)
+	models.DELETE("/:id/routing", middleware.RequirePermission("model:approve"), routingHandler.DeletePolicy)
+
+	// 生产版本与候选版本并排对比
+	models.GET("/:id/routing/report", middleware.RequirePermission("model:view"), routingHandler.GetComparison)
+	// 影子版本与生产版本的差异报告
+	models.GET("/:id/routing/shadow-report", middleware.RequirePermission("model:view"), routingHandler.GetShadowReport)
+}
+
+// RegisterClientRecognitionRoutes 注册客户端识别路由，客户端由API密钥认证
+// guards在识别请求之前依次执行，如security.ConcurrencyLimit、FeatureGate.RequireModelFeature和quota.Enforce
+func RegisterClientRecognitionRoutes(r *gin.RouterGroup, recognitionHandler *client.RecognitionHandler, guards ...gin.HandlerFunc) {
+	recognitions := r.Group("/client/recognitions")
+
+	recognize := append(append([]gin.HandlerFunc{}, guards...), recognitionHandler.Recognize)
+	recognitions.POST("", recognize...)
+	recognitions.GET("", recognitionHandler.ListRecognitions)
+	recognitions.GET("/:id", recognitionHandler.GetRecognition)
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/middleware"
+)
+
+// RegisterTuningRoutes 注册超参数搜索相关的路由
+func RegisterTuningRoutes(r *gin.RouterGroup, tuningHandler *handler.TuningHandler) {
+	tuning := r.Group("/tuning")
+	tuning.Use(middleware.RequireAuth()) // 需要认证
+
+	// 调参任务
+	tuning.POST("/jobs", middleware.RequirePermission("model:train"), tuningHandler.CreateJob)
+	tuning.GET("/jobs", middleware.RequirePermission("model:view"), tuningHandler.ListJobs)
+	tuning.GET("/jobs/:id", middleware.RequirePermission("model:view"), tuningHandler.GetJob)
+	tuning.POST("/jobs/:id/cancel", middleware.RequirePermission("model:train"), tuningHandler.CancelJob)
+
+	// 训练端回调：开始执行及上报结果
+	tuning.POST("/trials/:id/start", middleware.RequirePermission("model:train"), tuningHandler.StartTrial)
+	tuning.POST("/trials/:id/result", middleware.RequirePermission("model:train"), tuningHandler.ReportTrialResult)
+}
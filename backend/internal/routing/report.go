@@ -0,0 +1,105 @@
+package routing
+
+import (
+	"math"
+	"sort"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// confidenceBins 置信度直方图分箱数
+const confidenceBins = 10
+
+// Compare 按服务版本汇总识别记录，生产版本排在前面
+func Compare(records []*model.RecognitionRecord) []*model.VersionServingStats {
+	type group struct {
+		stats      *model.VersionServingStats
+		latency    []float64
+		confidence []float64
+	}
+
+	groups := make(map[string]*group)
+	for _, r := range records {
+		variant := r.Variant
+		if variant == "" {
+			variant = model.VariantProduction
+		}
+		key := variant + "\x00" + r.ModelVersion
+		g, ok := groups[key]
+		if !ok {
+			g = &group{stats: &model.VersionServingStats{
+				ModelVersion: r.ModelVersion,
+				Variant:      variant,
+				Categories:   make(map[string]int64),
+			}}
+			groups[key] = g
+		}
+
+		g.stats.Requests++
+		if r.Status != model.RecognitionSucceeded {
+			g.stats.Failures++
+			continue
+		}
+		g.latency = append(g.latency, float64(r.ProcessTime))
+		g.confidence = append(g.confidence, r.Confidence)
+		g.stats.Categories[r.Category]++
+	}
+
+	result := make([]*model.VersionServingStats, 0, len(groups))
+	for _, g := range groups {
+		s := g.stats
+		s.ErrorRate = float64(s.Failures) / float64(s.Requests)
+		s.TrafficShare = float64(s.Requests) / float64(len(records))
+		s.LatencyMs = Distribution(g.latency, false)
+		s.Confidence = Distribution(g.confidence, true)
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Variant != result[j].Variant {
+			return result[i].Variant == model.VariantProduction
+		}
+		return result[i].ModelVersion < result[j].ModelVersion
+	})
+	return result
+}
+
+// Distribution 计算均值和分位数，histogram为true时按[0,1]等宽分箱
+func Distribution(values []float64, histogram bool) model.DistributionStats {
+	var d model.DistributionStats
+	if histogram {
+		d.Histogram = make([]int64, confidenceBins)
+	}
+	if len(values) == 0 {
+		return d
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+		if histogram {
+			i := int(math.Max(0, math.Min(1, v)) * confidenceBins)
+			if i == confidenceBins {
+				i--
+			}
+			d.Histogram[i]++
+		}
+	}
+	d.Mean = sum / float64(len(sorted))
+	d.P50 = percentile(sorted, 0.5)
+	d.P90 = percentile(sorted, 0.9)
+	d.P99 = percentile(sorted, 0.99)
+	return d
+}
+
+// percentile 最近秩法计算已排序数据的分位数
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,312 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// Buckets 流量分桶数，百分比精度为0.01%
+const Buckets = 10000
+
+// cacheTTL 策略及生产版本的本地缓存时间，修改策略后最多延迟该时长在其他实例生效
+const cacheTTL = 10 * time.Second
+
+// Bucket 将key稳定地映射到[0, Buckets)，同一策略下相同key始终落在同一桶
+func Bucket(salt, key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(salt))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return int(h.Sum32() % Buckets)
+}
+
+// StickyKey 根据策略的粘性依据生成分桶key，未配置策略时按请求分桶
+func StickyKey(policy *model.RoutingPolicy, customerID int64, requestHash string) string {
+	if policy != nil && policy.StickyBy == model.StickyByCustomer && customerID != 0 {
+		return "customer:" + strconv.FormatInt(customerID, 10)
+	}
+	return "request:" + requestHash
+}
+
+// UseCandidate 判断key是否落入候选版本的流量
+// 分桶以模型ID为盐，调整百分比时已分到候选版本的key保持不变
+func UseCandidate(policy *model.RoutingPolicy, key string) bool {
+	if policy == nil || !policy.Enabled || policy.CandidateVersion == "" || policy.CandidatePercent <= 0 {
+		return false
+	}
+	return float64(Bucket(policy.ModelID, key)) < policy.CandidatePercent*Buckets/100
+}
+
+// UseShadow 判断生产请求是否需要在影子版本上复跑
+// 影子复跑与候选版本分流相互独立，未启用候选分流时也可单独使用
+func UseShadow(policy *model.RoutingPolicy, key string) bool {
+	if policy == nil || policy.ShadowVersion == "" || policy.ShadowPercent <= 0 {
+		return false
+	}
+	return float64(Bucket(policy.ModelID+":shadow", key)) < policy.ShadowPercent*Buckets/100
+}
+
+// ValidatePolicy 校验流量分配策略
+func ValidatePolicy(policy *model.RoutingPolicy) error {
+	if policy.CandidatePercent < 0 || policy.CandidatePercent > 100 {
+		return fmt.Errorf("候选版本流量百分比必须在0到100之间")
+	}
+	if policy.StickyBy == "" {
+		policy.StickyBy = model.StickyByCustomer
+	}
+	if policy.StickyBy != model.StickyByCustomer && policy.StickyBy != model.StickyByRequest {
+		return fmt.Errorf("不支持的粘性依据: %s", policy.StickyBy)
+	}
+	if policy.Enabled && policy.CandidateVersion == "" {
+		return fmt.Errorf("启用策略时必须指定候选版本")
+	}
+	if policy.ShadowPercent < 0 || policy.ShadowPercent > 100 {
+		return fmt.Errorf("影子复跑百分比必须在0到100之间")
+	}
+	if policy.ShadowPercent > 0 && policy.ShadowVersion == "" {
+		return fmt.Errorf("设置影子复跑百分比时必须指定影子版本")
+	}
+	return nil
+}
+
+// Decision 一次识别请求的版本选择结果
+type Decision struct {
+	ModelID      string
+	ModelVersion string
+	Variant      string
+	// ShadowVersion 非空时请求还需异步在该版本上复跑，仅生产版本请求会被复跑
+	ShadowVersion string
+}
+
+// VersionStore 模型版本查询，由repository.ModelRepository实现
+type VersionStore interface {
+	GetProductionVersion(ctx context.Context, modelID string) (*model.ModelVersion, error)
+	FindModelVersion(ctx context.Context, modelID, version string) (*model.ModelVersion, error)
+}
+
+// ArtifactVerifier 模型文件校验，由artifact.Store实现
+type ArtifactVerifier interface {
+	Verify(ctx context.Context, modelID, version string) (*model.ArtifactManifest, error)
+}
+
+// cachedRoute 缓存的模型路由信息
+type cachedRoute struct {
+	policy     *model.RoutingPolicy
+	production string
+	expireAt   time.Time
+}
+
+// Service 识别流量分配服务
+type Service struct {
+	policies model.RoutingPolicyRepository
+	versions VersionStore
+	models   model.ModelRepository
+	verifier ArtifactVerifier // 为nil时不校验模型文件
+
+	mu    sync.Mutex
+	cache map[string]*cachedRoute
+}
+
+// NewService 创建识别流量分配服务
+func NewService(policies model.RoutingPolicyRepository, versions VersionStore, models model.ModelRepository, verifier ArtifactVerifier) *Service {
+	return &Service{
+		policies: policies,
+		versions: versions,
+		models:   models,
+		verifier: verifier,
+		cache:    make(map[string]*cachedRoute),
+	}
+}
+
+// Resolve 为识别请求选择模型版本
+func (s *Service) Resolve(ctx context.Context, modelID string, customerID int64, requestHash string) (*Decision, error) {
+	route, err := s.route(ctx, modelID)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &Decision{ModelID: modelID, ModelVersion: route.production, Variant: model.VariantProduction}
+	key := StickyKey(route.policy, customerID, requestHash)
+	if UseCandidate(route.policy, key) {
+		decision.ModelVersion = route.policy.CandidateVersion
+		decision.Variant = model.VariantCandidate
+	} else if UseShadow(route.policy, key) && route.policy.ShadowVersion != route.production {
+		decision.ShadowVersion = route.policy.ShadowVersion
+	}
+	return decision, nil
+}
+
+// Policy 获取模型的流量分配策略
+func (s *Service) Policy(modelID string) (*model.RoutingPolicy, error) {
+	policy, err := s.policies.FindByModel(modelID)
+	if err != nil {
+		return nil, errors.NewServerError("查询流量分配策略失败")
+	}
+	return policy, nil
+}
+
+// SavePolicy 校验并保存模型的流量分配策略
+func (s *Service) SavePolicy(ctx context.Context, policy *model.RoutingPolicy) error {
+	if err := ValidatePolicy(policy); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+	if err := s.checkVersion(ctx, policy.ModelID, policy.CandidateVersion, "候选版本"); err != nil {
+		return err
+	}
+	if err := s.checkVersion(ctx, policy.ModelID, policy.ShadowVersion, "影子版本"); err != nil {
+		return err
+	}
+
+	if err := s.policies.Save(policy); err != nil {
+		return errors.NewServerError("保存流量分配策略失败")
+	}
+	s.Invalidate(policy.ModelID)
+	return nil
+}
+
+// checkVersion 校验策略引用的版本存在且不是生产版本，version为空时跳过
+func (s *Service) checkVersion(ctx context.Context, modelID, version, name string) error {
+	if version == "" {
+		return nil
+	}
+	v, err := s.versions.FindModelVersion(ctx, modelID, version)
+	if err != nil {
+		return errors.NewServerError("查询" + name + "失败")
+	}
+	if v == nil {
+		return errors.NewValidationError(name + "不存在")
+	}
+	if v.Status == model.ModelVersionProduction {
+		return errors.NewValidationError(name + "已是生产版本")
+	}
+	if s.verifier != nil {
+		if _, err := s.verifier.Verify(ctx, modelID, version); err != nil {
+			return errors.NewAppError(errors.ValidationError, name+"模型文件校验失败", err.Error())
+		}
+	}
+	return nil
+}
+
+// DeletePolicy 删除模型的流量分配策略，全部流量回到生产版本
+func (s *Service) DeletePolicy(modelID string) error {
+	if err := s.policies.DeleteByModel(modelID); err != nil {
+		return errors.NewServerError("删除流量分配策略失败")
+	}
+	s.Invalidate(modelID)
+	return nil
+}
+
+// ReleaseVersion 版本上线为生产版本后从策略中移除该版本
+// 作为候选版本时清除候选版本及其流量百分比并停用分流，作为影子版本时停止复跑，避免生产版本被当作候选或影子版本继续分流
+func (s *Service) ReleaseVersion(ctx context.Context, modelID, version string) error {
+	policy, err := s.policies.FindByModel(modelID)
+	if err != nil {
+		return errors.NewServerError("查询流量分配策略失败")
+	}
+	if policy == nil || version == "" {
+		s.Invalidate(modelID)
+		return nil
+	}
+
+	changed := false
+	if policy.CandidateVersion == version {
+		policy.CandidateVersion = ""
+		policy.CandidatePercent = 0
+		policy.Enabled = false
+		changed = true
+	}
+	if policy.ShadowVersion == version {
+		policy.ShadowVersion = ""
+		policy.ShadowPercent = 0
+		changed = true
+	}
+	if changed {
+		if err := s.policies.Save(policy); err != nil {
+			return errors.NewServerError("保存流量分配策略失败")
+		}
+	}
+	s.Invalidate(modelID)
+	return nil
+}
+
+// Invalidate 清除模型的本地路由缓存
+func (s *Service) Invalidate(modelID string) {
+	s.mu.Lock()
+	delete(s.cache, modelID)
+	s.mu.Unlock()
+}
+
+// verifyPolicy 校验策略中候选和影子版本的模型文件，校验失败的版本从策略副本中移除
+func (s *Service) verifyPolicy(ctx context.Context, policy *model.RoutingPolicy) *model.RoutingPolicy {
+	if policy == nil {
+		return nil
+	}
+	verified := *policy
+	if verified.Enabled && verified.CandidateVersion != "" {
+		if _, err := s.verifier.Verify(ctx, verified.ModelID, verified.CandidateVersion); err != nil {
+			log.Printf("模型%s候选版本%s文件校验失败，停止分流: %v", verified.ModelID, verified.CandidateVersion, err)
+			verified.Enabled = false
+		}
+	}
+	if verified.ShadowVersion != "" {
+		if _, err := s.verifier.Verify(ctx, verified.ModelID, verified.ShadowVersion); err != nil {
+			log.Printf("模型%s影子版本%s文件校验失败，停止复跑: %v", verified.ModelID, verified.ShadowVersion, err)
+			verified.ShadowVersion = ""
+		}
+	}
+	return &verified
+}
+
+// route 获取模型的策略及生产版本，优先使用本地缓存
+func (s *Service) route(ctx context.Context, modelID string) (*cachedRoute, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[modelID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expireAt) {
+		return cached, nil
+	}
+
+	policy, err := s.policies.FindByModel(modelID)
+	if err != nil {
+		return nil, errors.NewServerError("查询流量分配策略失败")
+	}
+
+	// 没有生产版本时使用模型当前版本
+	var production string
+	v, err := s.versions.GetProductionVersion(ctx, modelID)
+	if err != nil {
+		return nil, errors.NewServerError("查询生产版本失败")
+	}
+	if v != nil {
+		production = v.Version
+	} else {
+		m, err := s.models.FindByID(modelID)
+		if err != nil || m == nil {
+			return nil, errors.NewNotFoundError("模型不存在")
+		}
+		production = m.Version
+	}
+
+	// 模型文件缺失或被篡改的版本不提供服务：生产版本直接拒绝，候选和影子版本停止分流
+	if s.verifier != nil {
+		if _, err := s.verifier.Verify(ctx, modelID, production); err != nil {
+			log.Printf("模型%s生产版本%s文件校验失败: %v", modelID, production, err)
+			return nil, errors.NewAppError(errors.ModelArtifactError, "模型文件校验失败，暂停服务", err.Error())
+		}
+		policy = s.verifyPolicy(ctx, policy)
+	}
+
+	route := &cachedRoute{policy: policy, production: production, expireAt: time.Now().Add(cacheTTL)}
+	s.mu.Lock()
+	s.cache[modelID] = route
+	s.mu.Unlock()
+	return route, nil
+}
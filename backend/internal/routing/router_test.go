@@ -0,0 +1,128 @@
+package routing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+func TestUseCandidate(t *testing.T) {
+	policy := &model.RoutingPolicy{ModelID: "m1", CandidateVersion: "v2", CandidatePercent: 20, StickyBy: model.StickyByCustomer, Enabled: true}
+
+	hits := 0
+	for i := 0; i < 10000; i++ {
+		if UseCandidate(policy, StickyKey(policy, int64(i+1), "")) {
+			hits++
+		}
+	}
+	assert.InDelta(t, 2000, hits, 200)
+
+	// 同一客户始终命中同一版本；提高百分比后原候选客户仍在候选版本
+	key := StickyKey(policy, 42, "abc")
+	assert.Equal(t, "customer:42", key)
+	first := UseCandidate(policy, key)
+	assert.Equal(t, first, UseCandidate(policy, key))
+	if first {
+		policy.CandidatePercent = 50
+		assert.True(t, UseCandidate(policy, key))
+	}
+
+	policy.Enabled = false
+	assert.False(t, UseCandidate(policy, key))
+
+	policy.StickyBy = model.StickyByRequest
+	assert.Equal(t, "request:abc", StickyKey(policy, 42, "abc"))
+
+	// 未配置策略的模型全部流量走生产版本
+	assert.Equal(t, "request:abc", StickyKey(nil, 42, "abc"))
+	assert.False(t, UseCandidate(nil, "request:abc"))
+}
+
+func TestValidatePolicy(t *testing.T) {
+	p := &model.RoutingPolicy{CandidateVersion: "v2", CandidatePercent: 10, Enabled: true}
+	require.NoError(t, ValidatePolicy(p))
+	assert.Equal(t, model.StickyByCustomer, p.StickyBy)
+
+	assert.Error(t, ValidatePolicy(&model.RoutingPolicy{CandidatePercent: 120}))
+	assert.Error(t, ValidatePolicy(&model.RoutingPolicy{StickyBy: "ip"}))
+	assert.Error(t, ValidatePolicy(&model.RoutingPolicy{Enabled: true}))
+}
+
+func TestCompare(t *testing.T) {
+	var records []*model.RecognitionRecord
+	for i := 1; i <= 10; i++ {
+		records = append(records, &model.RecognitionRecord{
+			ModelVersion: "v1", Variant: model.VariantProduction, Status: model.RecognitionSucceeded,
+			ProcessTime: int64(i * 10), Confidence: 0.9, Category: "cat",
+		})
+	}
+	records = append(records,
+		&model.RecognitionRecord{ModelVersion: "v2", Variant: model.VariantCandidate, Status: model.RecognitionSucceeded, ProcessTime: 30, Confidence: 0.55, Category: "dog"},
+		&model.RecognitionRecord{ModelVersion: "v2", Variant: model.VariantCandidate, Status: model.RecognitionFailed},
+	)
+
+	stats := Compare(records)
+	require.Len(t, stats, 2)
+
+	prod, cand := stats[0], stats[1]
+	assert.Equal(t, "v1", prod.ModelVersion)
+	assert.Equal(t, int64(10), prod.Requests)
+	assert.InDelta(t, 55, prod.LatencyMs.Mean, 1e-9)
+	assert.Equal(t, 50.0, prod.LatencyMs.P50)
+	assert.Equal(t, 90.0, prod.LatencyMs.P90)
+	assert.Equal(t, int64(10), prod.Confidence.Histogram[9])
+	assert.InDelta(t, 10.0/12, prod.TrafficShare, 1e-9)
+
+	assert.Equal(t, model.VariantCandidate, cand.Variant)
+	assert.InDelta(t, 0.5, cand.ErrorRate, 1e-9)
+	assert.Equal(t, int64(1), cand.Confidence.Histogram[5])
+	assert.Equal(t, int64(1), cand.Categories["dog"])
+}
+
+type memoryPolicies struct {
+	model.RoutingPolicyRepository
+	policy *model.RoutingPolicy
+	saves  int
+}
+
+func (m *memoryPolicies) FindByModel(modelID string) (*model.RoutingPolicy, error) {
+	if m.policy == nil {
+		return nil, nil
+	}
+	copied := *m.policy
+	return &copied, nil
+}
+
+func (m *memoryPolicies) Save(policy *model.RoutingPolicy) error {
+	copied := *policy
+	m.policy = &copied
+	m.saves++
+	return nil
+}
+
+func TestReleaseVersion(t *testing.T) {
+	policies := &memoryPolicies{policy: &model.RoutingPolicy{
+		ModelID: "m1", CandidateVersion: "v2", CandidatePercent: 30, Enabled: true,
+		ShadowVersion: "v3", ShadowPercent: 10,
+	}}
+	s := NewService(policies, nil, nil, nil)
+
+	// 与策略无关的版本上线不修改策略
+	require.NoError(t, s.ReleaseVersion(context.Background(), "m1", "v9"))
+	assert.Equal(t, 0, policies.saves)
+
+	require.NoError(t, s.ReleaseVersion(context.Background(), "m1", "v2"))
+	assert.Equal(t, "", policies.policy.CandidateVersion)
+	assert.Equal(t, 0.0, policies.policy.CandidatePercent)
+	assert.False(t, policies.policy.Enabled)
+	assert.Equal(t, "v3", policies.policy.ShadowVersion)
+	require.NoError(t, ValidatePolicy(policies.policy))
+
+	require.NoError(t, s.ReleaseVersion(context.Background(), "m1", "v3"))
+	assert.Equal(t, "", policies.policy.ShadowVersion)
+	assert.Equal(t, 0.0, policies.policy.ShadowPercent)
+}
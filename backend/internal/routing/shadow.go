@@ -0,0 +1,228 @@
+package routing
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/image-recognition-engine/internal/inference"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// shadowLabels 参与标签对比的top-N标签数，与客户端识别结果返回的标签数一致
+const shadowLabels = 5
+
+// shadowTimeout 单次影子复跑的超时时间
+const shadowTimeout = 30 * time.Second
+
+// ShadowJob 一次待复跑的生产请求
+type ShadowJob struct {
+	Record        *model.RecognitionRecord // 已保存的生产识别记录
+	ShadowVersion string
+	Image         []byte
+}
+
+// ShadowRunner 在后台将生产请求复跑到影子版本并保存对比结果
+// 队列满时直接丢弃任务，保证复跑不会阻塞或拖慢生产请求
+type ShadowRunner struct {
+	predictor inference.Predictor
+	results   model.ShadowResultRepository
+
+	jobs    chan *ShadowJob
+	wg      sync.WaitGroup
+	dropped int64
+	once    sync.Once
+}
+
+// NewShadowRunner 创建影子复跑执行器并启动workers个后台协程
+func NewShadowRunner(predictor inference.Predictor, results model.ShadowResultRepository, workers, queueSize int) *ShadowRunner {
+	if workers <= 0 {
+		workers = 1
+	}
+	r := &ShadowRunner{
+		predictor: predictor,
+		results:   results,
+		jobs:      make(chan *ShadowJob, queueSize),
+	}
+	for i := 0; i < workers; i++ {
+		r.wg.Add(1)
+		go r.loop()
+	}
+	return r
+}
+
+// Submit 提交复跑任务，队列已满或执行器已关闭时返回false
+func (r *ShadowRunner) Submit(job *ShadowJob) (ok bool) {
+	defer func() {
+		// 关闭后提交会向已关闭的通道发送，按丢弃处理
+		if recover() != nil {
+			ok = false
+		}
+		if !ok {
+			atomic.AddInt64(&r.dropped, 1)
+		}
+	}()
+
+	select {
+	case r.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// Dropped 因队列满而丢弃的任务数
+func (r *ShadowRunner) Dropped() int64 {
+	return atomic.LoadInt64(&r.dropped)
+}
+
+// Close 停止接收任务并等待已入队的任务执行完成
+func (r *ShadowRunner) Close() {
+	r.once.Do(func() {
+		close(r.jobs)
+	})
+	r.wg.Wait()
+}
+
+// loop 后台协程，逐个执行复跑任务
+func (r *ShadowRunner) loop() {
+	defer r.wg.Done()
+	for job := range r.jobs {
+		r.run(job)
+	}
+}
+
+// run 执行一次复跑并保存对比结果，失败只记录日志
+func (r *ShadowRunner) run(job *ShadowJob) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("影子复跑异常: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shadowTimeout)
+	defer cancel()
+
+	startTime := time.Now()
+	predictions, err := r.predictor.Predict(ctx, job.Record.ModelID, job.ShadowVersion, job.Image)
+	result := NewShadowResult(job.Record, job.ShadowVersion, predictions, err)
+	result.ShadowLatency = time.Since(startTime).Milliseconds()
+
+	if err := r.results.Create(result); err != nil {
+		log.Printf("保存影子复跑结果失败: %v", err)
+	}
+}
+
+// NewShadowResult 对比生产识别记录与影子版本的预测结果
+func NewShadowResult(record *model.RecognitionRecord, shadowVersion string, predictions []inference.Prediction, err error) *model.ShadowResult {
+	result := &model.ShadowResult{
+		ModelID:              record.ModelID,
+		RecordID:             record.ID.Hex(),
+		ProductionVersion:    record.ModelVersion,
+		ShadowVersion:        shadowVersion,
+		ProductionCategory:   record.Category,
+		ProductionConfidence: record.Confidence,
+		ProductionLabels:     record.Labels,
+		CreateTime:           time.Now(),
+	}
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		return result
+	}
+
+	sorted := append([]inference.Prediction(nil), predictions...)
+	inference.SortPredictions(sorted)
+	if len(sorted) > shadowLabels {
+		sorted = sorted[:shadowLabels]
+	}
+	for _, p := range sorted {
+		result.ShadowLabels = append(result.ShadowLabels, p.Label)
+	}
+	if len(sorted) > 0 {
+		result.ShadowCategory = sorted[0].Label
+		result.ShadowConfidence = sorted[0].Confidence
+	}
+
+	result.Agree = result.ShadowCategory == result.ProductionCategory
+	result.AddedLabels = subtract(result.ShadowLabels, result.ProductionLabels)
+	result.RemovedLabels = subtract(result.ProductionLabels, result.ShadowLabels)
+	return result
+}
+
+// subtract 返回在a中但不在b中的标签，保持a中的顺序
+func subtract(a, b []string) []string {
+	exclude := make(map[string]bool, len(b))
+	for _, s := range b {
+		exclude[s] = true
+	}
+	var diff []string
+	for _, s := range a {
+		if !exclude[s] {
+			diff = append(diff, s)
+		}
+	}
+	return diff
+}
+
+// SummarizeShadow 汇总影子复跑结果，按生产版本类别统计不一致率和标签差异
+func SummarizeShadow(results []*model.ShadowResult) *model.ShadowReport {
+	report := &model.ShadowReport{Categories: make([]*model.ShadowCategoryStats, 0)}
+
+	categories := make(map[string]*model.ShadowCategoryStats)
+	var latency []float64
+	for _, r := range results {
+		report.Total++
+		if r.ErrorMessage != "" {
+			report.Failures++
+			continue
+		}
+		latency = append(latency, float64(r.ShadowLatency))
+
+		c, ok := categories[r.ProductionCategory]
+		if !ok {
+			c = &model.ShadowCategoryStats{
+				Category:         r.ProductionCategory,
+				ShadowCategories: make(map[string]int64),
+				AddedLabels:      make(map[string]int64),
+				RemovedLabels:    make(map[string]int64),
+			}
+			categories[r.ProductionCategory] = c
+			report.Categories = append(report.Categories, c)
+		}
+
+		report.Compared++
+		c.Compared++
+		if !r.Agree {
+			report.Disagreements++
+			c.Disagreements++
+			c.ShadowCategories[r.ShadowCategory]++
+		}
+		for _, l := range r.AddedLabels {
+			c.AddedLabels[l]++
+		}
+		for _, l := range r.RemovedLabels {
+			c.RemovedLabels[l]++
+		}
+	}
+
+	if report.Compared > 0 {
+		report.DisagreementRate = float64(report.Disagreements) / float64(report.Compared)
+	}
+	for _, c := range report.Categories {
+		c.DisagreementRate = float64(c.Disagreements) / float64(c.Compared)
+	}
+	report.LatencyMs = Distribution(latency, false)
+
+	// 不一致最多的类别排在前面
+	sort.SliceStable(report.Categories, func(i, j int) bool {
+		a, b := report.Categories[i], report.Categories[j]
+		if a.Disagreements != b.Disagreements {
+			return a.Disagreements > b.Disagreements
+		}
+		return a.Category < b.Category
+	})
+	return report
+}
@@ -0,0 +1,139 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/inference"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+type fakePredictor struct {
+	predictions map[string][]inference.Prediction
+}
+
+func (p *fakePredictor) Predict(ctx context.Context, modelID, modelVersion string, image []byte) ([]inference.Prediction, error) {
+	preds, ok := p.predictions[modelVersion]
+	if !ok {
+		return nil, fmt.Errorf("版本不存在: %s", modelVersion)
+	}
+	return preds, nil
+}
+
+type memoryShadowResults struct {
+	mu      sync.Mutex
+	results []*model.ShadowResult
+}
+
+func (m *memoryShadowResults) Create(result *model.ShadowResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results = append(m.results, result)
+	return nil
+}
+
+func (m *memoryShadowResults) FindByModel(modelID, shadowVersion string, start, end time.Time) ([]*model.ShadowResult, error) {
+	return m.results, nil
+}
+
+func TestUseShadow(t *testing.T) {
+	policy := &model.RoutingPolicy{ModelID: "m1", ShadowVersion: "v3", ShadowPercent: 30, StickyBy: model.StickyByCustomer}
+
+	hits := 0
+	for i := 0; i < 10000; i++ {
+		if UseShadow(policy, StickyKey(policy, int64(i+1), "")) {
+			hits++
+		}
+	}
+	assert.InDelta(t, 3000, hits, 300)
+
+	// 未启用候选分流时影子复跑仍然生效
+	assert.False(t, policy.Enabled)
+	policy.ShadowPercent = 100
+	assert.True(t, UseShadow(policy, "customer:1"))
+
+	policy.ShadowVersion = ""
+	assert.False(t, UseShadow(policy, "customer:1"))
+
+	assert.Error(t, ValidatePolicy(&model.RoutingPolicy{ShadowPercent: 10}))
+	assert.Error(t, ValidatePolicy(&model.RoutingPolicy{ShadowVersion: "v3", ShadowPercent: 101}))
+	assert.NoError(t, ValidatePolicy(&model.RoutingPolicy{ShadowVersion: "v3", ShadowPercent: 10}))
+}
+
+func TestNewShadowResult(t *testing.T) {
+	record := &model.RecognitionRecord{ModelID: "m1", ModelVersion: "v1", Category: "cat", Confidence: 0.9, Labels: []string{"cat", "animal", "pet"}}
+
+	result := NewShadowResult(record, "v2", []inference.Prediction{
+		{Label: "animal", Confidence: 0.3},
+		{Label: "dog", Confidence: 0.6},
+		{Label: "cat", Confidence: 0.1},
+	}, nil)
+	assert.Equal(t, "dog", result.ShadowCategory)
+	assert.Equal(t, []string{"dog", "animal", "cat"}, result.ShadowLabels)
+	assert.False(t, result.Agree)
+	assert.Equal(t, []string{"dog"}, result.AddedLabels)
+	assert.Equal(t, []string{"pet"}, result.RemovedLabels)
+
+	failed := NewShadowResult(record, "v2", nil, fmt.Errorf("超时"))
+	assert.Equal(t, "超时", failed.ErrorMessage)
+	assert.Empty(t, failed.ShadowLabels)
+}
+
+func TestSummarizeShadow(t *testing.T) {
+	results := []*model.ShadowResult{
+		{ProductionCategory: "cat", ShadowCategory: "cat", Agree: true, ShadowLatency: 10},
+		{ProductionCategory: "cat", ShadowCategory: "dog", AddedLabels: []string{"dog"}, RemovedLabels: []string{"pet"}, ShadowLatency: 20},
+		{ProductionCategory: "car", ShadowCategory: "truck", AddedLabels: []string{"truck"}, ShadowLatency: 30},
+		{ProductionCategory: "car", ShadowCategory: "truck", AddedLabels: []string{"truck"}, ShadowLatency: 40},
+		{ProductionCategory: "car", ErrorMessage: "超时"},
+	}
+
+	report := SummarizeShadow(results)
+	assert.Equal(t, int64(5), report.Total)
+	assert.Equal(t, int64(1), report.Failures)
+	assert.Equal(t, int64(4), report.Compared)
+	assert.InDelta(t, 0.75, report.DisagreementRate, 1e-9)
+	assert.InDelta(t, 25, report.LatencyMs.Mean, 1e-9)
+
+	require.Len(t, report.Categories, 2)
+	car, cat := report.Categories[0], report.Categories[1]
+	assert.Equal(t, "car", car.Category)
+	assert.InDelta(t, 1.0, car.DisagreementRate, 1e-9)
+	assert.Equal(t, int64(2), car.ShadowCategories["truck"])
+	assert.Equal(t, int64(2), car.AddedLabels["truck"])
+	assert.Equal(t, "cat", cat.Category)
+	assert.InDelta(t, 0.5, cat.DisagreementRate, 1e-9)
+	assert.Equal(t, int64(1), cat.RemovedLabels["pet"])
+
+	empty := SummarizeShadow(nil)
+	assert.Zero(t, empty.DisagreementRate)
+	assert.NotNil(t, empty.Categories)
+}
+
+func TestShadowRunner(t *testing.T) {
+	predictor := &fakePredictor{predictions: map[string][]inference.Prediction{
+		"v2": {{Label: "cat", Confidence: 0.8}},
+	}}
+	store := &memoryShadowResults{}
+	runner := NewShadowRunner(predictor, store, 2, 10)
+
+	record := &model.RecognitionRecord{ModelID: "m1", ModelVersion: "v1", Category: "cat", Labels: []string{"cat"}}
+	require.True(t, runner.Submit(&ShadowJob{Record: record, ShadowVersion: "v2"}))
+	require.True(t, runner.Submit(&ShadowJob{Record: record, ShadowVersion: "v9"}))
+	runner.Close()
+
+	require.Len(t, store.results, 2)
+	report := SummarizeShadow(store.results)
+	assert.Equal(t, int64(1), report.Failures)
+	assert.Equal(t, int64(0), report.Disagreements)
+
+	// 关闭后提交的任务被丢弃
+	assert.False(t, runner.Submit(&ShadowJob{Record: record, ShadowVersion: "v2"}))
+	assert.Equal(t, int64(1), runner.Dropped())
+}
@@ -0,0 +1,149 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/image-recognition-engine/internal/handler/auth"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// DefaultAccessTokenTTL 客户端访问令牌的默认有效期
+const DefaultAccessTokenTTL = 15 * time.Minute
+
+// 访问令牌校验失败的原因
+var (
+	ErrInvalidToken = errors.New("无效的访问令牌")
+	ErrTokenRevoked = errors.New("访问令牌已被吊销")
+	ErrInvalidScope = errors.New("请求的访问范围超出密钥的授权")
+)
+
+// AccessTokens 签发、校验和吊销OAuth2客户端凭证模式的访问令牌
+// 吊销记录保存在Redis中直到令牌过期，Redis不可用时改用本地记录，多实例部署下吊销仅对单个实例生效
+type AccessTokens struct {
+	redis  *redis.Client
+	secret string
+	ttl    time.Duration
+
+	mu             sync.Mutex
+	revoked        map[string]time.Time // 令牌ID到过期时间
+	redisDownUntil time.Time
+}
+
+// NewAccessTokens 创建访问令牌服务，ttl为0时使用DefaultAccessTokenTTL
+func NewAccessTokens(redisClient *redis.Client, jwtSecret string, ttl time.Duration) *AccessTokens {
+	if ttl <= 0 {
+		ttl = DefaultAccessTokenTTL
+	}
+	return &AccessTokens{
+		redis:   redisClient,
+		secret:  jwtSecret,
+		ttl:     ttl,
+		revoked: make(map[string]time.Time),
+	}
+}
+
+// GrantScopes 计算授予的访问范围，requested为空时授予密钥的全部访问范围
+func GrantScopes(key *model.APIKey, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return key.Scopes, nil
+	}
+	for _, scope := range requested {
+		if !key.HasScope(scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+	return requested, nil
+}
+
+// Issue 为密钥签发访问令牌
+func (t *AccessTokens) Issue(key *model.APIKey, scopes []string) (string, time.Time, error) {
+	return auth.IssueClientToken(t.secret, uuid.New().String(), key.AppID, key.ID, key.CustomerID, scopes, t.ttl)
+}
+
+// Parse 校验访问令牌的签名、有效期和吊销状态
+func (t *AccessTokens) Parse(ctx context.Context, token string) (*auth.ClientClaims, error) {
+	claims, err := auth.ParseClientToken(t.secret, token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if t.isRevoked(ctx, claims.ID) {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// Revoke 吊销访问令牌，记录保留到令牌过期
+func (t *AccessTokens) Revoke(ctx context.Context, claims *auth.ClientClaims) error {
+	expireAt := claims.ExpiresAt.Time
+	ttl := time.Until(expireAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if t.redisAvailable() {
+		err := t.redis.Set(ctx, revokedTokenKey(claims.ID), 1, ttl).Err()
+		if err == nil {
+			return nil
+		}
+		t.markRedisDown(err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.revoked[claims.ID] = expireAt
+	return nil
+}
+
+func (t *AccessTokens) isRevoked(ctx context.Context, tokenID string) bool {
+	if t.redisAvailable() {
+		n, err := t.redis.Exists(ctx, revokedTokenKey(tokenID)).Result()
+		if err == nil {
+			if n > 0 {
+				return true
+			}
+		} else {
+			t.markRedisDown(err)
+		}
+	}
+
+	// Redis可用时也检查本地记录，覆盖Redis不可用期间吊销的令牌
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	for id, expireAt := range t.revoked {
+		if !now.Before(expireAt) {
+			delete(t.revoked, id)
+		}
+	}
+	_, ok := t.revoked[tokenID]
+	return ok
+}
+
+func (t *AccessTokens) redisAvailable() bool {
+	if t.redis == nil {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return time.Now().After(t.redisDownUntil)
+}
+
+func (t *AccessTokens) markRedisDown(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if time.Now().After(t.redisDownUntil) {
+		log.Printf("Redis令牌吊销记录不可用，改用本地记录: %v", err)
+	}
+	t.redisDownUntil = time.Now().Add(redisRetryInterval)
+}
+
+func revokedTokenKey(tokenID string) string {
+	return "oauth:revoked:" + tokenID
+}
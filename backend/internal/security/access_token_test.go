@@ -0,0 +1,105 @@
+package security
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/handler/auth"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+const tokenKeyID = "65f0c0ffee0000000000beef"
+
+func TestGrantScopes(t *testing.T) {
+	key := &model.APIKey{Scopes: []string{model.ScopeRecognitionWrite, model.ScopeHistoryRead}}
+
+	scopes, err := GrantScopes(key, nil)
+	require.NoError(t, err)
+	assert.Equal(t, key.Scopes, scopes)
+
+	scopes, err = GrantScopes(key, []string{model.ScopeHistoryRead})
+	require.NoError(t, err)
+	assert.Equal(t, []string{model.ScopeHistoryRead}, scopes)
+
+	_, err = GrantScopes(key, []string{model.ScopeHistoryRead, model.ScopeFeedbackWrite})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+}
+
+func TestAccessTokenIssueParseRevoke(t *testing.T) {
+	tokens := NewAccessTokens(nil, "test-secret", time.Minute)
+	key := testKey(tokenKeyID, "sk_secret", "app_a")
+
+	token, expiresAt, err := tokens.Issue(key, []string{model.ScopeRecognitionWrite})
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+
+	claims, err := tokens.Parse(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "app_a", claims.AppID)
+	assert.Equal(t, tokenKeyID, claims.KeyID())
+	assert.Equal(t, int64(7), claims.CustomerID)
+	assert.Equal(t, []string{model.ScopeRecognitionWrite}, claims.Scopes())
+	assert.NotEmpty(t, claims.ID)
+
+	require.NoError(t, tokens.Revoke(context.Background(), claims))
+	_, err = tokens.Parse(context.Background(), token)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// 其他密钥签名或其他受众的令牌无效
+	other := NewAccessTokens(nil, "other-secret", time.Minute)
+	_, err = other.Parse(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	portal, _, err := auth.IssueCustomerToken("test-secret", 7, "acme", time.Minute)
+	require.NoError(t, err)
+	_, err = tokens.Parse(context.Background(), portal)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = auth.ParseCustomerToken("test-secret", token)
+	assert.Error(t, err)
+}
+
+func TestValidateAccessToken(t *testing.T) {
+	key := testKey(tokenKeyID, "sk_secret", "app_a")
+	key.Scopes = []string{model.ScopeRecognitionWrite, model.ScopeHistoryRead}
+	store, _, _ := newTestKeyStore(key)
+	tokens := NewAccessTokens(nil, "test-secret", time.Minute)
+	s := NewAPISecurityService(nil, nil, nil, store, tokens, 0)
+
+	token, _, err := tokens.Issue(key, []string{model.ScopeHistoryRead})
+	require.NoError(t, err)
+
+	info, err := s.ValidateAccessToken(context.Background(), token, "203.0.113.1")
+	require.NoError(t, err)
+	assert.Equal(t, "app_a", info.AppID)
+	assert.Equal(t, int64(7), info.OwnerID)
+	// 权限为令牌授予的访问范围，而非密钥的全部访问范围
+	assert.Equal(t, []string{model.ScopeHistoryRead}, info.Permissions)
+	assert.Equal(t, DefaultRateLimit, info.RateLimit)
+	assert.True(t, s.checkPermission(info.Permissions, "/api/v1/client/recognitions", "GET"))
+	assert.False(t, s.checkPermission(info.Permissions, "/api/v1/client/recognitions", "POST"))
+
+	// 签发令牌的密钥被吊销后令牌随之失效
+	key.Status = model.APIKeyRevoked
+	store.Invalidate()
+	_, err = s.ValidateAccessToken(context.Background(), token, "203.0.113.1")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	_, err = s.ValidateAccessToken(context.Background(), "not-a-token", "203.0.113.1")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestKeyInfoUsesConfiguredRateLimit(t *testing.T) {
+	key := testKey(tokenKeyID, "sk_secret", "app_a")
+	store, _, _ := newTestKeyStore(key)
+	tokens := NewAccessTokens(nil, "test-secret", time.Minute)
+	s := NewAPISecurityService(nil, nil, nil, store, tokens, 100)
+
+	token, _, err := tokens.Issue(key, nil)
+	require.NoError(t, err)
+	info, err := s.ValidateAccessToken(context.Background(), token, "203.0.113.1")
+	require.NoError(t, err)
+	assert.Equal(t, 100, info.RateLimit)
+}
@@ -6,6 +6,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -13,88 +15,198 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
-	"golang.org/x/time/rate"
+
+	"github.com/image-recognition-engine/internal/model"
 )
 
 // APISecurityService 提供API安全相关功能
 type APISecurityService struct {
 	redisClient *redis.Client
-	limiters    map[string]*rate.Limiter
+	limiter     *RateLimiter
+	plans       RateLimitSource // 为nil时只使用API密钥的限流配置
+	keys        *KeyStore       // 为nil时拒绝所有API密钥
+	signatures  *SignatureVerifier
+	tokens      *AccessTokens // 为nil时拒绝Bearer访问令牌
 	encryption  *EncryptionService
+	rateLimit   int // 套餐未配置限流时每分钟允许的请求数
 }
 
 // APIKeyInfo API密钥信息
 type APIKeyInfo struct {
 	AppID       string    `json:"appId"`
 	APIKey      string    `json:"apiKey"`
+	KeyID       string    `json:"keyId"`
+	KeyName     string    `json:"keyName"`
 	OwnerID     int64     `json:"ownerId"`
-	Permissions []string  `json:"permissions"`
+	Permissions []string  `json:"permissions"` // 密钥的访问范围
 	RateLimit   int       `json:"rateLimit"` // 每分钟请求数限制
-	ExpireAt    time.Time `json:"expireAt"`
+	ExpireAt    time.Time `json:"expireAt"` // 永不过期时为零值
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
 // NewAPISecurityService 创建一个新的API安全服务实例
-func NewAPISecurityService(redisClient *redis.Client, encryption *EncryptionService) *APISecurityService {
+// rateLimit为套餐未配置限流时每分钟允许的请求数，不大于0时使用DefaultRateLimit
+func NewAPISecurityService(redisClient *redis.Client, encryption *EncryptionService, plans RateLimitSource, keys *KeyStore, tokens *AccessTokens, rateLimit int) *APISecurityService {
+	if rateLimit <= 0 {
+		rateLimit = DefaultRateLimit
+	}
 	return &APISecurityService{
 		redisClient: redisClient,
-		limiters:    make(map[string]*rate.Limiter),
+		limiter:     NewRateLimiter(redisClient, "rate_limit"),
+		plans:       plans,
+		keys:        keys,
+		signatures:  NewSignatureVerifier(redisClient, DefaultSignatureSkew),
+		tokens:      tokens,
 		encryption:  encryption,
+		rateLimit:   rateLimit,
 	}
 }
 
-// ValidateAPIKey 验证API密钥
-func (s *APISecurityService) ValidateAPIKey(appID, apiKey string) (*APIKeyInfo, error) {
+// ValidateAPIKey 验证API密钥及请求来源IP
+// 失败时返回ErrInvalidAPIKey、ErrAPIKeyExpired、ErrAPIKeyRotated、ErrApplicationDisabled或ErrIPNotAllowed
+func (s *APISecurityService) ValidateAPIKey(appID, apiKey, clientIP string) (*APIKeyInfo, error) {
 	if appID == "" || apiKey == "" {
-		return nil, errors.New("AppID和APIKey不能为空")
+		return nil, fmt.Errorf("AppID和APIKey不能为空: %w", ErrInvalidAPIKey)
+	}
+	if s.keys == nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.keys.Authenticate(appID, apiKey, clientIP, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return s.keyInfo(key), nil
+}
+
+// ValidateSignature 验证签名认证的请求：按X-Key-ID查找密钥，校验签名、时间戳和随机串
+// 除ValidateAPIKey的错误外，还可能返回ErrSignatureMissing、ErrSignatureInvalid、ErrSignatureExpired、
+// ErrSignatureUnsupported、ErrNonceReused或ErrBodyTooLarge
+func (s *APISecurityService) ValidateSignature(ctx context.Context, appID string, r *http.Request, clientIP string) (*APIKeyInfo, error) {
+	keyID := r.Header.Get(HeaderKeyID)
+	if appID == "" || keyID == "" {
+		return nil, ErrSignatureMissing
+	}
+	if s.keys == nil {
+		return nil, ErrInvalidAPIKey
 	}
 
-	// 这里应该从数据库或缓存中获取API密钥信息
-	// 为了演示，这里使用硬编码的方式
-	// TODO: 实现从数据库或Redis缓存中获取API密钥信息
-	if appID == "test_app" && apiKey == "test_key" {
-		return &APIKeyInfo{
-			AppID:       appID,
-			APIKey:      apiKey,
-			OwnerID:     1,
-			Permissions: []string{"recognition:read", "recognition:write"},
-			RateLimit:   100,
-			ExpireAt:    time.Now().AddDate(1, 0, 0),
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}, nil
+	now := time.Now()
+	key, err := s.keys.KeyByID(appID, keyID, clientIP, now)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := openSigningSecret(s.encryption, key)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ReadBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.signatures.Verify(ctx, key.ID, secret, r, body, now); err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("无效的API密钥")
+	s.keys.MarkUsed(key, clientIP, now)
+	return s.keyInfo(key), nil
+}
+
+// ValidateAccessToken 验证OAuth2访问令牌，权限为令牌授予的访问范围
+// 签发令牌的密钥被吊销、轮换失效或应用停用后，令牌随之失效
+func (s *APISecurityService) ValidateAccessToken(ctx context.Context, token, clientIP string) (*APIKeyInfo, error) {
+	if s.tokens == nil || s.keys == nil {
+		return nil, ErrInvalidToken
+	}
+	claims, err := s.tokens.Parse(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	key, err := s.keys.KeyByID(claims.AppID, claims.KeyID(), clientIP, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.keys.MarkUsed(key, clientIP, now)
+	info := s.keyInfo(key)
+	info.Permissions = claims.Scopes()
+	info.ExpireAt = claims.ExpiresAt.Time
+	return info, nil
+}
+
+// bearerToken 获取Authorization头中的Bearer令牌
+func bearerToken(c *gin.Context) string {
+	parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+		return strings.TrimSpace(parts[1])
+	}
+	return ""
+}
+
+// keyInfo 应用密钥转换为API密钥信息，限流使用配置的默认值
+func (s *APISecurityService) keyInfo(key *model.APIKey) *APIKeyInfo {
+	info := &APIKeyInfo{
+		AppID:       key.AppID,
+		KeyID:       key.ID,
+		KeyName:     key.Name,
+		OwnerID:     key.CustomerID,
+		Permissions: key.Scopes,
+		RateLimit:   s.rateLimit,
+		CreatedAt:   key.CreateTime,
+		UpdatedAt:   key.UpdateTime,
+	}
+	if key.ExpireAt != nil {
+		info.ExpireAt = *key.ExpireAt
+	}
+	return info
 }
 
 // CheckRateLimit 检查请求频率限制
-func (s *APISecurityService) CheckRateLimit(ctx context.Context, apiKey string, limit int) error {
-	// 使用Redis记录和检查请求频率
-	key := fmt.Sprintf("rate_limit:%s", apiKey)
-	count, err := s.redisClient.Get(ctx, key).Int()
-	if err == redis.Nil {
-		// 第一次请求
-		err = s.redisClient.Set(ctx, key, 1, time.Hour).Err()
+// 优先使用客户服务套餐的限流配置，套餐未配置时使用API密钥的每分钟请求数限制，
+// 同一客户的多个API密钥共享额度
+func (s *APISecurityService) CheckRateLimit(ctx context.Context, apiInfo *APIKeyInfo) *RateLimitResult {
+	limit := RateLimit{Limit: apiInfo.RateLimit, Window: time.Minute}
+	if s.plans != nil && apiInfo.OwnerID != 0 {
+		planLimit, ok, err := s.plans.RateLimitFor(apiInfo.OwnerID)
 		if err != nil {
-			return fmt.Errorf("设置请求计数失败: %v", err)
+			log.Printf("获取客户%d的套餐限流配置失败: %v", apiInfo.OwnerID, err)
+		} else if ok {
+			limit = planLimit
 		}
-		return nil
-	} else if err != nil {
-		return fmt.Errorf("获取请求计数失败: %v", err)
 	}
 
-	if count >= limit {
-		return fmt.Errorf("超出API请求限制")
+	key := "app:" + apiInfo.AppID
+	if apiInfo.OwnerID != 0 {
+		key = fmt.Sprintf("customer:%d", apiInfo.OwnerID)
 	}
+	return s.limiter.Allow(ctx, key, limit)
+}
 
-	// 增加请求计数
-	_, err = s.redisClient.Incr(ctx, key).Result()
-	return err
+// setRateLimitHeaders 写入限流相关的响应头
+func setRateLimitHeaders(c *gin.Context, result *RateLimitResult) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.Reset).Unix(), 10))
+	if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+	}
+}
+
+// ceilSeconds 时长向上取整为秒，至少为1秒
+func ceilSeconds(d time.Duration) int {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
 }
 
 // GenerateAPIKey 生成新的API密钥
+//
+// Deprecated: 结果可由AppID和时间推算，应用密钥使用NewAPIKeySecret生成，并通过KeyRotator轮换
 func (s *APISecurityService) GenerateAPIKey(appID string, salt string) string {
 	// 使用应用ID、时间戳和盐值生成API密钥
 	timestamp := strconv.FormatInt(time.Now().UnixNano(), 10)
@@ -118,31 +230,46 @@ func (s *APISecurityService) APISecurityMiddleware() gin.HandlerFunc {
 		appID := c.GetHeader("X-App-ID")
 		apiKey := c.GetHeader("X-API-Key")
 
-		// 验证API密钥
-		apiInfo, err := s.ValidateAPIKey(appID, apiKey)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "无效的API密钥",
-				"data":    nil,
-			})
-			return
+		// 验证API密钥、请求签名或访问令牌，以及有效期和来源IP
+		// 携带X-Signature时使用签名认证，携带Bearer令牌时使用OAuth2访问令牌
+		var apiInfo *APIKeyInfo
+		var err error
+		if Signed(c.Request) {
+			apiInfo, err = s.ValidateSignature(c.Request.Context(), appID, c.Request, c.ClientIP())
+		} else if token := bearerToken(c); token != "" {
+			apiInfo, err = s.ValidateAccessToken(c.Request.Context(), token, c.ClientIP())
+		} else {
+			apiInfo, err = s.ValidateAPIKey(appID, apiKey, c.ClientIP())
 		}
-
-		// 检查API密钥是否过期
-		if apiInfo.ExpireAt.Before(time.Now()) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "API密钥已过期",
+		if err != nil {
+			status, message := http.StatusUnauthorized, "无效的API密钥"
+			switch {
+			case errors.Is(err, ErrAPIKeyExpired), errors.Is(err, ErrAPIKeyRotated),
+				errors.Is(err, ErrSignatureMissing), errors.Is(err, ErrSignatureInvalid),
+				errors.Is(err, ErrSignatureExpired), errors.Is(err, ErrNonceReused),
+				errors.Is(err, ErrSignatureUnsupported),
+				errors.Is(err, ErrInvalidToken), errors.Is(err, ErrTokenRevoked):
+				message = err.Error()
+			case errors.Is(err, ErrBodyTooLarge):
+				status, message = http.StatusRequestEntityTooLarge, err.Error()
+			case errors.Is(err, ErrApplicationDisabled), errors.Is(err, ErrIPNotAllowed):
+				status, message = http.StatusForbidden, err.Error()
+			case !errors.Is(err, ErrInvalidAPIKey):
+				log.Printf("验证应用%s的API密钥失败: %v", appID, err)
+				status, message = http.StatusInternalServerError, "验证API密钥失败"
+			}
+			c.AbortWithStatusJSON(status, gin.H{
+				"code":    status,
+				"message": message,
 				"data":    nil,
 			})
 			return
 		}
 
-		// 检查权限
+		// 按路由检查密钥的访问范围
 		endpoint := c.Request.URL.Path
 		method := c.Request.Method
-		if !s.checkPermission(apiInfo.Permissions, endpoint, method) {
+		if !s.checkPermission(apiInfo.Permissions, c.FullPath(), method) {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"code":    403,
 				"message": "没有访问权限",
@@ -153,12 +280,16 @@ func (s *APISecurityService) APISecurityMiddleware() gin.HandlerFunc {
 
 		// 检查频率限制
 		ctx := c.Request.Context()
-		err = s.CheckRateLimit(ctx, appID, apiInfo.RateLimit)
-		if err != nil {
+		result := s.CheckRateLimit(ctx, apiInfo)
+		setRateLimitHeaders(c, result)
+		if !result.Allowed {
 			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
 				"code":    429,
 				"message": "请求频率超过限制",
-				"data":    nil,
+				"data": gin.H{
+					"limit":      result.Limit,
+					"retryAfter": ceilSeconds(result.RetryAfter),
+				},
 			})
 			return
 		}
@@ -167,8 +298,9 @@ func (s *APISecurityService) APISecurityMiddleware() gin.HandlerFunc {
 		startTime := time.Now()
 
 		// 将API信息存储到上下文中
-		c.Set("appID", appID)
+		c.Set("appID", apiInfo.AppID)
 		c.Set("ownerID", apiInfo.OwnerID)
+		c.Set("apiKeyID", apiInfo.KeyID)
 		c.Set("permissions", apiInfo.Permissions)
 
 		// 处理请求
@@ -179,7 +311,7 @@ func (s *APISecurityService) APISecurityMiddleware() gin.HandlerFunc {
 
 		// 记录访问日志
 		s.LogAPIAccess(
-			appID,
+			apiInfo.AppID,
 			endpoint,
 			method,
 			c.ClientIP(),
@@ -189,6 +321,24 @@ func (s *APISecurityService) APISecurityMiddleware() gin.HandlerFunc {
 	}
 }
 
+// CustomerIDFrom 获取认证后的客户ID
+// 客户门户令牌写入customerId，API密钥认证写入ownerID，两者均未设置时返回false
+func CustomerIDFrom(c *gin.Context) (int64, bool) {
+	for _, key := range []string{"customerId", "ownerID"} {
+		v, exists := c.Get(key)
+		if !exists {
+			continue
+		}
+		switch id := v.(type) {
+		case int64:
+			return id, true
+		case int:
+			return int64(id), true
+		}
+	}
+	return 0, false
+}
+
 // contains 检查字符串切片中是否包含指定字符串
 func contains(slice []string, str string) bool {
 	for _, item := range slice {
@@ -199,24 +349,29 @@ func contains(slice []string, str string) bool {
 	return false
 }
 
-// checkPermission 检查是否有权限访问指定的端点
-func (s *APISecurityService) checkPermission(permissions []string, endpoint, method string) bool {
-	// 简化的权限检查逻辑
-	// 实际应用中应该有更复杂的权限管理系统
-	if contains(permissions, "admin") {
-		return true
-	}
+// scopeRule 客户端路由所需的访问范围
+type scopeRule struct {
+	method string
+	route  string // 路由模板的后缀，与挂载的前缀无关
+	scope  string
+}
 
-	// 检查端点特定权限
-	if strings.HasPrefix(endpoint, "/api/v1/recognition") {
-		if method == http.MethodGet && contains(permissions, "recognition:read") {
-			return true
-		}
-		if (method == http.MethodPost || method == http.MethodPut || method == http.MethodDelete) && 
-		   contains(permissions, "recognition:write") {
-			return true
+// scopeRules 客户端路由与访问范围的对应关系，未登记的路由一律拒绝
+var scopeRules = []scopeRule{
+	{http.MethodPost, "/client/recognize", model.ScopeRecognitionWrite},
+	{http.MethodPost, "/client/recognitions", model.ScopeRecognitionWrite},
+	{http.MethodGet, "/client/recognitions", model.ScopeHistoryRead},
+	{http.MethodGet, "/client/recognitions/:id", model.ScopeHistoryRead},
+	{http.MethodPost, "/client/recognitions/:id/feedback", model.ScopeFeedbackWrite},
+}
+
+// checkPermission 检查密钥的访问范围是否允许访问指定路由
+// route为gin的路由模板，如/api/v1/client/recognitions/:id
+func (s *APISecurityService) checkPermission(permissions []string, route, method string) bool {
+	for _, rule := range scopeRules {
+		if rule.method == method && strings.HasSuffix(route, rule.route) {
+			return contains(permissions, rule.scope)
 		}
 	}
-
 	return false
-}
\ No newline at end of file
+}
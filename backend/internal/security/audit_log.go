@@ -141,16 +141,15 @@ func (s *AuditLogService) GetUserLogs(ctx context.Context, userID int64, startTi
 		return nil, 0, err
 	}
 
-	// 查询日志
-	options := bson.D{
-		{"$sort", bson.D{{"timestamp", -1}}},
-		{"$skip", int64((page - 1) * pageSize)},
-		{"$limit", int64(pageSize)},
+	// 查询日志，排序、跳过和限制需为独立的管道阶段
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "timestamp", Value: -1}}}},
+		{{Key: "$skip", Value: int64((page - 1) * pageSize)}},
+		{{Key: "$limit", Value: int64(pageSize)}},
 	}
 
-	cursor, err := s.mongoDB.Collection("audit_logs").Aggregate(ctx, []bson.D{{
-		{"$match", filter},
-	}, options})
+	cursor, err := s.mongoDB.Collection("audit_logs").Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -194,16 +193,15 @@ func (s *AuditLogService) GetResourceLogs(ctx context.Context, resource, resourc
 		return nil, 0, err
 	}
 
-	// 查询日志
-	options := bson.D{
-		{"$sort", bson.D{{"timestamp", -1}}},
-		{"$skip", int64((page - 1) * pageSize)},
-		{"$limit", int64(pageSize)},
+	// 查询日志，排序、跳过和限制需为独立的管道阶段
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "timestamp", Value: -1}}}},
+		{{Key: "$skip", Value: int64((page - 1) * pageSize)}},
+		{{Key: "$limit", Value: int64(pageSize)}},
 	}
 
-	cursor, err := s.mongoDB.Collection("audit_logs").Aggregate(ctx, []bson.D{{
-		{"$match", filter},
-	}, options})
+	cursor, err := s.mongoDB.Collection("audit_logs").Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -0,0 +1,58 @@
+package security
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConcurrencyLimit 限制每个客户同时处理中的请求数，已满时最多排队wait，仍无空闲时返回429
+// 需要在API密钥认证之后使用，无法识别客户或套餐不限并发时直接放行
+func ConcurrencyLimit(sem *Semaphore, source ConcurrencySource, wait time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerID, ok := CustomerIDFrom(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		limit, err := source.ConcurrencyFor(customerID)
+		if err != nil {
+			log.Printf("获取客户%d的并发限制失败，本次不限制: %v", customerID, err)
+			c.Next()
+			return
+		}
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		permit, inFlight, err := sem.Acquire(c.Request.Context(), fmt.Sprintf("customer:%d", customerID), limit, wait)
+		if err != nil {
+			// 客户端已断开
+			c.Abort()
+			return
+		}
+		if permit == nil {
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"code":    429,
+				"message": fmt.Sprintf("同时处理中的请求数已达到套餐上限%d，请稍后重试", limit),
+				"data": gin.H{
+					"reason":   "concurrency_limit",
+					"limit":    limit,
+					"inFlight": inFlight,
+				},
+			})
+			return
+		}
+		defer permit.Release()
+
+		c.Header("X-Concurrency-Limit", strconv.Itoa(limit))
+		c.Next()
+	}
+}
@@ -0,0 +1,183 @@
+package security
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// planCacheTTL 客户套餐的缓存时长，套餐调整后最多延迟该时长生效
+const planCacheTTL = 30 * time.Second
+
+// RateLimitSource 按客户查询限流配置
+// 返回ok为false表示客户没有配置限流，由调用方使用API密钥的配置
+type RateLimitSource interface {
+	RateLimitFor(customerID int64) (limit RateLimit, ok bool, err error)
+}
+
+// ConcurrencySource 按客户查询同时处理中的请求数上限，0表示不限
+type ConcurrencySource interface {
+	ConcurrencyFor(customerID int64) (int, error)
+}
+
+// CustomerPlans 查询客户订阅的服务套餐，提供限流、并发、请求额度和功能权限配置
+type CustomerPlans struct {
+	customers model.CustomerRepository
+	plans     model.ServicePlanRepository
+
+	mu       sync.Mutex
+	cache    map[int64]cachedPlan
+	all      []*model.ServicePlan
+	allUntil time.Time
+}
+
+type cachedPlan struct {
+	plan     *model.ServicePlan // 客户未订阅套餐时为nil
+	expireAt time.Time
+}
+
+// NewCustomerPlans 创建客户套餐查询
+func NewCustomerPlans(customers model.CustomerRepository, plans model.ServicePlanRepository) *CustomerPlans {
+	return &CustomerPlans{
+		customers: customers,
+		plans:     plans,
+		cache:     make(map[int64]cachedPlan),
+	}
+}
+
+// RateLimitFor 查询客户套餐的限流配置
+func (p *CustomerPlans) RateLimitFor(customerID int64) (RateLimit, bool, error) {
+	plan, err := p.plan(customerID)
+	if err != nil {
+		return RateLimit{}, false, err
+	}
+	return PlanRateLimit(plan)
+}
+
+// ConcurrencyFor 查询客户套餐的并发请求数上限，未订阅套餐时不限
+func (p *CustomerPlans) ConcurrencyFor(customerID int64) (int, error) {
+	plan, err := p.plan(customerID)
+	if err != nil || plan == nil || plan.Concurrent < 0 {
+		return 0, err
+	}
+	return plan.Concurrent, nil
+}
+
+// RequestLimitFor 查询客户套餐的每月请求次数额度，未订阅套餐时不限，实现quota.LimitSource
+func (p *CustomerPlans) RequestLimitFor(customerID int64) (int64, error) {
+	plan, err := p.plan(customerID)
+	if err != nil || plan == nil || plan.RequestLimit < 0 {
+		return 0, err
+	}
+	return plan.RequestLimit, nil
+}
+
+// PlanFor 查询客户订阅的套餐，未订阅时返回nil
+func (p *CustomerPlans) PlanFor(customerID int64) (*model.ServicePlan, error) {
+	return p.plan(customerID)
+}
+
+// Plans 获取全部服务套餐，结果缓存planCacheTTL
+func (p *CustomerPlans) Plans() ([]*model.ServicePlan, error) {
+	p.mu.Lock()
+	if time.Now().Before(p.allUntil) {
+		all := p.all
+		p.mu.Unlock()
+		return all, nil
+	}
+	p.mu.Unlock()
+
+	const pageSize = 100
+	all := make([]*model.ServicePlan, 0)
+	for page := 1; ; page++ {
+		plans, total, err := p.plans.List(page, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("查询服务套餐列表失败: %w", err)
+		}
+		all = append(all, plans...)
+		if len(plans) == 0 || int64(len(all)) >= total {
+			break
+		}
+	}
+
+	p.mu.Lock()
+	p.all = all
+	p.allUntil = time.Now().Add(planCacheTTL)
+	p.mu.Unlock()
+	return all, nil
+}
+
+// plan 查询客户订阅的套餐，结果缓存planCacheTTL
+func (p *CustomerPlans) plan(customerID int64) (*model.ServicePlan, error) {
+	p.mu.Lock()
+	cached, hit := p.cache[customerID]
+	p.mu.Unlock()
+	if hit && time.Now().Before(cached.expireAt) {
+		return cached.plan, nil
+	}
+
+	plan, err := p.load(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.cache[customerID] = cachedPlan{plan: plan, expireAt: time.Now().Add(planCacheTTL)}
+	p.mu.Unlock()
+	return plan, nil
+}
+
+// Invalidate 清除客户的缓存，customerID为0时清除全部，包括套餐列表
+func (p *CustomerPlans) Invalidate(customerID int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if customerID == 0 {
+		p.cache = make(map[int64]cachedPlan)
+		p.allUntil = time.Time{}
+		return
+	}
+	delete(p.cache, customerID)
+}
+
+func (p *CustomerPlans) load(customerID int64) (*model.ServicePlan, error) {
+	customer, err := p.customers.FindByID(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("查询客户失败: %w", err)
+	}
+	if customer == nil || customer.PlanID == 0 {
+		return nil, nil
+	}
+
+	plan, err := p.plans.FindByID(customer.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("查询服务套餐失败: %w", err)
+	}
+	return plan, nil
+}
+
+// PlanRateLimit 服务套餐转换为限流配置，套餐未设置限流时ok为false
+func PlanRateLimit(plan *model.ServicePlan) (RateLimit, bool, error) {
+	if plan == nil || plan.RateLimit <= 0 {
+		return RateLimit{}, false, nil
+	}
+	if err := ValidateRateAlgorithm(plan.RateAlgorithm); err != nil {
+		return RateLimit{}, false, err
+	}
+	return RateLimit{
+		Limit:     plan.RateLimit,
+		Window:    time.Minute,
+		Burst:     plan.RateBurst,
+		Algorithm: plan.RateAlgorithm,
+	}, true, nil
+}
+
+// ValidateRateAlgorithm 校验限流算法名称，空字符串表示使用默认的令牌桶
+func ValidateRateAlgorithm(algorithm string) error {
+	switch algorithm {
+	case "", RateAlgorithmTokenBucket, RateAlgorithmSlidingWindow:
+		return nil
+	}
+	return fmt.Errorf("不支持的限流算法: %s", algorithm)
+}
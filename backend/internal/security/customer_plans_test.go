@@ -0,0 +1,77 @@
+package security
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+type fakeCustomerRepository struct {
+	model.CustomerRepository
+	customers map[int64]*model.Customer
+	finds     int
+}
+
+func (r *fakeCustomerRepository) FindByID(id int64) (*model.Customer, error) {
+	r.finds++
+	return r.customers[id], nil
+}
+
+type fakePlanRepository struct {
+	model.ServicePlanRepository
+	plans map[int64]*model.ServicePlan
+}
+
+func (r *fakePlanRepository) FindByID(id int64) (*model.ServicePlan, error) {
+	return r.plans[id], nil
+}
+
+func TestCustomerPlansRequestLimit(t *testing.T) {
+	customers := &fakeCustomerRepository{customers: map[int64]*model.Customer{
+		1: {ID: 1, PlanID: 10},
+		2: {ID: 2},
+		3: {ID: 3, PlanID: 11},
+	}}
+	plans := &fakePlanRepository{plans: map[int64]*model.ServicePlan{
+		10: {ID: 10, RequestLimit: 1000},
+		11: {ID: 11, RequestLimit: -1},
+	}}
+	p := NewCustomerPlans(customers, plans)
+
+	limit, err := p.RequestLimitFor(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), limit)
+
+	// 与限流、并发共用同一份套餐缓存
+	_, err = p.ConcurrencyFor(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, customers.finds)
+
+	// 未订阅套餐或套餐不限次数
+	for _, id := range []int64{2, 3} {
+		limit, err = p.RequestLimitFor(id)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), limit)
+	}
+}
+
+func TestCustomerIDFrom(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	_, ok := CustomerIDFrom(c)
+	assert.False(t, ok)
+
+	c.Set("ownerID", 7)
+	id, ok := CustomerIDFrom(c)
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), id)
+
+	c.Set("customerId", int64(3))
+	id, ok = CustomerIDFrom(c)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), id)
+}
@@ -37,6 +37,20 @@ func OverrideSecurityConfigFromEnv(cfg *SecurityConfig) {
 		}
 	}
 
+	// 并发排队时间
+	if wait := os.Getenv("SECURITY_CONCURRENCY_WAIT_MS"); wait != "" {
+		if ms, err := strconv.Atoi(wait); err == nil && ms >= 0 {
+			cfg.ConcurrencyWait = ms
+		}
+	}
+
+	// 密钥轮换宽限期
+	if grace := os.Getenv("SECURITY_KEY_ROTATION_GRACE_HOURS"); grace != "" {
+		if hours, err := strconv.Atoi(grace); err == nil && hours >= 0 {
+			cfg.KeyRotationGrace = hours
+		}
+	}
+
 	// 是否启用审计日志
 	if enableAuditLog := os.Getenv("SECURITY_ENABLE_AUDIT_LOG"); enableAuditLog != "" {
 		if enableAuditLog == "true" || enableAuditLog == "1" {
@@ -0,0 +1,175 @@
+package security
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// ModelTypeFeatures 使用该类型的模型需要套餐包含的功能
+var ModelTypeFeatures = map[string]string{
+	"detection": model.FeatureDetectionModels,
+}
+
+// PlanSource 查询客户套餐和全部套餐，由CustomerPlans实现
+type PlanSource interface {
+	PlanFor(customerID int64) (*model.ServicePlan, error)
+	Plans() ([]*model.ServicePlan, error)
+}
+
+// ModelTypeSource 查询模型，由model.ModelRepository实现
+type ModelTypeSource interface {
+	FindByID(id string) (*model.Model, error)
+}
+
+// PlanSummary 套餐概要，用于提示升级
+type PlanSummary struct {
+	ID    int64   `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// FeatureDenial 客户套餐不包含所需功能时的拒绝原因及可升级的套餐
+type FeatureDenial struct {
+	Feature      string        `json:"feature"`
+	FeatureName  string        `json:"featureName"`
+	CurrentPlan  *PlanSummary  `json:"currentPlan"` // 未订阅套餐时为nil
+	UpgradePlans []PlanSummary `json:"upgradePlans"`
+}
+
+// Message 拒绝提示
+func (d *FeatureDenial) Message() string {
+	current := "当前未订阅套餐"
+	if d.CurrentPlan != nil {
+		current = fmt.Sprintf("当前套餐%s", d.CurrentPlan.Name)
+	}
+	if len(d.UpgradePlans) == 0 {
+		return fmt.Sprintf("%s不支持%s", current, d.FeatureName)
+	}
+	return fmt.Sprintf("%s不支持%s，请升级到包含该功能的套餐", current, d.FeatureName)
+}
+
+// FeatureGate 按客户订阅的套餐检查功能权限
+type FeatureGate struct {
+	plans PlanSource
+}
+
+// NewFeatureGate 创建功能权限检查
+func NewFeatureGate(plans PlanSource) *FeatureGate {
+	return &FeatureGate{plans: plans}
+}
+
+// Check 检查客户套餐是否包含功能，包含时返回nil
+func (g *FeatureGate) Check(customerID int64, feature string) (*FeatureDenial, error) {
+	plan, err := g.plans.PlanFor(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if plan.HasFeature(feature) {
+		return nil, nil
+	}
+
+	denial := &FeatureDenial{Feature: feature, FeatureName: model.FeatureName(feature), UpgradePlans: make([]PlanSummary, 0)}
+	if plan != nil {
+		denial.CurrentPlan = &PlanSummary{ID: plan.ID, Name: plan.Name, Price: plan.Price}
+	}
+
+	all, err := g.plans.Plans()
+	if err != nil {
+		// 无法给出升级建议时仍然拒绝请求
+		log.Printf("查询可升级套餐失败: %v", err)
+		return denial, nil
+	}
+	denial.UpgradePlans = upgradePlans(all, feature)
+	return denial, nil
+}
+
+// Require 要求客户套餐包含功能，用于批量识别、Webhook、异步任务等接口
+// 需要在API密钥认证之后使用
+func (g *FeatureGate) Require(feature string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerID, ok := CustomerIDFrom(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": 401, "message": "未授权的访问", "data": nil})
+			return
+		}
+		if !g.allow(c, customerID, feature) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireModelFeature 按请求中modelId对应的模型类型检查功能，如检测模型需要detection_models
+// 模型不存在或类型不需要额外功能时放行，由后续处理器处理
+func (g *FeatureGate) RequireModelFeature(models ModelTypeSource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		customerID, ok := CustomerIDFrom(c)
+		modelID := c.PostForm("modelId")
+		if modelID == "" {
+			modelID = c.Query("modelId")
+		}
+		if !ok || modelID == "" {
+			c.Next()
+			return
+		}
+
+		m, err := models.FindByID(modelID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询模型失败", "data": nil})
+			return
+		}
+		if m != nil {
+			if feature, required := ModelTypeFeatures[m.Type]; required && !g.allow(c, customerID, feature) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// allow 检查功能权限，不允许时写入403响应
+func (g *FeatureGate) allow(c *gin.Context, customerID int64, feature string) bool {
+	denial, err := g.Check(customerID, feature)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"code": 500, "message": "查询客户套餐失败", "data": nil})
+		return false
+	}
+	if denial != nil {
+		WriteFeatureDenied(c, denial)
+		return false
+	}
+	return true
+}
+
+// WriteFeatureDenied 写入功能不可用的403响应，供在处理器内部检查功能的接口使用
+func WriteFeatureDenied(c *gin.Context, denial *FeatureDenial) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"code":    403,
+		"message": denial.Message(),
+		"data": gin.H{
+			"reason":       "feature_not_in_plan",
+			"feature":      denial.Feature,
+			"featureName":  denial.FeatureName,
+			"currentPlan":  denial.CurrentPlan,
+			"upgradePlans": denial.UpgradePlans,
+		},
+	})
+}
+
+// upgradePlans 包含功能的套餐，按价格升序
+func upgradePlans(plans []*model.ServicePlan, feature string) []PlanSummary {
+	result := make([]PlanSummary, 0)
+	for _, p := range plans {
+		if p.HasFeature(feature) {
+			result = append(result, PlanSummary{ID: p.ID, Name: p.Name, Price: p.Price})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Price < result[j].Price })
+	return result
+}
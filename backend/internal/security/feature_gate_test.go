@@ -0,0 +1,143 @@
+package security
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+type stubPlans struct {
+	customers map[int64]*model.ServicePlan
+	all       []*model.ServicePlan
+}
+
+func (s *stubPlans) PlanFor(customerID int64) (*model.ServicePlan, error) {
+	return s.customers[customerID], nil
+}
+
+func (s *stubPlans) Plans() ([]*model.ServicePlan, error) { return s.all, nil }
+
+type stubModels map[string]*model.Model
+
+func (s stubModels) FindByID(id string) (*model.Model, error) { return s[id], nil }
+
+func newStubPlans() *stubPlans {
+	basic := &model.ServicePlan{ID: 1, Name: "基础版", Price: 99}
+	enterprise := &model.ServicePlan{ID: 3, Name: "企业版", Price: 999, Features: model.StringList{model.FeatureBatchRecognition, model.FeatureDetectionModels}}
+	pro := &model.ServicePlan{ID: 2, Name: "专业版", Price: 299, Features: model.StringList{model.FeatureDetectionModels}}
+	return &stubPlans{
+		customers: map[int64]*model.ServicePlan{1: basic, 2: pro},
+		all:       []*model.ServicePlan{basic, enterprise, pro},
+	}
+}
+
+func TestFeatureGateCheck(t *testing.T) {
+	gate := NewFeatureGate(newStubPlans())
+
+	denial, err := gate.Check(2, model.FeatureDetectionModels)
+	require.NoError(t, err)
+	assert.Nil(t, denial)
+
+	denial, err = gate.Check(1, model.FeatureDetectionModels)
+	require.NoError(t, err)
+	require.NotNil(t, denial)
+	assert.Equal(t, "目标检测模型", denial.FeatureName)
+	assert.Equal(t, int64(1), denial.CurrentPlan.ID)
+	// 升级建议按价格升序
+	require.Len(t, denial.UpgradePlans, 2)
+	assert.Equal(t, "专业版", denial.UpgradePlans[0].Name)
+	assert.Equal(t, "企业版", denial.UpgradePlans[1].Name)
+
+	// 未订阅套餐的客户
+	denial, err = gate.Check(9, model.FeatureWebhooks)
+	require.NoError(t, err)
+	require.NotNil(t, denial)
+	assert.Nil(t, denial.CurrentPlan)
+	assert.Empty(t, denial.UpgradePlans)
+	assert.Equal(t, "当前未订阅套餐不支持Webhook回调", denial.Message())
+}
+
+func TestFeatureGateRequire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gate := NewFeatureGate(newStubPlans())
+
+	r := gin.New()
+	r.POST("/batch", func(c *gin.Context) {
+		c.Set("ownerID", int64(1))
+		c.Next()
+	}, gate.Require(model.FeatureBatchRecognition), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batch", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var body struct {
+		Code int `json:"code"`
+		Data struct {
+			Reason       string        `json:"reason"`
+			Feature      string        `json:"feature"`
+			UpgradePlans []PlanSummary `json:"upgradePlans"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, 403, body.Code)
+	assert.Equal(t, "feature_not_in_plan", body.Data.Reason)
+	assert.Equal(t, model.FeatureBatchRecognition, body.Data.Feature)
+	require.Len(t, body.Data.UpgradePlans, 1)
+	assert.Equal(t, int64(3), body.Data.UpgradePlans[0].ID)
+}
+
+func TestFeatureGateRequireModelFeature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gate := NewFeatureGate(newStubPlans())
+	models := stubModels{
+		"det": {ID: "det", Type: "detection"},
+		"cls": {ID: "cls", Type: "classification"},
+	}
+
+	r := gin.New()
+	r.POST("/recognize", func(c *gin.Context) {
+		c.Set("ownerID", int64(1))
+		c.Next()
+	}, gate.RequireModelFeature(models), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	post := func(modelID string) int {
+		req := httptest.NewRequest(http.MethodPost, "/recognize", strings.NewReader("modelId="+modelID))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusForbidden, post("det"))
+	assert.Equal(t, http.StatusOK, post("cls"))
+	assert.Equal(t, http.StatusOK, post("missing"))
+}
+
+func TestStringList(t *testing.T) {
+	value, err := model.StringList{"a", "b"}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, `["a","b"]`, value)
+
+	empty, err := model.StringList(nil).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "[]", empty)
+
+	var list model.StringList
+	require.NoError(t, list.Scan([]byte(`["webhooks"]`)))
+	assert.Equal(t, model.StringList{"webhooks"}, list)
+	require.NoError(t, list.Scan(nil))
+	assert.Nil(t, list)
+	assert.Error(t, list.Scan(42))
+}
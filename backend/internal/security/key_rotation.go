@@ -0,0 +1,124 @@
+package security
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/queue"
+)
+
+// MaxRotationGrace 轮换宽限期上限
+const MaxRotationGrace = 7 * 24 * time.Hour
+
+// 密钥轮换失败的原因
+var (
+	ErrKeyNotRotatable = errors.New("只有未被轮换的有效密钥可以轮换")
+	ErrInvalidGrace    = fmt.Errorf("宽限期需在0到%d小时之间", int(MaxRotationGrace.Hours()))
+)
+
+// Notifier 客户通知，由queue.NotificationService实现
+type Notifier interface {
+	SendNotification(ctx context.Context, taskID string, nType queue.NotificationType, message string) error
+}
+
+// KeyRotator 轮换API密钥，新密钥立即生效，旧密钥在宽限期内继续有效
+type KeyRotator struct {
+	keys       model.APIKeyRepository
+	store      *KeyStore          // 为nil时不清除密钥缓存，旧密钥的轮换状态最多延迟keyCacheTTL生效
+	encryption *EncryptionService // 为nil时新密钥不支持签名认证
+	notifier   Notifier           // 为nil时不通知客户
+	grace      time.Duration
+}
+
+// NewKeyRotator 创建密钥轮换，grace为未指定宽限期时使用的默认值
+func NewKeyRotator(keys model.APIKeyRepository, store *KeyStore, encryption *EncryptionService, notifier Notifier, grace time.Duration) *KeyRotator {
+	return &KeyRotator{
+		keys:       keys,
+		store:      store,
+		encryption: encryption,
+		notifier:   notifier,
+		grace:      grace,
+	}
+}
+
+// DefaultGrace 默认宽限期
+func (r *KeyRotator) DefaultGrace() time.Duration {
+	return r.grace
+}
+
+// Rotate 为old签发继承名称、访问范围、IP范围和有效期的新密钥，返回新密钥及其明文
+// grace为nil时使用默认宽限期，为0时旧密钥立即失效
+func (r *KeyRotator) Rotate(ctx context.Context, old *model.APIKey, grace *time.Duration, now time.Time) (*model.APIKey, string, error) {
+	overlap := r.grace
+	if grace != nil {
+		overlap = *grace
+	}
+	if overlap < 0 || overlap > MaxRotationGrace {
+		return nil, "", ErrInvalidGrace
+	}
+	if old.Status != model.APIKeyActive || old.ReplacedBy != "" || old.Expired(now) {
+		return nil, "", ErrKeyNotRotatable
+	}
+
+	secret, prefix, err := NewAPIKeySecret()
+	if err != nil {
+		return nil, "", fmt.Errorf("生成API密钥失败: %w", err)
+	}
+	signing, err := SealSigningSecret(r.encryption, secret)
+	if err != nil {
+		return nil, "", err
+	}
+	overlapUntil := now.Add(overlap)
+	key := &model.APIKey{
+		CustomerID:    old.CustomerID,
+		AppID:         old.AppID,
+		Name:          old.Name,
+		KeyHash:       HashAPIKey(secret),
+		SigningSecret: signing,
+		Prefix:        prefix,
+		Scopes:        old.Scopes,
+		AllowedIPs:    old.AllowedIPs,
+		Status:        model.APIKeyActive,
+		ExpireAt:      old.ExpireAt,
+		RotatedFrom:   old.ID,
+		OverlapUntil:  &overlapUntil,
+	}
+	if err := r.keys.Create(key); err != nil {
+		return nil, "", err
+	}
+
+	marked, err := r.keys.MarkRotated(old.ID, old.CustomerID, key.ID, overlapUntil)
+	if err == nil && !marked {
+		err = ErrKeyNotRotatable
+	}
+	if err != nil {
+		// 旧密钥已被并发轮换或吊销，撤回本次签发的新密钥
+		if _, revokeErr := r.keys.Revoke(key.ID, key.CustomerID); revokeErr != nil {
+			log.Printf("撤回API密钥%s失败: %v", key.ID, revokeErr)
+		}
+		return nil, "", err
+	}
+	if r.store != nil {
+		r.store.Invalidate()
+	}
+
+	log.Printf("应用%s的API密钥%s已轮换为%s，旧密钥有效期至%s", old.AppID, old.ID, key.ID, overlapUntil.Format(time.RFC3339))
+	r.notify(ctx, old, key, overlapUntil)
+	return key, secret, nil
+}
+
+// notify 通知客户密钥已轮换，发送失败只记录日志
+func (r *KeyRotator) notify(ctx context.Context, old, key *model.APIKey, overlapUntil time.Time) {
+	if r.notifier == nil {
+		return
+	}
+	message := fmt.Sprintf("应用%s的API密钥「%s」已轮换，新密钥以%s开头；旧密钥(%s...)将于%s失效，请在此之前完成切换",
+		old.AppID, old.Name, key.Prefix, old.Prefix, overlapUntil.Format("2006-01-02 15:04:05"))
+	if err := r.notifier.SendNotification(ctx, fmt.Sprintf("customer:%d", old.CustomerID), queue.NotificationTypeKeyRotated, message); err != nil {
+		log.Printf("发送密钥轮换通知失败: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package security
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/queue"
+)
+
+type recordingNotifier struct {
+	taskIDs  []string
+	messages []string
+}
+
+func (n *recordingNotifier) SendNotification(ctx context.Context, taskID string, nType queue.NotificationType, message string) error {
+	n.taskIDs = append(n.taskIDs, taskID)
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+func TestRotateKeepsOldKeyDuringGrace(t *testing.T) {
+	now := time.Now()
+	old := testKey("k1", "sk_old", "app_a")
+	old.Name = "production"
+	old.Prefix = "sk_old"
+	old.AllowedIPs = []string{"10.0.0.0/8"}
+	store, keyRepo, _ := newTestKeyStore(old)
+	notifier := &recordingNotifier{}
+	encryption, err := NewEncryptionService("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	rotator := NewKeyRotator(keyRepo, store, encryption, notifier, 24*time.Hour)
+
+	// 轮换前先缓存旧密钥，轮换后缓存应被清除
+	_, err = store.Authenticate("app_a", "sk_old", "10.1.1.1", now)
+	require.NoError(t, err)
+
+	key, secret, err := rotator.Rotate(context.Background(), old, nil, now)
+	require.NoError(t, err)
+	assert.NotEqual(t, "sk_old", secret)
+	assert.Equal(t, "production", key.Name)
+	assert.Equal(t, old.Scopes, key.Scopes)
+	assert.Equal(t, old.AllowedIPs, key.AllowedIPs)
+	assert.Equal(t, "k1", key.RotatedFrom)
+	signing, err := encryption.Decrypt(key.SigningSecret)
+	require.NoError(t, err)
+	assert.Equal(t, secret, signing)
+	assert.Equal(t, key.ID, old.ReplacedBy)
+	require.NotNil(t, key.OverlapUntil)
+	assert.Equal(t, now.Add(24*time.Hour), *key.OverlapUntil)
+	assert.Equal(t, []string{"customer:7"}, notifier.taskIDs)
+	assert.Contains(t, notifier.messages[0], key.Prefix)
+
+	// 宽限期内新旧密钥都有效
+	during := now.Add(time.Hour)
+	_, err = store.Authenticate("app_a", "sk_old", "10.1.1.1", during)
+	assert.NoError(t, err)
+	_, err = store.Authenticate("app_a", secret, "10.1.1.1", during)
+	assert.NoError(t, err)
+
+	// 宽限期结束后旧密钥失效
+	after := now.Add(25 * time.Hour)
+	_, err = store.Authenticate("app_a", "sk_old", "10.1.1.1", after)
+	assert.ErrorIs(t, err, ErrAPIKeyRotated)
+	_, err = store.Authenticate("app_a", secret, "10.1.1.1", after)
+	assert.NoError(t, err)
+
+	// 已轮换的密钥不能再次轮换
+	_, _, err = rotator.Rotate(context.Background(), old, nil, during)
+	assert.ErrorIs(t, err, ErrKeyNotRotatable)
+}
+
+func TestRotateGraceBounds(t *testing.T) {
+	now := time.Now()
+	old := testKey("k1", "sk_old", "app_a")
+	store, keyRepo, _ := newTestKeyStore(old)
+	rotator := NewKeyRotator(keyRepo, store, nil, nil, 24*time.Hour)
+
+	tooLong := MaxRotationGrace + time.Hour
+	_, _, err := rotator.Rotate(context.Background(), old, &tooLong, now)
+	assert.ErrorIs(t, err, ErrInvalidGrace)
+	negative := -time.Hour
+	_, _, err = rotator.Rotate(context.Background(), old, &negative, now)
+	assert.ErrorIs(t, err, ErrInvalidGrace)
+
+	// 宽限期为0时旧密钥立即失效
+	zero := time.Duration(0)
+	_, secret, err := rotator.Rotate(context.Background(), old, &zero, now)
+	require.NoError(t, err)
+	_, err = store.Authenticate("app_a", "sk_old", "203.0.113.1", now)
+	assert.ErrorIs(t, err, ErrAPIKeyRotated)
+	_, err = store.Authenticate("app_a", secret, "203.0.113.1", now)
+	assert.NoError(t, err)
+}
+
+func TestSecurityServiceKeyRotatorUsesConfiguredGrace(t *testing.T) {
+	now := time.Now()
+	old := testKey("k1", "sk_old", "app_a")
+	store, keyRepo, _ := newTestKeyStore(old)
+	s := &SecurityService{config: &SecurityConfig{KeyRotationGrace: 6}}
+	rotator := s.NewKeyRotator(keyRepo, store, nil)
+	assert.Equal(t, 6*time.Hour, rotator.DefaultGrace())
+
+	key, _, err := rotator.Rotate(context.Background(), old, nil, now)
+	require.NoError(t, err)
+	require.NotNil(t, key.OverlapUntil)
+	assert.Equal(t, now.Add(6*time.Hour), *key.OverlapUntil)
+}
+
+func TestRotateRejectsRevokedKey(t *testing.T) {
+	old := testKey("k1", "sk_old", "app_a")
+	old.Status = model.APIKeyRevoked
+	_, keyRepo, _ := newTestKeyStore(old)
+	rotator := NewKeyRotator(keyRepo, nil, nil, nil, time.Hour)
+
+	_, _, err := rotator.Rotate(context.Background(), old, nil, time.Now())
+	assert.ErrorIs(t, err, ErrKeyNotRotatable)
+	assert.Zero(t, keyRepo.created)
+}
@@ -0,0 +1,224 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// keyCacheTTL API密钥的缓存时长，密钥吊销或应用停用后最多延迟该时长生效
+const keyCacheTTL = 30 * time.Second
+
+// touchInterval 同一密钥的最近使用时间最多每隔该时长写入一次
+const touchInterval = time.Minute
+
+// keyPrefixLength 保存的密钥明文前缀长度
+const keyPrefixLength = 8
+
+// API密钥校验失败的原因
+var (
+	ErrInvalidAPIKey       = errors.New("无效的API密钥")
+	ErrAPIKeyExpired       = errors.New("API密钥已过期")
+	ErrAPIKeyRotated       = errors.New("API密钥已轮换，请使用新密钥")
+	ErrApplicationDisabled = errors.New("应用已停用")
+	ErrIPNotAllowed        = errors.New("请求来源IP不在密钥允许的范围内")
+)
+
+// KeyStore 查询和校验应用下的API密钥
+type KeyStore struct {
+	keys model.APIKeyRepository
+	apps model.ApplicationRepository
+
+	mu          sync.Mutex
+	cache       map[string]cachedKey
+	sweepAt     time.Time // 下次清理过期缓存的时间
+	touched     map[string]time.Time
+	overlapUses map[string]int64 // 轮换重叠期内尚未记录日志的使用次数
+}
+
+type cachedKey struct {
+	key      *model.APIKey
+	app      *model.Application // 应用不存在时为nil
+	expireAt time.Time
+}
+
+// NewKeyStore 创建API密钥查询
+func NewKeyStore(keys model.APIKeyRepository, apps model.ApplicationRepository) *KeyStore {
+	return &KeyStore{
+		keys:        keys,
+		apps:        apps,
+		cache:       make(map[string]cachedKey),
+		touched:     make(map[string]time.Time),
+		overlapUses: make(map[string]int64),
+	}
+}
+
+// HashAPIKey 计算API密钥明文的摘要
+func HashAPIKey(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// NewAPIKeySecret 生成新的API密钥明文，返回明文和用于展示的前缀
+func NewAPIKeySecret() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	secret := "sk_" + hex.EncodeToString(buf)
+	return secret, secret[:keyPrefixLength], nil
+}
+
+// Authenticate 校验AppID、密钥明文和请求来源IP，成功时返回密钥
+func (s *KeyStore) Authenticate(appID, secret, clientIP string, now time.Time) (*model.APIKey, error) {
+	hash := HashAPIKey(secret)
+	entry, err := s.lookup("hash:"+hash, func() (*model.APIKey, error) { return s.keys.FindByHash(hash) }, now)
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.check(entry, appID, clientIP, now)
+	if err != nil {
+		return nil, err
+	}
+
+	s.touch(key, clientIP, now)
+	return key, nil
+}
+
+// KeyByID 按密钥ID查找密钥，用于签名和访问令牌认证，校验规则与Authenticate相同
+// 调用方完成签名或令牌校验后调用MarkUsed记录使用
+func (s *KeyStore) KeyByID(appID, keyID, clientIP string, now time.Time) (*model.APIKey, error) {
+	if !validKeyID(keyID) {
+		return nil, ErrInvalidAPIKey
+	}
+	entry, err := s.lookup("id:"+keyID, func() (*model.APIKey, error) { return s.keys.FindByID(keyID) }, now)
+	if err != nil {
+		return nil, err
+	}
+	return s.check(entry, appID, clientIP, now)
+}
+
+// MarkUsed 记录密钥的一次使用
+func (s *KeyStore) MarkUsed(key *model.APIKey, clientIP string, now time.Time) {
+	s.touch(key, clientIP, now)
+}
+
+// Invalidate 清除全部缓存
+func (s *KeyStore) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = make(map[string]cachedKey)
+}
+
+// check 校验密钥所属应用、状态、有效期和来源IP
+func (s *KeyStore) check(entry cachedKey, appID, clientIP string, now time.Time) (*model.APIKey, error) {
+	key := entry.key
+	if key == nil || key.AppID != appID || key.Status != model.APIKeyActive || entry.app == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if entry.app.Status != model.ApplicationActive {
+		return nil, ErrApplicationDisabled
+	}
+	if key.Retired(now) {
+		return nil, ErrAPIKeyRotated
+	}
+	if key.Expired(now) {
+		return nil, ErrAPIKeyExpired
+	}
+	if !key.AllowsIP(clientIP) {
+		return nil, ErrIPNotAllowed
+	}
+	return key, nil
+}
+
+// lookup 查询密钥及其所属应用，只缓存存在的密钥，避免无效密钥的请求占满缓存
+func (s *KeyStore) lookup(cacheKey string, find func() (*model.APIKey, error), now time.Time) (cachedKey, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[cacheKey]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expireAt) {
+		return cached, nil
+	}
+
+	key, err := find()
+	if err != nil {
+		return cachedKey{}, fmt.Errorf("查询API密钥失败: %w", err)
+	}
+	if key == nil {
+		return cachedKey{}, nil
+	}
+	app, err := s.apps.FindByAppID(key.AppID)
+	if err != nil {
+		return cachedKey{}, fmt.Errorf("查询应用失败: %w", err)
+	}
+	entry := cachedKey{key: key, app: app, expireAt: now.Add(keyCacheTTL)}
+
+	s.mu.Lock()
+	s.sweep(now)
+	s.cache[cacheKey] = entry
+	s.mu.Unlock()
+	return entry, nil
+}
+
+// sweep 每隔keyCacheTTL删除一次过期的缓存，调用方需持有锁
+func (s *KeyStore) sweep(now time.Time) {
+	if now.Before(s.sweepAt) {
+		return
+	}
+	for k, entry := range s.cache {
+		if !now.Before(entry.expireAt) {
+			delete(s.cache, k)
+		}
+	}
+	s.sweepAt = now.Add(keyCacheTTL)
+}
+
+// validKeyID 密钥ID为24位十六进制字符串
+func validKeyID(id string) bool {
+	if len(id) != 24 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// touch 异步记录密钥的最近使用时间，写入失败只记录日志
+// 轮换重叠期内新旧密钥的使用次数随最近使用时间一起写入日志，便于确认客户是否已切换到新密钥
+func (s *KeyStore) touch(key *model.APIKey, ip string, now time.Time) {
+	id := key.ID
+	s.mu.Lock()
+	if key.InOverlap(now) {
+		s.overlapUses[id]++
+	}
+	last, ok := s.touched[id]
+	if ok && now.Sub(last) < touchInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.touched[id] = now
+	uses := s.overlapUses[id]
+	delete(s.overlapUses, id)
+	s.mu.Unlock()
+
+	if uses > 0 {
+		role, peer := "新密钥", key.RotatedFrom
+		if key.ReplacedBy != "" {
+			role, peer = "旧密钥", key.ReplacedBy
+		}
+		log.Printf("应用%s的%s%s(对应%s)在轮换重叠期内使用%d次，最近来源IP %s，重叠期至%s",
+			key.AppID, role, id, peer, uses, ip, key.OverlapUntil.Format(time.RFC3339))
+	}
+
+	go func() {
+		if err := s.keys.TouchLastUsed(id, ip, now); err != nil {
+			log.Printf("记录API密钥%s的使用时间失败: %v", id, err)
+		}
+	}()
+}
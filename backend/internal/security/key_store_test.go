@@ -0,0 +1,224 @@
+package security
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+type fakeKeyRepository struct {
+	mu      sync.Mutex
+	keys    map[string]*model.APIKey // 按摘要索引
+	touched map[string]string
+	created int
+}
+
+func (r *fakeKeyRepository) Create(key *model.APIKey) error {
+	r.created++
+	key.ID = fmt.Sprintf("new%d", r.created)
+	r.keys[key.KeyHash] = key
+	return nil
+}
+
+func (r *fakeKeyRepository) FindByID(id string) (*model.APIKey, error) {
+	for _, key := range r.keys {
+		if key.ID == id {
+			return key, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeKeyRepository) FindByHash(hash string) (*model.APIKey, error) {
+	return r.keys[hash], nil
+}
+
+func (r *fakeKeyRepository) ListByApp(appID string) ([]*model.APIKey, error) { return nil, nil }
+
+func (r *fakeKeyRepository) Revoke(id string, customerID int64) (bool, error) {
+	key, _ := r.FindByID(id)
+	if key == nil || key.CustomerID != customerID {
+		return false, nil
+	}
+	key.Status = model.APIKeyRevoked
+	return true, nil
+}
+
+func (r *fakeKeyRepository) MarkRotated(id string, customerID int64, replacedBy string, overlapUntil time.Time) (bool, error) {
+	key, _ := r.FindByID(id)
+	if key == nil || key.CustomerID != customerID || key.Status != model.APIKeyActive || key.ReplacedBy != "" {
+		return false, nil
+	}
+	key.ReplacedBy = replacedBy
+	key.OverlapUntil = &overlapUntil
+	return true, nil
+}
+
+func (r *fakeKeyRepository) TouchLastUsed(id, ip string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.touched[id] = ip
+	return nil
+}
+
+type fakeApplicationRepository struct {
+	apps map[string]*model.Application
+}
+
+func (r *fakeApplicationRepository) Create(app *model.Application) error { return nil }
+
+func (r *fakeApplicationRepository) Update(app *model.Application) error { return nil }
+
+func (r *fakeApplicationRepository) Delete(id string, customerID int64) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeApplicationRepository) FindByID(id string) (*model.Application, error) { return nil, nil }
+
+func (r *fakeApplicationRepository) FindByAppID(appID string) (*model.Application, error) {
+	return r.apps[appID], nil
+}
+
+func (r *fakeApplicationRepository) ListByCustomer(customerID int64) ([]*model.Application, error) {
+	return nil, nil
+}
+
+func newTestKeyStore(keys ...*model.APIKey) (*KeyStore, *fakeKeyRepository, *fakeApplicationRepository) {
+	keyRepo := &fakeKeyRepository{keys: make(map[string]*model.APIKey), touched: make(map[string]string)}
+	for _, key := range keys {
+		keyRepo.keys[key.KeyHash] = key
+	}
+	appRepo := &fakeApplicationRepository{apps: map[string]*model.Application{
+		"app_a": {AppID: "app_a", CustomerID: 7, Status: model.ApplicationActive},
+		"app_b": {AppID: "app_b", CustomerID: 7, Status: model.ApplicationDisabled},
+	}}
+	return NewKeyStore(keyRepo, appRepo), keyRepo, appRepo
+}
+
+func testKey(id, secret, appID string) *model.APIKey {
+	return &model.APIKey{
+		ID:         id,
+		CustomerID: 7,
+		AppID:      appID,
+		KeyHash:    HashAPIKey(secret),
+		Scopes:     []string{model.ScopeRecognitionWrite},
+		Status:     model.APIKeyActive,
+	}
+}
+
+func TestKeyStoreAuthenticate(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+
+	active := testKey("k1", "sk_active", "app_a")
+	restricted := testKey("k2", "sk_restricted", "app_a")
+	restricted.AllowedIPs = []string{"10.0.0.0/8", "192.168.1.5"}
+	old := testKey("k3", "sk_old", "app_a")
+	old.ExpireAt = &expired
+	revoked := testKey("k4", "sk_revoked", "app_a")
+	revoked.Status = model.APIKeyRevoked
+	disabled := testKey("k5", "sk_disabled", "app_b")
+
+	store, keyRepo, _ := newTestKeyStore(active, restricted, old, revoked, disabled)
+
+	key, err := store.Authenticate("app_a", "sk_active", "203.0.113.1", now)
+	require.NoError(t, err)
+	assert.Equal(t, "k1", key.ID)
+	assert.Eventually(t, func() bool {
+		keyRepo.mu.Lock()
+		defer keyRepo.mu.Unlock()
+		return keyRepo.touched["k1"] == "203.0.113.1"
+	}, time.Second, 10*time.Millisecond)
+
+	// 密钥只能用于所属应用
+	_, err = store.Authenticate("app_b", "sk_active", "203.0.113.1", now)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, err = store.Authenticate("app_a", "sk_unknown", "203.0.113.1", now)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, err = store.Authenticate("app_a", "sk_revoked", "203.0.113.1", now)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, err = store.Authenticate("app_a", "sk_old", "203.0.113.1", now)
+	assert.ErrorIs(t, err, ErrAPIKeyExpired)
+	_, err = store.Authenticate("app_b", "sk_disabled", "203.0.113.1", now)
+	assert.ErrorIs(t, err, ErrApplicationDisabled)
+
+	_, err = store.Authenticate("app_a", "sk_restricted", "10.20.30.40", now)
+	assert.NoError(t, err)
+	_, err = store.Authenticate("app_a", "sk_restricted", "192.168.1.5", now)
+	assert.NoError(t, err)
+	_, err = store.Authenticate("app_a", "sk_restricted", "192.168.1.6", now)
+	assert.ErrorIs(t, err, ErrIPNotAllowed)
+}
+
+func TestKeyStoreCachesLookups(t *testing.T) {
+	now := time.Now()
+	key := testKey("k1", "sk_active", "app_a")
+	store, keyRepo, _ := newTestKeyStore(key)
+
+	_, err := store.Authenticate("app_a", "sk_active", "203.0.113.1", now)
+	require.NoError(t, err)
+
+	// 缓存有效期内吊销不立即生效，过期或清除缓存后生效
+	delete(keyRepo.keys, key.KeyHash)
+	_, err = store.Authenticate("app_a", "sk_active", "203.0.113.1", now.Add(time.Second))
+	assert.NoError(t, err)
+	_, err = store.Authenticate("app_a", "sk_active", "203.0.113.1", now.Add(keyCacheTTL))
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	keyRepo.keys[key.KeyHash] = key
+	store.Invalidate()
+	_, err = store.Authenticate("app_a", "sk_active", "203.0.113.1", now.Add(keyCacheTTL))
+	assert.NoError(t, err)
+}
+
+func TestKeyStoreDoesNotCacheMisses(t *testing.T) {
+	now := time.Now()
+	key := testKey("k1", "sk_active", "app_a")
+	store, keyRepo, _ := newTestKeyStore()
+
+	for i := 0; i < 100; i++ {
+		_, err := store.Authenticate("app_a", fmt.Sprintf("sk_unknown_%d", i), "203.0.113.1", now)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	}
+	assert.Empty(t, store.cache)
+
+	// 新签发的密钥无需等待缓存过期即可使用
+	_, err := store.Authenticate("app_a", "sk_active", "203.0.113.1", now)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	keyRepo.keys[key.KeyHash] = key
+	_, err = store.Authenticate("app_a", "sk_active", "203.0.113.1", now)
+	assert.NoError(t, err)
+	assert.Len(t, store.cache, 1)
+
+	// 过期的缓存在下次写入缓存时清理
+	other := testKey("k2", "sk_other", "app_a")
+	keyRepo.keys[other.KeyHash] = other
+	_, err = store.Authenticate("app_a", "sk_other", "203.0.113.1", now.Add(keyCacheTTL))
+	assert.NoError(t, err)
+	assert.Len(t, store.cache, 1)
+}
+
+func TestCheckPermissionScopes(t *testing.T) {
+	s := &APISecurityService{}
+	write := []string{model.ScopeRecognitionWrite}
+	read := []string{model.ScopeHistoryRead}
+
+	assert.True(t, s.checkPermission(write, "/api/v1/client/recognitions", http.MethodPost))
+	assert.False(t, s.checkPermission(read, "/api/v1/client/recognitions", http.MethodPost))
+	assert.True(t, s.checkPermission(read, "/api/v1/client/recognitions", http.MethodGet))
+	assert.True(t, s.checkPermission(read, "/api/v1/client/recognitions/:id", http.MethodGet))
+	assert.False(t, s.checkPermission(write, "/api/v1/client/recognitions/:id", http.MethodGet))
+	assert.True(t, s.checkPermission([]string{model.ScopeFeedbackWrite}, "/api/v1/client/recognitions/:id/feedback", http.MethodPost))
+	assert.False(t, s.checkPermission(write, "/api/v1/client/recognitions/:id/feedback", http.MethodPost))
+
+	// 未登记的路由一律拒绝
+	assert.False(t, s.checkPermission(write, "/api/v1/admin/models", http.MethodGet))
+	assert.False(t, s.checkPermission(write, "", http.MethodPost))
+}
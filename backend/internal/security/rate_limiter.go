@@ -0,0 +1,255 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// 限流算法
+const (
+	RateAlgorithmTokenBucket   = "token_bucket"   // 令牌桶，允许不超过Burst的突发
+	RateAlgorithmSlidingWindow = "sliding_window" // 滑动窗口，任意Window时长内不超过Limit次
+)
+
+// DefaultRateLimit 套餐和API密钥都未配置限流时每分钟允许的请求数
+const DefaultRateLimit = 60
+
+// redisRetryInterval Redis不可用后改用本地限流的时长，期间不再访问Redis
+const redisRetryInterval = 5 * time.Second
+
+// localIdleTimeout 本地限流器闲置超过该时长后被清理
+const localIdleTimeout = 10 * time.Minute
+
+// tokenBucketScript 原子地补充令牌并尝试取出一个，使用Redis服务器时间避免各实例时钟偏差
+// 返回{是否允许, 剩余令牌, 重试等待毫秒, 令牌补满毫秒}
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity / rate) + 1000)
+local retry = 0
+if allowed == 0 then
+	retry = math.ceil((1 - tokens) / rate)
+end
+return {allowed, math.floor(tokens), retry, math.ceil((capacity - tokens) / rate)}
+`)
+
+// slidingWindowScript 以有序集合记录窗口内的请求时间，原子地清理过期请求并判断是否允许
+// 返回{是否允许, 剩余次数, 重试等待毫秒, 窗口内最早请求过期的毫秒数}
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+local reset = 0
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+local retry = 0
+if allowed == 0 then
+	retry = reset
+end
+return {allowed, limit - count, retry, reset}
+`)
+
+// RateLimit 限流配置
+type RateLimit struct {
+	Limit     int           `json:"limit"`     // 每个窗口允许的请求数
+	Window    time.Duration `json:"window"`    // 窗口时长
+	Burst     int           `json:"burst"`     // 令牌桶容量，不大于0时等于Limit
+	Algorithm string        `json:"algorithm"` // 为空时使用令牌桶
+}
+
+// normalize 补全默认值
+func (l RateLimit) normalize() RateLimit {
+	if l.Limit <= 0 {
+		l.Limit = DefaultRateLimit
+	}
+	if l.Window <= 0 {
+		l.Window = time.Minute
+	}
+	if l.Burst <= 0 {
+		l.Burst = l.Limit
+	}
+	if l.Algorithm != RateAlgorithmSlidingWindow {
+		l.Algorithm = RateAlgorithmTokenBucket
+	}
+	return l
+}
+
+// RateLimitResult 限流判断结果
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int           // 每个窗口允许的请求数
+	Remaining  int           // 剩余可用次数
+	Reset      time.Duration // 额度完全恢复的等待时长
+	RetryAfter time.Duration // 被拒绝时的建议重试等待时长
+	Local      bool          // Redis不可用，由本实例限流
+}
+
+// RateLimiter 基于Redis Lua脚本的分布式限流器
+// Redis不可用时退化为进程内令牌桶，此时各实例分别限流，总体额度会放宽到实例数倍
+type RateLimiter struct {
+	redis  *redis.Client
+	prefix string
+
+	mu             sync.Mutex
+	local          map[string]*localLimiter
+	redisDownUntil time.Time
+	lastSweep      time.Time
+}
+
+type localLimiter struct {
+	limiter  *rate.Limiter
+	config   RateLimit
+	lastSeen time.Time
+}
+
+// NewRateLimiter 创建限流器，redisClient为nil时只使用本地限流
+func NewRateLimiter(redisClient *redis.Client, prefix string) *RateLimiter {
+	return &RateLimiter{
+		redis:  redisClient,
+		prefix: prefix,
+		local:  make(map[string]*localLimiter),
+	}
+}
+
+// Allow 判断key的一次请求是否允许
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit RateLimit) *RateLimitResult {
+	limit = limit.normalize()
+	if l.redisAvailable() {
+		result, err := l.allowRedis(ctx, key, limit)
+		if err == nil {
+			return result
+		}
+		l.markRedisDown(err)
+	}
+	return l.allowLocal(key, limit, time.Now())
+}
+
+func (l *RateLimiter) redisAvailable() bool {
+	if l.redis == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Now().After(l.redisDownUntil)
+}
+
+func (l *RateLimiter) markRedisDown(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if time.Now().After(l.redisDownUntil) {
+		log.Printf("Redis限流不可用，改用本地限流: %v", err)
+	}
+	l.redisDownUntil = time.Now().Add(redisRetryInterval)
+}
+
+func (l *RateLimiter) allowRedis(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	redisKey := fmt.Sprintf("%s:%s:%s", l.prefix, limit.Algorithm, key)
+
+	var values []int64
+	var err error
+	if limit.Algorithm == RateAlgorithmSlidingWindow {
+		values, err = slidingWindowScript.Run(ctx, l.redis, []string{redisKey},
+			limit.Limit, limit.Window.Milliseconds(), uuid.New().String()).Int64Slice()
+	} else {
+		perMillisecond := float64(limit.Limit) / float64(limit.Window.Milliseconds())
+		values, err = tokenBucketScript.Run(ctx, l.redis, []string{redisKey},
+			limit.Burst, perMillisecond).Int64Slice()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("限流脚本返回值格式错误: %v", values)
+	}
+
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit.Limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		Reset:      time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// allowLocal 进程内令牌桶，滑动窗口也按容量为Limit的令牌桶近似
+func (l *RateLimiter) allowLocal(key string, limit RateLimit, now time.Time) *RateLimitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	burst := limit.Burst
+	if limit.Algorithm == RateAlgorithmSlidingWindow {
+		burst = limit.Limit
+	}
+	every := rate.Limit(float64(limit.Limit) / limit.Window.Seconds())
+
+	entry, ok := l.local[key]
+	if !ok || entry.config != limit {
+		entry = &localLimiter{limiter: rate.NewLimiter(every, burst), config: limit}
+		l.local[key] = entry
+	}
+	entry.lastSeen = now
+
+	result := &RateLimitResult{Limit: limit.Limit, Local: true}
+	reservation := entry.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		result.RetryAfter = delay
+	} else {
+		result.Allowed = true
+	}
+
+	tokens := entry.limiter.TokensAt(now)
+	result.Remaining = int(math.Max(0, math.Floor(tokens)))
+	result.Reset = time.Duration((float64(burst) - tokens) / float64(every) * float64(time.Second))
+	return result
+}
+
+// sweep 清理闲置的本地限流器，每分钟最多执行一次
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, entry := range l.local {
+		if now.Sub(entry.lastSeen) > localIdleTimeout {
+			delete(l.local, key)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package security
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+func TestRateLimiterLocalTokenBucket(t *testing.T) {
+	limiter := NewRateLimiter(nil, "test")
+	now := time.Now()
+	limit := RateLimit{Limit: 60, Window: time.Minute, Burst: 3}.normalize()
+
+	for i := 0; i < 3; i++ {
+		result := limiter.allowLocal("customer:1", limit, now)
+		require.True(t, result.Allowed)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	result := limiter.allowLocal("customer:1", limit, now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 60, result.Limit)
+	assert.InDelta(t, time.Second, result.RetryAfter, float64(10*time.Millisecond))
+
+	// 其他客户不受影响
+	assert.True(t, limiter.allowLocal("customer:2", limit, now).Allowed)
+	// 一秒后补充一个令牌
+	assert.True(t, limiter.allowLocal("customer:1", limit, now.Add(time.Second)).Allowed)
+}
+
+func TestRateLimiterLocalSlidingWindowUsesLimitAsBurst(t *testing.T) {
+	limiter := NewRateLimiter(nil, "test")
+	now := time.Now()
+	limit := RateLimit{Limit: 5, Window: time.Minute, Burst: 1, Algorithm: RateAlgorithmSlidingWindow}.normalize()
+
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		if limiter.allowLocal("k", limit, now).Allowed {
+			allowed++
+		}
+	}
+	assert.Equal(t, 5, allowed)
+}
+
+func TestRateLimiterFallsBackWhenRedisUnavailable(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	defer client.Close()
+	limiter := NewRateLimiter(client, "test")
+	limit := RateLimit{Limit: 2, Window: time.Minute}
+
+	first := limiter.Allow(context.Background(), "k", limit)
+	assert.True(t, first.Allowed)
+	assert.True(t, first.Local)
+	assert.False(t, limiter.redisAvailable())
+
+	assert.True(t, limiter.Allow(context.Background(), "k", limit).Allowed)
+	assert.False(t, limiter.Allow(context.Background(), "k", limit).Allowed)
+}
+
+func TestRateLimiterConfigChangeResetsLocalLimiter(t *testing.T) {
+	limiter := NewRateLimiter(nil, "test")
+	now := time.Now()
+	small := RateLimit{Limit: 1}.normalize()
+	assert.True(t, limiter.allowLocal("k", small, now).Allowed)
+	assert.False(t, limiter.allowLocal("k", small, now).Allowed)
+
+	large := RateLimit{Limit: 10}.normalize()
+	assert.True(t, limiter.allowLocal("k", large, now).Allowed)
+}
+
+func TestRateLimitNormalize(t *testing.T) {
+	limit := RateLimit{}.normalize()
+	assert.Equal(t, DefaultRateLimit, limit.Limit)
+	assert.Equal(t, time.Minute, limit.Window)
+	assert.Equal(t, DefaultRateLimit, limit.Burst)
+	assert.Equal(t, RateAlgorithmTokenBucket, limit.Algorithm)
+}
+
+func TestPlanRateLimit(t *testing.T) {
+	_, ok, err := PlanRateLimit(&model.ServicePlan{})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	limit, ok, err := PlanRateLimit(&model.ServicePlan{RateLimit: 600, RateBurst: 50, RateAlgorithm: RateAlgorithmSlidingWindow})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, RateLimit{Limit: 600, Window: time.Minute, Burst: 50, Algorithm: RateAlgorithmSlidingWindow}, limit)
+
+	_, _, err = PlanRateLimit(&model.ServicePlan{RateLimit: 10, RateAlgorithm: "fixed"})
+	assert.Error(t, err)
+}
+
+func TestCeilSeconds(t *testing.T) {
+	assert.Equal(t, 1, ceilSeconds(0))
+	assert.Equal(t, 1, ceilSeconds(200*time.Millisecond))
+	assert.Equal(t, 3, ceilSeconds(2100*time.Millisecond))
+}
@@ -1,9 +1,13 @@
 package security
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/image-recognition-engine/internal/model"
 )
 
 // SecurityService 提供统一的安全服务接口
@@ -12,12 +16,15 @@ type SecurityService struct {
 	APISecurity *APISecurityService
 	DataMasking *DataMaskingService
 	AuditLog    *AuditLogService
+	config      *SecurityConfig
 }
 
-// NewSecurityService 创建一个新的安全服务实例
-func NewSecurityService(mongoDB *mongo.Database, redisClient *redis.Client, encryptionKey string) (*SecurityService, error) {
+// NewSecurityService 按安全配置创建安全服务实例
+// plans为客户套餐的限流配置来源，为nil时按配置的默认值限流；keys为应用API密钥的查询；
+// tokens为OAuth2访问令牌服务，为nil时客户端接口不接受Bearer令牌
+func NewSecurityService(mongoDB *mongo.Database, redisClient *redis.Client, cfg *SecurityConfig, plans RateLimitSource, keys *KeyStore, tokens *AccessTokens) (*SecurityService, error) {
 	// 创建加密服务
-	encryption, err := NewEncryptionService(encryptionKey)
+	encryption, err := NewEncryptionService(cfg.EncryptionKey)
 	if err != nil {
 		return nil, err
 	}
@@ -26,16 +33,17 @@ func NewSecurityService(mongoDB *mongo.Database, redisClient *redis.Client, encr
 	dataMasking := NewDataMaskingService(encryption)
 
 	// 创建API安全服务
-	apiSecurity := NewAPISecurityService(redisClient, encryption)
+	apiSecurity := NewAPISecurityService(redisClient, encryption, plans, keys, tokens, cfg.RateLimitDefault)
 
 	// 创建审计日志服务
-	auditLog := NewAuditLogService(mongoDB, redisClient, dataMasking, true)
+	auditLog := NewAuditLogService(mongoDB, redisClient, dataMasking, cfg.EnableAuditLog)
 
 	return &SecurityService{
 		Encryption:  encryption,
 		APISecurity: apiSecurity,
 		DataMasking: dataMasking,
 		AuditLog:    auditLog,
+		config:      cfg,
 	}, nil
 }
 
@@ -47,6 +55,16 @@ func (s *SecurityService) RegisterMiddlewares(r *gin.Engine) {
 	// 可以在这里注册其他安全相关的中间件
 }
 
+// ConcurrencyLimit 按配置的排队时间创建客户并发限制中间件
+func (s *SecurityService) ConcurrencyLimit(sem *Semaphore, source ConcurrencySource) gin.HandlerFunc {
+	return ConcurrencyLimit(sem, source, time.Duration(s.config.ConcurrencyWait)*time.Millisecond)
+}
+
+// NewKeyRotator 按配置的宽限期创建密钥轮换，store为nil时不清除密钥缓存，notifier为nil时不通知客户
+func (s *SecurityService) NewKeyRotator(keys model.APIKeyRepository, store *KeyStore, notifier Notifier) *KeyRotator {
+	return NewKeyRotator(keys, store, s.Encryption, notifier, time.Duration(s.config.KeyRotationGrace)*time.Hour)
+}
+
 // EncryptData 加密数据
 func (s *SecurityService) EncryptData(plaintext string) (string, error) {
 	return s.Encryption.Encrypt(plaintext)
@@ -68,8 +86,8 @@ func (s *SecurityService) LogSecurityEvent(userID int64, username, action, resou
 }
 
 // ValidateAPIAccess 验证API访问权限
-func (s *SecurityService) ValidateAPIAccess(appID, apiKey string) (*APIKeyInfo, error) {
-	return s.APISecurity.ValidateAPIKey(appID, apiKey)
+func (s *SecurityService) ValidateAPIAccess(appID, apiKey, clientIP string) (*APIKeyInfo, error) {
+	return s.APISecurity.ValidateAPIKey(appID, apiKey, clientIP)
 }
 
 // GenerateNewAPIKey 生成新的API密钥
@@ -11,6 +11,8 @@ type SecurityConfig struct {
 	APIKeyExpiration int      `json:"apiKeyExpiration"` // API密钥过期时间(天)
 	SensitiveFields  []string `json:"sensitiveFields"`  // 敏感字段列表
 	RateLimitDefault int      `json:"rateLimitDefault"` // 默认API请求限制(每分钟)
+	ConcurrencyWait  int      `json:"concurrencyWait"`  // 并发请求数已满时的最长排队时间(毫秒)
+	KeyRotationGrace int      `json:"keyRotationGrace"` // 密钥轮换后旧密钥继续有效的时间(小时)
 	EnableAuditLog   bool     `json:"enableAuditLog"`   // 是否启用审计日志
 }
 
@@ -30,6 +32,8 @@ func LoadSecurityConfig() (*SecurityConfig, error) {
 		APIKeyExpiration: 365, // 默认一年
 		SensitiveFields:  []string{"password", "phone", "email", "idCard", "bankCard"},
 		RateLimitDefault: 100, // 每分钟100次请求
+		ConcurrencyWait:  2000,
+		KeyRotationGrace: 24,
 		EnableAuditLog:   true,
 	}, nil
 }
\ No newline at end of file
@@ -0,0 +1,209 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultLease 并发许可的租约时长，持有实例异常退出时许可在租约到期后自动释放
+const DefaultLease = 30 * time.Second
+
+// semaphorePollInterval 排队等待时重试获取许可的间隔
+const semaphorePollInterval = 50 * time.Millisecond
+
+// acquireScript 清理过期许可后，未达到上限时登记一个许可，分数为租约到期的毫秒时间戳
+// 返回{是否获取成功, 当前许可数}
+var acquireScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local lease = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+local count = redis.call('ZCARD', KEYS[1])
+if count >= limit then
+	return {0, count}
+end
+redis.call('ZADD', KEYS[1], now + lease, ARGV[3])
+redis.call('PEXPIRE', KEYS[1], lease)
+return {1, count + 1}
+`)
+
+// renewScript 延长仍然有效的许可的租约，许可已过期被清理时返回0
+var renewScript = redis.NewScript(`
+local lease = tonumber(ARGV[1])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+if not redis.call('ZSCORE', KEYS[1], ARGV[2]) then
+	return 0
+end
+redis.call('ZADD', KEYS[1], now + lease, ARGV[2])
+redis.call('PEXPIRE', KEYS[1], lease)
+return 1
+`)
+
+// Permit 已获取的并发许可，使用完毕后必须调用Release
+type Permit struct {
+	sem   *Semaphore
+	key   string
+	id    string
+	local bool // Redis不可用时由本实例计数
+	stop  chan struct{}
+	once  sync.Once
+}
+
+// Release 释放许可，可以重复调用
+func (p *Permit) Release() {
+	p.once.Do(func() {
+		close(p.stop)
+		p.sem.release(p)
+	})
+}
+
+// Semaphore 基于Redis有序集合的分布式信号量，限制同一个键在所有实例上同时持有的许可数
+// 许可带租约，持有期间自动续约；Redis不可用时退化为本实例内计数
+type Semaphore struct {
+	redis  *redis.Client
+	prefix string
+	lease  time.Duration
+
+	mu             sync.Mutex
+	local          map[string]int
+	redisDownUntil time.Time
+}
+
+// NewSemaphore 创建分布式信号量，lease不大于0时使用DefaultLease
+func NewSemaphore(redisClient *redis.Client, prefix string, lease time.Duration) *Semaphore {
+	if lease <= 0 {
+		lease = DefaultLease
+	}
+	return &Semaphore{
+		redis:  redisClient,
+		prefix: prefix,
+		lease:  lease,
+		local:  make(map[string]int),
+	}
+}
+
+// Acquire 获取key的一个许可，已达到limit时最多等待wait，仍未获取到时返回nil
+// 返回的count为获取失败时观察到的已持有许可数
+func (s *Semaphore) Acquire(ctx context.Context, key string, limit int, wait time.Duration) (*Permit, int, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		permit, count, err := s.tryAcquire(ctx, key, limit)
+		if err != nil || permit != nil {
+			return permit, count, err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, count, nil
+		}
+		// 加入随机抖动，避免排队的请求同时重试
+		delay := semaphorePollInterval + time.Duration(rand.Int63n(int64(semaphorePollInterval)))
+		if delay > remaining {
+			delay = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return nil, count, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *Semaphore) tryAcquire(ctx context.Context, key string, limit int) (*Permit, int, error) {
+	permit := &Permit{sem: s, key: fmt.Sprintf("%s:%s", s.prefix, key), id: uuid.New().String(), stop: make(chan struct{})}
+
+	if s.redisAvailable() {
+		values, err := acquireScript.Run(ctx, s.redis, []string{permit.key}, limit, s.lease.Milliseconds(), permit.id).Int64Slice()
+		if err == nil && len(values) == 2 {
+			if values[0] == 0 {
+				return nil, int(values[1]), nil
+			}
+			go s.keepAlive(permit)
+			return permit, int(values[1]), nil
+		}
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		s.markRedisDown(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := s.local[permit.key]
+	if count >= limit {
+		return nil, count, nil
+	}
+	s.local[permit.key] = count + 1
+	permit.local = true
+	return permit, count + 1, nil
+}
+
+// keepAlive 持有许可期间定期续约，避免长时间的请求被当作过期许可清理
+func (s *Semaphore) keepAlive(p *Permit) {
+	ticker := time.NewTicker(s.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			renewed, err := renewScript.Run(ctx, s.redis, []string{p.key}, s.lease.Milliseconds(), p.id).Int()
+			cancel()
+			if err != nil {
+				log.Printf("并发许可续约失败: %v", err)
+				continue
+			}
+			if renewed == 0 {
+				return
+			}
+		}
+	}
+}
+
+func (s *Semaphore) release(p *Permit) {
+	if p.local {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.local[p.key] <= 1 {
+			delete(s.local, p.key)
+		} else {
+			s.local[p.key]--
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.redis.ZRem(ctx, p.key, p.id).Err(); err != nil {
+		// 释放失败的许可在租约到期后自动清理
+		log.Printf("释放并发许可失败: %v", err)
+	}
+}
+
+func (s *Semaphore) redisAvailable() bool {
+	if s.redis == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Now().After(s.redisDownUntil)
+}
+
+func (s *Semaphore) markRedisDown(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Now().After(s.redisDownUntil) {
+		log.Printf("Redis并发控制不可用，改用本地计数: %v", err)
+	}
+	s.redisDownUntil = time.Now().Add(redisRetryInterval)
+}
@@ -0,0 +1,141 @@
+package security
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemaphoreLocalLimit(t *testing.T) {
+	sem := NewSemaphore(nil, "test", time.Second)
+	ctx := context.Background()
+
+	first, _, err := sem.Acquire(ctx, "customer:1", 2, 0)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	second, count, err := sem.Acquire(ctx, "customer:1", 2, 0)
+	require.NoError(t, err)
+	require.NotNil(t, second)
+	assert.Equal(t, 2, count)
+
+	third, count, err := sem.Acquire(ctx, "customer:1", 2, 0)
+	require.NoError(t, err)
+	assert.Nil(t, third)
+	assert.Equal(t, 2, count)
+
+	// 其他客户不受影响
+	other, _, err := sem.Acquire(ctx, "customer:2", 2, 0)
+	require.NoError(t, err)
+	require.NotNil(t, other)
+	other.Release()
+
+	first.Release()
+	first.Release() // 重复释放不影响计数
+	again, _, err := sem.Acquire(ctx, "customer:1", 2, 0)
+	require.NoError(t, err)
+	assert.NotNil(t, again)
+}
+
+func TestSemaphoreWaitsForRelease(t *testing.T) {
+	sem := NewSemaphore(nil, "test", time.Second)
+	held, _, err := sem.Acquire(context.Background(), "k", 1, 0)
+	require.NoError(t, err)
+
+	time.AfterFunc(80*time.Millisecond, held.Release)
+	start := time.Now()
+	permit, _, err := sem.Acquire(context.Background(), "k", 1, time.Second)
+	require.NoError(t, err)
+	require.NotNil(t, permit)
+	assert.Less(t, time.Since(start), time.Second)
+	permit.Release()
+}
+
+func TestSemaphoreStopsWaitingWhenContextCanceled(t *testing.T) {
+	sem := NewSemaphore(nil, "test", time.Second)
+	_, _, err := sem.Acquire(context.Background(), "k", 1, 0)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	permit, _, err := sem.Acquire(ctx, "k", 1, time.Second)
+	assert.Nil(t, permit)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+type fixedConcurrency int
+
+func (f fixedConcurrency) ConcurrencyFor(int64) (int, error) { return int(f), nil }
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sem := NewSemaphore(nil, "test", time.Second)
+	entered := make(chan struct{})
+	finish := make(chan struct{})
+
+	r := gin.New()
+	r.POST("/recognize", func(c *gin.Context) {
+		c.Set("ownerID", int64(1))
+		c.Next()
+	}, ConcurrencyLimit(sem, fixedConcurrency(1), 20*time.Millisecond), func(c *gin.Context) {
+		entered <- struct{}{}
+		<-finish
+		c.Status(http.StatusOK)
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	first := httptest.NewRecorder()
+	go func() {
+		defer wg.Done()
+		r.ServeHTTP(first, httptest.NewRequest(http.MethodPost, "/recognize", nil))
+	}()
+	<-entered
+
+	second := httptest.NewRecorder()
+	r.ServeHTTP(second, httptest.NewRequest(http.MethodPost, "/recognize", nil))
+	assert.Equal(t, http.StatusTooManyRequests, second.Code)
+	assert.Contains(t, second.Body.String(), "concurrency_limit")
+	assert.Equal(t, "1", second.Header().Get("Retry-After"))
+
+	close(finish)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	// 第一个请求结束后许可已释放
+	third := httptest.NewRecorder()
+	go func() { <-entered }()
+	r.ServeHTTP(third, httptest.NewRequest(http.MethodPost, "/recognize", nil))
+	assert.Equal(t, http.StatusOK, third.Code)
+}
+
+func TestSecurityServiceConcurrencyLimitUsesConfiguredWait(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sem := NewSemaphore(nil, "test", time.Second)
+	held, _, err := sem.Acquire(context.Background(), "customer:1", 1, 0)
+	require.NoError(t, err)
+	require.NotNil(t, held)
+	defer held.Release()
+
+	s := &SecurityService{config: &SecurityConfig{ConcurrencyWait: 50}}
+	r := gin.New()
+	r.POST("/recognize", func(c *gin.Context) {
+		c.Set("ownerID", int64(1))
+		c.Next()
+	}, s.ConcurrencyLimit(sem, fixedConcurrency(1)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// 并发已满时按配置的排队时间等待后返回429
+	start := time.Now()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/recognize", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
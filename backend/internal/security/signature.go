@@ -0,0 +1,225 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// 签名认证的请求头，客户端用X-Key-ID替代X-API-Key，密钥明文不随请求发送
+const (
+	HeaderKeyID     = "X-Key-ID"
+	HeaderTimestamp = "X-Timestamp" // Unix秒
+	HeaderNonce     = "X-Nonce"     // 每个请求唯一的随机串
+	HeaderSignature = "X-Signature" // 十六进制的HMAC-SHA256
+)
+
+// DefaultSignatureSkew 允许的客户端与服务器时钟偏差
+const DefaultSignatureSkew = 5 * time.Minute
+
+// maxSignedBody 参与签名的请求体大小上限，覆盖10MB图片及表单开销
+const maxSignedBody = 12 * 1024 * 1024
+
+// maxNonceLength 随机串长度上限
+const maxNonceLength = 64
+
+// 签名验证失败的原因
+var (
+	ErrSignatureMissing     = errors.New("缺少签名认证信息")
+	ErrSignatureInvalid     = errors.New("请求签名无效")
+	ErrSignatureExpired     = errors.New("请求时间戳超出允许范围")
+	ErrNonceReused          = errors.New("请求已被处理，请勿重放")
+	ErrBodyTooLarge         = errors.New("请求体过大")
+	ErrSignatureUnsupported = errors.New("该密钥不支持签名认证，请轮换密钥后使用新密钥签名")
+)
+
+// SealSigningSecret 加密密钥明文，保存在密钥的SigningSecret中用于验证签名
+// 客户端以密钥明文作为HMAC密钥；key_hash可以从数据库读到，不能用于验证签名，否则读到摘要即可伪造请求
+// encryption为nil时返回空串，该密钥不支持签名认证
+func SealSigningSecret(encryption *EncryptionService, secret string) (string, error) {
+	if encryption == nil {
+		return "", nil
+	}
+	sealed, err := encryption.Encrypt(secret)
+	if err != nil {
+		return "", fmt.Errorf("加密签名密钥失败: %w", err)
+	}
+	return sealed, nil
+}
+
+// openSigningSecret 解密密钥的签名密钥，未保存签名密钥时返回ErrSignatureUnsupported
+func openSigningSecret(encryption *EncryptionService, key *model.APIKey) (string, error) {
+	if encryption == nil || key.SigningSecret == "" {
+		return "", ErrSignatureUnsupported
+	}
+	secret, err := encryption.Decrypt(key.SigningSecret)
+	if err != nil {
+		return "", fmt.Errorf("解密密钥%s的签名密钥失败: %w", key.ID, err)
+	}
+	return secret, nil
+}
+
+// StringToSign 待签名字符串：请求方法、路径(含查询串)、请求体SHA-256、时间戳和随机串，以换行分隔
+func StringToSign(method, path string, body []byte, timestamp, nonce string) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		hex.EncodeToString(bodyHash[:]),
+		timestamp,
+		nonce,
+	}, "\n")
+}
+
+// SignRequest 计算请求签名，secret为API密钥明文
+func SignRequest(secret, method, path string, body []byte, timestamp, nonce string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(StringToSign(method, path, body, timestamp, nonce)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureVerifier 验证请求签名，并在Redis中记录随机串防止重放
+// Redis不可用时改用本地记录，多实例部署下重放保护仅限单个实例
+type SignatureVerifier struct {
+	redis *redis.Client
+	skew  time.Duration
+
+	mu             sync.Mutex
+	local          map[string]time.Time // 随机串到过期时间
+	redisDownUntil time.Time
+	lastSweep      time.Time
+}
+
+// NewSignatureVerifier 创建签名验证，skew为0时使用DefaultSignatureSkew
+func NewSignatureVerifier(redisClient *redis.Client, skew time.Duration) *SignatureVerifier {
+	if skew <= 0 {
+		skew = DefaultSignatureSkew
+	}
+	return &SignatureVerifier{
+		redis: redisClient,
+		skew:  skew,
+		local: make(map[string]time.Time),
+	}
+}
+
+// Signed 请求是否使用签名认证
+func Signed(r *http.Request) bool {
+	return r.Header.Get(HeaderSignature) != ""
+}
+
+// ReadBody 读取请求体用于计算签名，并还原请求体供后续处理
+func ReadBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBody+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("读取请求体失败: %w", err)
+	}
+	if len(body) > maxSignedBody {
+		return nil, ErrBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// Verify 验证签名、时间戳和随机串，secret为解密后的密钥明文
+func (v *SignatureVerifier) Verify(ctx context.Context, keyID, secret string, r *http.Request, body []byte, now time.Time) error {
+	timestamp := r.Header.Get(HeaderTimestamp)
+	nonce := r.Header.Get(HeaderNonce)
+	signature := r.Header.Get(HeaderSignature)
+	if timestamp == "" || nonce == "" || signature == "" {
+		return ErrSignatureMissing
+	}
+	if len(nonce) > maxNonceLength {
+		return ErrSignatureInvalid
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	if diff := now.Sub(time.Unix(seconds, 0)); diff > v.skew || diff < -v.skew {
+		return ErrSignatureExpired
+	}
+
+	expected := SignRequest(secret, r.Method, r.URL.RequestURI(), body, timestamp, nonce)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return ErrSignatureInvalid
+	}
+
+	// 随机串保留到时间戳超出允许范围为止，此后的重放会因时间戳被拒绝
+	fresh, err := v.remember(ctx, keyID+":"+nonce, now, 2*v.skew)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrNonceReused
+	}
+	return nil
+}
+
+// remember 记录随机串，已记录过时返回false
+func (v *SignatureVerifier) remember(ctx context.Context, nonce string, now time.Time, ttl time.Duration) (bool, error) {
+	if v.redisAvailable() {
+		fresh, err := v.redis.SetNX(ctx, "sig_nonce:"+nonce, 1, ttl).Result()
+		if err == nil {
+			return fresh, nil
+		}
+		v.markRedisDown(err)
+	}
+	return v.rememberLocal(nonce, now, ttl), nil
+}
+
+func (v *SignatureVerifier) redisAvailable() bool {
+	if v.redis == nil {
+		return false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return time.Now().After(v.redisDownUntil)
+}
+
+func (v *SignatureVerifier) markRedisDown(err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if time.Now().After(v.redisDownUntil) {
+		log.Printf("Redis签名随机串记录不可用，改用本地记录: %v", err)
+	}
+	v.redisDownUntil = time.Now().Add(redisRetryInterval)
+}
+
+func (v *SignatureVerifier) rememberLocal(nonce string, now time.Time, ttl time.Duration) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if now.Sub(v.lastSweep) >= time.Minute {
+		v.lastSweep = now
+		for n, expireAt := range v.local {
+			if !now.Before(expireAt) {
+				delete(v.local, n)
+			}
+		}
+	}
+	if expireAt, ok := v.local[nonce]; ok && now.Before(expireAt) {
+		return false
+	}
+	v.local[nonce] = now.Add(ttl)
+	return true
+}
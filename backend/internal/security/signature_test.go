@@ -0,0 +1,147 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const signingKeyID = "65f0c0ffee0000000000abcd"
+
+func signedRequest(t *testing.T, secret, path string, body []byte, at time.Time, nonce string) *http.Request {
+	t.Helper()
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("X-App-ID", "app_a")
+	req.Header.Set(HeaderKeyID, signingKeyID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, SignRequest(secret, http.MethodPost, path, body, timestamp, nonce))
+	return req
+}
+
+func TestSignatureVerify(t *testing.T) {
+	v := NewSignatureVerifier(nil, time.Minute)
+	signing := "sk_secret"
+	now := time.Now()
+	body := []byte(`{"modelId":"m1"}`)
+
+	req := signedRequest(t, "sk_secret", "/api/v1/client/recognitions?locale=zh", body, now, "n1")
+	assert.NoError(t, v.Verify(context.Background(), signingKeyID, signing, req, body, now))
+
+	// 同一随机串不能重复使用
+	req = signedRequest(t, "sk_secret", "/api/v1/client/recognitions?locale=zh", body, now, "n1")
+	assert.ErrorIs(t, v.Verify(context.Background(), signingKeyID, signing, req, body, now), ErrNonceReused)
+
+	// 请求体、路径或密钥不一致时签名无效
+	req = signedRequest(t, "sk_secret", "/api/v1/client/recognitions", body, now, "n2")
+	assert.ErrorIs(t, v.Verify(context.Background(), signingKeyID, signing, req, []byte(`{"modelId":"m2"}`), now), ErrSignatureInvalid)
+	req = signedRequest(t, "sk_secret", "/api/v1/client/recognitions", body, now, "n3")
+	req.URL.Path = "/api/v1/client/recognitions/1"
+	assert.ErrorIs(t, v.Verify(context.Background(), signingKeyID, signing, req, body, now), ErrSignatureInvalid)
+	req = signedRequest(t, "sk_other", "/api/v1/client/recognitions", body, now, "n4")
+	assert.ErrorIs(t, v.Verify(context.Background(), signingKeyID, signing, req, body, now), ErrSignatureInvalid)
+
+	// 时间戳超出允许的时钟偏差
+	req = signedRequest(t, "sk_secret", "/api/v1/client/recognitions", body, now.Add(-2*time.Minute), "n5")
+	assert.ErrorIs(t, v.Verify(context.Background(), signingKeyID, signing, req, body, now), ErrSignatureExpired)
+	req = signedRequest(t, "sk_secret", "/api/v1/client/recognitions", body, now.Add(2*time.Minute), "n6")
+	assert.ErrorIs(t, v.Verify(context.Background(), signingKeyID, signing, req, body, now), ErrSignatureExpired)
+	req = signedRequest(t, "sk_secret", "/api/v1/client/recognitions", body, now.Add(30*time.Second), "n7")
+	assert.NoError(t, v.Verify(context.Background(), signingKeyID, signing, req, body, now))
+
+	req = signedRequest(t, "sk_secret", "/api/v1/client/recognitions", body, now, "n8")
+	req.Header.Del(HeaderNonce)
+	assert.ErrorIs(t, v.Verify(context.Background(), signingKeyID, signing, req, body, now), ErrSignatureMissing)
+}
+
+func TestSignatureNonceExpires(t *testing.T) {
+	v := NewSignatureVerifier(nil, time.Minute)
+	now := time.Now()
+
+	fresh, err := v.remember(context.Background(), "k:n1", now, 2*time.Minute)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+	fresh, _ = v.remember(context.Background(), "k:n1", now.Add(time.Minute), 2*time.Minute)
+	assert.False(t, fresh)
+	fresh, _ = v.remember(context.Background(), "k:n1", now.Add(3*time.Minute), 2*time.Minute)
+	assert.True(t, fresh)
+}
+
+func newSigningEncryption(t *testing.T) *EncryptionService {
+	t.Helper()
+	encryption, err := NewEncryptionService("0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+	return encryption
+}
+
+func TestValidateSignatureRestoresBody(t *testing.T) {
+	encryption := newSigningEncryption(t)
+	key := testKey(signingKeyID, "sk_secret", "app_a")
+	sealed, err := SealSigningSecret(encryption, "sk_secret")
+	require.NoError(t, err)
+	key.SigningSecret = sealed
+	store, _, _ := newTestKeyStore(key)
+	s := NewAPISecurityService(nil, encryption, nil, store, nil, 0)
+	body := []byte(`{"modelId":"m1"}`)
+
+	req := signedRequest(t, "sk_secret", "/api/v1/client/recognitions", body, time.Now(), "n1")
+	info, err := s.ValidateSignature(context.Background(), "app_a", req, "203.0.113.1")
+	require.NoError(t, err)
+	assert.Equal(t, signingKeyID, info.KeyID)
+	assert.Equal(t, int64(7), info.OwnerID)
+
+	// 签名验证后请求体仍可被处理器读取
+	restored, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, restored)
+
+	req = signedRequest(t, "sk_secret", "/api/v1/client/recognitions", body, time.Now(), "n2")
+	_, err = s.ValidateSignature(context.Background(), "app_b", req, "203.0.113.1")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	req = signedRequest(t, "sk_secret", "/api/v1/client/recognitions", body, time.Now(), "n3")
+	req.Header.Set(HeaderKeyID, "not-a-key")
+	_, err = s.ValidateSignature(context.Background(), "app_a", req, "203.0.113.1")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+func TestValidateSignatureRejectsStoredHash(t *testing.T) {
+	encryption := newSigningEncryption(t)
+	key := testKey(signingKeyID, "sk_secret", "app_a")
+	sealed, err := SealSigningSecret(encryption, "sk_secret")
+	require.NoError(t, err)
+	key.SigningSecret = sealed
+	assert.NotContains(t, sealed, "sk_secret")
+	store, _, _ := newTestKeyStore(key)
+	s := NewAPISecurityService(nil, encryption, nil, store, nil, 0)
+	body := []byte(`{"modelId":"m1"}`)
+
+	// 能读取数据库的人只拿到key_hash，用它签名的请求必须被拒绝
+	req := signedRequest(t, key.KeyHash, "/api/v1/client/recognitions", body, time.Now(), "n1")
+	_, err = s.ValidateSignature(context.Background(), "app_a", req, "203.0.113.1")
+	assert.ErrorIs(t, err, ErrSignatureInvalid)
+
+	req = signedRequest(t, "sk_secret", "/api/v1/client/recognitions", body, time.Now(), "n2")
+	_, err = s.ValidateSignature(context.Background(), "app_a", req, "203.0.113.1")
+	assert.NoError(t, err)
+}
+
+func TestValidateSignatureRequiresSigningSecret(t *testing.T) {
+	// 未保存签名密钥的旧密钥不能用于签名认证
+	key := testKey(signingKeyID, "sk_secret", "app_a")
+	store, _, _ := newTestKeyStore(key)
+	s := NewAPISecurityService(nil, newSigningEncryption(t), nil, store, nil, 0)
+
+	req := signedRequest(t, "sk_secret", "/api/v1/client/recognitions", []byte(`{}`), time.Now(), "n1")
+	_, err := s.ValidateSignature(context.Background(), "app_a", req, "203.0.113.1")
+	assert.ErrorIs(t, err, ErrSignatureUnsupported)
+}
@@ -0,0 +1,103 @@
+package taxonomy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// cacheTTL 最新标签映射的本地缓存时间，发布新版本后最多延迟该时长在其他实例生效
+const cacheTTL = 30 * time.Second
+
+type cachedTaxonomy struct {
+	taxonomy *Taxonomy
+	expireAt time.Time
+}
+
+// Service 标签映射管理服务，缓存各模型最新版本的分类体系供识别接口使用
+type Service struct {
+	repo model.LabelMapRepository
+
+	mu    sync.Mutex
+	cache map[string]*cachedTaxonomy
+}
+
+// NewService 创建标签映射管理服务
+func NewService(repo model.LabelMapRepository) *Service {
+	return &Service{
+		repo:  repo,
+		cache: make(map[string]*cachedTaxonomy),
+	}
+}
+
+// Publish 校验并发布模型标签映射的新版本
+func (s *Service) Publish(labelMap *model.LabelMap) error {
+	if err := Validate(labelMap.Labels); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+	if err := s.repo.Create(labelMap); err != nil {
+		return errors.NewServerError("保存标签映射失败")
+	}
+	s.Invalidate(labelMap.ModelID)
+	return nil
+}
+
+// LabelMap 获取模型标签映射，version不大于0时返回最新版本，不存在时返回nil
+func (s *Service) LabelMap(modelID string, version int) (*model.LabelMap, error) {
+	var labelMap *model.LabelMap
+	var err error
+	if version > 0 {
+		labelMap, err = s.repo.FindByVersion(modelID, version)
+	} else {
+		labelMap, err = s.repo.FindLatest(modelID)
+	}
+	if err != nil {
+		return nil, errors.NewServerError("查询标签映射失败")
+	}
+	return labelMap, nil
+}
+
+// Versions 获取模型标签映射的全部版本
+func (s *Service) Versions(modelID string) ([]*model.LabelMap, error) {
+	versions, err := s.repo.ListVersions(modelID)
+	if err != nil {
+		return nil, errors.NewServerError("查询标签映射版本失败")
+	}
+	return versions, nil
+}
+
+// Taxonomy 获取模型最新版本的分类体系，未配置标签映射时返回nil
+func (s *Service) Taxonomy(modelID string) (*Taxonomy, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[modelID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expireAt) {
+		return cached.taxonomy, nil
+	}
+
+	labelMap, err := s.LabelMap(modelID, 0)
+	if err != nil {
+		return nil, err
+	}
+	var t *Taxonomy
+	if labelMap != nil {
+		// 已保存的版本发布时校验过，这里出错说明数据被直接修改，按未配置处理
+		if t, err = New(labelMap); err != nil {
+			t = nil
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[modelID] = &cachedTaxonomy{taxonomy: t, expireAt: time.Now().Add(cacheTTL)}
+	s.mu.Unlock()
+	return t, nil
+}
+
+// Invalidate 清除模型的缓存
+func (s *Service) Invalidate(modelID string) {
+	s.mu.Lock()
+	delete(s.cache, modelID)
+	s.mu.Unlock()
+}
@@ -0,0 +1,164 @@
+package taxonomy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// DefaultLocale 请求的语言区域没有显示名称时使用的语言
+const DefaultLocale = "en"
+
+// MaxDepth 分类层级上限，避免误配置产生过深的层级
+const MaxDepth = 10
+
+// Taxonomy 由标签映射构建的层级分类体系，nil表示模型未配置标签映射
+type Taxonomy struct {
+	version int
+	entries map[string]model.LabelEntry
+	order   []string
+}
+
+// New 校验标签映射并构建分类体系
+func New(labelMap *model.LabelMap) (*Taxonomy, error) {
+	if err := Validate(labelMap.Labels); err != nil {
+		return nil, err
+	}
+	t := &Taxonomy{
+		version: labelMap.Version,
+		entries: make(map[string]model.LabelEntry, len(labelMap.Labels)),
+		order:   make([]string, 0, len(labelMap.Labels)),
+	}
+	for _, e := range labelMap.Labels {
+		t.entries[e.Name] = e
+		t.order = append(t.order, e.Name)
+	}
+	return t, nil
+}
+
+// Validate 校验标签名唯一、上级标签已定义且不存在循环
+func Validate(entries []model.LabelEntry) error {
+	if len(entries) == 0 {
+		return fmt.Errorf("标签映射不能为空")
+	}
+	parents := make(map[string]string, len(entries))
+	for _, e := range entries {
+		if strings.TrimSpace(e.Name) == "" || e.Name != strings.TrimSpace(e.Name) {
+			return fmt.Errorf("标签名不能为空或包含首尾空白")
+		}
+		if _, ok := parents[e.Name]; ok {
+			return fmt.Errorf("标签%s重复", e.Name)
+		}
+		if e.Parent == e.Name {
+			return fmt.Errorf("标签%s的上级不能是自身", e.Name)
+		}
+		for locale, name := range e.DisplayNames {
+			if strings.TrimSpace(locale) == "" || strings.TrimSpace(name) == "" {
+				return fmt.Errorf("标签%s的显示名称和语言区域不能为空", e.Name)
+			}
+		}
+		parents[e.Name] = e.Parent
+	}
+
+	for _, e := range entries {
+		depth := 0
+		for p := e.Parent; p != ""; p = parents[p] {
+			if _, ok := parents[p]; !ok {
+				return fmt.Errorf("标签%s的上级%s未定义", e.Name, p)
+			}
+			depth++
+			if p == e.Name {
+				return fmt.Errorf("标签%s的上级存在循环", e.Name)
+			}
+			if depth > MaxDepth {
+				return fmt.Errorf("标签%s的层级超过%d层", e.Name, MaxDepth)
+			}
+		}
+	}
+	return nil
+}
+
+// Version 标签映射版本
+func (t *Taxonomy) Version() int {
+	if t == nil {
+		return 0
+	}
+	return t.version
+}
+
+// Labels 按标签映射中的顺序返回全部标签
+func (t *Taxonomy) Labels() []string {
+	if t == nil {
+		return nil
+	}
+	return append([]string(nil), t.order...)
+}
+
+// Ancestors 由近及远的上级标签，未定义的标签没有上级
+func (t *Taxonomy) Ancestors(label string) []string {
+	if t == nil {
+		return nil
+	}
+	var ancestors []string
+	for p := t.entries[label].Parent; p != ""; p = t.entries[p].Parent {
+		ancestors = append(ancestors, p)
+	}
+	return ancestors
+}
+
+// Expand 在每个标签后追加其上级标签并去重，如[cat]展开为[cat animal pet]
+func (t *Taxonomy) Expand(labels []string) []string {
+	expanded := make([]string, 0, len(labels))
+	seen := make(map[string]bool)
+	for _, label := range labels {
+		for _, l := range append([]string{label}, t.Ancestors(label)...) {
+			if !seen[l] {
+				seen[l] = true
+				expanded = append(expanded, l)
+			}
+		}
+	}
+	return expanded
+}
+
+// DisplayName 标签在语言区域下的显示名称
+// 依次匹配完整区域(如zh-CN)、同一语言的任意区域、DefaultLocale，都没有时返回标签本身
+func (t *Taxonomy) DisplayName(label, locale string) string {
+	if t == nil {
+		return label
+	}
+	names := t.entries[label].DisplayNames
+	if len(names) == 0 {
+		return label
+	}
+
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	language := strings.SplitN(locale, "-", 2)[0]
+	var sameLanguage, fallback string
+	for key, name := range names {
+		key = strings.ReplaceAll(key, "_", "-")
+		if locale != "" && strings.EqualFold(key, locale) {
+			return name
+		}
+		if language != "" && sameLanguage == "" && strings.EqualFold(strings.SplitN(key, "-", 2)[0], language) {
+			sameLanguage = name
+		}
+		if strings.EqualFold(key, DefaultLocale) {
+			fallback = name
+		}
+	}
+	if sameLanguage != "" {
+		return sameLanguage
+	}
+	if fallback != "" {
+		return fallback
+	}
+	return label
+}
+
+// ParseLocale 从Accept-Language取优先级最高的语言区域
+func ParseLocale(acceptLanguage string) string {
+	first := strings.SplitN(acceptLanguage, ",", 2)[0]
+	return strings.TrimSpace(strings.SplitN(first, ";", 2)[0])
+}
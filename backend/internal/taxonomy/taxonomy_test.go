@@ -0,0 +1,74 @@
+package taxonomy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+func petTaxonomy(t *testing.T) *Taxonomy {
+	tax, err := New(&model.LabelMap{Version: 3, Labels: []model.LabelEntry{
+		{Name: "pet", DisplayNames: map[string]string{"en": "Pet", "zh-CN": "宠物"}},
+		{Name: "animal", Parent: "pet", DisplayNames: map[string]string{"en": "Animal", "zh-CN": "动物"}},
+		{Name: "cat", Parent: "animal", DisplayNames: map[string]string{"en": "Cat", "zh-TW": "貓"}},
+		{Name: "dog", Parent: "animal"},
+	}})
+	require.NoError(t, err)
+	return tax
+}
+
+func TestExpandDerivesAncestors(t *testing.T) {
+	tax := petTaxonomy(t)
+
+	assert.Equal(t, 3, tax.Version())
+	assert.Equal(t, []string{"animal", "pet"}, tax.Ancestors("cat"))
+	assert.Equal(t, []string{"cat", "animal", "pet"}, tax.Expand([]string{"cat"}))
+	assert.Equal(t, []string{"cat", "animal", "pet", "dog"}, tax.Expand([]string{"cat", "dog"}))
+	// 未定义的标签原样返回
+	assert.Equal(t, []string{"bird"}, tax.Expand([]string{"bird"}))
+
+	var none *Taxonomy
+	assert.Equal(t, []string{"cat"}, none.Expand([]string{"cat"}))
+	assert.Equal(t, "cat", none.DisplayName("cat", "zh-CN"))
+}
+
+func TestDisplayNameFallback(t *testing.T) {
+	tax := petTaxonomy(t)
+
+	assert.Equal(t, "宠物", tax.DisplayName("pet", "zh-CN"))
+	assert.Equal(t, "宠物", tax.DisplayName("pet", "zh_cn"))
+	// 同一语言的其他区域
+	assert.Equal(t, "貓", tax.DisplayName("cat", "zh-CN"))
+	// 默认语言
+	assert.Equal(t, "Cat", tax.DisplayName("cat", "fr"))
+	assert.Equal(t, "dog", tax.DisplayName("dog", "en"))
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []model.LabelEntry
+	}{
+		{"empty", nil},
+		{"blank name", []model.LabelEntry{{Name: " "}}},
+		{"duplicate", []model.LabelEntry{{Name: "cat"}, {Name: "cat"}}},
+		{"self parent", []model.LabelEntry{{Name: "cat", Parent: "cat"}}},
+		{"undefined parent", []model.LabelEntry{{Name: "cat", Parent: "animal"}}},
+		{"cycle", []model.LabelEntry{{Name: "a", Parent: "b"}, {Name: "b", Parent: "a"}}},
+		{"blank display name", []model.LabelEntry{{Name: "cat", DisplayNames: map[string]string{"en": ""}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, Validate(tt.entries))
+		})
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	assert.Equal(t, "zh-CN", ParseLocale("zh-CN,zh;q=0.9,en;q=0.8"))
+	assert.Equal(t, "en", ParseLocale("en;q=0.8"))
+	assert.Equal(t, "", ParseLocale(""))
+}
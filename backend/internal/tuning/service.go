@@ -0,0 +1,429 @@
+package tuning
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/image-recognition-engine/internal/errors"
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/queue"
+)
+
+// 调参任务默认值与上限
+const (
+	DefaultMaxTrials     = 20
+	MaxTrialsLimit       = 500
+	DefaultMaxConcurrent = 2
+	DefaultObjective     = "accuracy"
+
+	// DefaultTrialTimeout 已提交或执行中的试验超过该时间未更新即视为失联
+	DefaultTrialTimeout = 6 * time.Hour
+	// DefaultReapInterval 检查失联试验的间隔
+	DefaultReapInterval = 5 * time.Minute
+)
+
+// TaskQueue 训练任务队列
+type TaskQueue interface {
+	Enqueue(ctx context.Context, taskType queue.TaskType, data interface{}) (string, error)
+}
+
+// TrainingTaskData 调参试验提交到训练队列的任务数据
+type TrainingTaskData struct {
+	DatasetID   string                 `json:"dataset_id"`
+	ModelID     string                 `json:"model_id"`
+	Params      map[string]interface{} `json:"params"`
+	TuningJobID string                 `json:"tuning_job_id"`
+	TrialID     string                 `json:"trial_id"`
+}
+
+// Service 超参数搜索服务
+// 创建任务时展开全部试验，按并发上限逐个提交训练任务；
+// 训练端上报试验结果后释放名额、更新最优配置并继续调度
+type Service struct {
+	jobRepo   model.TuningJobRepository
+	trialRepo model.TuningTrialRepository
+	paramRepo model.TrainingParamRepository
+	queue     TaskQueue
+}
+
+// NewService 创建超参数搜索服务
+func NewService(jobRepo model.TuningJobRepository, trialRepo model.TuningTrialRepository, paramRepo model.TrainingParamRepository, queue TaskQueue) *Service {
+	return &Service{
+		jobRepo:   jobRepo,
+		trialRepo: trialRepo,
+		paramRepo: paramRepo,
+		queue:     queue,
+	}
+}
+
+// CreateJob 创建调参任务，展开搜索空间并开始调度
+func (s *Service) CreateJob(ctx context.Context, job *model.TuningJob) (*model.TuningJob, error) {
+	if job.Strategy == "" {
+		job.Strategy = model.TuningStrategyGrid
+	}
+	if job.Strategy != model.TuningStrategyGrid && job.Strategy != model.TuningStrategyRandom {
+		return nil, errors.NewValidationError("不支持的搜索策略: " + job.Strategy)
+	}
+	if job.MaxTrials <= 0 {
+		job.MaxTrials = DefaultMaxTrials
+	}
+	if job.MaxTrials > MaxTrialsLimit {
+		return nil, errors.NewValidationError(fmt.Sprintf("最大试验数不能超过%d", MaxTrialsLimit))
+	}
+	if job.MaxConcurrent <= 0 {
+		job.MaxConcurrent = DefaultMaxConcurrent
+	}
+	if job.Objective == "" {
+		job.Objective = DefaultObjective
+		job.Maximize = true
+	}
+
+	params, err := s.paramRepo.List(job.ModelID)
+	if err != nil {
+		return nil, errors.NewServerError("获取训练参数失败")
+	}
+	space, err := BuildSpace(params, job.Params)
+	if err != nil {
+		return nil, errors.NewValidationError(err.Error())
+	}
+
+	var combos []map[string]string
+	if job.Strategy == model.TuningStrategyGrid {
+		if combos, err = space.Grid(job.MaxTrials); err != nil {
+			return nil, errors.NewValidationError(err.Error())
+		}
+	} else {
+		combos = space.Random(job.MaxTrials, job.Seed)
+	}
+
+	job.Params = make([]string, 0, len(space.Dimensions))
+	for _, d := range space.Dimensions {
+		job.Params = append(job.Params, d.Name)
+	}
+	job.Status = model.TuningStatusPending
+	job.TotalTrials = len(combos)
+	id, err := s.jobRepo.Create(job)
+	if err != nil {
+		return nil, errors.NewServerError("创建调参任务失败")
+	}
+	job.ID = id
+
+	trials := make([]*model.TuningTrial, len(combos))
+	for i, combo := range combos {
+		trials[i] = &model.TuningTrial{
+			JobID:  id,
+			Index:  i,
+			Params: combo,
+			Status: model.TuningStatusPending,
+		}
+	}
+	if err := s.trialRepo.CreateMany(trials); err != nil {
+		s.jobRepo.UpdateStatus(id, model.TuningStatusFailed)
+		return nil, errors.NewServerError("创建调参试验失败")
+	}
+
+	if err := s.Dispatch(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.jobRepo.FindByID(id)
+}
+
+// Dispatch 在并发上限内提交待调度的试验
+func (s *Service) Dispatch(ctx context.Context, jobID string) error {
+	job, err := s.findJob(jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status != model.TuningStatusPending && job.Status != model.TuningStatusRunning {
+		return nil
+	}
+
+	params, err := s.paramRepo.List(job.ModelID)
+	if err != nil {
+		return errors.NewServerError("获取训练参数失败")
+	}
+	types := make(map[string]string, len(params))
+	for _, p := range params {
+		types[p.Name] = p.Type
+	}
+
+	for {
+		// 先占用名额再领取试验，名额计数在数据库中原子更新
+		ok, err := s.jobRepo.AcquireSlot(job.ID, job.MaxConcurrent)
+		if err != nil {
+			return errors.NewServerError("调度调参试验失败")
+		}
+		if !ok {
+			break
+		}
+		trial, err := s.trialRepo.ClaimNext(job.ID)
+		if err != nil || trial == nil {
+			s.jobRepo.ReleaseSlot(job.ID)
+			if err != nil {
+				return errors.NewServerError("调度调参试验失败")
+			}
+			break
+		}
+
+		taskID, err := s.queue.Enqueue(ctx, queue.TaskTypeModelTraining, TrainingTaskData{
+			DatasetID:   job.DatasetID,
+			ModelID:     job.ModelID,
+			Params:      TypedParams(trial.Params, types),
+			TuningJobID: job.ID,
+			TrialID:     trial.ID,
+		})
+		if err != nil {
+			log.Printf("Error enqueueing tuning trial %s: %v", trial.ID, err)
+			trial.Status = model.TuningStatusFailed
+			trial.ErrorMsg = "提交训练任务失败"
+			s.trialRepo.Finish(trial)
+			s.jobRepo.ReleaseSlot(job.ID)
+			continue
+		}
+
+		if err := s.trialRepo.SetTaskID(trial.ID, taskID); err != nil {
+			log.Printf("Error saving task id for tuning trial %s: %v", trial.ID, err)
+		}
+		if job.Status == model.TuningStatusPending {
+			job.Status = model.TuningStatusRunning
+			s.jobRepo.UpdateStatus(job.ID, job.Status)
+		}
+	}
+
+	return s.refresh(job)
+}
+
+// StartTrial 训练端开始执行试验时调用
+func (s *Service) StartTrial(trialID string) error {
+	trial, err := s.findTrial(trialID)
+	if err != nil {
+		return err
+	}
+	ok, err := s.trialRepo.TransitionStatus(trial.ID, model.TuningStatusQueued, model.TuningStatusRunning)
+	if err != nil {
+		return errors.NewServerError("更新试验状态失败")
+	}
+	if !ok && trial.Status != model.TuningStatusRunning {
+		return errors.NewValidationError("试验当前状态不能开始执行: " + trial.Status)
+	}
+	return nil
+}
+
+// ReportResult 记录试验结果，errMsg不为空表示训练失败
+// 成功的试验必须包含任务的目标指标，随后释放并发名额并继续调度
+func (s *Service) ReportResult(ctx context.Context, trialID string, metrics map[string]float64, errMsg string) (*model.TuningTrial, error) {
+	trial, err := s.findTrial(trialID)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.findJob(trial.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	trial.Metrics = metrics
+	trial.ErrorMsg = errMsg
+	trial.Status = model.TuningStatusCompleted
+	if errMsg == "" {
+		score, ok := metrics[job.Objective]
+		if !ok || math.IsNaN(score) || math.IsInf(score, 0) {
+			trial.Status = model.TuningStatusFailed
+			trial.ErrorMsg = fmt.Sprintf("未上报目标指标%s", job.Objective)
+		} else {
+			trial.Score = &score
+		}
+	} else {
+		trial.Status = model.TuningStatusFailed
+	}
+
+	finished, err := s.trialRepo.Finish(trial)
+	if err != nil {
+		return nil, errors.NewServerError("记录试验结果失败")
+	}
+	if !finished {
+		return nil, errors.NewValidationError("试验已结束或尚未提交，不能重复上报")
+	}
+
+	if err := s.jobRepo.ReleaseSlot(job.ID); err != nil {
+		log.Printf("Error releasing tuning slot for job %s: %v", job.ID, err)
+	}
+	if err := s.Dispatch(ctx, job.ID); err != nil {
+		return nil, err
+	}
+	return s.trialRepo.FindByID(trial.ID)
+}
+
+// FinishTrial 训练端上报试验结果，供训练队列处理器调用
+func (s *Service) FinishTrial(ctx context.Context, trialID string, metrics map[string]float64, errMsg string) error {
+	_, err := s.ReportResult(ctx, trialID, metrics, errMsg)
+	return err
+}
+
+// ReapStale 将超过timeout未更新的已提交或执行中试验记为失败，释放其并发名额并继续调度
+// 训练进程崩溃或任务丢失时试验不会再上报结果，否则会一直占用名额
+func (s *Service) ReapStale(ctx context.Context, timeout time.Duration, now time.Time) (int, error) {
+	if timeout <= 0 {
+		timeout = DefaultTrialTimeout
+	}
+	trials, err := s.trialRepo.ListStale(now.Add(-timeout))
+	if err != nil {
+		return 0, errors.NewServerError("获取超时调参试验失败")
+	}
+
+	reaped := 0
+	for _, trial := range trials {
+		// 结果通过条件更新记录，训练端在此期间已上报时不会重复释放名额
+		msg := fmt.Sprintf("试验超过%s未上报结果", timeout)
+		if _, err := s.ReportResult(ctx, trial.ID, nil, msg); err != nil {
+			log.Printf("Error reaping tuning trial %s: %v", trial.ID, err)
+			continue
+		}
+		reaped++
+	}
+	return reaped, nil
+}
+
+// RunReaper 每隔interval回收一次失联试验，直到ctx取消
+func (s *Service) RunReaper(ctx context.Context, interval, timeout time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReapInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ReapStale(ctx, timeout, time.Now()); err != nil {
+				log.Printf("回收调参试验失败: %v", err)
+			}
+		}
+	}
+}
+
+// CancelJob 取消调参任务，未提交的试验不再调度，已提交的试验结果仍会记录
+func (s *Service) CancelJob(jobID string) (*model.TuningJob, error) {
+	job, err := s.findJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != model.TuningStatusPending && job.Status != model.TuningStatusRunning {
+		return nil, errors.NewValidationError("调参任务已结束")
+	}
+
+	if _, err := s.trialRepo.CancelPending(job.ID); err != nil {
+		return nil, errors.NewServerError("取消调参试验失败")
+	}
+	job.Status = model.TuningStatusCancelled
+	if err := s.jobRepo.UpdateStatus(job.ID, job.Status); err != nil {
+		return nil, errors.NewServerError("取消调参任务失败")
+	}
+	return job, s.refresh(job)
+}
+
+// refresh 汇总试验进度，更新最优配置，所有试验结束后完成任务
+func (s *Service) refresh(job *model.TuningJob) error {
+	trials, err := s.trialRepo.ListByJob(job.ID)
+	if err != nil {
+		return errors.NewServerError("获取调参试验失败")
+	}
+
+	summary := Summarize(trials, job.Maximize)
+	job.TotalTrials = len(trials)
+	job.CompletedTrials = summary.Completed
+	job.FailedTrials = summary.Failed
+	if summary.Done && (job.Status == model.TuningStatusPending || job.Status == model.TuningStatusRunning) {
+		job.Status = model.TuningStatusCompleted
+		if summary.Best == nil {
+			job.Status = model.TuningStatusFailed
+		}
+		if err := s.jobRepo.UpdateStatus(job.ID, job.Status); err != nil {
+			return errors.NewServerError("更新调参任务失败")
+		}
+	}
+
+	previousBest := job.BestTrialID
+	if summary.Best != nil {
+		job.BestTrialID = summary.Best.ID
+		job.BestParams = summary.Best.Params
+		job.BestScore = summary.Best.Score
+	}
+	if err := s.jobRepo.UpdateProgress(job); err != nil {
+		return errors.NewServerError("更新调参任务失败")
+	}
+	if summary.Best != nil && summary.Best.ID != previousBest {
+		if err := s.trialRepo.MarkBest(job.ID, summary.Best.ID); err != nil {
+			return errors.NewServerError("更新最优试验失败")
+		}
+	}
+	return nil
+}
+
+// Summary 试验汇总
+type Summary struct {
+	Completed int
+	Failed    int
+	Done      bool               // 所有试验均已结束
+	Best      *model.TuningTrial // 目标指标最优的已完成试验，分数相同时取序号较小者
+}
+
+// Summarize 汇总试验状态并选出最优试验
+func Summarize(trials []*model.TuningTrial, maximize bool) Summary {
+	summary := Summary{Done: true}
+	for _, t := range trials {
+		switch t.Status {
+		case model.TuningStatusCompleted:
+			summary.Completed++
+			if t.Score == nil {
+				continue
+			}
+			if summary.Best == nil || better(*t.Score, *summary.Best.Score, maximize) ||
+				(*t.Score == *summary.Best.Score && t.Index < summary.Best.Index) {
+				summary.Best = t
+			}
+		case model.TuningStatusFailed:
+			summary.Failed++
+		case model.TuningStatusCancelled:
+		default:
+			summary.Done = false
+		}
+	}
+	return summary
+}
+
+// better 判断分数a是否优于b
+func better(a, b float64, maximize bool) bool {
+	if maximize {
+		return a > b
+	}
+	return a < b
+}
+
+// findJob 查找调参任务
+func (s *Service) findJob(id string) (*model.TuningJob, error) {
+	job, err := s.jobRepo.FindByID(id)
+	if err != nil {
+		return nil, errors.NewValidationError("无效的调参任务ID")
+	}
+	if job == nil {
+		return nil, errors.NewNotFoundError("调参任务不存在")
+	}
+	return job, nil
+}
+
+// findTrial 查找调参试验
+func (s *Service) findTrial(id string) (*model.TuningTrial, error) {
+	trial, err := s.trialRepo.FindByID(id)
+	if err != nil {
+		return nil, errors.NewValidationError("无效的试验ID")
+	}
+	if trial == nil {
+		return nil, errors.NewNotFoundError("试验不存在")
+	}
+	return trial, nil
+}
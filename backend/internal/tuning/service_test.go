@@ -0,0 +1,211 @@
+package tuning
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+	"github.com/image-recognition-engine/internal/queue"
+)
+
+type fakeJobRepo struct {
+	jobs map[string]*model.TuningJob
+}
+
+func (r *fakeJobRepo) Create(job *model.TuningJob) (string, error) {
+	job.ID = fmt.Sprintf("job-%d", len(r.jobs)+1)
+	r.jobs[job.ID] = job
+	return job.ID, nil
+}
+
+func (r *fakeJobRepo) FindByID(id string) (*model.TuningJob, error) {
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (r *fakeJobRepo) List(modelID string, page, size int) ([]*model.TuningJob, int64, error) {
+	return nil, 0, nil
+}
+
+func (r *fakeJobRepo) UpdateStatus(id, status string) error {
+	r.jobs[id].Status = status
+	return nil
+}
+
+func (r *fakeJobRepo) UpdateProgress(job *model.TuningJob) error {
+	stored := r.jobs[job.ID]
+	stored.CompletedTrials = job.CompletedTrials
+	stored.FailedTrials = job.FailedTrials
+	return nil
+}
+
+func (r *fakeJobRepo) AcquireSlot(id string, max int) (bool, error) {
+	job := r.jobs[id]
+	if job.ActiveTrials >= max {
+		return false, nil
+	}
+	job.ActiveTrials++
+	return true, nil
+}
+
+func (r *fakeJobRepo) ReleaseSlot(id string) error {
+	r.jobs[id].ActiveTrials--
+	return nil
+}
+
+type fakeTrialRepo struct {
+	trials []*model.TuningTrial
+}
+
+func (r *fakeTrialRepo) CreateMany(trials []*model.TuningTrial) error {
+	for _, t := range trials {
+		t.ID = fmt.Sprintf("trial-%d", len(r.trials)+1)
+		r.trials = append(r.trials, t)
+	}
+	return nil
+}
+
+func (r *fakeTrialRepo) find(id string) *model.TuningTrial {
+	for _, t := range r.trials {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (r *fakeTrialRepo) FindByID(id string) (*model.TuningTrial, error) {
+	t := r.find(id)
+	if t == nil {
+		return nil, nil
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func (r *fakeTrialRepo) ListByJob(jobID string) ([]*model.TuningTrial, error) {
+	return r.trials, nil
+}
+
+func (r *fakeTrialRepo) ClaimNext(jobID string) (*model.TuningTrial, error) {
+	for _, t := range r.trials {
+		if t.Status == model.TuningStatusPending {
+			t.Status = model.TuningStatusQueued
+			t.UpdateTime = time.Now()
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeTrialRepo) SetTaskID(id, taskID string) error {
+	r.find(id).TaskID = taskID
+	return nil
+}
+
+func (r *fakeTrialRepo) TransitionStatus(id, from, to string) (bool, error) {
+	t := r.find(id)
+	if t.Status != from {
+		return false, nil
+	}
+	t.Status = to
+	return true, nil
+}
+
+func (r *fakeTrialRepo) Finish(trial *model.TuningTrial) (bool, error) {
+	t := r.find(trial.ID)
+	if t.Status != model.TuningStatusQueued && t.Status != model.TuningStatusRunning {
+		return false, nil
+	}
+	t.Status = trial.Status
+	t.Metrics = trial.Metrics
+	t.Score = trial.Score
+	t.ErrorMsg = trial.ErrorMsg
+	return true, nil
+}
+
+func (r *fakeTrialRepo) CancelPending(jobID string) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeTrialRepo) ListStale(before time.Time) ([]*model.TuningTrial, error) {
+	var stale []*model.TuningTrial
+	for _, t := range r.trials {
+		if (t.Status == model.TuningStatusQueued || t.Status == model.TuningStatusRunning) && t.UpdateTime.Before(before) {
+			stale = append(stale, t)
+		}
+	}
+	return stale, nil
+}
+
+func (r *fakeTrialRepo) MarkBest(jobID, trialID string) error {
+	return nil
+}
+
+type fakeParamRepo struct {
+	model.TrainingParamRepository
+}
+
+func (fakeParamRepo) List(modelID string) ([]*model.TrainingParam, error) {
+	return testParams(), nil
+}
+
+type fakeQueue struct {
+	tasks []TrainingTaskData
+}
+
+func (q *fakeQueue) Enqueue(ctx context.Context, taskType queue.TaskType, data interface{}) (string, error) {
+	q.tasks = append(q.tasks, data.(TrainingTaskData))
+	return fmt.Sprintf("task-%d", len(q.tasks)), nil
+}
+
+func newTestService() (*Service, *fakeJobRepo, *fakeTrialRepo, *fakeQueue) {
+	jobs := &fakeJobRepo{jobs: make(map[string]*model.TuningJob)}
+	trials := &fakeTrialRepo{}
+	q := &fakeQueue{}
+	return NewService(jobs, trials, fakeParamRepo{}, q), jobs, trials, q
+}
+
+func TestServiceImplementsTrialReporter(t *testing.T) {
+	var reporter queue.TrialReporter = &Service{}
+	assert.NotNil(t, reporter)
+}
+
+func TestReapStaleReleasesSlots(t *testing.T) {
+	svc, jobs, trials, q := newTestService()
+	job, err := svc.CreateJob(context.Background(), &model.TuningJob{ModelID: "m1", MaxTrials: 3, MaxConcurrent: 2})
+	require.NoError(t, err)
+	require.Len(t, q.tasks, 2)
+	assert.Equal(t, 2, jobs.jobs[job.ID].ActiveTrials)
+
+	// 第一个试验开始执行后失联，第二个试验刚刚更新过
+	require.NoError(t, svc.StartTrial(q.tasks[0].TrialID))
+	now := time.Now()
+	trials.find(q.tasks[0].TrialID).UpdateTime = now.Add(-2 * time.Hour)
+
+	reaped, err := svc.ReapStale(context.Background(), time.Hour, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reaped)
+
+	stale := trials.find(q.tasks[0].TrialID)
+	assert.Equal(t, model.TuningStatusFailed, stale.Status)
+	assert.Contains(t, stale.ErrorMsg, "未上报结果")
+	// 释放的名额被下一个试验占用
+	require.Len(t, q.tasks, 3)
+	assert.Equal(t, 2, jobs.jobs[job.ID].ActiveTrials)
+
+	// 已回收的试验再上报结果会被拒绝
+	err = svc.FinishTrial(context.Background(), q.tasks[0].TrialID, map[string]float64{"accuracy": 0.9}, "")
+	assert.Error(t, err)
+	assert.Equal(t, 2, jobs.jobs[job.ID].ActiveTrials)
+}
@@ -0,0 +1,241 @@
+package tuning
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+// Dimension 搜索空间中的一个参数维度
+// Range为逗号分隔的候选值时为离散维度；数值参数的Range写作"min~max"时为连续区间，仅用于随机搜索
+type Dimension struct {
+	Name    string
+	Type    string
+	Values  []string // 离散候选值
+	Min     float64  // 连续区间下界
+	Max     float64  // 连续区间上界
+	Integer bool     // 连续区间是否只取整数
+}
+
+// Continuous 是否为连续区间
+func (d *Dimension) Continuous() bool {
+	return len(d.Values) == 0
+}
+
+// ParseRange 解析训练参数的取值范围
+func ParseRange(p *model.TrainingParam) (*Dimension, error) {
+	raw := strings.TrimSpace(p.Range)
+	if raw == "" {
+		return nil, fmt.Errorf("参数%s没有声明取值范围", p.Name)
+	}
+
+	dim := &Dimension{Name: p.Name, Type: p.Type}
+	if p.Type == "number" && strings.Contains(raw, "~") && !strings.Contains(raw, ",") {
+		bounds := strings.SplitN(raw, "~", 2)
+		lo, err1 := strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64)
+		hi, err2 := strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64)
+		if err1 != nil || err2 != nil || lo >= hi {
+			return nil, fmt.Errorf("参数%s的取值区间无效: %s", p.Name, raw)
+		}
+		dim.Min, dim.Max = lo, hi
+		dim.Integer = isInteger(bounds[0]) && isInteger(bounds[1])
+		return dim, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range strings.Split(raw, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		if p.Type == "number" {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return nil, fmt.Errorf("参数%s的候选值%s不是数字", p.Name, v)
+			}
+		}
+		if p.Type == "boolean" {
+			if _, err := strconv.ParseBool(v); err != nil {
+				return nil, fmt.Errorf("参数%s的候选值%s不是布尔值", p.Name, v)
+			}
+		}
+		seen[v] = true
+		dim.Values = append(dim.Values, v)
+	}
+	if len(dim.Values) == 0 {
+		return nil, fmt.Errorf("参数%s没有有效的候选值", p.Name)
+	}
+	return dim, nil
+}
+
+// isInteger 判断数值字面量是否为整数
+func isInteger(s string) bool {
+	_, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	return err == nil
+}
+
+// Space 搜索空间：参与搜索的维度及其余参数的固定取值
+type Space struct {
+	Dimensions []*Dimension
+	Fixed      map[string]string
+	types      map[string]string
+}
+
+// BuildSpace 由模型训练参数构建搜索空间
+// names为空时所有声明了Range的参数参与搜索，否则只搜索指定参数；其余参数固定为当前Value
+func BuildSpace(params []*model.TrainingParam, names []string) (*Space, error) {
+	selected := make(map[string]bool, len(names))
+	for _, n := range names {
+		selected[n] = true
+	}
+
+	space := &Space{Fixed: make(map[string]string), types: make(map[string]string)}
+	for _, p := range params {
+		space.types[p.Name] = p.Type
+		search := selected[p.Name] || (len(names) == 0 && strings.TrimSpace(p.Range) != "")
+		if !search {
+			space.Fixed[p.Name] = p.Value
+			continue
+		}
+		dim, err := ParseRange(p)
+		if err != nil {
+			return nil, err
+		}
+		space.Dimensions = append(space.Dimensions, dim)
+		delete(selected, p.Name)
+	}
+	for n := range selected {
+		return nil, fmt.Errorf("训练参数%s不存在", n)
+	}
+	if len(space.Dimensions) == 0 {
+		return nil, fmt.Errorf("没有可搜索的训练参数，请先为参数声明取值范围")
+	}
+
+	sort.Slice(space.Dimensions, func(i, j int) bool { return space.Dimensions[i].Name < space.Dimensions[j].Name })
+	return space, nil
+}
+
+// GridSize 网格搜索的组合总数，存在连续维度时返回0
+func (s *Space) GridSize() int {
+	size := 1
+	for _, d := range s.Dimensions {
+		if d.Continuous() {
+			return 0
+		}
+		size *= len(d.Values)
+	}
+	return size
+}
+
+// Grid 展开网格搜索的全部组合，最后一个维度变化最快，limit大于0时截断
+func (s *Space) Grid(limit int) ([]map[string]string, error) {
+	size := s.GridSize()
+	if size == 0 {
+		return nil, fmt.Errorf("网格搜索不支持连续区间参数")
+	}
+	if limit > 0 && size > limit {
+		size = limit
+	}
+
+	trials := make([]map[string]string, 0, size)
+	for i := 0; i < size; i++ {
+		params := s.base()
+		rest := i
+		for d := len(s.Dimensions) - 1; d >= 0; d-- {
+			dim := s.Dimensions[d]
+			params[dim.Name] = dim.Values[rest%len(dim.Values)]
+			rest /= len(dim.Values)
+		}
+		trials = append(trials, params)
+	}
+	return trials, nil
+}
+
+// Random 按种子随机抽取n个互不相同的组合，离散空间不足n个组合时返回全部组合
+func (s *Space) Random(n int, seed int64) []map[string]string {
+	if size := s.GridSize(); size > 0 && size < n {
+		n = size
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	trials := make([]map[string]string, 0, n)
+	seen := make(map[string]bool, n)
+	for attempts := 0; len(trials) < n && attempts < n*50; attempts++ {
+		params := s.base()
+		for _, dim := range s.Dimensions {
+			params[dim.Name] = dim.sample(rng)
+		}
+		key := s.key(params)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		trials = append(trials, params)
+	}
+	return trials
+}
+
+// sample 从维度中随机取值
+func (d *Dimension) sample(rng *rand.Rand) string {
+	if !d.Continuous() {
+		return d.Values[rng.Intn(len(d.Values))]
+	}
+	if d.Integer {
+		lo, hi := int64(math.Ceil(d.Min)), int64(math.Floor(d.Max))
+		return strconv.FormatInt(lo+rng.Int63n(hi-lo+1), 10)
+	}
+	return strconv.FormatFloat(d.Min+rng.Float64()*(d.Max-d.Min), 'g', 6, 64)
+}
+
+// base 复制固定参数
+func (s *Space) base() map[string]string {
+	params := make(map[string]string, len(s.Fixed)+len(s.Dimensions))
+	for k, v := range s.Fixed {
+		params[k] = v
+	}
+	return params
+}
+
+// key 参数组合的唯一标识
+func (s *Space) key(params map[string]string) string {
+	parts := make([]string, len(s.Dimensions))
+	for i, d := range s.Dimensions {
+		parts[i] = params[d.Name]
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// TypedParams 按参数类型转换取值，作为训练任务的参数
+func (s *Space) TypedParams(params map[string]string) map[string]interface{} {
+	return TypedParams(params, s.types)
+}
+
+// TypedParams 按参数类型将字符串取值转换为数字、布尔或JSON
+func TypedParams(params map[string]string, types map[string]string) map[string]interface{} {
+	typed := make(map[string]interface{}, len(params))
+	for name, v := range params {
+		typed[name] = v
+		switch types[name] {
+		case "number":
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				typed[name] = i
+			} else if f, err := strconv.ParseFloat(v, 64); err == nil {
+				typed[name] = f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(v); err == nil {
+				typed[name] = b
+			}
+		case "json":
+			if json.Valid([]byte(v)) {
+				typed[name] = json.RawMessage(v)
+			}
+		}
+	}
+	return typed
+}
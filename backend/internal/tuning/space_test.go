@@ -0,0 +1,98 @@
+package tuning
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/image-recognition-engine/internal/model"
+)
+
+func testParams() []*model.TrainingParam {
+	return []*model.TrainingParam{
+		{Name: "lr", Type: "number", Value: "0.01", Range: "0.1,0.01"},
+		{Name: "batch", Type: "number", Value: "32", Range: "16,32,64"},
+		{Name: "augment", Type: "boolean", Value: "true"},
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	dim, err := ParseRange(&model.TrainingParam{Name: "epochs", Type: "number", Range: "10~50"})
+	require.NoError(t, err)
+	assert.True(t, dim.Continuous())
+	assert.True(t, dim.Integer)
+	assert.Equal(t, 10.0, dim.Min)
+
+	dim, err = ParseRange(&model.TrainingParam{Name: "opt", Type: "string", Range: "sgd, adam,sgd"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sgd", "adam"}, dim.Values)
+
+	_, err = ParseRange(&model.TrainingParam{Name: "lr", Type: "number", Range: "0.1,fast"})
+	assert.Error(t, err)
+	_, err = ParseRange(&model.TrainingParam{Name: "lr", Type: "number", Range: "5~1"})
+	assert.Error(t, err)
+}
+
+func TestGrid(t *testing.T) {
+	space, err := BuildSpace(testParams(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 6, space.GridSize())
+
+	trials, err := space.Grid(0)
+	require.NoError(t, err)
+	require.Len(t, trials, 6)
+	// 维度按名称排序，最后一个维度(lr)变化最快
+	assert.Equal(t, map[string]string{"batch": "16", "lr": "0.1", "augment": "true"}, trials[0])
+	assert.Equal(t, map[string]string{"batch": "16", "lr": "0.01", "augment": "true"}, trials[1])
+	assert.Equal(t, "64", trials[5]["batch"])
+
+	trials, err = space.Grid(4)
+	require.NoError(t, err)
+	assert.Len(t, trials, 4)
+
+	_, err = BuildSpace(testParams(), []string{"missing"})
+	assert.Error(t, err)
+}
+
+func TestRandom(t *testing.T) {
+	params := append(testParams(), &model.TrainingParam{Name: "epochs", Type: "number", Value: "10", Range: "5~100"})
+	space, err := BuildSpace(params, []string{"lr", "epochs"})
+	require.NoError(t, err)
+	assert.Equal(t, "32", space.Fixed["batch"])
+
+	a := space.Random(10, 7)
+	b := space.Random(10, 7)
+	assert.Len(t, a, 10)
+	assert.Equal(t, a, b)
+
+	typed := space.TypedParams(a[0])
+	assert.IsType(t, int64(0), typed["epochs"])
+	assert.Equal(t, true, typed["augment"])
+
+	// 离散空间组合不足时返回全部组合
+	space, err = BuildSpace(testParams(), nil)
+	require.NoError(t, err)
+	assert.Len(t, space.Random(100, 1), 6)
+}
+
+func TestSummarize(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	trials := []*model.TuningTrial{
+		{ID: "a", Index: 0, Status: model.TuningStatusCompleted, Score: score(0.8)},
+		{ID: "b", Index: 1, Status: model.TuningStatusCompleted, Score: score(0.9)},
+		{ID: "c", Index: 2, Status: model.TuningStatusFailed},
+		{ID: "d", Index: 3, Status: model.TuningStatusCompleted, Score: score(0.9)},
+	}
+
+	summary := Summarize(trials, true)
+	assert.True(t, summary.Done)
+	assert.Equal(t, 3, summary.Completed)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, "b", summary.Best.ID)
+
+	assert.Equal(t, "a", Summarize(trials, false).Best.ID)
+
+	trials = append(trials, &model.TuningTrial{ID: "e", Index: 4, Status: model.TuningStatusRunning})
+	assert.False(t, Summarize(trials, true).Done)
+}
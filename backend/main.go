@@ -12,7 +12,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/image-recognition-engine/config"
+	"github.com/image-recognition-engine/internal/artifact"
+	"github.com/image-recognition-engine/internal/database"
+	"github.com/image-recognition-engine/internal/handler"
+	"github.com/image-recognition-engine/internal/inference"
 	"github.com/image-recognition-engine/internal/middleware"
+	"github.com/image-recognition-engine/internal/repository"
+	"github.com/image-recognition-engine/internal/repository/mongodb"
 	"github.com/image-recognition-engine/internal/router"
 )
 
@@ -23,6 +29,31 @@ func main() {
 		log.Fatalf("加载配置失败: %v", err)
 	}
 
+	// 初始化数据库连接
+	if err := database.InitDatabase(cfg); err != nil {
+		log.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer database.CloseDatabase()
+
+	// 创建MongoDB索引，单个索引失败不影响启动
+	if err := database.OptimizeMongoDB(); err != nil {
+		log.Printf("优化MongoDB失败: %v", err)
+	}
+
+	// 创建模型管理器，后台预热默认模型，预热完成前的请求等待同一次加载
+	modelRepo := repository.NewModelRepository(database.MongoDB)
+	artifacts := artifact.NewStore(cfg.Model.BasePath, artifact.KeyFromConfig(cfg), mongodb.NewArtifactUploadRepository(), modelRepo)
+	manager := inference.NewManager(inference.FormatLoader{}, artifacts, int64(cfg.Model.MaxMemoryMB)*1024*1024)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		if err := manager.PrewarmDefault(ctx, cfg.Model.DefaultModel, modelRepo); err != nil {
+			log.Printf("预热默认模型失败: %v", err)
+			return
+		}
+		log.Printf("默认模型 %s 预热完成", cfg.Model.DefaultModel)
+	}()
+
 	// 设置运行模式
 	if cfg.Server.Mode == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -36,6 +67,7 @@ func main() {
 
 	// 注册路由
 	router.RegisterRoutes(app)
+	router.RegisterModelManagerRoutes(app.Group("/api/v1"), handler.NewModelManagerHandler(manager))
 
 	// 配置HTTP服务器
 	server := &http.Server{